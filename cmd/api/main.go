@@ -10,12 +10,18 @@ import (
 	otpApp "github.com/YasserCherfaoui/darween/internal/application/otp"
 	"github.com/YasserCherfaoui/darween/internal/application/franchise"
 	"github.com/YasserCherfaoui/darween/internal/application/inventory"
+	loyaltyApp "github.com/YasserCherfaoui/darween/internal/application/loyalty"
+	numberingApp "github.com/YasserCherfaoui/darween/internal/application/numbering"
 	"github.com/YasserCherfaoui/darween/internal/application/pos"
 	"github.com/YasserCherfaoui/darween/internal/application/product"
+	pricelistApp "github.com/YasserCherfaoui/darween/internal/application/pricelist"
+	promotionApp "github.com/YasserCherfaoui/darween/internal/application/promotion"
 	smtpconfigApp "github.com/YasserCherfaoui/darween/internal/application/smtpconfig"
 	"github.com/YasserCherfaoui/darween/internal/application/subscription"
 	"github.com/YasserCherfaoui/darween/internal/application/supplier"
+	taxApp "github.com/YasserCherfaoui/darween/internal/application/tax"
 	"github.com/YasserCherfaoui/darween/internal/application/user"
+	voucherApp "github.com/YasserCherfaoui/darween/internal/application/voucher"
 	warehousebillApp "github.com/YasserCherfaoui/darween/internal/application/warehousebill"
 	"github.com/YasserCherfaoui/darween/internal/infrastructure/mailing"
 	"github.com/YasserCherfaoui/darween/internal/infrastructure/persistence/migrations"
@@ -61,6 +67,14 @@ func main() {
 	emailQueueRepo := postgres.NewEmailQueueRepository(db)
 	invitationRepo := postgres.NewInvitationRepository(db)
 	otpRepo := postgres.NewOTPRepository(db)
+	taxRepo := postgres.NewTaxRepository(db)
+	promotionRepo := postgres.NewPromotionRepository(db)
+	customerGroupRepo := postgres.NewCustomerGroupRepository(db)
+	priceListRepo := postgres.NewPriceListRepository(db)
+	loyaltyProgramRepo := postgres.NewLoyaltyProgramRepository(db)
+	loyaltyTransactionRepo := postgres.NewLoyaltyTransactionRepository(db)
+	voucherRepo := postgres.NewVoucherRepository(db)
+	numberingRepo := postgres.NewNumberingRepository(db)
 	
 	// Initialize POS repositories
 	customerRepo := postgres.NewCustomerRepository(db)
@@ -70,6 +84,11 @@ func main() {
 	cashDrawerRepo := postgres.NewCashDrawerRepository(db)
 	cashDrawerTransactionRepo := postgres.NewCashDrawerTransactionRepository(db)
 	refundRepo := postgres.NewRefundRepository(db)
+	exchangeRepo := postgres.NewExchangeRepository(db)
+	customerAccountRepo := postgres.NewCustomerAccountRepository(db)
+	layawayRepo := postgres.NewLayawayRepository(db)
+	invoiceRepo := postgres.NewInvoiceRepository(db)
+	proformaRepo := postgres.NewProformaRepository(db)
 
 	// Initialize JWT manager
 	jwtManager := security.NewJWTManager(cfg.JWT.Secret, cfg.JWT.Expiration)
@@ -83,18 +102,27 @@ func main() {
 	// Initialize OTP service
 	otpService := otpApp.NewService(otpRepo)
 	
+	// Initialize numbering service (document numbers for POS, supplier and warehouse bills)
+	numberingService := numberingApp.NewService(numberingRepo, franchiseRepo, userRepo, db)
+
 	// Initialize services
 	authService := auth.NewService(userRepo, companyRepo, invitationRepo, jwtManager, emailService, otpService)
 	userService := user.NewService(userRepo, companyRepo, franchiseRepo)
 	companyService := company.NewService(companyRepo, userRepo, subscriptionRepo, emailService, invitationRepo, smtpConfigRepo, otpService)
 	subscriptionService := subscription.NewService(subscriptionRepo, userRepo)
-	productService := product.NewService(productRepo, userRepo, supplierRepo, franchiseRepo)
-	supplierService := supplier.NewService(supplierRepo, userRepo, inventoryRepo, productRepo, db)
+	productService := product.NewService(productRepo, userRepo, supplierRepo, franchiseRepo, taxRepo)
+	supplierService := supplier.NewService(supplierRepo, userRepo, inventoryRepo, productRepo, numberingService, db)
 	inventoryService := inventory.NewService(inventoryRepo, companyRepo, franchiseRepo, userRepo, productRepo, emailService)
 	franchiseService := franchise.NewService(franchiseRepo, inventoryRepo, companyRepo, userRepo, productRepo, emailService, smtpConfigRepo, invitationRepo, otpService)
-	posService := pos.NewService(customerRepo, saleRepo, saleItemRepo, paymentRepo, cashDrawerRepo, cashDrawerTransactionRepo, refundRepo, userRepo, inventoryRepo, inventoryRepo, productRepo, franchiseRepo, db)
-	warehouseBillService := warehousebillApp.NewService(warehouseBillRepo, inventoryRepo, companyRepo, franchiseRepo, userRepo, productRepo, emailService, db)
+	receiptLinks := pos.ReceiptLinkConfig{BaseURL: cfg.Server.PublicURL, TTL: time.Duration(cfg.POS.ReceiptLinkTTL) * time.Hour}
+	posService := pos.NewService(customerRepo, saleRepo, saleItemRepo, paymentRepo, cashDrawerRepo, cashDrawerTransactionRepo, refundRepo, exchangeRepo, customerAccountRepo, layawayRepo, invoiceRepo, proformaRepo, userRepo, inventoryRepo, inventoryRepo, productRepo, franchiseRepo, taxRepo, promotionRepo, customerGroupRepo, priceListRepo, loyaltyProgramRepo, loyaltyTransactionRepo, voucherRepo, emailService, numberingService, jwtManager, receiptLinks, db)
+	warehouseBillService := warehousebillApp.NewService(warehouseBillRepo, inventoryRepo, companyRepo, franchiseRepo, userRepo, productRepo, emailService, numberingService, db)
 	smtpConfigService := smtpconfigApp.NewService(smtpConfigRepo, userRepo)
+	taxService := taxApp.NewService(taxRepo, userRepo)
+	promotionService := promotionApp.NewService(promotionRepo, userRepo, productRepo, supplierRepo, franchiseRepo)
+	pricelistService := pricelistApp.NewService(customerGroupRepo, priceListRepo, userRepo, productRepo, franchiseRepo)
+	loyaltyService := loyaltyApp.NewService(loyaltyProgramRepo, loyaltyTransactionRepo, customerRepo, userRepo)
+	voucherService := voucherApp.NewService(voucherRepo, userRepo, franchiseRepo, customerRepo, db)
 
 	// Initialize handlers
 	authHandler := handler.NewAuthHandler(authService)
@@ -109,15 +137,36 @@ func main() {
 	warehouseBillHandler := handler.NewWarehouseBillHandler(warehouseBillService)
 	smtpConfigHandler := handler.NewSMTPConfigHandler(smtpConfigService)
 	emailHandler := handler.NewEmailHandler(emailService)
+	taxHandler := handler.NewTaxHandler(taxService)
+	promotionHandler := handler.NewPromotionHandler(promotionService)
+	loyaltyHandler := handler.NewLoyaltyHandler(loyaltyService)
+	voucherHandler := handler.NewVoucherHandler(voucherService)
+	numberingHandler := handler.NewNumberingHandler(numberingService)
+	priceListHandler := handler.NewPriceListHandler(pricelistService)
 
 	// Initialize router
-	r := router.NewRouter(authHandler, userHandler, companyHandler, subscriptionHandler, productHandler, supplierHandler, inventoryHandler, franchiseHandler, posHandler, warehouseBillHandler, smtpConfigHandler, emailHandler, jwtManager)
+	r := router.NewRouter(authHandler, userHandler, companyHandler, subscriptionHandler, productHandler, supplierHandler, inventoryHandler, franchiseHandler, posHandler, warehouseBillHandler, smtpConfigHandler, emailHandler, taxHandler, promotionHandler, loyaltyHandler, voucherHandler, numberingHandler, priceListHandler, jwtManager)
 
 	// Start email queue worker (processes emails in background)
 	emailWorker := mailing.NewEmailQueueWorker(mailingService, 30*time.Second)
 	emailWorker.Start()
 	defer emailWorker.Stop()
 
+	// Start parked sale worker (releases stock held by abandoned parked sales)
+	parkedSaleWorker := pos.NewParkedSaleWorker(posService, 15*time.Minute, time.Duration(cfg.POS.ParkedSaleTTL)*time.Hour)
+	parkedSaleWorker.Start()
+	defer parkedSaleWorker.Stop()
+
+	// Start layaway expiry worker (releases stock held by layaways not picked up in time)
+	layawayExpiryWorker := pos.NewLayawayExpiryWorker(posService, 15*time.Minute)
+	layawayExpiryWorker.Start()
+	defer layawayExpiryWorker.Stop()
+
+	// Start loyalty expiry worker (writes off points that were not used in time)
+	loyaltyExpiryWorker := loyaltyApp.NewExpiryWorker(loyaltyService, time.Hour)
+	loyaltyExpiryWorker.Start()
+	defer loyaltyExpiryWorker.Stop()
+
 	// Create Gin engine
 	engine := gin.Default()
 
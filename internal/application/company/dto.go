@@ -1,5 +1,7 @@
 package company
 
+import "github.com/YasserCherfaoui/darween/internal/domain/company"
+
 type CreateCompanyRequest struct {
 	Name        string `json:"name" binding:"required"`
 	Code        string `json:"code" binding:"required"`
@@ -8,19 +10,56 @@ type CreateCompanyRequest struct {
 }
 
 type UpdateCompanyRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	ERPUrl      string `json:"erp_url"`
-	IsActive    *bool  `json:"is_active"`
+	Name              string   `json:"name"`
+	Description       string   `json:"description"`
+	ERPUrl            string   `json:"erp_url"`
+	PricesIncludeTax  *bool    `json:"prices_include_tax"`
+	CashApprovalLimit *float64 `json:"cash_approval_limit" binding:"omitempty,min=0"`
+	WholesaleMinOrder *float64 `json:"wholesale_min_order" binding:"omitempty,min=0"`
+	IsActive          *bool    `json:"is_active"`
+
+	// Legal details printed on invoices
+	Address *string `json:"address"`
+	NIF     *string `json:"nif" binding:"omitempty,max=30"`
+	NIS     *string `json:"nis" binding:"omitempty,max=30"`
+	RC      *string `json:"rc" binding:"omitempty,max=30"`
+	AI      *string `json:"ai" binding:"omitempty,max=30"`
 }
 
 type CompanyResponse struct {
-	ID          uint   `json:"id"`
-	Name        string `json:"name"`
-	Code        string `json:"code"`
-	Description string `json:"description"`
-	ERPUrl      string `json:"erp_url"`
-	IsActive    bool   `json:"is_active"`
+	ID                uint    `json:"id"`
+	Name              string  `json:"name"`
+	Code              string  `json:"code"`
+	Description       string  `json:"description"`
+	ERPUrl            string  `json:"erp_url"`
+	PricesIncludeTax  bool    `json:"prices_include_tax"`
+	CashApprovalLimit float64 `json:"cash_approval_limit"`
+	WholesaleMinOrder float64 `json:"wholesale_min_order"`
+	IsActive          bool    `json:"is_active"`
+	Address           string  `json:"address,omitempty"`
+	NIF               string  `json:"nif,omitempty"`
+	NIS               string  `json:"nis,omitempty"`
+	RC                string  `json:"rc,omitempty"`
+	AI                string  `json:"ai,omitempty"`
+}
+
+func ToCompanyResponse(c *company.Company) *CompanyResponse {
+	return &CompanyResponse{
+		ID:                c.ID,
+		Name:              c.Name,
+		Code:              c.Code,
+		Description:       c.Description,
+		ERPUrl:            c.ERPUrl,
+		PricesIncludeTax:  c.PricesIncludeTax,
+		CashApprovalLimit: c.CashApprovalLimit,
+		WholesaleMinOrder: c.WholesaleMinOrder,
+		IsActive:          c.IsActive,
+		Address:           c.Address,
+		NIF:               c.NIF,
+		NIS:               c.NIS,
+		RC:                c.RC,
+		AI:                c.AI,
+	}
 }
 
 type AddUserToCompanyRequest struct {
@@ -86,14 +86,7 @@ func (s *Service) CreateCompany(userID uint, req *CreateCompanyRequest) (*Compan
 		return nil, errors.NewInternalError("failed to create subscription", err)
 	}
 
-	return &CompanyResponse{
-		ID:          newCompany.ID,
-		Name:        newCompany.Name,
-		Code:        newCompany.Code,
-		Description: newCompany.Description,
-		ERPUrl:      newCompany.ERPUrl,
-		IsActive:    newCompany.IsActive,
-	}, nil
+	return ToCompanyResponse(newCompany), nil
 }
 
 func (s *Service) GetCompaniesByUserID(userID uint) ([]*CompanyResponse, error) {
@@ -104,14 +97,7 @@ func (s *Service) GetCompaniesByUserID(userID uint) ([]*CompanyResponse, error)
 
 	var result []*CompanyResponse
 	for _, c := range companies {
-		result = append(result, &CompanyResponse{
-			ID:          c.ID,
-			Name:        c.Name,
-			Code:        c.Code,
-			Description: c.Description,
-			ERPUrl:      c.ERPUrl,
-			IsActive:    c.IsActive,
-		})
+		result = append(result, ToCompanyResponse(c))
 	}
 
 	return result, nil
@@ -123,13 +109,7 @@ func (s *Service) GetCompanyByID(companyID uint) (*CompanyResponse, error) {
 		return nil, errors.NewNotFoundError("company not found")
 	}
 
-	return &CompanyResponse{
-		ID:          c.ID,
-		Name:        c.Name,
-		Code:        c.Code,
-		Description: c.Description,
-		IsActive:    c.IsActive,
-	}, nil
+	return ToCompanyResponse(c), nil
 }
 
 func (s *Service) UpdateCompany(userID, companyID uint, req *UpdateCompanyRequest) (*CompanyResponse, error) {
@@ -159,6 +139,30 @@ func (s *Service) UpdateCompany(userID, companyID uint, req *UpdateCompanyReques
 	// ERPUrl: Always update if provided (allows clearing by setting to empty string)
 	// The frontend will always send this field, so we update it
 	c.ERPUrl = req.ERPUrl
+	if req.PricesIncludeTax != nil {
+		c.PricesIncludeTax = *req.PricesIncludeTax
+	}
+	if req.CashApprovalLimit != nil {
+		c.CashApprovalLimit = *req.CashApprovalLimit
+	}
+	if req.WholesaleMinOrder != nil {
+		c.WholesaleMinOrder = *req.WholesaleMinOrder
+	}
+	if req.Address != nil {
+		c.Address = *req.Address
+	}
+	if req.NIF != nil {
+		c.NIF = *req.NIF
+	}
+	if req.NIS != nil {
+		c.NIS = *req.NIS
+	}
+	if req.RC != nil {
+		c.RC = *req.RC
+	}
+	if req.AI != nil {
+		c.AI = *req.AI
+	}
 	if req.IsActive != nil {
 		c.IsActive = *req.IsActive
 	}
@@ -167,13 +171,7 @@ func (s *Service) UpdateCompany(userID, companyID uint, req *UpdateCompanyReques
 		return nil, errors.NewInternalError("failed to update company", err)
 	}
 
-	return &CompanyResponse{
-		ID:          c.ID,
-		Name:        c.Name,
-		Code:        c.Code,
-		Description: c.Description,
-		IsActive:    c.IsActive,
-	}, nil
+	return ToCompanyResponse(c), nil
 }
 
 // generateRandomPassword generates a secure random password
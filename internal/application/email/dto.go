@@ -40,6 +40,18 @@ type SendWarehouseBillEmailRequest struct {
 	CompanyName string
 }
 
+type SendReceiptEmailRequest struct {
+	CompanyID     uint
+	To            []string
+	ReceiptNumber string
+	ReceiptDate   string
+	TotalAmount   float64
+	ReceiptURL    string
+	CompanyName   string
+	PDF           []byte
+	PDFFilename   string
+}
+
 type SendCustomEmailRequest struct {
 	CompanyID uint
 	To        []string
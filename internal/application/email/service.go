@@ -136,6 +136,40 @@ func (s *Service) SendWarehouseBillEmail(req *SendWarehouseBillEmailRequest) err
 	)
 }
 
+// SendReceiptEmail sends a sale receipt to a customer, with the PDF attached
+func (s *Service) SendReceiptEmail(req *SendReceiptEmailRequest) error {
+	data := mailing.EmailTemplateData{
+		CompanyName:   req.CompanyName,
+		ReceiptNumber: req.ReceiptNumber,
+		ReceiptDate:   req.ReceiptDate,
+		ReceiptURL:    req.ReceiptURL,
+		TotalAmount:   req.TotalAmount,
+	}
+
+	htmlBody, plainBody := mailing.GenerateReceiptEmail(data)
+	_ = plainBody
+
+	attachments := []emailqueue.Attachment{
+		{
+			Filename:    req.PDFFilename,
+			ContentType: "application/pdf",
+			Content:     req.PDF,
+		},
+	}
+
+	return s.mailingService.QueueEmailWithAttachments(
+		req.CompanyID,
+		nil,
+		req.To,
+		fmt.Sprintf("Your receipt from %s - %s", req.CompanyName, req.ReceiptNumber),
+		htmlBody,
+		true,
+		emailqueue.EmailTypeReceipt,
+		attachments,
+		nil,
+	)
+}
+
 // SendCustomEmail sends a custom email (for frontend composer)
 func (s *Service) SendCustomEmail(req *SendCustomEmailRequest) error {
 	return s.mailingService.QueueEmailWithType(
@@ -78,8 +78,13 @@ type InventoryMovementListResponse struct {
 }
 
 type MovementFilterRequest struct {
-	Page         int    `form:"page" binding:"min=1"`
-	Limit        int    `form:"limit" binding:"min=1,max=100"`
+	Page  int `form:"page" binding:"min=1"`
+	Limit int `form:"limit" binding:"min=1,max=100"`
+	MovementFilters
+}
+
+// MovementFilters holds the filters of the movement list and export endpoints
+type MovementFilters struct {
 	MovementType string `form:"movement_type"`
 	StartDate    string `form:"start_date"`
 	EndDate      string `form:"end_date"`
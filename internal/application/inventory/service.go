@@ -9,6 +9,7 @@ import (
 	"github.com/YasserCherfaoui/darween/internal/domain/inventory"
 	productDomain "github.com/YasserCherfaoui/darween/internal/domain/product"
 	userDomain "github.com/YasserCherfaoui/darween/internal/domain/user"
+	"github.com/YasserCherfaoui/darween/internal/infrastructure/export"
 	"github.com/YasserCherfaoui/darween/pkg/errors"
 )
 
@@ -130,18 +131,8 @@ func (s *Service) GetInventoryByCompany(userID, companyID uint) ([]*InventoryRes
 }
 
 func (s *Service) GetInventoryByFranchise(userID, franchiseID uint) ([]*InventoryResponse, error) {
-	franchise, err := s.franchiseRepo.FindByID(franchiseID)
-	if err != nil {
-		return nil, errors.NewNotFoundError("franchise not found")
-	}
-
-	// Check if user is parent company admin or franchise user
-	parentRole, _ := s.userRepo.FindUserRoleInCompany(userID, franchise.ParentCompanyID)
-	franchiseRole, _ := s.userRepo.FindUserRoleInFranchise(userID, franchiseID)
-
-	if (parentRole == nil || (parentRole.Role != userDomain.RoleOwner && parentRole.Role != userDomain.RoleAdmin)) &&
-		franchiseRole == nil {
-		return nil, errors.NewForbiddenError("you don't have access to this franchise")
+	if _, err := s.checkFranchiseInventoryAccess(userID, franchiseID); err != nil {
+		return nil, err
 	}
 
 	inventories, err := s.inventoryRepo.FindByFranchise(franchiseID)
@@ -403,6 +394,92 @@ func (s *Service) GetInventoryMovementsWithFilters(inventoryID uint, req *Moveme
 	}, nil
 }
 
+// ExportInventory writes the company's inventory, or the inventory of one of its franchises, one row per variant
+func (s *Service) ExportInventory(userID, companyID uint, franchiseID *uint, w export.Writer) error {
+	if franchiseID != nil {
+		franchise, err := s.checkFranchiseInventoryAccess(userID, *franchiseID)
+		if err != nil {
+			return err
+		}
+		if franchise.ParentCompanyID != companyID {
+			return errors.NewNotFoundError("franchise not found")
+		}
+	} else if _, err := s.userRepo.FindUserRoleInCompany(userID, companyID); err != nil {
+		return errors.NewForbiddenError("you don't have access to this company")
+	}
+
+	err := w.WriteRow("Inventory ID", "SKU", "Product", "Variant", "Franchise", "Stock", "Reserved", "Available", "Updated at")
+	if err != nil {
+		return err
+	}
+
+	writeBatch := func(inventories []*inventory.Inventory) error {
+		for _, inv := range inventories {
+			resp, err := s.buildInventoryResponse(inv)
+			if err != nil {
+				continue
+			}
+			err = w.WriteRow(resp.ID, resp.VariantSKU, resp.ProductName, resp.VariantName, resp.FranchiseName,
+				resp.Stock, resp.ReservedStock, resp.AvailableStock, inv.UpdatedAt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if franchiseID != nil {
+		return s.inventoryRepo.StreamByFranchise(*franchiseID, writeBatch)
+	}
+	return s.inventoryRepo.StreamByCompany(companyID, writeBatch)
+}
+
+// ExportInventoryMovements writes the movements of an inventory matching the filters, oldest first
+func (s *Service) ExportInventoryMovements(userID, inventoryID uint, req *MovementFilters, w export.Writer) error {
+	inv, err := s.inventoryRepo.FindByID(inventoryID)
+	if err != nil {
+		return errors.NewNotFoundError("inventory not found")
+	}
+
+	if inv.FranchiseID != nil {
+		if _, err := s.checkFranchiseInventoryAccess(userID, *inv.FranchiseID); err != nil {
+			return err
+		}
+	} else if inv.CompanyID != nil {
+		if _, err := s.userRepo.FindUserRoleInCompany(userID, *inv.CompanyID); err != nil {
+			return errors.NewForbiddenError("you don't have access to this company")
+		}
+	}
+
+	var movementType, startDate, endDate *string
+	if req.MovementType != "" {
+		movementType = &req.MovementType
+	}
+	if req.StartDate != "" {
+		startDate = &req.StartDate
+	}
+	if req.EndDate != "" {
+		endDate = &req.EndDate
+	}
+
+	err = w.WriteRow("Movement ID", "Date", "Type", "Quantity", "Previous stock", "New stock",
+		"Reference type", "Reference ID", "Notes", "Created by ID")
+	if err != nil {
+		return err
+	}
+
+	return s.inventoryRepo.StreamMovementsByInventoryWithFilters(inventoryID, movementType, startDate, endDate, func(movements []*inventory.InventoryMovement) error {
+		for _, m := range movements {
+			err := w.WriteRow(m.ID, m.CreatedAt, m.MovementType, m.Quantity, m.PreviousStock, m.NewStock,
+				m.ReferenceType, m.ReferenceID, m.Notes, m.CreatedByID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // Helper methods
 
 func (s *Service) verifyInventoryAccess(userID uint, inv *inventory.Inventory) error {
@@ -429,6 +506,26 @@ func (s *Service) verifyInventoryAccess(userID uint, inv *inventory.Inventory) e
 	return nil
 }
 
+// checkFranchiseInventoryAccess checks the user may view the franchise's inventory: parent
+// company owners and admins, and the franchise's own users
+func (s *Service) checkFranchiseInventoryAccess(userID, franchiseID uint) (*franchiseDomain.Franchise, error) {
+	franchise, err := s.franchiseRepo.FindByID(franchiseID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("franchise not found")
+	}
+
+	// Check if user is parent company admin or franchise user
+	parentRole, _ := s.userRepo.FindUserRoleInCompany(userID, franchise.ParentCompanyID)
+	franchiseRole, _ := s.userRepo.FindUserRoleInFranchise(userID, franchiseID)
+
+	if (parentRole == nil || (parentRole.Role != userDomain.RoleOwner && parentRole.Role != userDomain.RoleAdmin)) &&
+		franchiseRole == nil {
+		return nil, errors.NewForbiddenError("you don't have access to this franchise")
+	}
+
+	return franchise, nil
+}
+
 func (s *Service) buildInventoryResponse(inv *inventory.Inventory) (*InventoryResponse, error) {
 	response := &InventoryResponse{
 		ID:               inv.ID,
@@ -0,0 +1,107 @@
+package loyalty
+
+import (
+	"time"
+
+	"github.com/YasserCherfaoui/darween/internal/domain/loyalty"
+)
+
+type UpdateProgramRequest struct {
+	PointsPerUnit   *float64 `json:"points_per_unit" binding:"omitempty,min=0"`
+	PointValue      *float64 `json:"point_value" binding:"omitempty,gt=0"`
+	MinRedeemPoints *int     `json:"min_redeem_points" binding:"omitempty,min=0"`
+	ExpiryDays      *int     `json:"expiry_days" binding:"omitempty,min=0"`
+	IsActive        *bool    `json:"is_active"`
+}
+
+type ProgramResponse struct {
+	CompanyID       uint    `json:"company_id"`
+	PointsPerUnit   float64 `json:"points_per_unit"`
+	PointValue      float64 `json:"point_value"`
+	MinRedeemPoints int     `json:"min_redeem_points"`
+	ExpiryDays      int     `json:"expiry_days"`
+	IsActive        bool    `json:"is_active"`
+}
+
+type TransactionResponse struct {
+	ID              uint                    `json:"id"`
+	SaleID          *uint                   `json:"sale_id,omitempty"`
+	RefundID        *uint                   `json:"refund_id,omitempty"`
+	Type            loyalty.TransactionType `json:"type"`
+	Points          int                     `json:"points"`
+	RemainingPoints int                     `json:"remaining_points,omitempty"`
+	ExpiresAt       *time.Time              `json:"expires_at,omitempty"`
+	Notes           string                  `json:"notes,omitempty"`
+	CreatedByID     *uint                   `json:"created_by_id,omitempty"`
+	CreatedAt       time.Time               `json:"created_at"`
+}
+
+type CustomerLoyaltyResponse struct {
+	CustomerID   uint               `json:"customer_id"`
+	Balance      int                `json:"balance"`
+	BalanceValue float64            `json:"balance_value"` // What the balance is worth when redeemed
+	History      *PaginatedResponse `json:"history"`
+}
+
+type PaginationRequest struct {
+	Page  int `form:"page"`
+	Limit int `form:"limit"`
+}
+
+func (p *PaginationRequest) GetDefaults() {
+	if p.Page <= 0 {
+		p.Page = 1
+	}
+	if p.Limit <= 0 {
+		p.Limit = 20
+	}
+}
+
+type PaginatedResponse struct {
+	Data       interface{} `json:"data"`
+	Page       int         `json:"page"`
+	Limit      int         `json:"limit"`
+	Total      int64       `json:"total"`
+	TotalPages int         `json:"total_pages"`
+}
+
+func NewPaginatedResponse(data interface{}, total int64, page, limit int) *PaginatedResponse {
+	totalPages := int(total) / limit
+	if int(total)%limit != 0 {
+		totalPages++
+	}
+
+	return &PaginatedResponse{
+		Data:       data,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+}
+
+func ToProgramResponse(p *loyalty.Program) *ProgramResponse {
+	return &ProgramResponse{
+		CompanyID:       p.CompanyID,
+		PointsPerUnit:   p.PointsPerUnit,
+		PointValue:      p.PointValue,
+		MinRedeemPoints: p.MinRedeemPoints,
+		ExpiryDays:      p.ExpiryDays,
+		IsActive:        p.IsActive,
+	}
+}
+
+func ToTransactionResponse(t *loyalty.Transaction) *TransactionResponse {
+	return &TransactionResponse{
+		ID:              t.ID,
+		SaleID:          t.SaleID,
+		RefundID:        t.RefundID,
+		Type:            t.Type,
+		Points:          t.Points,
+		RemainingPoints: t.RemainingPoints,
+		ExpiresAt:       t.ExpiresAt,
+		Notes:           t.Notes,
+		CreatedByID:     t.CreatedByID,
+		CreatedAt:       t.CreatedAt,
+	}
+}
@@ -0,0 +1,168 @@
+package loyalty
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"github.com/YasserCherfaoui/darween/internal/domain/loyalty"
+	"github.com/YasserCherfaoui/darween/internal/domain/pos"
+	"github.com/YasserCherfaoui/darween/internal/domain/user"
+	"github.com/YasserCherfaoui/darween/pkg/errors"
+)
+
+type Service struct {
+	programRepo     loyalty.ProgramRepository
+	transactionRepo loyalty.TransactionRepository
+	customerRepo    pos.CustomerRepository
+	userRepo        user.Repository
+}
+
+func NewService(programRepo loyalty.ProgramRepository, transactionRepo loyalty.TransactionRepository, customerRepo pos.CustomerRepository, userRepo user.Repository) *Service {
+	return &Service{
+		programRepo:     programRepo,
+		transactionRepo: transactionRepo,
+		customerRepo:    customerRepo,
+		userRepo:        userRepo,
+	}
+}
+
+// GetProgram returns the company's loyalty rules; companies without a program get the inactive defaults
+func (s *Service) GetProgram(userID, companyID uint) (*ProgramResponse, error) {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	return ToProgramResponse(s.findProgram(companyID)), nil
+}
+
+func (s *Service) UpdateProgram(userID, companyID uint, req *UpdateProgramRequest) (*ProgramResponse, error) {
+	role, err := s.userRepo.FindUserRoleInCompany(userID, companyID)
+	if err != nil {
+		return nil, errors.NewForbiddenError("you don't have access to this company")
+	}
+
+	if role.Role != user.RoleOwner && role.Role != user.RoleAdmin {
+		return nil, errors.NewForbiddenError("only owners and admins can manage the loyalty program")
+	}
+
+	program := s.findProgram(companyID)
+
+	// Update fields
+	if req.PointsPerUnit != nil {
+		program.PointsPerUnit = *req.PointsPerUnit
+	}
+	if req.PointValue != nil {
+		program.PointValue = *req.PointValue
+	}
+	if req.MinRedeemPoints != nil {
+		program.MinRedeemPoints = *req.MinRedeemPoints
+	}
+	if req.ExpiryDays != nil {
+		program.ExpiryDays = *req.ExpiryDays
+	}
+	if req.IsActive != nil {
+		program.IsActive = *req.IsActive
+	}
+
+	if !program.IsValid() {
+		return nil, errors.NewValidationError("invalid loyalty program data")
+	}
+
+	if program.ID == 0 {
+		err = s.programRepo.Create(program)
+	} else {
+		err = s.programRepo.Update(program)
+	}
+	if err != nil {
+		return nil, errors.NewInternalError("failed to save loyalty program", err)
+	}
+
+	return ToProgramResponse(program), nil
+}
+
+// GetCustomerLoyalty returns a customer's points balance and ledger, newest first
+func (s *Service) GetCustomerLoyalty(userID, companyID, customerID uint, page, limit int) (*CustomerLoyaltyResponse, error) {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	customer, err := s.customerRepo.FindByID(customerID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("customer not found")
+	}
+
+	if customer.CompanyID != companyID {
+		return nil, errors.NewForbiddenError("access denied to this customer")
+	}
+
+	balance, err := s.transactionRepo.GetBalance(customerID)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to calculate points balance", err)
+	}
+
+	transactions, total, err := s.transactionRepo.FindByCustomerID(customerID, page, limit)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch points history", err)
+	}
+
+	history := make([]*TransactionResponse, len(transactions))
+	for i, t := range transactions {
+		history[i] = ToTransactionResponse(t)
+	}
+
+	program := s.findProgram(companyID)
+
+	return &CustomerLoyaltyResponse{
+		CustomerID:   customerID,
+		Balance:      balance,
+		BalanceValue: math.Round(float64(balance)*program.PointValue*100) / 100,
+		History:      NewPaginatedResponse(history, total, page, limit),
+	}, nil
+}
+
+// ExpirePoints writes off every lot whose points expired before now and returns how many were expired
+func (s *Service) ExpirePoints(now time.Time) (int, error) {
+	lots, err := s.transactionRepo.FindExpiredLots(now)
+	if err != nil {
+		return 0, errors.NewInternalError("failed to fetch expired points", err)
+	}
+
+	expired := 0
+	for _, lot := range lots {
+		if err := s.transactionRepo.ExpireLot(lot); err != nil {
+			log.Printf("Failed to expire loyalty lot %d: %v", lot.ID, err)
+			continue
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+// findProgram returns the company's program, or the inactive defaults when it has none
+func (s *Service) findProgram(companyID uint) *loyalty.Program {
+	program, err := s.programRepo.FindByCompanyID(companyID)
+	if err != nil {
+		return &loyalty.Program{
+			CompanyID:     companyID,
+			PointsPerUnit: 1,
+			PointValue:    0.01,
+			IsActive:      false,
+		}
+	}
+	return program
+}
+
+func (s *Service) checkUserCompanyAccess(userID, companyID uint, minimumRole user.Role) error {
+	ucr, err := s.userRepo.FindUserRoleInCompany(userID, companyID)
+	if err != nil {
+		return errors.NewForbiddenError("access denied to this company")
+	}
+
+	if !ucr.Role.HasPermission(minimumRole) {
+		return errors.NewForbiddenError("insufficient permissions")
+	}
+
+	return nil
+}
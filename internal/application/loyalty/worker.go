@@ -0,0 +1,65 @@
+package loyalty
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// ExpiryWorker periodically writes off loyalty points that were not used in time
+type ExpiryWorker struct {
+	service  *Service
+	interval time.Duration
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// NewExpiryWorker creates a new loyalty points expiry worker
+func NewExpiryWorker(service *Service, interval time.Duration) *ExpiryWorker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ExpiryWorker{
+		service:  service,
+		interval: interval,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start starts the worker
+func (w *ExpiryWorker) Start() {
+	go w.run()
+}
+
+// Stop stops the worker
+func (w *ExpiryWorker) Stop() {
+	w.cancel()
+}
+
+func (w *ExpiryWorker) run() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	log.Println("Loyalty expiry worker started")
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			log.Println("Loyalty expiry worker stopped")
+			return
+		case <-ticker.C:
+			w.expirePoints()
+		}
+	}
+}
+
+func (w *ExpiryWorker) expirePoints() {
+	expired, err := w.service.ExpirePoints(time.Now())
+	if err != nil {
+		log.Printf("Failed to expire loyalty points: %v", err)
+		return
+	}
+
+	if expired > 0 {
+		log.Printf("Expired %d loyalty point lot(s)", expired)
+	}
+}
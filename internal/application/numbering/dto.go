@@ -0,0 +1,47 @@
+package numbering
+
+import (
+	"github.com/YasserCherfaoui/darween/internal/domain/numbering"
+)
+
+type ConfigureSequenceRequest struct {
+	DocumentType string `json:"document_type" binding:"required"`
+	FranchiseID  *uint  `json:"franchise_id"` // Empty for the company sequence, which franchises without their own start from
+	Pattern      string `json:"pattern" binding:"required"`
+	ResetPolicy  string `json:"reset_policy" binding:"required,oneof=never yearly monthly"`
+	NextNumber   *int64 `json:"next_number" binding:"omitempty,min=1"` // Can only move forward
+}
+
+type SequenceResponse struct {
+	CompanyID    uint   `json:"company_id"`
+	FranchiseID  *uint  `json:"franchise_id,omitempty"`
+	DocumentType string `json:"document_type"`
+	Pattern      string `json:"pattern"`
+	ResetPolicy  string `json:"reset_policy"`
+	Period       string `json:"period,omitempty"`
+	NextNumber   int64  `json:"next_number"`
+	NextPreview  string `json:"next_preview"` // Number the next document would get now
+	IsDefault    bool   `json:"is_default"`   // Not configured yet, the default pattern applies
+}
+
+type ListSequencesResponse struct {
+	Sequences []*SequenceResponse `json:"sequences"`
+}
+
+func ToSequenceResponse(s *numbering.Sequence, nextPreview string) *SequenceResponse {
+	response := &SequenceResponse{
+		CompanyID:    s.CompanyID,
+		DocumentType: string(s.DocumentType),
+		Pattern:      s.Pattern,
+		ResetPolicy:  string(s.ResetPolicy),
+		Period:       s.Period,
+		NextNumber:   s.NextNumber,
+		NextPreview:  nextPreview,
+		IsDefault:    s.ID == 0,
+	}
+	if s.FranchiseID != numbering.CompanyScope {
+		franchiseID := s.FranchiseID
+		response.FranchiseID = &franchiseID
+	}
+	return response
+}
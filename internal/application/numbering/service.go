@@ -0,0 +1,243 @@
+package numbering
+
+import (
+	"fmt"
+	"time"
+
+	franchiseDomain "github.com/YasserCherfaoui/darween/internal/domain/franchise"
+	"github.com/YasserCherfaoui/darween/internal/domain/numbering"
+	"github.com/YasserCherfaoui/darween/internal/domain/user"
+	"github.com/YasserCherfaoui/darween/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Service hands out document numbers from the sequences of each company and franchise
+type Service struct {
+	sequenceRepo  numbering.Repository
+	franchiseRepo franchiseDomain.Repository
+	userRepo      user.Repository
+	db            *gorm.DB
+}
+
+func NewService(sequenceRepo numbering.Repository, franchiseRepo franchiseDomain.Repository, userRepo user.Repository, db *gorm.DB) *Service {
+	return &Service{
+		sequenceRepo:  sequenceRepo,
+		franchiseRepo: franchiseRepo,
+		userRepo:      userRepo,
+		db:            db,
+	}
+}
+
+// Next takes the next number of a document within the transaction creating the document.
+// The sequence stays locked until the transaction ends, so concurrent documents get
+// consecutive numbers, and a rolled back document gives its number back.
+func (s *Service) Next(tx *gorm.DB, companyID uint, franchiseID *uint, documentType numbering.DocumentType) (string, error) {
+	scope := numbering.CompanyScope
+	if franchiseID != nil {
+		scope = *franchiseID
+	}
+
+	sequence, err := s.lockSequence(tx, companyID, scope, documentType)
+	if err != nil {
+		return "", errors.NewInternalError("failed to lock numbering sequence", err)
+	}
+
+	franchiseCode, err := s.franchiseCode(sequence)
+	if err != nil {
+		return "", err
+	}
+
+	number := sequence.Take(time.Now(), franchiseCode)
+	if err := tx.Save(sequence).Error; err != nil {
+		return "", errors.NewInternalError("failed to update numbering sequence", err)
+	}
+
+	return number, nil
+}
+
+// lockSequence loads a sequence locked for update, creating it on first use. A franchise
+// sequence starts from the company's pattern for the document type.
+func (s *Service) lockSequence(tx *gorm.DB, companyID, franchiseID uint, documentType numbering.DocumentType) (*numbering.Sequence, error) {
+	var sequence numbering.Sequence
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("company_id = ? AND franchise_id = ? AND document_type = ?", companyID, franchiseID, documentType).
+		First(&sequence).Error
+	if err == nil {
+		return &sequence, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	newSequence := s.templateSequence(tx, companyID, franchiseID, documentType)
+
+	// Concurrent first uses insert the same row: the others wait for it, then lock it
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(newSequence).Error; err != nil {
+		return nil, err
+	}
+
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("company_id = ? AND franchise_id = ? AND document_type = ?", companyID, franchiseID, documentType).
+		First(&sequence).Error
+	return &sequence, err
+}
+
+// templateSequence returns the sequence to start for a scope: the default one, or for a
+// franchise, one following the company's configured pattern
+func (s *Service) templateSequence(db *gorm.DB, companyID, franchiseID uint, documentType numbering.DocumentType) *numbering.Sequence {
+	sequence := numbering.NewSequence(companyID, franchiseID, documentType)
+	if franchiseID == numbering.CompanyScope {
+		return sequence
+	}
+
+	var companySequence numbering.Sequence
+	err := db.Where("company_id = ? AND franchise_id = ? AND document_type = ?", companyID, numbering.CompanyScope, documentType).
+		First(&companySequence).Error
+	if err == nil {
+		sequence.Pattern = companySequence.Pattern
+		sequence.ResetPolicy = companySequence.ResetPolicy
+	}
+	return sequence
+}
+
+// franchiseCode returns the code of the sequence's franchise when its numbers show it
+func (s *Service) franchiseCode(sequence *numbering.Sequence) (string, error) {
+	if sequence.FranchiseID == numbering.CompanyScope || !sequence.UsesFranchiseCode() {
+		return "", nil
+	}
+	franchise, err := s.franchiseRepo.FindByID(sequence.FranchiseID)
+	if err != nil {
+		return "", errors.NewNotFoundError("franchise not found")
+	}
+	return franchise.Code, nil
+}
+
+// ListSequences lists the company sequence of every document type, configured or default,
+// followed by the franchise sequences in use
+func (s *Service) ListSequences(userID, companyID uint) (*ListSequencesResponse, error) {
+	if _, err := s.userRepo.FindUserRoleInCompany(userID, companyID); err != nil {
+		return nil, errors.NewForbiddenError("you don't have access to this company")
+	}
+
+	sequences, err := s.sequenceRepo.FindByCompany(companyID)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch numbering sequences", err)
+	}
+
+	configured := make(map[numbering.DocumentType]bool)
+	for _, sequence := range sequences {
+		if sequence.FranchiseID == numbering.CompanyScope {
+			configured[sequence.DocumentType] = true
+		}
+	}
+
+	responses := make([]*SequenceResponse, 0, len(sequences)+len(numbering.DocumentTypes()))
+	for _, documentType := range numbering.DocumentTypes() {
+		if !configured[documentType] {
+			sequence := numbering.NewSequence(companyID, numbering.CompanyScope, documentType)
+			responses = append(responses, s.toSequenceResponse(sequence))
+		}
+	}
+	for _, sequence := range sequences {
+		responses = append(responses, s.toSequenceResponse(sequence))
+	}
+
+	return &ListSequencesResponse{
+		Sequences: responses,
+	}, nil
+}
+
+// ConfigureSequence sets the pattern of a sequence. The counter carries on, even when the
+// reset policy changes, and can only be moved forward so that numbers already given are
+// never given again; for the same reason, a sequence in use only takes a new pattern that
+// cannot produce its previous numbers.
+func (s *Service) ConfigureSequence(userID, companyID uint, req *ConfigureSequenceRequest) (*SequenceResponse, error) {
+	if err := s.checkCanManage(userID, companyID); err != nil {
+		return nil, err
+	}
+
+	scope := numbering.CompanyScope
+	if req.FranchiseID != nil {
+		franchise, err := s.franchiseRepo.FindByID(*req.FranchiseID)
+		if err != nil || franchise.ParentCompanyID != companyID {
+			return nil, errors.NewNotFoundError("franchise not found")
+		}
+		scope = franchise.ID
+	}
+
+	documentType := numbering.DocumentType(req.DocumentType)
+	if !documentType.IsValid() {
+		return nil, errors.NewValidationError("invalid document type")
+	}
+
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	sequence, err := s.lockSequence(tx, companyID, scope, documentType)
+	if err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to lock numbering sequence", err)
+	}
+
+	previous := *sequence
+	sequence.Pattern = req.Pattern
+	sequence.ResetPolicy = numbering.ResetPolicy(req.ResetPolicy)
+	if sequence.ResetPolicy != previous.ResetPolicy || !previous.HasIssued() {
+		// The counter carries on into the current period of the policy; Take only
+		// starts it over once that period ends
+		sequence.Period = sequence.CurrentPeriod(time.Now())
+	}
+	if !sequence.CanFollow(&previous) {
+		tx.Rollback()
+		return nil, errors.NewValidationError(fmt.Sprintf("pattern %q could repeat numbers already given by %q, its fixed start must differ", sequence.Pattern, previous.Pattern))
+	}
+	if req.NextNumber != nil {
+		if *req.NextNumber < sequence.NextNumber {
+			tx.Rollback()
+			return nil, errors.NewValidationError("next number cannot go back, numbers already given would be repeated")
+		}
+		sequence.NextNumber = *req.NextNumber
+	}
+
+	if err := sequence.Validate(); err != nil {
+		tx.Rollback()
+		return nil, errors.NewValidationError(err.Error())
+	}
+
+	if err := tx.Save(sequence).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to save numbering sequence", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	return s.toSequenceResponse(sequence), nil
+}
+
+func (s *Service) toSequenceResponse(sequence *numbering.Sequence) *SequenceResponse {
+	franchiseCode, _ := s.franchiseCode(sequence)
+	preview := *sequence
+	return ToSequenceResponse(sequence, preview.Take(time.Now(), franchiseCode))
+}
+
+// checkCanManage restricts numbering configuration to company owners and admins
+func (s *Service) checkCanManage(userID, companyID uint) error {
+	role, err := s.userRepo.FindUserRoleInCompany(userID, companyID)
+	if err != nil {
+		return errors.NewForbiddenError("you don't have access to this company")
+	}
+
+	if role.Role != user.RoleOwner && role.Role != user.RoleAdmin {
+		return errors.NewForbiddenError("only owners and admins can manage numbering sequences")
+	}
+
+	return nil
+}
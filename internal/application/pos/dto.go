@@ -1,7 +1,7 @@
 package pos
 
 import (
-	"fmt"
+	"math"
 	"time"
 
 	"github.com/YasserCherfaoui/darween/internal/domain/pos"
@@ -10,10 +10,18 @@ import (
 // Customer DTOs
 
 type CreateCustomerRequest struct {
-	Name    string `json:"name" binding:"required"`
-	Email   string `json:"email"`
-	Phone   string `json:"phone"`
-	Address string `json:"address"`
+	Name        string  `json:"name" binding:"required"`
+	Email       string  `json:"email"`
+	Phone       string  `json:"phone"`
+	Address     string  `json:"address"`
+	CreditLimit float64 `json:"credit_limit" binding:"min=0"` // Set by managers only
+	IsBusiness  bool    `json:"is_business"`                  // Set by managers only
+	NIF         string  `json:"nif" binding:"max=30"`
+	NIS         string  `json:"nis" binding:"max=30"`
+	RC          string  `json:"rc" binding:"max=30"`
+	AI          string  `json:"ai" binding:"max=30"`
+
+	CustomerGroupID *uint `json:"customer_group_id"` // Set by managers only
 }
 
 func (req *CreateCustomerRequest) ToCustomer(companyID uint) *pos.Customer {
@@ -22,8 +30,16 @@ func (req *CreateCustomerRequest) ToCustomer(companyID uint) *pos.Customer {
 		Name:      req.Name,
 		Email:     req.Email,
 		Phone:     req.Phone,
-		Address:   req.Address,
-		IsActive:  true,
+		Address:     req.Address,
+		CreditLimit: req.CreditLimit,
+		IsBusiness:  req.IsBusiness,
+		NIF:         req.NIF,
+		NIS:         req.NIS,
+		RC:          req.RC,
+		AI:          req.AI,
+		IsActive:    true,
+
+		CustomerGroupID: req.CustomerGroupID,
 	}
 }
 
@@ -31,66 +47,316 @@ type UpdateCustomerRequest struct {
 	Name     *string `json:"name"`
 	Email    *string `json:"email"`
 	Phone    *string `json:"phone"`
-	Address  *string `json:"address"`
-	IsActive *bool   `json:"is_active"`
+	Address     *string  `json:"address"`
+	CreditLimit *float64 `json:"credit_limit" binding:"omitempty,min=0"` // Set by managers only
+	IsBusiness  *bool    `json:"is_business"`                             // Set by managers only
+	NIF         *string  `json:"nif" binding:"omitempty,max=30"`
+	NIS         *string  `json:"nis" binding:"omitempty,max=30"`
+	RC          *string  `json:"rc" binding:"omitempty,max=30"`
+	AI          *string  `json:"ai" binding:"omitempty,max=30"`
+	IsActive    *bool    `json:"is_active"`
+
+	CustomerGroupID *uint `json:"customer_group_id"` // Set by managers only; 0 removes the group
 }
 
 type CustomerResponse struct {
-	ID             uint      `json:"id"`
-	CompanyID      uint      `json:"company_id"`
-	Name           string    `json:"name"`
-	Email          string    `json:"email"`
-	Phone          string    `json:"phone"`
-	Address        string    `json:"address"`
-	TotalPurchases float64   `json:"total_purchases"`
-	IsActive       bool      `json:"is_active"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID              uint      `json:"id"`
+	CompanyID       uint      `json:"company_id"`
+	Name            string    `json:"name"`
+	Email           string    `json:"email"`
+	Phone           string    `json:"phone"`
+	Address         string    `json:"address"`
+	TotalPurchases  float64   `json:"total_purchases"`
+	CreditLimit     float64   `json:"credit_limit"`
+	IsBusiness      bool      `json:"is_business"`
+	NIF             string    `json:"nif,omitempty"`
+	NIS             string    `json:"nis,omitempty"`
+	RC              string    `json:"rc,omitempty"`
+	AI              string    `json:"ai,omitempty"`
+	CustomerGroupID *uint     `json:"customer_group_id,omitempty"`
+	IsActive        bool      `json:"is_active"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
 }
 
 func ToCustomerResponse(customer *pos.Customer) *CustomerResponse {
 	return &CustomerResponse{
-		ID:             customer.ID,
-		CompanyID:      customer.CompanyID,
-		Name:           customer.Name,
-		Email:          customer.Email,
-		Phone:          customer.Phone,
-		Address:        customer.Address,
-		TotalPurchases: customer.TotalPurchases,
-		IsActive:       customer.IsActive,
-		CreatedAt:      customer.CreatedAt,
-		UpdatedAt:      customer.UpdatedAt,
+		ID:              customer.ID,
+		CompanyID:       customer.CompanyID,
+		Name:            customer.Name,
+		Email:           customer.Email,
+		Phone:           customer.Phone,
+		Address:         customer.Address,
+		TotalPurchases:  customer.TotalPurchases,
+		CreditLimit:     customer.CreditLimit,
+		IsBusiness:      customer.IsBusiness,
+		NIF:             customer.NIF,
+		NIS:             customer.NIS,
+		RC:              customer.RC,
+		AI:              customer.AI,
+		CustomerGroupID: customer.CustomerGroupID,
+		IsActive:        customer.IsActive,
+		CreatedAt:       customer.CreatedAt,
+		UpdatedAt:       customer.UpdatedAt,
+	}
+}
+
+// Customer import, duplicates and merge DTOs
+
+const (
+	CustomerImportRowSkipped = "skipped" // Matches an existing customer or an earlier row
+	CustomerImportRowFailed  = "failed"  // Invalid values
+)
+
+type CustomerImportRowResponse struct {
+	Row        int    `json:"row"` // Line of the file, the header being line 1
+	Status     string `json:"status"`
+	Message    string `json:"message"`
+	CustomerID *uint  `json:"customer_id,omitempty"` // Existing customer a skipped row matches
+}
+
+type CustomerImportResponse struct {
+	DryRun    bool                        `json:"dry_run"`
+	TotalRows int                         `json:"total_rows"`
+	Created   int                         `json:"created"` // Rows imported, or that would be on a dry run
+	Skipped   int                         `json:"skipped"`
+	Failed    int                         `json:"failed"`
+	Rows      []CustomerImportRowResponse `json:"rows"` // Rows skipped or failed, and why
+}
+
+type CustomerDuplicateGroupResponse struct {
+	MatchedOn string              `json:"matched_on"` // phone, email or name
+	Value     string              `json:"value"`      // Normalized value the customers share
+	Customers []*CustomerResponse `json:"customers"`  // Oldest first
+}
+
+type MergeCustomersRequest struct {
+	DuplicateIDs []uint `json:"duplicate_ids" binding:"required,min=1"` // Merged into the customer of the URL, then deleted
+}
+
+// Customer account DTOs
+
+type RecordCustomerPaymentRequest struct {
+	Amount        float64           `json:"amount" binding:"required,gt=0"`
+	PaymentMethod pos.PaymentMethod `json:"payment_method" binding:"required"`
+	Reference     string            `json:"reference"`
+	Notes         string            `json:"notes"`
+}
+
+type CustomerPaymentResponse struct {
+	ID            uint                                  `json:"id"`
+	CompanyID     uint                                  `json:"company_id"`
+	CustomerID    uint                                  `json:"customer_id"`
+	Amount        float64                               `json:"amount"`
+	PaymentMethod pos.PaymentMethod                     `json:"payment_method"`
+	Reference     string                                `json:"reference"`
+	Notes         string                                `json:"notes"`
+	CreatedByID   uint                                  `json:"created_by_id"`
+	CreatedAt     time.Time                             `json:"created_at"`
+	Distributions []CustomerPaymentDistributionResponse `json:"distributions"`
+}
+
+type CustomerPaymentDistributionResponse struct {
+	SaleID    uint    `json:"sale_id"`
+	PaymentID uint    `json:"payment_id"`
+	Amount    float64 `json:"amount"`
+}
+
+// AgingBuckets splits what is owed by the age of the sales it was charged on
+type AgingBuckets struct {
+	Current    float64 `json:"current"` // 0 to 30 days
+	Days31To60 float64 `json:"days_31_60"`
+	Days61To90 float64 `json:"days_61_90"`
+	Over90     float64 `json:"over_90"`
+	Total      float64 `json:"total"`
+}
+
+// Add puts an amount owed for ageDays days in its bucket
+func (b *AgingBuckets) Add(ageDays int, amount float64) {
+	switch {
+	case ageDays <= 30:
+		b.Current = roundCurrency(b.Current + amount)
+	case ageDays <= 60:
+		b.Days31To60 = roundCurrency(b.Days31To60 + amount)
+	case ageDays <= 90:
+		b.Days61To90 = roundCurrency(b.Days61To90 + amount)
+	default:
+		b.Over90 = roundCurrency(b.Over90 + amount)
+	}
+	b.Total = roundCurrency(b.Total + amount)
+}
+
+type AccountSaleResponse struct {
+	SaleID        uint      `json:"sale_id"`
+	ReceiptNumber string    `json:"receipt_number"`
+	Date          time.Time `json:"date"`
+	TotalAmount   float64   `json:"total_amount"`
+	ChargedAmount float64   `json:"charged_amount"`
+	Outstanding   float64   `json:"outstanding"`
+	AgeDays       int       `json:"age_days"`
+}
+
+type CustomerAccountResponse struct {
+	CustomerID      uint                  `json:"customer_id"`
+	CreditLimit     float64               `json:"credit_limit"`
+	Balance         float64               `json:"balance"`
+	AvailableCredit float64               `json:"available_credit"`
+	Aging           AgingBuckets          `json:"aging"`
+	OpenSales       []AccountSaleResponse `json:"open_sales"`
+}
+
+type CustomerStatementRequest struct {
+	StartDate time.Time `form:"start_date" time_format:"2006-01-02" binding:"required"`
+	EndDate   time.Time `form:"end_date" time_format:"2006-01-02" binding:"required"` // Inclusive
+}
+
+type StatementEntryResponse struct {
+	Date              time.Time `json:"date"`
+	Type              string    `json:"type"` // "sale" or "payment"
+	Reference         string    `json:"reference"`
+	SaleID            *uint     `json:"sale_id,omitempty"`
+	CustomerPaymentID *uint     `json:"customer_payment_id,omitempty"`
+	Debit             float64   `json:"debit"`
+	Credit            float64   `json:"credit"`
+	Balance           float64   `json:"balance"`
+}
+
+type CustomerStatementResponse struct {
+	CustomerID     uint                     `json:"customer_id"`
+	CustomerName   string                   `json:"customer_name"`
+	CreditLimit    float64                  `json:"credit_limit"`
+	StartDate      time.Time                `json:"start_date"`
+	EndDate        time.Time                `json:"end_date"`
+	OpeningBalance float64                  `json:"opening_balance"`
+	Entries        []StatementEntryResponse `json:"entries"`
+	ClosingBalance float64                  `json:"closing_balance"`
+}
+
+type CustomerAgingLine struct {
+	CustomerID   uint         `json:"customer_id"`
+	CustomerName string       `json:"customer_name"`
+	CreditLimit  float64      `json:"credit_limit"`
+	Aging        AgingBuckets `json:"aging"`
+}
+
+type CustomerAgingReportResponse struct {
+	AsOf      time.Time           `json:"as_of"`
+	Customers []CustomerAgingLine `json:"customers"`
+	Totals    AgingBuckets        `json:"totals"`
+}
+
+func ToCustomerPaymentResponse(payment *pos.CustomerPayment) *CustomerPaymentResponse {
+	response := &CustomerPaymentResponse{
+		ID:            payment.ID,
+		CompanyID:     payment.CompanyID,
+		CustomerID:    payment.CustomerID,
+		Amount:        payment.Amount,
+		PaymentMethod: payment.PaymentMethod,
+		Reference:     payment.Reference,
+		Notes:         payment.Notes,
+		CreatedByID:   payment.CreatedByID,
+		CreatedAt:     payment.CreatedAt,
+		Distributions: make([]CustomerPaymentDistributionResponse, len(payment.Distributions)),
 	}
+
+	for i, distribution := range payment.Distributions {
+		response.Distributions[i] = CustomerPaymentDistributionResponse{
+			SaleID:    distribution.SaleID,
+			PaymentID: distribution.PaymentID,
+			Amount:    distribution.Amount,
+		}
+	}
+
+	return response
+}
+
+// Gift card DTOs
+
+type SellGiftCardRequest struct {
+	FranchiseID   *uint           `json:"franchise_id"`
+	CustomerID    *uint           `json:"customer_id"`
+	Amount        float64         `json:"amount" binding:"required,gt=0"`
+	FranchiseOnly bool            `json:"franchise_only"` // Only valid in the franchise it is sold in
+	ExpiresAt     *time.Time      `json:"expires_at"`
+	Tenders       []TenderRequest `json:"tenders" binding:"required,min=1,dive"`
+}
+
+type GiftCardResponse struct {
+	VoucherID   uint       `json:"voucher_id"`
+	Code        string     `json:"code"`
+	Balance     float64    `json:"balance"`
+	FranchiseID *uint      `json:"franchise_id,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+type SellGiftCardResponse struct {
+	Sale      *SaleResponse     `json:"sale"`
+	GiftCard  *GiftCardResponse `json:"gift_card"`
+	Payments  []PaymentResponse `json:"payments"`
+	ChangeDue float64           `json:"change_due"`
 }
 
 // Sale DTOs
 
 type SaleItemRequest struct {
-	ProductVariantID uint    `json:"product_variant_id" binding:"required"`
-	Quantity         int     `json:"quantity" binding:"required,min=1"`
-	UnitPrice        float64 `json:"unit_price" binding:"required,min=0"`
-	DiscountAmount   float64 `json:"discount_amount"`
+	ProductVariantID uint     `json:"product_variant_id" binding:"required"`
+	Quantity         int      `json:"quantity" binding:"required,min=1"`
+	UnitPrice        *float64 `json:"unit_price" binding:"omitempty,min=0"` // Optional - a price other than the resolved price is an override
+	DiscountAmount   float64  `json:"discount_amount"`
+	OverrideReason   string   `json:"override_reason"` // Required when UnitPrice overrides the resolved price
+}
+
+// ManagerApproval identifies the manager approving an action, such as price overrides on a sale.
+// The approver's user ID is required, with their PIN unless the approver is the cashier
+// making the request.
+type ManagerApproval struct {
+	ApproverID *uint  `json:"approver_id"`
+	PIN        string `json:"pin"`
 }
 
 type CreateSaleRequest struct {
-	FranchiseID    *uint             `json:"franchise_id"`
-	CustomerID     *uint             `json:"customer_id"`
-	Items          []SaleItemRequest `json:"items" binding:"required,min=1"`
-	TaxAmount      float64           `json:"tax_amount"`
-	DiscountAmount float64           `json:"discount_amount"`
-	Notes          string            `json:"notes"`
+	FranchiseID      *uint             `json:"franchise_id"`
+	CustomerID       *uint             `json:"customer_id"`
+	Items            []SaleItemRequest `json:"items" binding:"required,min=1"`
+	DiscountAmount   float64           `json:"discount_amount"`
+	CouponCode       string            `json:"coupon_code"`
+	Notes            string            `json:"notes"`
+	OverrideApproval *ManagerApproval  `json:"override_approval"`
+	IsWholesale      bool              `json:"is_wholesale"` // Price the lines at wholesale prices; needs a business customer and takes no promotions
+}
+
+// UpdateParkedSaleRequest replaces the cart of a parked sale
+type UpdateParkedSaleRequest struct {
+	CustomerID       *uint             `json:"customer_id"`
+	Items            []SaleItemRequest `json:"items" binding:"required,min=1,dive"`
+	DiscountAmount   float64           `json:"discount_amount"`
+	CouponCode       string            `json:"coupon_code"`
+	Notes            string            `json:"notes"`
+	OverrideApproval *ManagerApproval  `json:"override_approval"`
 }
 
 type SaleItemResponse struct {
-	ID               uint      `json:"id"`
-	SaleID           uint      `json:"sale_id"`
-	ProductVariantID uint      `json:"product_variant_id"`
-	Quantity         int       `json:"quantity"`
-	UnitPrice        float64   `json:"unit_price"`
-	DiscountAmount   float64   `json:"discount_amount"`
-	SubTotal         float64   `json:"sub_total"`
-	TotalAmount      float64   `json:"total_amount"`
-	CreatedAt        time.Time `json:"created_at"`
+	ID                   uint      `json:"id"`
+	SaleID               uint      `json:"sale_id"`
+	ProductVariantID     uint      `json:"product_variant_id"`
+	Quantity             int       `json:"quantity"`
+	ListPrice            float64   `json:"list_price"`
+	UnitPrice            float64   `json:"unit_price"`
+	DiscountAmount       float64   `json:"discount_amount"`
+	SubTotal             float64   `json:"sub_total"`
+	TotalAmount          float64   `json:"total_amount"`
+	TaxClassID           *uint     `json:"tax_class_id,omitempty"`
+	TaxRate              float64   `json:"tax_rate"`
+	TaxInclusive         bool      `json:"tax_inclusive"`
+	NetAmount            float64   `json:"net_amount"`
+	TaxAmount            float64   `json:"tax_amount"`
+	PromotionID          *uint     `json:"promotion_id,omitempty"`
+	PromotionDiscount    float64   `json:"promotion_discount"`
+	SaleDiscountShare    float64   `json:"sale_discount_share"`
+	IsPriceOverride      bool      `json:"is_price_override"`
+	OverrideApprovedByID *uint     `json:"override_approved_by_id,omitempty"`
+	OverrideReason       string    `json:"override_reason,omitempty"`
+	CreatedAt            time.Time `json:"created_at"`
 	// Product and variant details
 	ProductName      *string   `json:"product_name,omitempty"`
 	VariantName      *string   `json:"variant_name,omitempty"`
@@ -99,18 +365,30 @@ type SaleItemResponse struct {
 
 func ToSaleItemResponse(item *pos.SaleItem, productName, variantName, variantSKU *string) *SaleItemResponse {
 	return &SaleItemResponse{
-		ID:               item.ID,
-		SaleID:           item.SaleID,
-		ProductVariantID: item.ProductVariantID,
-		Quantity:         item.Quantity,
-		UnitPrice:        item.UnitPrice,
-		DiscountAmount:   item.DiscountAmount,
-		SubTotal:         item.SubTotal,
-		TotalAmount:      item.TotalAmount,
-		CreatedAt:        item.CreatedAt,
-		ProductName:      productName,
-		VariantName:      variantName,
-		VariantSKU:       variantSKU,
+		ID:                   item.ID,
+		SaleID:               item.SaleID,
+		ProductVariantID:     item.ProductVariantID,
+		Quantity:             item.Quantity,
+		ListPrice:            item.ListPrice,
+		UnitPrice:            item.UnitPrice,
+		DiscountAmount:       item.DiscountAmount,
+		SubTotal:             item.SubTotal,
+		TotalAmount:          item.TotalAmount,
+		TaxClassID:           item.TaxClassID,
+		TaxRate:              item.TaxRate,
+		TaxInclusive:         item.TaxInclusive,
+		NetAmount:            item.NetAmount,
+		TaxAmount:            item.TaxAmount,
+		PromotionID:          item.PromotionID,
+		PromotionDiscount:    item.PromotionDiscount,
+		SaleDiscountShare:    item.SaleDiscountShare,
+		IsPriceOverride:      item.IsPriceOverride,
+		OverrideApprovedByID: item.OverrideApprovedByID,
+		OverrideReason:       item.OverrideReason,
+		CreatedAt:            item.CreatedAt,
+		ProductName:          productName,
+		VariantName:          variantName,
+		VariantSKU:           variantSKU,
 	}
 }
 
@@ -127,6 +405,17 @@ type SaleResponse struct {
 	PaymentStatus  pos.PaymentStatus  `json:"payment_status"`
 	SaleStatus     pos.SaleStatus     `json:"sale_status"`
 	Notes          string             `json:"notes"`
+	CouponCode     string             `json:"coupon_code,omitempty"`
+	ChargedAmount  float64            `json:"charged_amount,omitempty"` // Put on the customer's account
+	ExchangeID     *uint              `json:"exchange_id,omitempty"`
+	VoucherID      *uint              `json:"voucher_id,omitempty"` // Gift card sold by this sale
+	IsWholesale    bool               `json:"is_wholesale"`
+	ParkedAt       *time.Time         `json:"parked_at,omitempty"`
+	ClientUUID     *string            `json:"client_uuid,omitempty"` // Set on sales rung up offline
+	SyncedAt       *time.Time         `json:"synced_at,omitempty"`
+	VoidedAt       *time.Time         `json:"voided_at,omitempty"`
+	VoidedByID     *uint              `json:"voided_by_id,omitempty"`
+	VoidReason     string             `json:"void_reason,omitempty"`
 	CreatedByID    uint               `json:"created_by_id"`
 	CreatedAt      time.Time          `json:"created_at"`
 	UpdatedAt      time.Time          `json:"updated_at"`
@@ -157,6 +446,17 @@ func ToSaleResponseWithDetails(sale *pos.Sale, itemDetails map[uint]struct {
 		PaymentStatus:  sale.PaymentStatus,
 		SaleStatus:     sale.SaleStatus,
 		Notes:          sale.Notes,
+		CouponCode:     sale.CouponCode,
+		ChargedAmount:  sale.ChargedAmount,
+		ExchangeID:     sale.ExchangeID,
+		VoucherID:      sale.VoucherID,
+		IsWholesale:    sale.IsWholesale,
+		ParkedAt:       sale.ParkedAt,
+		ClientUUID:     sale.ClientUUID,
+		SyncedAt:       sale.SyncedAt,
+		VoidedAt:       sale.VoidedAt,
+		VoidedByID:     sale.VoidedByID,
+		VoidReason:     sale.VoidReason,
 		CreatedByID:    sale.CreatedByID,
 		CreatedAt:      sale.CreatedAt,
 		UpdatedAt:      sale.UpdatedAt,
@@ -197,6 +497,112 @@ func ToSaleResponseWithDetails(sale *pos.Sale, itemDetails map[uint]struct {
 	return response
 }
 
+// Offline sync DTOs
+
+type ReserveReceiptRangeRequest struct {
+	DeviceID    string `json:"device_id" binding:"required,max=100"`
+	FranchiseID *uint  `json:"franchise_id"`
+	Size        int    `json:"size" binding:"required,min=1,max=10000"` // How many receipt numbers to reserve
+}
+
+type ReceiptRangeResponse struct {
+	ID                 uint      `json:"id"`
+	CompanyID          uint      `json:"company_id"`
+	FranchiseID        *uint     `json:"franchise_id"`
+	DeviceID           string    `json:"device_id"`
+	Prefix             string    `json:"prefix"` // Receipt numbers are the prefix followed by the number on 6 digits
+	StartNumber        int       `json:"start_number"`
+	EndNumber          int       `json:"end_number"`
+	FirstReceiptNumber string    `json:"first_receipt_number"`
+	LastReceiptNumber  string    `json:"last_receipt_number"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+func ToReceiptRangeResponse(r *pos.OfflineReceiptRange) *ReceiptRangeResponse {
+	return &ReceiptRangeResponse{
+		ID:                 r.ID,
+		CompanyID:          r.CompanyID,
+		FranchiseID:        r.FranchiseID,
+		DeviceID:           r.DeviceID,
+		Prefix:             pos.OfflineReceiptPrefix(r.CompanyID),
+		StartNumber:        r.StartNumber,
+		EndNumber:          r.EndNumber,
+		FirstReceiptNumber: pos.OfflineReceiptNumber(r.CompanyID, r.StartNumber),
+		LastReceiptNumber:  pos.OfflineReceiptNumber(r.CompanyID, r.EndNumber),
+		CreatedAt:          r.CreatedAt,
+	}
+}
+
+type SyncOfflineSalesRequest struct {
+	DeviceID string               `json:"device_id" binding:"required,max=100"`
+	Sales    []OfflineSaleRequest `json:"sales" binding:"required,min=1,max=100,dive"`
+}
+
+// OfflineSaleRequest is a sale rung up by a device while offline, as it happened on the device
+type OfflineSaleRequest struct {
+	ClientUUID     string                   `json:"client_uuid" binding:"required,uuid"`
+	ReceiptNumber  string                   `json:"receipt_number" binding:"required"` // From a range reserved by the device
+	FranchiseID    *uint                    `json:"franchise_id"`
+	CustomerID     *uint                    `json:"customer_id"`
+	Items          []OfflineSaleItemRequest `json:"items" binding:"required,min=1,dive"`
+	DiscountAmount float64                  `json:"discount_amount" binding:"min=0"`
+	TotalAmount    float64                  `json:"total_amount" binding:"min=0"` // Total computed by the device
+	Tenders        []TenderRequest          `json:"tenders" binding:"omitempty,dive"`
+	Notes          string                   `json:"notes"`
+	CreatedAt      time.Time                `json:"created_at" binding:"required"` // When the sale was rung up
+}
+
+type OfflineSaleItemRequest struct {
+	ProductVariantID uint    `json:"product_variant_id" binding:"required"`
+	Quantity         int     `json:"quantity" binding:"required,min=1"`
+	UnitPrice        float64 `json:"unit_price" binding:"min=0"` // Price charged by the device
+	DiscountAmount   float64 `json:"discount_amount" binding:"min=0"`
+}
+
+// OfflineSaleStatus tells what became of an uploaded offline sale
+type OfflineSaleStatus string
+
+const (
+	OfflineSaleStatusCreated   OfflineSaleStatus = "created"
+	OfflineSaleStatusDuplicate OfflineSaleStatus = "duplicate" // Already uploaded; nothing was changed
+	OfflineSaleStatusRejected  OfflineSaleStatus = "rejected"
+)
+
+type OfflineSaleResult struct {
+	ClientUUID    string                 `json:"client_uuid"`
+	Status        OfflineSaleStatus      `json:"status"`
+	SaleID        *uint                  `json:"sale_id,omitempty"`
+	ReceiptNumber string                 `json:"receipt_number"`
+	Conflicts     []SyncConflictResponse `json:"conflicts"`
+	Error         string                 `json:"error,omitempty"` // Why the sale was rejected
+}
+
+type SyncConflictResponse struct {
+	Type             pos.SyncConflictType `json:"type"`
+	ProductVariantID *uint                `json:"product_variant_id,omitempty"`
+	Expected         float64              `json:"expected"`
+	Actual           float64              `json:"actual"`
+	Message          string               `json:"message"`
+}
+
+func ToSyncConflictResponse(conflict *pos.SyncConflict) SyncConflictResponse {
+	return SyncConflictResponse{
+		Type:             conflict.Type,
+		ProductVariantID: conflict.ProductVariantID,
+		Expected:         conflict.Expected,
+		Actual:           conflict.Actual,
+		Message:          conflict.Message,
+	}
+}
+
+type SyncOfflineSalesResponse struct {
+	Results    []OfflineSaleResult `json:"results"`
+	Created    int                 `json:"created"`
+	Duplicates int                 `json:"duplicates"`
+	Rejected   int                 `json:"rejected"`
+	Conflicts  int                 `json:"conflicts"` // Sales accepted with conflicts to review
+}
+
 // Payment DTOs
 
 type AddPaymentRequest struct {
@@ -206,27 +612,52 @@ type AddPaymentRequest struct {
 	Notes         string            `json:"notes"`
 }
 
+// TenderRequest is one means of payment handed over at checkout; Amount is what the
+// customer gives, which may exceed the amount due for cash
+type TenderRequest struct {
+	PaymentMethod pos.PaymentMethod `json:"payment_method" binding:"required"`
+	Amount        float64           `json:"amount" binding:"required,gt=0"`
+	Reference     string            `json:"reference"`
+	Notes         string            `json:"notes"`
+}
+
+type CheckoutRequest struct {
+	Tenders         []TenderRequest `json:"tenders" binding:"omitempty,dive"`
+	ChargeToAccount bool            `json:"charge_to_account"` // Put what the tenders leave due on the customer's account
+}
+
+type CheckoutResponse struct {
+	Sale           *SaleResponse     `json:"sale"`
+	Payments       []PaymentResponse `json:"payments"`
+	TenderedAmount float64           `json:"tendered_amount"`
+	ChangeDue      float64           `json:"change_due"`
+}
+
 type PaymentResponse struct {
-	ID            uint                         `json:"id"`
-	SaleID        uint                         `json:"sale_id"`
-	PaymentMethod pos.PaymentMethod            `json:"payment_method"`
-	Amount        float64                      `json:"amount"`
-	PaymentStatus pos.PaymentTransactionStatus `json:"payment_status"`
-	Reference     string                       `json:"reference"`
-	Notes         string                       `json:"notes"`
-	CreatedAt     time.Time                    `json:"created_at"`
+	ID             uint                         `json:"id"`
+	SaleID         uint                         `json:"sale_id"`
+	PaymentMethod  pos.PaymentMethod            `json:"payment_method"`
+	Amount         float64                      `json:"amount"`
+	TenderedAmount float64                      `json:"tendered_amount"`
+	ChangeAmount   float64                      `json:"change_amount"`
+	PaymentStatus  pos.PaymentTransactionStatus `json:"payment_status"`
+	Reference      string                       `json:"reference"`
+	Notes          string                       `json:"notes"`
+	CreatedAt      time.Time                    `json:"created_at"`
 }
 
 func ToPaymentResponse(payment *pos.Payment) *PaymentResponse {
 	return &PaymentResponse{
-		ID:            payment.ID,
-		SaleID:        payment.SaleID,
-		PaymentMethod: payment.PaymentMethod,
-		Amount:        payment.Amount,
-		PaymentStatus: payment.PaymentStatus,
-		Reference:     payment.Reference,
-		Notes:         payment.Notes,
-		CreatedAt:     payment.CreatedAt,
+		ID:             payment.ID,
+		SaleID:         payment.SaleID,
+		PaymentMethod:  payment.PaymentMethod,
+		Amount:         payment.Amount,
+		TenderedAmount: payment.TenderedAmount,
+		ChangeAmount:   payment.ChangeAmount,
+		PaymentStatus:  payment.PaymentStatus,
+		Reference:      payment.Reference,
+		Notes:          payment.Notes,
+		CreatedAt:      payment.CreatedAt,
 	}
 }
 
@@ -238,9 +669,34 @@ type OpenCashDrawerRequest struct {
 	Notes          string  `json:"notes"`
 }
 
+// CloseCashDrawerRequest gives the cash counted at closing, either as one closing balance
+// or as a count per banknote and coin; with both, they must agree
 type CloseCashDrawerRequest struct {
-	ClosingBalance float64 `json:"closing_balance" binding:"required,min=0"`
-	Notes          string  `json:"notes"`
+	ClosingBalance *float64                   `json:"closing_balance" binding:"omitempty,min=0"`
+	Counts         []DenominationCountRequest `json:"counts" binding:"dive"`
+	Notes          string                     `json:"notes"`
+}
+
+type DenominationCountRequest struct {
+	Denomination float64 `json:"denomination" binding:"required,gt=0"`
+	Quantity     int     `json:"quantity" binding:"min=0"`
+}
+
+type DenominationCountResponse struct {
+	Denomination float64 `json:"denomination"`
+	Quantity     int     `json:"quantity"`
+	Total        float64 `json:"total"`
+}
+
+// CashMovementRequest records a pay-in, pay-out or safe drop on the open drawer.
+// Approval is needed when the amount is above the company's cash approval limit.
+type CashMovementRequest struct {
+	FranchiseID *uint            `json:"franchise_id"`
+	Type        string           `json:"type" binding:"required,oneof=pay_in pay_out safe_drop"`
+	Amount      float64          `json:"amount" binding:"required,gt=0"`
+	ReasonCode  string           `json:"reason_code" binding:"required"`
+	Notes       string           `json:"notes"`
+	Approval    *ManagerApproval `json:"approval"`
 }
 
 type CashDrawerTransactionResponse struct {
@@ -249,7 +705,10 @@ type CashDrawerTransactionResponse struct {
 	TransactionType pos.CashDrawerTransactionType `json:"transaction_type"`
 	Amount          float64                       `json:"amount"`
 	SaleID          *uint                         `json:"sale_id"`
+	ReasonCode      pos.CashMovementReason        `json:"reason_code,omitempty"`
 	Notes           string                        `json:"notes"`
+	CreatedByID     *uint                         `json:"created_by_id,omitempty"`
+	ApprovedByID    *uint                         `json:"approved_by_id,omitempty"`
 	CreatedAt       time.Time                     `json:"created_at"`
 }
 
@@ -260,7 +719,10 @@ func ToCashDrawerTransactionResponse(transaction *pos.CashDrawerTransaction) *Ca
 		TransactionType: transaction.TransactionType,
 		Amount:          transaction.Amount,
 		SaleID:          transaction.SaleID,
+		ReasonCode:      transaction.ReasonCode,
 		Notes:           transaction.Notes,
+		CreatedByID:     transaction.CreatedByID,
+		ApprovedByID:    transaction.ApprovedByID,
 		CreatedAt:       transaction.CreatedAt,
 	}
 }
@@ -280,6 +742,8 @@ type CashDrawerResponse struct {
 	ClosedAt        *time.Time                      `json:"closed_at"`
 	Notes           string                          `json:"notes"`
 	Transactions    []CashDrawerTransactionResponse `json:"transactions,omitempty"`
+	Counts          []DenominationCountResponse     `json:"counts,omitempty"`
+	ZReport         *ShiftReportResponse            `json:"z_report,omitempty"` // Set when the drawer has just been closed
 	CreatedAt       time.Time                       `json:"created_at"`
 	UpdatedAt       time.Time                       `json:"updated_at"`
 }
@@ -310,28 +774,148 @@ func ToCashDrawerResponse(drawer *pos.CashDrawer) *CashDrawerResponse {
 		}
 	}
 
+	if len(drawer.Counts) > 0 {
+		response.Counts = ToDenominationCountResponses(drawer.Counts)
+	}
+
 	return response
 }
 
+func ToDenominationCountResponses(counts []pos.CashDrawerCount) []DenominationCountResponse {
+	responses := make([]DenominationCountResponse, len(counts))
+	for i := range counts {
+		responses[i] = DenominationCountResponse{
+			Denomination: counts[i].Denomination,
+			Quantity:     counts[i].Quantity,
+			Total:        counts[i].Total(),
+		}
+	}
+	return responses
+}
+
+// Shift report DTOs
+
+// ShiftReportType tells a mid-shift X report from the Z report of a closed drawer
+type ShiftReportType string
+
+const (
+	ShiftReportTypeX ShiftReportType = "X"
+	ShiftReportTypeZ ShiftReportType = "Z"
+)
+
+// ShiftReportResponse summarizes a cash drawer session. Cash amounts are signed like
+// drawer transactions: money leaving the drawer is negative.
+type ShiftReportResponse struct {
+	ReportType       ShiftReportType             `json:"report_type"`
+	CashDrawerID     uint                        `json:"cash_drawer_id"`
+	CompanyID        *uint                       `json:"company_id,omitempty"`
+	FranchiseID      *uint                       `json:"franchise_id,omitempty"`
+	OpenedByID       uint                        `json:"opened_by_id"`
+	ClosedByID       *uint                       `json:"closed_by_id,omitempty"`
+	OpenedAt         time.Time                   `json:"opened_at"`
+	ClosedAt         *time.Time                  `json:"closed_at,omitempty"`
+	GeneratedAt      time.Time                   `json:"generated_at"`
+	SaleCount        int64                       `json:"sale_count"`
+	SalesTotal       float64                     `json:"sales_total"`
+	TaxTotal         float64                     `json:"tax_total"`
+	DiscountTotal    float64                     `json:"discount_total"`
+	VoidCount        int64                       `json:"void_count"`
+	VoidTotal        float64                     `json:"void_total"`
+	RefundCount      int64                       `json:"refund_count"`
+	RefundTotal      float64                     `json:"refund_total"`
+	RefundsByMethod  []MethodTotalResponse       `json:"refunds_by_method"`
+	PaymentsByMethod []MethodTotalResponse       `json:"payments_by_method"`
+	Cash             ShiftCashResponse           `json:"cash"`
+	Counts           []DenominationCountResponse `json:"counts,omitempty"`
+}
+
+type MethodTotalResponse struct {
+	Method pos.PaymentMethod `json:"method"`
+	Count  int64             `json:"count"`
+	Amount float64           `json:"amount"`
+}
+
+type ShiftCashResponse struct {
+	OpeningBalance  float64  `json:"opening_balance"`
+	Sales           float64  `json:"sales"`
+	Refunds         float64  `json:"refunds"`
+	Voids           float64  `json:"voids"`
+	PayIns          float64  `json:"pay_ins"`
+	PayOuts         float64  `json:"pay_outs"`
+	SafeDrops       float64  `json:"safe_drops"`
+	Adjustments     float64  `json:"adjustments"`
+	ExpectedBalance float64  `json:"expected_balance"`
+	CountedBalance  *float64 `json:"counted_balance,omitempty"` // Z report only
+	Variance        *float64 `json:"variance,omitempty"`        // Counted minus expected
+}
+
+func ToMethodTotalResponses(lines []pos.PaymentMethodTotal) []MethodTotalResponse {
+	responses := make([]MethodTotalResponse, len(lines))
+	for i, line := range lines {
+		responses[i] = MethodTotalResponse{
+			Method: line.Method,
+			Count:  line.Count,
+			Amount: line.Amount,
+		}
+	}
+	return responses
+}
+
+type VoidSaleRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
 // Refund DTOs
 
+type RefundItemRequest struct {
+	SaleItemID uint `json:"sale_item_id" binding:"required"`
+	Quantity   int  `json:"quantity" binding:"required,min=1"`
+}
+
 type ProcessRefundRequest struct {
-	RefundAmount float64           `json:"refund_amount" binding:"required,min=0"`
-	Reason       string            `json:"reason" binding:"required"`
-	RefundMethod pos.PaymentMethod `json:"refund_method" binding:"required"`
+	Items        []RefundItemRequest `json:"items" binding:"omitempty,dive"` // Optional - refunds all remaining quantities when empty
+	RefundAmount float64             `json:"refund_amount" binding:"omitempty,min=0"` // Optional - computed from the returned lines when zero
+	Reason       string              `json:"reason" binding:"required"`
+	RefundMethod pos.PaymentMethod   `json:"refund_method" binding:"required"`
+}
+
+type RefundItemResponse struct {
+	ID               uint      `json:"id"`
+	RefundID         uint      `json:"refund_id"`
+	SaleItemID       uint      `json:"sale_item_id"`
+	ProductVariantID uint      `json:"product_variant_id"`
+	Quantity         int       `json:"quantity"`
+	RefundAmount     float64   `json:"refund_amount"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func ToRefundItemResponse(item *pos.RefundItem) *RefundItemResponse {
+	return &RefundItemResponse{
+		ID:               item.ID,
+		RefundID:         item.RefundID,
+		SaleItemID:       item.SaleItemID,
+		ProductVariantID: item.ProductVariantID,
+		Quantity:         item.Quantity,
+		RefundAmount:     item.RefundAmount,
+		CreatedAt:        item.CreatedAt,
+	}
 }
 
 type RefundResponse struct {
-	ID             uint              `json:"id"`
-	OriginalSaleID uint              `json:"original_sale_id"`
-	RefundAmount   float64           `json:"refund_amount"`
-	Reason         string            `json:"reason"`
-	RefundMethod   pos.PaymentMethod `json:"refund_method"`
-	RefundStatus   pos.RefundStatus  `json:"refund_status"`
-	ProcessedByID  uint              `json:"processed_by_id"`
-	CreatedAt      time.Time         `json:"created_at"`
-	UpdatedAt      time.Time         `json:"updated_at"`
-	OriginalSale   *SaleResponse     `json:"original_sale,omitempty"`
+	ID             uint                 `json:"id"`
+	OriginalSaleID uint                 `json:"original_sale_id"`
+	RefundAmount   float64              `json:"refund_amount"`
+	Reason         string               `json:"reason"`
+	RefundMethod   pos.PaymentMethod    `json:"refund_method"`
+	RefundStatus   pos.RefundStatus     `json:"refund_status"`
+	ExchangeID     *uint                `json:"exchange_id,omitempty"`
+	VoucherID      *uint                `json:"voucher_id,omitempty"`   // Store credit issued for the refund
+	VoucherCode    string               `json:"voucher_code,omitempty"` // Code to hand to the customer
+	ProcessedByID  uint                 `json:"processed_by_id"`
+	CreatedAt      time.Time            `json:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at"`
+	Items          []RefundItemResponse `json:"items,omitempty"`
+	OriginalSale   *SaleResponse        `json:"original_sale,omitempty"`
 }
 
 func ToRefundResponse(refund *pos.Refund) *RefundResponse {
@@ -342,11 +926,21 @@ func ToRefundResponse(refund *pos.Refund) *RefundResponse {
 		Reason:         refund.Reason,
 		RefundMethod:   refund.RefundMethod,
 		RefundStatus:   refund.RefundStatus,
+		ExchangeID:     refund.ExchangeID,
+		VoucherID:      refund.VoucherID,
+		VoucherCode:    refund.VoucherCode,
 		ProcessedByID:  refund.ProcessedByID,
 		CreatedAt:      refund.CreatedAt,
 		UpdatedAt:      refund.UpdatedAt,
 	}
 
+	if len(refund.Items) > 0 {
+		response.Items = make([]RefundItemResponse, len(refund.Items))
+		for i, item := range refund.Items {
+			response.Items[i] = *ToRefundItemResponse(&item)
+		}
+	}
+
 	if refund.OriginalSale != nil {
 		response.OriginalSale = ToSaleResponse(refund.OriginalSale)
 	}
@@ -354,6 +948,258 @@ func ToRefundResponse(refund *pos.Refund) *RefundResponse {
 	return response
 }
 
+// Layaway DTOs
+
+type CreateLayawayRequest struct {
+	FranchiseID      *uint             `json:"franchise_id"`
+	CustomerID       uint              `json:"customer_id" binding:"required"`
+	Items            []SaleItemRequest `json:"items" binding:"required,min=1,dive"`
+	DiscountAmount   float64           `json:"discount_amount"`
+	CouponCode       string            `json:"coupon_code"`
+	Notes            string            `json:"notes"`
+	OverrideApproval *ManagerApproval  `json:"override_approval"`
+	ExpiresAt        *time.Time        `json:"expires_at"`        // Defaults to a week from now
+	ForfeitOnExpiry  bool              `json:"forfeit_on_expiry"` // Whether the store keeps the deposits if the items are not picked up in time
+	Deposits         []TenderRequest   `json:"deposits" binding:"required,min=1,dive"`
+}
+
+// CompleteLayawayRequest pays what the deposits left due when the items are picked up
+type CompleteLayawayRequest struct {
+	Tenders []TenderRequest `json:"tenders" binding:"omitempty,dive"`
+}
+
+type CancelLayawayRequest struct {
+	Reason         string `json:"reason" binding:"required"`
+	ForfeitDeposit bool   `json:"forfeit_deposit"` // Managers only, the deposits are set aside for a refund otherwise
+}
+
+// ResolveLayawayDepositRequest settles the deposits of a cancelled or expired layaway
+type ResolveLayawayDepositRequest struct {
+	Action string `json:"action" binding:"required,oneof=refund forfeit"`
+	Reason string `json:"reason"`
+}
+
+type LayawayResponse struct {
+	ID              uint              `json:"id"`
+	CompanyID       uint              `json:"company_id"`
+	FranchiseID     *uint             `json:"franchise_id"`
+	CustomerID      uint              `json:"customer_id"`
+	SaleID          uint              `json:"sale_id"`
+	Status          pos.LayawayStatus `json:"status"`
+	ExpiresAt       time.Time         `json:"expires_at"`
+	ForfeitOnExpiry bool              `json:"forfeit_on_expiry"`
+	DepositStatus   pos.DepositStatus `json:"deposit_status"`
+	DepositRefundID *uint             `json:"deposit_refund_id,omitempty"`
+	TotalAmount     float64           `json:"total_amount"`
+	DepositPaid     float64           `json:"deposit_paid"`
+	Balance         float64           `json:"balance"` // Left to pay to pick the items up
+	ClosedAt        *time.Time        `json:"closed_at,omitempty"`
+	ClosedByID      *uint             `json:"closed_by_id,omitempty"`
+	CloseReason     string            `json:"close_reason,omitempty"`
+	CreatedByID     uint              `json:"created_by_id"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+	Sale            *SaleResponse     `json:"sale,omitempty"`
+	Customer        *CustomerResponse `json:"customer,omitempty"`
+}
+
+func ToLayawayResponse(layaway *pos.Layaway) *LayawayResponse {
+	response := &LayawayResponse{
+		ID:              layaway.ID,
+		CompanyID:       layaway.CompanyID,
+		FranchiseID:     layaway.FranchiseID,
+		CustomerID:      layaway.CustomerID,
+		SaleID:          layaway.SaleID,
+		Status:          layaway.Status,
+		ExpiresAt:       layaway.ExpiresAt,
+		ForfeitOnExpiry: layaway.ForfeitOnExpiry,
+		DepositStatus:   layaway.DepositStatus,
+		DepositRefundID: layaway.DepositRefundID,
+		ClosedAt:        layaway.ClosedAt,
+		ClosedByID:      layaway.ClosedByID,
+		CloseReason:     layaway.CloseReason,
+		CreatedByID:     layaway.CreatedByID,
+		CreatedAt:       layaway.CreatedAt,
+		UpdatedAt:       layaway.UpdatedAt,
+	}
+
+	if layaway.Sale != nil {
+		paid := 0.0
+		for _, payment := range layaway.Sale.Payments {
+			if payment.PaymentStatus == pos.PaymentTransactionStatusCompleted {
+				paid += payment.Amount
+			}
+		}
+		response.TotalAmount = layaway.Sale.TotalAmount
+		response.DepositPaid = roundCurrency(paid)
+		response.Balance = roundCurrency(math.Max(layaway.Sale.TotalAmount-paid, 0))
+		response.Sale = ToSaleResponse(layaway.Sale)
+	}
+
+	if layaway.Customer != nil {
+		response.Customer = ToCustomerResponse(layaway.Customer)
+	}
+
+	return response
+}
+
+// Invoice and proforma DTOs
+
+type InvoiceResponse struct {
+	ID            uint      `json:"id"`
+	CompanyID     uint      `json:"company_id"`
+	FranchiseID   *uint     `json:"franchise_id"`
+	SaleID        uint      `json:"sale_id"`
+	InvoiceNumber string    `json:"invoice_number"`
+	SellerName    string    `json:"seller_name"`
+	BuyerName     string    `json:"buyer_name"`
+	IssuedByID    uint      `json:"issued_by_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func ToInvoiceResponse(invoice *pos.Invoice) *InvoiceResponse {
+	return &InvoiceResponse{
+		ID:            invoice.ID,
+		CompanyID:     invoice.CompanyID,
+		FranchiseID:   invoice.FranchiseID,
+		SaleID:        invoice.SaleID,
+		InvoiceNumber: invoice.InvoiceNumber,
+		SellerName:    invoice.Seller.Name,
+		BuyerName:     invoice.Buyer.Name,
+		IssuedByID:    invoice.IssuedByID,
+		CreatedAt:     invoice.CreatedAt,
+	}
+}
+
+type CreateProformaRequest struct {
+	FranchiseID      *uint             `json:"franchise_id"`
+	CustomerID       uint              `json:"customer_id" binding:"required"`
+	Items            []SaleItemRequest `json:"items" binding:"required,min=1,dive"`
+	DiscountAmount   float64           `json:"discount_amount"`
+	CouponCode       string            `json:"coupon_code"`
+	Notes            string            `json:"notes"`
+	OverrideApproval *ManagerApproval  `json:"override_approval"`
+	IsWholesale      bool              `json:"is_wholesale"`
+	ValidUntil       *time.Time        `json:"valid_until"` // Defaults to 30 days from now
+}
+
+type ProformaResponse struct {
+	ID             uint               `json:"id"`
+	CompanyID      uint               `json:"company_id"`
+	FranchiseID    *uint              `json:"franchise_id"`
+	CustomerID     uint               `json:"customer_id"`
+	SaleID         uint               `json:"sale_id"`
+	ProformaNumber string             `json:"proforma_number"`
+	Status         pos.ProformaStatus `json:"status"`
+	ValidUntil     time.Time          `json:"valid_until"`
+	IsExpired      bool               `json:"is_expired"`
+	TotalAmount    float64            `json:"total_amount"`
+	ClosedAt       *time.Time         `json:"closed_at,omitempty"`
+	ClosedByID     *uint              `json:"closed_by_id,omitempty"`
+	CreatedByID    uint               `json:"created_by_id"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+	Sale           *SaleResponse      `json:"sale,omitempty"`
+	Customer       *CustomerResponse  `json:"customer,omitempty"`
+}
+
+func ToProformaResponse(proforma *pos.Proforma) *ProformaResponse {
+	response := &ProformaResponse{
+		ID:             proforma.ID,
+		CompanyID:      proforma.CompanyID,
+		FranchiseID:    proforma.FranchiseID,
+		CustomerID:     proforma.CustomerID,
+		SaleID:         proforma.SaleID,
+		ProformaNumber: proforma.ProformaNumber,
+		Status:         proforma.Status,
+		ValidUntil:     proforma.ValidUntil,
+		IsExpired:      proforma.IsOpen() && proforma.IsExpired(time.Now()),
+		ClosedAt:       proforma.ClosedAt,
+		ClosedByID:     proforma.ClosedByID,
+		CreatedByID:    proforma.CreatedByID,
+		CreatedAt:      proforma.CreatedAt,
+		UpdatedAt:      proforma.UpdatedAt,
+	}
+
+	if proforma.Sale != nil {
+		response.TotalAmount = proforma.Sale.TotalAmount
+		response.Sale = ToSaleResponse(proforma.Sale)
+	}
+
+	if proforma.Customer != nil {
+		response.Customer = ToCustomerResponse(proforma.Customer)
+	}
+
+	return response
+}
+
+// Exchange DTOs
+
+type ExchangeReplacementItemRequest struct {
+	ProductVariantID uint `json:"product_variant_id" binding:"required"`
+	Quantity         int  `json:"quantity" binding:"required,min=1"`
+}
+
+type ProcessExchangeRequest struct {
+	ReturnedItems    []RefundItemRequest              `json:"returned_items" binding:"required,min=1,dive"`
+	ReplacementItems []ExchangeReplacementItemRequest `json:"replacement_items" binding:"required,min=1,dive"`
+	SettlementMethod pos.PaymentMethod                `json:"settlement_method"` // Optional - how the balance is paid or given back, defaults to cash
+	Reference        string                           `json:"reference"`
+	Reason           string                           `json:"reason" binding:"required"`
+	Approval         *ManagerApproval                 `json:"approval"` // Needed from cashiers when the customer is paid back
+}
+
+type ExchangeResponse struct {
+	ID                uint                 `json:"id"`
+	CompanyID         uint                 `json:"company_id"`
+	FranchiseID       *uint                `json:"franchise_id"`
+	OriginalSaleID    uint                 `json:"original_sale_id"`
+	RefundID          *uint                `json:"refund_id"`
+	ReplacementSaleID *uint                `json:"replacement_sale_id"`
+	ReturnedAmount    float64              `json:"returned_amount"`
+	ReplacementAmount float64              `json:"replacement_amount"`
+	BalanceAmount     float64              `json:"balance_amount"`
+	SettlementMethod  pos.PaymentMethod    `json:"settlement_method"`
+	Reason            string               `json:"reason"`
+	ProcessedByID     uint                 `json:"processed_by_id"`
+	ApprovedByID      *uint                `json:"approved_by_id,omitempty"`
+	CreatedAt         time.Time            `json:"created_at"`
+	ReturnedItems     []RefundItemResponse `json:"returned_items,omitempty"`
+	ReplacementSale   *SaleResponse        `json:"replacement_sale,omitempty"`
+}
+
+func ToExchangeResponse(exchange *pos.Exchange) *ExchangeResponse {
+	response := &ExchangeResponse{
+		ID:                exchange.ID,
+		CompanyID:         exchange.CompanyID,
+		FranchiseID:       exchange.FranchiseID,
+		OriginalSaleID:    exchange.OriginalSaleID,
+		RefundID:          exchange.RefundID,
+		ReplacementSaleID: exchange.ReplacementSaleID,
+		ReturnedAmount:    exchange.ReturnedAmount,
+		ReplacementAmount: exchange.ReplacementAmount,
+		BalanceAmount:     exchange.BalanceAmount,
+		SettlementMethod:  exchange.SettlementMethod,
+		Reason:            exchange.Reason,
+		ProcessedByID:     exchange.ProcessedByID,
+		ApprovedByID:      exchange.ApprovedByID,
+		CreatedAt:         exchange.CreatedAt,
+	}
+
+	if exchange.Refund != nil && len(exchange.Refund.Items) > 0 {
+		response.ReturnedItems = make([]RefundItemResponse, len(exchange.Refund.Items))
+		for i, item := range exchange.Refund.Items {
+			response.ReturnedItems[i] = *ToRefundItemResponse(&item)
+		}
+	}
+
+	if exchange.ReplacementSale != nil {
+		response.ReplacementSale = ToSaleResponse(exchange.ReplacementSale)
+	}
+
+	return response
+}
+
 // Sales Report DTOs
 
 type SalesReportRequest struct {
@@ -370,18 +1216,220 @@ type SalesReportResponse struct {
 	TotalRefunded     float64            `json:"total_refunded"`
 	AverageOrderValue float64            `json:"average_order_value"`
 	SalesByDate       map[string]float64 `json:"sales_by_date"`
+	// Wholesale and retail split of the revenue
+	WholesaleSales   int64   `json:"wholesale_sales"`
+	WholesaleRevenue float64 `json:"wholesale_revenue"`
+	RetailRevenue    float64 `json:"retail_revenue"`
+	// Price overrides
+	PriceOverrideCount  int64   `json:"price_override_count"`
+	PriceOverrideAmount float64 `json:"price_override_amount"`
+	// Exchanges (returned value is already netted out of revenue)
+	ExchangeCount    int64   `json:"exchange_count"`
+	ExchangeReturned float64 `json:"exchange_returned"`
+	ExchangeBalance  float64 `json:"exchange_balance"`
+	// Voids (cancelled sales, not part of revenue or refunds)
+	VoidCount  int64   `json:"void_count"`
+	VoidAmount float64 `json:"void_amount"`
+	// Gift cards sold (a liability until spent, not part of revenue)
+	GiftCardCount  int64   `json:"gift_card_count"`
+	GiftCardAmount float64 `json:"gift_card_amount"`
+	// Tax collected per class and rate, net of refunds
+	TotalTax     float64                    `json:"total_tax"`
+	TaxBreakdown []TaxBreakdownLineResponse `json:"tax_breakdown"`
+	// Discounts given by promotions
+	PromotionCost      float64                     `json:"promotion_cost"`
+	PromotionBreakdown []PromotionCostLineResponse `json:"promotion_breakdown"`
+}
+
+type PromotionCostLineResponse struct {
+	PromotionID   uint    `json:"promotion_id"`
+	PromotionName string  `json:"promotion_name"`
+	SaleCount     int64   `json:"sale_count"`
+	Amount        float64 `json:"amount"`
+}
+
+type TaxBreakdownLineResponse struct {
+	TaxClassID   *uint   `json:"tax_class_id,omitempty"`
+	TaxClassName string  `json:"tax_class_name"`
+	TaxRate      float64 `json:"tax_rate"`
+	NetAmount    float64 `json:"net_amount"`
+	TaxAmount    float64 `json:"tax_amount"`
 }
 
 func ToSalesReportResponse(data *pos.SalesReportData) *SalesReportResponse {
+	taxBreakdown := make([]TaxBreakdownLineResponse, len(data.TaxBreakdown))
+	for i, line := range data.TaxBreakdown {
+		taxBreakdown[i] = TaxBreakdownLineResponse{
+			TaxClassID:   line.TaxClassID,
+			TaxClassName: line.TaxClassName,
+			TaxRate:      line.TaxRate,
+			NetAmount:    roundCurrency(line.NetAmount),
+			TaxAmount:    roundCurrency(line.TaxAmount),
+		}
+	}
+
+	promotionBreakdown := make([]PromotionCostLineResponse, len(data.PromotionBreakdown))
+	for i, line := range data.PromotionBreakdown {
+		promotionBreakdown[i] = PromotionCostLineResponse{
+			PromotionID:   line.PromotionID,
+			PromotionName: line.PromotionName,
+			SaleCount:     line.SaleCount,
+			Amount:        roundCurrency(line.Amount),
+		}
+	}
+
 	return &SalesReportResponse{
-		TotalSales:        data.TotalSales,
-		TotalRevenue:      data.TotalRevenue,
-		TotalCash:         data.TotalCash,
-		TotalCard:         data.TotalCard,
-		TotalRefunded:     data.TotalRefunded,
-		AverageOrderValue: data.AverageOrderValue,
-		SalesByDate:       data.SalesByDate,
+		TotalSales:          data.TotalSales,
+		TotalRevenue:        data.TotalRevenue,
+		TotalCash:           data.TotalCash,
+		TotalCard:           data.TotalCard,
+		TotalRefunded:       data.TotalRefunded,
+		AverageOrderValue:   data.AverageOrderValue,
+		SalesByDate:         data.SalesByDate,
+		WholesaleSales:      data.WholesaleSales,
+		WholesaleRevenue:    roundCurrency(data.WholesaleRevenue),
+		RetailRevenue:       roundCurrency(data.RetailRevenue),
+		PriceOverrideCount:  data.PriceOverrideCount,
+		PriceOverrideAmount: data.PriceOverrideAmount,
+		ExchangeCount:       data.ExchangeCount,
+		ExchangeReturned:    data.ExchangeReturned,
+		ExchangeBalance:     data.ExchangeBalance,
+		VoidCount:           data.VoidCount,
+		VoidAmount:          data.VoidAmount,
+		GiftCardCount:       data.GiftCardCount,
+		GiftCardAmount:      roundCurrency(data.GiftCardAmount),
+		TotalTax:            roundCurrency(data.TotalTax),
+		TaxBreakdown:        taxBreakdown,
+		PromotionCost:       roundCurrency(data.PromotionCost),
+		PromotionBreakdown:  promotionBreakdown,
+	}
+}
+
+// Sales Analytics DTOs
+
+// AnalyticsComparison is the period sales analytics are compared with
+type AnalyticsComparison string
+
+const (
+	AnalyticsComparePreviousPeriod AnalyticsComparison = "previous_period" // Same length, right before the period
+	AnalyticsComparePreviousYear   AnalyticsComparison = "previous_year"   // Same dates one year earlier
+)
+
+type SalesAnalyticsRequest struct {
+	StartDate   time.Time           `json:"start_date" binding:"required"`
+	EndDate     time.Time           `json:"end_date" binding:"required"`
+	FranchiseID *uint               `json:"franchise_id"`
+	Timezone    string              `json:"timezone"` // IANA zone for the hour and weekday breakdowns, UTC by default
+	CompareTo   AnalyticsComparison `json:"compare_to" binding:"omitempty,oneof=previous_period previous_year"`
+	TopProducts int                 `json:"top_products" binding:"omitempty,min=1,max=500"`
+}
+
+type SalesAnalyticsResponse struct {
+	StartDate       time.Time                    `json:"start_date"`
+	EndDate         time.Time                    `json:"end_date"`
+	Timezone        string                       `json:"timezone"`
+	Summary         AnalyticsSummaryResponse     `json:"summary"`
+	Comparison      *AnalyticsComparisonResponse `json:"comparison,omitempty"`
+	ByProduct       []AnalyticsLineResponse      `json:"by_product"`
+	ByCategory      []AnalyticsLineResponse      `json:"by_category"`
+	ByCashier       []AnalyticsLineResponse      `json:"by_cashier"`
+	ByHour          []AnalyticsLineResponse      `json:"by_hour"`
+	ByWeekday       []AnalyticsLineResponse      `json:"by_weekday"`
+	ByFranchise     []AnalyticsLineResponse      `json:"by_franchise"`
+	ByChannel       []AnalyticsLineResponse      `json:"by_channel"` // Wholesale and retail
+	ByPaymentMethod []MethodTotalResponse        `json:"by_payment_method"`
+}
+
+// AnalyticsSummaryResponse holds the totals of a period, net of returns
+type AnalyticsSummaryResponse struct {
+	SaleCount         int64   `json:"sale_count"`
+	Quantity          int64   `json:"quantity"`
+	Revenue           float64 `json:"revenue"`     // Tax included
+	NetRevenue        float64 `json:"net_revenue"` // Tax excluded
+	Cost              float64 `json:"cost"`
+	GrossMargin       float64 `json:"gross_margin"`   // Net revenue minus cost
+	MarginPercent     float64 `json:"margin_percent"` // Gross margin over net revenue
+	AverageOrderValue float64 `json:"average_order_value"`
+	UncostedQuantity  int64   `json:"uncosted_quantity"` // Units sold without a known cost, counted at zero cost
+}
+
+// AnalyticsLineResponse holds the totals of one product, category, cashier, hour, weekday or franchise
+type AnalyticsLineResponse struct {
+	Key              string  `json:"key"`
+	Label            string  `json:"label"`
+	SKU              string  `json:"sku,omitempty"`
+	SaleCount        int64   `json:"sale_count"`
+	Quantity         int64   `json:"quantity"`
+	Revenue          float64 `json:"revenue"`
+	NetRevenue       float64 `json:"net_revenue"`
+	Cost             float64 `json:"cost"`
+	GrossMargin      float64 `json:"gross_margin"`
+	MarginPercent    float64 `json:"margin_percent"`
+	RevenueShare     float64 `json:"revenue_share"` // Percentage of the period's revenue
+	UncostedQuantity int64   `json:"uncosted_quantity"`
+}
+
+// AnalyticsComparisonResponse holds the totals of the comparison period and the change
+// in percent from them; a change is omitted when the comparison value is zero
+type AnalyticsComparisonResponse struct {
+	CompareTo               AnalyticsComparison      `json:"compare_to"`
+	StartDate               time.Time                `json:"start_date"`
+	EndDate                 time.Time                `json:"end_date"`
+	Summary                 AnalyticsSummaryResponse `json:"summary"`
+	SaleCountChange         *float64                 `json:"sale_count_change,omitempty"`
+	QuantityChange          *float64                 `json:"quantity_change,omitempty"`
+	RevenueChange           *float64                 `json:"revenue_change,omitempty"`
+	NetRevenueChange        *float64                 `json:"net_revenue_change,omitempty"`
+	GrossMarginChange       *float64                 `json:"gross_margin_change,omitempty"`
+	AverageOrderValueChange *float64                 `json:"average_order_value_change,omitempty"`
+}
+
+func ToAnalyticsSummaryResponse(line *pos.AnalyticsLine) AnalyticsSummaryResponse {
+	summary := AnalyticsSummaryResponse{
+		SaleCount:        line.SaleCount,
+		Quantity:         line.Quantity,
+		Revenue:          roundCurrency(line.Revenue),
+		NetRevenue:       roundCurrency(line.NetRevenue),
+		Cost:             roundCurrency(line.Cost),
+		GrossMargin:      roundCurrency(line.NetRevenue - line.Cost),
+		MarginPercent:    marginPercent(line),
+		UncostedQuantity: line.UncostedQuantity,
 	}
+	if line.SaleCount > 0 {
+		summary.AverageOrderValue = roundCurrency(line.Revenue / float64(line.SaleCount))
+	}
+	return summary
+}
+
+// ToAnalyticsLineResponses converts breakdown lines; totalRevenue is the period's revenue the shares are taken of
+func ToAnalyticsLineResponses(lines []pos.AnalyticsLine, totalRevenue float64) []AnalyticsLineResponse {
+	responses := make([]AnalyticsLineResponse, len(lines))
+	for i, line := range lines {
+		responses[i] = AnalyticsLineResponse{
+			Key:              line.Key,
+			Label:            line.Label,
+			SKU:              line.SKU,
+			SaleCount:        line.SaleCount,
+			Quantity:         line.Quantity,
+			Revenue:          roundCurrency(line.Revenue),
+			NetRevenue:       roundCurrency(line.NetRevenue),
+			Cost:             roundCurrency(line.Cost),
+			GrossMargin:      roundCurrency(line.NetRevenue - line.Cost),
+			MarginPercent:    marginPercent(&line),
+			UncostedQuantity: line.UncostedQuantity,
+		}
+		if totalRevenue != 0 {
+			responses[i].RevenueShare = roundCurrency(line.Revenue / totalRevenue * 100)
+		}
+	}
+	return responses
+}
+
+func marginPercent(line *pos.AnalyticsLine) float64 {
+	if line.NetRevenue == 0 {
+		return 0
+	}
+	return roundCurrency((line.NetRevenue - line.Cost) / line.NetRevenue * 100)
 }
 
 // Pagination
@@ -479,8 +1527,34 @@ type ProductVariantSearchResponse struct {
 	UseParentPricing bool `json:"use_parent_pricing"`
 }
 
-// Generate receipt number
-func GenerateReceiptNumber(companyID uint, saleID uint) string {
-	timestamp := time.Now().Format("20060102")
-	return fmt.Sprintf("RCP-%d-%s-%d", companyID, timestamp, saleID)
+type ScanProductRequest struct {
+	Code        string `form:"code" binding:"required"`
+	FranchiseID *uint  `form:"franchise_id"`
+}
+
+// ScanProductResponse is the variant matching a scanned code, with the stock of the franchise
+// (or the company warehouse without franchise_id)
+type ScanProductResponse struct {
+	ProductVariantSearchResponse
+	Barcodes       []string `json:"barcodes"`
+	Stock          int      `json:"stock"`
+	ReservedStock  int      `json:"reserved_stock"`
+	AvailableStock int      `json:"available_stock"`
+}
+
+// Digital receipt DTOs
+
+type EmailReceiptRequest struct {
+	Email string `json:"email" binding:"omitempty,email"` // Defaults to the customer's email
+}
+
+type ReceiptLinkResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type EmailReceiptResponse struct {
+	Email     string    `json:"email"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
@@ -1,18 +1,40 @@
 package pos
 
 import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/mail"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	emailApp "github.com/YasserCherfaoui/darween/internal/application/email"
+	numberingApp "github.com/YasserCherfaoui/darween/internal/application/numbering"
 	"github.com/YasserCherfaoui/darween/internal/domain/company"
 	"github.com/YasserCherfaoui/darween/internal/domain/franchise"
 	"github.com/YasserCherfaoui/darween/internal/domain/inventory"
+	"github.com/YasserCherfaoui/darween/internal/domain/loyalty"
+	"github.com/YasserCherfaoui/darween/internal/domain/numbering"
 	"github.com/YasserCherfaoui/darween/internal/domain/pos"
+	"github.com/YasserCherfaoui/darween/internal/domain/pricelist"
 	"github.com/YasserCherfaoui/darween/internal/domain/product"
+	"github.com/YasserCherfaoui/darween/internal/domain/promotion"
+	"github.com/YasserCherfaoui/darween/internal/domain/tax"
 	"github.com/YasserCherfaoui/darween/internal/domain/user"
+	"github.com/YasserCherfaoui/darween/internal/domain/voucher"
+	"github.com/YasserCherfaoui/darween/internal/infrastructure/export"
 	"github.com/YasserCherfaoui/darween/internal/infrastructure/receipt"
+	"github.com/YasserCherfaoui/darween/internal/infrastructure/security"
 	"github.com/YasserCherfaoui/darween/pkg/errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Service struct {
@@ -23,14 +45,36 @@ type Service struct {
 	cashDrawerRepo            pos.CashDrawerRepository
 	cashDrawerTransactionRepo pos.CashDrawerTransactionRepository
 	refundRepo                pos.RefundRepository
+	exchangeRepo              pos.ExchangeRepository
+	customerAccountRepo       pos.CustomerAccountRepository
+	layawayRepo               pos.LayawayRepository
+	invoiceRepo               pos.InvoiceRepository
+	proformaRepo              pos.ProformaRepository
 	userRepo                  user.Repository
 	inventoryRepo             inventory.Repository
 	inventoryMovementRepo     inventory.Repository
 	productVariantRepo        product.Repository
 	franchiseRepo             franchise.Repository
+	taxRepo                   tax.Repository
+	promotionRepo             promotion.Repository
+	customerGroupRepo         pricelist.CustomerGroupRepository
+	priceListRepo             pricelist.PriceListRepository
+	loyaltyProgramRepo        loyalty.ProgramRepository
+	loyaltyTransactionRepo    loyalty.TransactionRepository
+	voucherRepo               voucher.Repository
+	emailService              *emailApp.Service
+	numberingService          *numberingApp.Service
+	jwtManager                *security.JWTManager
+	receiptLinks              ReceiptLinkConfig
 	db                        *gorm.DB
 }
 
+// ReceiptLinkConfig controls the public links to digital receipts
+type ReceiptLinkConfig struct {
+	BaseURL string        // Public URL of the API the links point to
+	TTL     time.Duration // How long a link stays valid
+}
+
 func NewService(
 	customerRepo pos.CustomerRepository,
 	saleRepo pos.SaleRepository,
@@ -39,11 +83,27 @@ func NewService(
 	cashDrawerRepo pos.CashDrawerRepository,
 	cashDrawerTransactionRepo pos.CashDrawerTransactionRepository,
 	refundRepo pos.RefundRepository,
+	exchangeRepo pos.ExchangeRepository,
+	customerAccountRepo pos.CustomerAccountRepository,
+	layawayRepo pos.LayawayRepository,
+	invoiceRepo pos.InvoiceRepository,
+	proformaRepo pos.ProformaRepository,
 	userRepo user.Repository,
 	inventoryRepo inventory.Repository,
 	inventoryMovementRepo inventory.Repository,
 	productVariantRepo product.Repository,
 	franchiseRepo franchise.Repository,
+	taxRepo tax.Repository,
+	promotionRepo promotion.Repository,
+	customerGroupRepo pricelist.CustomerGroupRepository,
+	priceListRepo pricelist.PriceListRepository,
+	loyaltyProgramRepo loyalty.ProgramRepository,
+	loyaltyTransactionRepo loyalty.TransactionRepository,
+	voucherRepo voucher.Repository,
+	emailService *emailApp.Service,
+	numberingService *numberingApp.Service,
+	jwtManager *security.JWTManager,
+	receiptLinks ReceiptLinkConfig,
 	db *gorm.DB,
 ) *Service {
 	return &Service{
@@ -54,11 +114,27 @@ func NewService(
 		cashDrawerRepo:            cashDrawerRepo,
 		cashDrawerTransactionRepo: cashDrawerTransactionRepo,
 		refundRepo:                refundRepo,
+		exchangeRepo:              exchangeRepo,
+		customerAccountRepo:       customerAccountRepo,
+		layawayRepo:               layawayRepo,
+		invoiceRepo:               invoiceRepo,
+		proformaRepo:              proformaRepo,
 		userRepo:                  userRepo,
 		inventoryRepo:             inventoryRepo,
 		inventoryMovementRepo:     inventoryMovementRepo,
 		productVariantRepo:        productVariantRepo,
 		franchiseRepo:             franchiseRepo,
+		taxRepo:                   taxRepo,
+		promotionRepo:             promotionRepo,
+		customerGroupRepo:         customerGroupRepo,
+		priceListRepo:             priceListRepo,
+		loyaltyProgramRepo:        loyaltyProgramRepo,
+		loyaltyTransactionRepo:    loyaltyTransactionRepo,
+		voucherRepo:               voucherRepo,
+		emailService:              emailService,
+		numberingService:          numberingService,
+		jwtManager:                jwtManager,
+		receiptLinks:              receiptLinks,
 		db:                        db,
 	}
 }
@@ -79,6 +155,20 @@ func (s *Service) CreateCustomer(userID, companyID uint, req *CreateCustomerRequ
 		}
 	}
 
+	if req.CreditLimit > 0 && !s.hasManagerRole(userID, companyID, nil) {
+		return nil, errors.NewForbiddenError("only managers can set a credit limit")
+	}
+
+	if req.IsBusiness && !s.hasManagerRole(userID, companyID, nil) {
+		return nil, errors.NewForbiddenError("only managers can mark a customer as a business")
+	}
+
+	if req.CustomerGroupID != nil {
+		if err := s.checkCustomerGroup(userID, companyID, *req.CustomerGroupID); err != nil {
+			return nil, err
+		}
+	}
+
 	customer := req.ToCustomer(companyID)
 	if !customer.IsValid() {
 		return nil, errors.NewValidationError("invalid customer data")
@@ -119,6 +209,43 @@ func (s *Service) UpdateCustomer(userID, companyID, customerID uint, req *Update
 	if req.Address != nil {
 		customer.Address = *req.Address
 	}
+	if req.CreditLimit != nil {
+		if !s.hasManagerRole(userID, companyID, nil) {
+			return nil, errors.NewForbiddenError("only managers can set a credit limit")
+		}
+		customer.CreditLimit = *req.CreditLimit
+	}
+	if req.IsBusiness != nil {
+		if !s.hasManagerRole(userID, companyID, nil) {
+			return nil, errors.NewForbiddenError("only managers can mark a customer as a business")
+		}
+		customer.IsBusiness = *req.IsBusiness
+	}
+	if req.NIF != nil {
+		customer.NIF = *req.NIF
+	}
+	if req.NIS != nil {
+		customer.NIS = *req.NIS
+	}
+	if req.RC != nil {
+		customer.RC = *req.RC
+	}
+	if req.AI != nil {
+		customer.AI = *req.AI
+	}
+	if req.CustomerGroupID != nil {
+		if *req.CustomerGroupID == 0 {
+			if !s.hasManagerRole(userID, companyID, nil) {
+				return nil, errors.NewForbiddenError("only managers can change a customer's group")
+			}
+			customer.CustomerGroupID = nil
+		} else {
+			if err := s.checkCustomerGroup(userID, companyID, *req.CustomerGroupID); err != nil {
+				return nil, err
+			}
+			customer.CustomerGroupID = req.CustomerGroupID
+		}
+	}
 	if req.IsActive != nil {
 		customer.IsActive = *req.IsActive
 	}
@@ -130,6 +257,23 @@ func (s *Service) UpdateCustomer(userID, companyID, customerID uint, req *Update
 	return ToCustomerResponse(customer), nil
 }
 
+// checkCustomerGroup checks that a manager assigns customers to an active group of the company
+func (s *Service) checkCustomerGroup(userID, companyID, groupID uint) error {
+	if !s.hasManagerRole(userID, companyID, nil) {
+		return errors.NewForbiddenError("only managers can change a customer's group")
+	}
+
+	group, err := s.customerGroupRepo.FindByIDAndCompany(groupID, companyID)
+	if err != nil {
+		return errors.NewNotFoundError("customer group not found or does not belong to this company")
+	}
+	if !group.IsActive {
+		return errors.NewValidationError("customer group is not active")
+	}
+
+	return nil
+}
+
 func (s *Service) GetCustomerByID(userID, companyID, customerID uint) (*CustomerResponse, error) {
 	// Check user authorization
 	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
@@ -189,298 +333,346 @@ func (s *Service) DeleteCustomer(userID, companyID, customerID uint) error {
 	return nil
 }
 
-// Sale operations
+// maxCustomerImportRows caps the rows of a customer import file
+const maxCustomerImportRows = 5000
+
+// customerImportColumns maps the headers an import file may use, including those of the
+// customer export, to the field they set. Other columns are ignored.
+var customerImportColumns = map[string]string{
+	"name":           "name",
+	"email":          "email",
+	"phone":          "phone",
+	"address":        "address",
+	"customer_group": "customer_group",
+	"credit_limit":   "credit_limit",
+	"business":       "is_business",
+	"is_business":    "is_business",
+	"nif":            "nif",
+	"nis":            "nis",
+	"rc":             "rc",
+	"ai":             "ai",
+}
 
-func (s *Service) CreateSale(userID, companyID uint, req *CreateSaleRequest) (*SaleResponse, error) {
-	// Check user authorization
-	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
-		return nil, err
+// ExportCustomers writes the company's customers, one row per customer, in the columns
+// ImportCustomers reads back
+func (s *Service) ExportCustomers(userID, companyID uint, w export.Writer) error {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleManager); err != nil {
+		return err
 	}
 
-	// If franchise is specified, verify access
-	if req.FranchiseID != nil {
-		if err := s.checkUserFranchiseAccess(userID, *req.FranchiseID, user.RoleEmployee); err != nil {
-			return nil, err
-		}
+	groups, err := s.customerGroupRepo.FindByCompanyID(companyID)
+	if err != nil {
+		return errors.NewInternalError("failed to fetch customer groups", err)
 	}
-
-	// Validate customer if specified
-	if req.CustomerID != nil {
-		customer, err := s.customerRepo.FindByID(*req.CustomerID)
-		if err != nil {
-			return nil, errors.NewNotFoundError("customer not found")
-		}
-		if customer.CompanyID != companyID {
-			return nil, errors.NewForbiddenError("customer does not belong to this company")
-		}
+	groupNames := make(map[uint]string, len(groups))
+	for _, g := range groups {
+		groupNames[g.ID] = g.Name
 	}
 
-	// Start transaction
-	tx := s.db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// Create sale
-	sale := &pos.Sale{
-		CompanyID:      companyID,
-		FranchiseID:    req.FranchiseID,
-		CustomerID:     req.CustomerID,
-		TaxAmount:      req.TaxAmount,
-		DiscountAmount: req.DiscountAmount,
-		PaymentStatus:  pos.PaymentStatusUnpaid,
-		SaleStatus:     pos.SaleStatusDraft,
-		Notes:          req.Notes,
-		CreatedByID:    userID,
+	err = w.WriteRow("ID", "Name", "Email", "Phone", "Address", "Customer group", "Credit limit", "Business",
+		"NIF", "NIS", "RC", "AI", "Total purchases", "Active", "Created at")
+	if err != nil {
+		return err
 	}
 
-	// Create sale items and validate inventory
-	saleItems := make([]pos.SaleItem, len(req.Items))
-	for i, itemReq := range req.Items {
-		// Validate product variant
-		variant, err := s.productVariantRepo.FindProductVariantByID(itemReq.ProductVariantID)
-		if err != nil {
-			tx.Rollback()
-			return nil, errors.NewNotFoundError(fmt.Sprintf("product variant %d not found", itemReq.ProductVariantID))
-		}
-
-		// Check inventory availability
-		var inv *inventory.Inventory
-		if req.FranchiseID != nil {
-			inv, err = s.inventoryRepo.FindByVariantAndFranchise(itemReq.ProductVariantID, *req.FranchiseID)
-		} else {
-			inv, err = s.inventoryRepo.FindByVariantAndCompany(itemReq.ProductVariantID, companyID)
-		}
+	return s.customerRepo.StreamByCompanyID(companyID, func(customers []*pos.Customer) error {
+		for _, c := range customers {
+			groupName := ""
+			if c.CustomerGroupID != nil {
+				groupName = groupNames[*c.CustomerGroupID]
+			}
 
-		if err != nil || !inv.CanFulfill(itemReq.Quantity) {
-			tx.Rollback()
-			return nil, errors.NewValidationError(fmt.Sprintf("insufficient inventory for product variant %d (SKU: %s)", itemReq.ProductVariantID, variant.SKU))
+			err := w.WriteRow(c.ID, c.Name, c.Email, c.Phone, c.Address, groupName, c.CreditLimit, c.IsBusiness,
+				c.NIF, c.NIS, c.RC, c.AI, c.TotalPurchases, c.IsActive, c.CreatedAt)
+			if err != nil {
+				return err
+			}
 		}
+		return nil
+	})
+}
 
-		saleItem := pos.SaleItem{
-			ProductVariantID: itemReq.ProductVariantID,
-			Quantity:         itemReq.Quantity,
-			UnitPrice:        itemReq.UnitPrice,
-			DiscountAmount:   itemReq.DiscountAmount,
-		}
-		saleItem.CalculateTotals()
-		saleItems[i] = saleItem
+// ImportCustomers creates customers from a CSV file with a header row. Rows with invalid values
+// are reported and left out; rows whose phone or email matches an existing customer or an
+// earlier row are skipped. A dry run reports the same without creating anything.
+func (s *Service) ImportCustomers(userID, companyID uint, file io.Reader, dryRun bool) (*CustomerImportResponse, error) {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleManager); err != nil {
+		return nil, err
 	}
 
-	// Calculate sale totals (temporarily set items for calculation)
-	sale.Items = saleItems
-	sale.CalculateTotals()
-	sale.ReceiptNumber = GenerateReceiptNumber(companyID, 0) // Will update after getting ID
-
-	if !sale.IsValid() {
-		tx.Rollback()
-		return nil, errors.NewValidationError("invalid sale data")
+	records, err := readCustomerImportFile(file)
+	if err != nil {
+		return nil, err
 	}
 
-	// Create sale in database without items to avoid GORM auto-creating them
-	// We'll create items separately after getting the sale ID
-	saleWithoutItems := *sale
-	saleWithoutItems.Items = nil // Clear items to prevent GORM from creating them
-	if err := tx.Create(&saleWithoutItems).Error; err != nil {
-		tx.Rollback()
-		return nil, errors.NewInternalError("failed to create sale", err)
+	columns := make(map[string]int)
+	for i, header := range records[0] {
+		key := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(header)), " ", "_")
+		if field, ok := customerImportColumns[key]; ok {
+			if _, seen := columns[field]; !seen {
+				columns[field] = i
+			}
+		}
 	}
-
-	// Update receipt number with actual sale ID
-	saleWithoutItems.ReceiptNumber = GenerateReceiptNumber(companyID, saleWithoutItems.ID)
-	if err := tx.Save(&saleWithoutItems).Error; err != nil {
-		tx.Rollback()
-		return nil, errors.NewInternalError("failed to update receipt number", err)
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.NewValidationError("the file has no name column")
 	}
 
-	// Update sale item IDs and create sale items
-	for i := range saleItems {
-		saleItems[i].SaleID = saleWithoutItems.ID
+	rows := records[1:]
+	if len(rows) == 0 {
+		return nil, errors.NewValidationError("the file has no customers")
 	}
-
-	// Create sale items
-	if err := tx.Create(&saleItems).Error; err != nil {
-		tx.Rollback()
-		return nil, errors.NewInternalError("failed to create sale items", err)
+	if len(rows) > maxCustomerImportRows {
+		return nil, errors.NewValidationError(fmt.Sprintf("the file has %d customers, import at most %d at a time", len(rows), maxCustomerImportRows))
 	}
 
-	// Update sale reference for later use
-	sale.ID = saleWithoutItems.ID
-	sale.ReceiptNumber = saleWithoutItems.ReceiptNumber
-
-	// Deduct inventory and create movements
-	for _, item := range saleItems {
-		var inv *inventory.Inventory
-		var err error
-		if req.FranchiseID != nil {
-			inv, err = s.inventoryRepo.FindByVariantAndFranchise(item.ProductVariantID, *req.FranchiseID)
-		} else {
-			inv, err = s.inventoryRepo.FindByVariantAndCompany(item.ProductVariantID, companyID)
+	existing, err := s.customerRepo.FindAllByCompanyID(companyID)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch customers", err)
+	}
+	byPhone := make(map[string]*uint)
+	byEmail := make(map[string]*uint)
+	for _, c := range existing {
+		id := c.ID
+		if phone := pos.NormalizeCustomerPhone(c.Phone); phone != "" {
+			byPhone[phone] = &id
+		}
+		if email := pos.NormalizeCustomerEmail(c.Email); email != "" {
+			byEmail[email] = &id
+		}
+	}
+	// Rows of the file already taken, by the line they are on
+	phoneRows := make(map[string]int)
+	emailRows := make(map[string]int)
+
+	groupIDs := make(map[string]*uint)
+	result := &CustomerImportResponse{
+		DryRun:    dryRun,
+		TotalRows: len(rows),
+		Rows:      []CustomerImportRowResponse{},
+	}
+	customers := make([]*pos.Customer, 0, len(rows))
+	for i, record := range rows {
+		line := i + 2
+		value := func(field string) string {
+			if col, ok := columns[field]; ok && col < len(record) {
+				return strings.TrimSpace(record[col])
+			}
+			return ""
 		}
 
+		customer, err := s.parseImportedCustomer(companyID, value, groupIDs)
 		if err != nil {
-			tx.Rollback()
-			return nil, errors.NewInternalError("failed to fetch inventory", err)
+			result.Failed++
+			result.Rows = append(result.Rows, CustomerImportRowResponse{Row: line, Status: CustomerImportRowFailed, Message: err.Error()})
+			continue
 		}
 
-		previousStock := inv.Stock
-		if !inv.RemoveStock(item.Quantity) {
-			tx.Rollback()
-			return nil, errors.NewValidationError(fmt.Sprintf("insufficient stock for variant %d", item.ProductVariantID))
+		phone := pos.NormalizeCustomerPhone(customer.Phone)
+		email := pos.NormalizeCustomerEmail(customer.Email)
+		skipped := CustomerImportRowResponse{Row: line, Status: CustomerImportRowSkipped}
+		switch {
+		case phone != "" && byPhone[phone] != nil:
+			skipped.Message, skipped.CustomerID = "a customer with this phone number already exists", byPhone[phone]
+		case email != "" && byEmail[email] != nil:
+			skipped.Message, skipped.CustomerID = "a customer with this email already exists", byEmail[email]
+		case phone != "" && phoneRows[phone] > 0:
+			skipped.Message = fmt.Sprintf("same phone number as row %d", phoneRows[phone])
+		case email != "" && emailRows[email] > 0:
+			skipped.Message = fmt.Sprintf("same email as row %d", emailRows[email])
 		}
-
-		if err := tx.Save(inv).Error; err != nil {
-			tx.Rollback()
-			return nil, errors.NewInternalError("failed to update inventory", err)
+		if skipped.Message != "" {
+			result.Skipped++
+			result.Rows = append(result.Rows, skipped)
+			continue
 		}
 
-		// Create inventory movement
-		saleIDStr := fmt.Sprintf("%d", sale.ID)
-		movement := &inventory.InventoryMovement{
-			InventoryID:   inv.ID,
-			MovementType:  inventory.MovementTypeSale,
-			Quantity:      -item.Quantity,
-			PreviousStock: previousStock,
-			NewStock:      inv.Stock,
-			ReferenceType: stringPtr("sale"),
-			ReferenceID:   &saleIDStr,
-			CreatedByID:   userID,
+		if phone != "" {
+			phoneRows[phone] = line
 		}
-
-		if err := tx.Create(movement).Error; err != nil {
-			tx.Rollback()
-			return nil, errors.NewInternalError("failed to create inventory movement", err)
+		if email != "" {
+			emailRows[email] = line
 		}
+		customers = append(customers, customer)
 	}
+	result.Created = len(customers)
 
-	// Mark sale as completed
-	saleWithoutItems.Complete()
-	if err := tx.Save(&saleWithoutItems).Error; err != nil {
-		tx.Rollback()
-		return nil, errors.NewInternalError("failed to complete sale", err)
-	}
-
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		return nil, errors.NewInternalError("failed to commit transaction", err)
+	if dryRun || len(customers) == 0 {
+		return result, nil
 	}
 
-	// Reload sale with items
-	completedSale, err := s.saleRepo.FindByID(sale.ID)
-	if err != nil {
-		return nil, errors.NewInternalError("failed to fetch created sale", err)
+	if err := s.db.CreateInBatches(customers, 100).Error; err != nil {
+		return nil, errors.NewInternalError("failed to import customers", err)
 	}
 
-	return ToSaleResponse(completedSale), nil
+	return result, nil
 }
 
-func (s *Service) GetSaleByID(userID, companyID, saleID uint) (*SaleResponse, error) {
-	// Check user authorization
-	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
-		return nil, err
+// readCustomerImportFile reads the records of a customer import file. Files saved by spreadsheets
+// set to use semicolons as separators are read as well.
+func readCustomerImportFile(file io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, errors.NewBadRequestError("failed to read the file")
+	}
+	data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, _, _ := strings.Cut(string(data), "\n")
+	if strings.Count(header, ";") > strings.Count(header, ",") {
+		reader.Comma = ';'
 	}
 
-	sale, err := s.saleRepo.FindByID(saleID)
+	records, err := reader.ReadAll()
 	if err != nil {
-		return nil, errors.NewNotFoundError("sale not found")
+		return nil, errors.NewValidationError(fmt.Sprintf("invalid CSV file: %v", err))
+	}
+	if len(records) == 0 {
+		return nil, errors.NewValidationError("the file is empty")
 	}
 
-	if sale.CompanyID != companyID {
-		return nil, errors.NewForbiddenError("access denied to this sale")
+	return records, nil
+}
+
+// parseImportedCustomer builds a customer from the values of an import row. groupIDs caches
+// the customer groups looked up by name, nil for names that match no active group.
+func (s *Service) parseImportedCustomer(companyID uint, value func(field string) string, groupIDs map[string]*uint) (*pos.Customer, error) {
+	customer := &pos.Customer{
+		CompanyID: companyID,
+		Name:      value("name"),
+		Email:     value("email"),
+		Phone:     value("phone"),
+		Address:   value("address"),
+		NIF:       value("nif"),
+		NIS:       value("nis"),
+		RC:        value("rc"),
+		AI:        value("ai"),
+		IsActive:  true,
 	}
 
-	// Fetch product and variant details for each item
-	itemDetails := make(map[uint]struct {
-		productName string
-		variantName string
-		variantSKU  string
-	})
+	if customer.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if customer.Email != "" {
+		if address, err := mail.ParseAddress(customer.Email); err != nil || address.Address != customer.Email {
+			return nil, fmt.Errorf("invalid email %q", customer.Email)
+		}
+	}
+	for _, id := range []struct{ name, value string }{
+		{"NIF", customer.NIF}, {"NIS", customer.NIS}, {"RC", customer.RC}, {"AI", customer.AI},
+	} {
+		if len(id.value) > 30 {
+			return nil, fmt.Errorf("%s is longer than 30 characters", id.name)
+		}
+	}
 
-	for _, item := range sale.Items {
-		if _, exists := itemDetails[item.ProductVariantID]; exists {
-			continue // Already fetched
+	if raw := value("credit_limit"); raw != "" {
+		limit, err := strconv.ParseFloat(strings.ReplaceAll(raw, ",", "."), 64)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid credit limit %q", raw)
 		}
+		customer.CreditLimit = roundCurrency(limit)
+	}
 
-		variant, err := s.productVariantRepo.FindProductVariantByID(item.ProductVariantID)
-		if err == nil && variant != nil {
-			var productName string
-			product, err := s.productVariantRepo.FindProductByID(variant.ProductID)
-			if err == nil && product != nil {
-				productName = product.Name
-			}
+	if raw := value("is_business"); raw != "" {
+		switch strings.ToLower(raw) {
+		case "true", "yes", "1", "oui":
+			customer.IsBusiness = true
+		case "false", "no", "0", "non":
+		default:
+			return nil, fmt.Errorf("invalid business flag %q, use true or false", raw)
+		}
+	}
 
-			itemDetails[item.ProductVariantID] = struct {
-				productName string
-				variantName string
-				variantSKU  string
-			}{
-				productName: productName,
-				variantName: variant.Name,
-				variantSKU:  variant.SKU,
+	if name := value("customer_group"); name != "" {
+		key := strings.ToLower(name)
+		groupID, looked := groupIDs[key]
+		if !looked {
+			if group, err := s.customerGroupRepo.FindByName(companyID, name); err == nil && group.IsActive {
+				groupID = &group.ID
 			}
+			groupIDs[key] = groupID
+		}
+		if groupID == nil {
+			return nil, fmt.Errorf("no active customer group named %q", name)
 		}
+		customer.CustomerGroupID = groupID
 	}
 
-	return ToSaleResponseWithDetails(sale, itemDetails), nil
+	return customer, nil
 }
 
-func (s *Service) ListSales(userID, companyID uint, franchiseID *uint, page, limit int) (*PaginatedResponse, error) {
-	// Check user authorization
-	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+// FindDuplicateCustomers groups the company's customers sharing a phone number, email or name
+// once normalized. matchOn restricts the search to one of phone, email or name.
+func (s *Service) FindDuplicateCustomers(userID, companyID uint, matchOn string) ([]*CustomerDuplicateGroupResponse, error) {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleManager); err != nil {
 		return nil, err
 	}
 
-	var sales []*pos.Sale
-	var total int64
-	var err error
-
-	if franchiseID != nil {
-		// Verify franchise access
-		if err := s.checkUserFranchiseAccess(userID, *franchiseID, user.RoleEmployee); err != nil {
-			return nil, err
+	keys := map[string]func(c *pos.Customer) string{
+		"phone": func(c *pos.Customer) string { return pos.NormalizeCustomerPhone(c.Phone) },
+		"email": func(c *pos.Customer) string { return pos.NormalizeCustomerEmail(c.Email) },
+		"name":  func(c *pos.Customer) string { return pos.NormalizeCustomerName(c.Name) },
+	}
+	matches := []string{"phone", "email", "name"}
+	if matchOn != "" {
+		if _, ok := keys[matchOn]; !ok {
+			return nil, errors.NewValidationError("match must be phone, email or name")
 		}
-		sales, total, err = s.saleRepo.FindByFranchiseID(*franchiseID, page, limit)
-	} else {
-		sales, total, err = s.saleRepo.FindByCompanyID(companyID, page, limit)
+		matches = []string{matchOn}
 	}
 
+	customers, err := s.customerRepo.FindAllByCompanyID(companyID)
 	if err != nil {
-		return nil, errors.NewInternalError("failed to fetch sales", err)
+		return nil, errors.NewInternalError("failed to fetch customers", err)
 	}
 
-	saleResponses := make([]*SaleResponse, len(sales))
-	for i, sale := range sales {
-		saleResponses[i] = ToSaleResponse(sale)
+	groups := []*CustomerDuplicateGroupResponse{}
+	for _, match := range matches {
+		byValue := make(map[string][]*CustomerResponse)
+		values := []string{}
+		for _, c := range customers {
+			value := keys[match](c)
+			if value == "" {
+				continue
+			}
+			if _, seen := byValue[value]; !seen {
+				values = append(values, value)
+			}
+			byValue[value] = append(byValue[value], ToCustomerResponse(c))
+		}
+
+		sort.Strings(values)
+		for _, value := range values {
+			if len(byValue[value]) > 1 {
+				groups = append(groups, &CustomerDuplicateGroupResponse{MatchedOn: match, Value: value, Customers: byValue[value]})
+			}
+		}
 	}
 
-	return NewPaginatedResponse(saleResponses, total, page, limit), nil
+	return groups, nil
 }
 
-// Payment operations
-
-func (s *Service) AddPaymentToSale(userID, companyID, saleID uint, req *AddPaymentRequest) (*PaymentResponse, error) {
-	// Check user authorization
-	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+// MergeCustomers merges duplicates into a customer: their sales, repayments, layaways, proformas,
+// loyalty history and vouchers move to the customer, their total purchases are added to the
+// customer's, and the duplicates are deleted.
+func (s *Service) MergeCustomers(userID, companyID, customerID uint, req *MergeCustomersRequest) (*CustomerResponse, error) {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleManager); err != nil {
 		return nil, err
 	}
 
-	// Get sale
-	sale, err := s.saleRepo.FindByID(saleID)
-	if err != nil {
-		return nil, errors.NewNotFoundError("sale not found")
+	duplicateIDs := []uint{}
+	for _, id := range req.DuplicateIDs {
+		if id == customerID {
+			return nil, errors.NewValidationError("a customer cannot be merged into itself")
+		}
+		if !slices.Contains(duplicateIDs, id) {
+			duplicateIDs = append(duplicateIDs, id)
+		}
 	}
 
-	if sale.CompanyID != companyID {
-		return nil, errors.NewForbiddenError("access denied to this sale")
-	}
-
-	if sale.SaleStatus != pos.SaleStatusCompleted {
-		return nil, errors.NewValidationError("can only add payments to completed sales")
-	}
-
-	// Start transaction
 	tx := s.db.Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -488,105 +680,84 @@ func (s *Service) AddPaymentToSale(userID, companyID, saleID uint, req *AddPayme
 		}
 	}()
 
-	// Create payment
-	payment := &pos.Payment{
-		SaleID:        saleID,
-		PaymentMethod: req.PaymentMethod,
-		Amount:        req.Amount,
-		PaymentStatus: pos.PaymentTransactionStatusCompleted,
-		Reference:     req.Reference,
-		Notes:         req.Notes,
-	}
-
-	if !payment.IsValid() {
+	var customer pos.Customer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND company_id = ?", customerID, companyID).First(&customer).Error; err != nil {
 		tx.Rollback()
-		return nil, errors.NewValidationError("invalid payment data")
+		return nil, errors.NewNotFoundError("customer not found")
 	}
 
-	if err := tx.Create(payment).Error; err != nil {
+	var duplicates []*pos.Customer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ? AND company_id = ?", duplicateIDs, companyID).Order("created_at ASC").Find(&duplicates).Error; err != nil {
 		tx.Rollback()
-		return nil, errors.NewInternalError("failed to create payment", err)
+		return nil, errors.NewInternalError("failed to fetch customers to merge", err)
 	}
-
-	// Update sale payment status
-	totalPaid, err := s.paymentRepo.GetTotalPaidForSale(saleID)
-	if err != nil {
+	if len(duplicates) != len(duplicateIDs) {
 		tx.Rollback()
-		return nil, errors.NewInternalError("failed to calculate total paid", err)
+		return nil, errors.NewNotFoundError("customer to merge not found")
 	}
 
-	sale.UpdatePaymentStatus(totalPaid)
-	if err := tx.Save(sale).Error; err != nil {
-		tx.Rollback()
-		return nil, errors.NewInternalError("failed to update sale", err)
+	for _, duplicate := range duplicates {
+		customer.Absorb(duplicate)
 	}
 
-	// If payment is cash, add to active cash drawer
-	if req.PaymentMethod == pos.PaymentMethodCash {
-		var activeDrawer *pos.CashDrawer
-		var drawerErr error
-
-		if sale.FranchiseID != nil {
-			activeDrawer, drawerErr = s.cashDrawerRepo.FindActiveByFranchiseID(*sale.FranchiseID)
-		} else {
-			activeDrawer, drawerErr = s.cashDrawerRepo.FindActiveByCompanyID(companyID)
+	// Everything recorded against the duplicates now belongs to the customer
+	for _, model := range []interface{}{&pos.Sale{}, &pos.CustomerPayment{}, &pos.Layaway{}, &pos.Proforma{}, &loyalty.Transaction{}, &voucher.Voucher{}} {
+		if err := tx.Model(model).Where("customer_id IN ?", duplicateIDs).UpdateColumn("customer_id", customer.ID).Error; err != nil {
+			tx.Rollback()
+			return nil, errors.NewInternalError("failed to move the history of merged customers", err)
 		}
+	}
 
-		if drawerErr == nil && activeDrawer != nil {
-			drawerTx := &pos.CashDrawerTransaction{
-				CashDrawerID:    activeDrawer.ID,
-				TransactionType: pos.CashDrawerTransactionTypeSale,
-				Amount:          req.Amount,
-				SaleID:          &saleID,
-			}
-			if err := tx.Create(drawerTx).Error; err != nil {
-				// Don't fail payment if drawer transaction fails
-				tx.Rollback()
-				return nil, errors.NewInternalError("failed to record cash drawer transaction", err)
-			}
-		}
+	if err := tx.Where("id IN ?", duplicateIDs).Delete(&pos.Customer{}).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to delete merged customers", err)
 	}
 
-	// Update customer total purchases if customer is linked
-	if sale.CustomerID != nil && sale.PaymentStatus == pos.PaymentStatusPaid {
-		customer, err := s.customerRepo.FindByID(*sale.CustomerID)
-		if err == nil {
-			customer.AddPurchase(sale.TotalAmount)
-			tx.Save(customer)
-		}
+	if err := tx.Save(&customer).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to update customer", err)
 	}
 
 	if err := tx.Commit().Error; err != nil {
 		return nil, errors.NewInternalError("failed to commit transaction", err)
 	}
 
-	return ToPaymentResponse(payment), nil
+	return ToCustomerResponse(&customer), nil
 }
 
-// Refund operations
+// Customer account operations
 
-func (s *Service) ProcessRefund(userID, companyID, saleID uint, req *ProcessRefundRequest) (*RefundResponse, error) {
+// RecordCustomerPayment takes a repayment from a customer and settles their sales on account,
+// oldest first. Each share is recorded as a payment on the sale it settles.
+func (s *Service) RecordCustomerPayment(userID, companyID, customerID uint, req *RecordCustomerPaymentRequest) (*CustomerPaymentResponse, error) {
 	// Check user authorization
-	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleManager); err != nil {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
 		return nil, err
 	}
 
-	// Get sale
-	sale, err := s.saleRepo.FindByID(saleID)
+	customer, err := s.customerRepo.FindByID(customerID)
 	if err != nil {
-		return nil, errors.NewNotFoundError("sale not found")
+		return nil, errors.NewNotFoundError("customer not found")
 	}
 
-	if sale.CompanyID != companyID {
-		return nil, errors.NewForbiddenError("access denied to this sale")
+	if customer.CompanyID != companyID {
+		return nil, errors.NewForbiddenError("access denied to this customer")
 	}
 
-	if !sale.CanBeRefunded() {
-		return nil, errors.NewValidationError("sale cannot be refunded")
+	payment := &pos.CustomerPayment{
+		CompanyID:     companyID,
+		CustomerID:    customerID,
+		Amount:        roundCurrency(req.Amount),
+		PaymentMethod: req.PaymentMethod,
+		Reference:     req.Reference,
+		Notes:         req.Notes,
+		CreatedByID:   userID,
 	}
 
-	if req.RefundAmount > sale.TotalAmount {
-		return nil, errors.NewValidationError("refund amount cannot exceed sale total")
+	if !payment.IsValid() {
+		return nil, errors.NewValidationError("invalid payment data")
 	}
 
 	// Start transaction
@@ -597,530 +768,6159 @@ func (s *Service) ProcessRefund(userID, companyID, saleID uint, req *ProcessRefu
 		}
 	}()
 
-	// Create refund
-	refund := &pos.Refund{
-		OriginalSaleID: saleID,
-		RefundAmount:   req.RefundAmount,
-		Reason:         req.Reason,
-		RefundMethod:   req.RefundMethod,
-		RefundStatus:   pos.RefundStatusCompleted,
-		ProcessedByID:  userID,
+	// Lock the customer so concurrent repayments and charges see the same balance
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pos.Customer{}, customerID).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to lock customer", err)
 	}
 
-	if !refund.IsValid() {
+	openSales, err := s.customerAccountRepo.FindOpenBalances(companyID, &customerID)
+	if err != nil {
 		tx.Rollback()
-		return nil, errors.NewValidationError("invalid refund data")
+		return nil, errors.NewInternalError("failed to fetch open sales", err)
 	}
 
-	if err := tx.Create(refund).Error; err != nil {
+	balance := 0.0
+	for _, open := range openSales {
+		balance += open.Outstanding
+	}
+	if payment.Amount > roundCurrency(balance)+0.005 {
 		tx.Rollback()
-		return nil, errors.NewInternalError("failed to create refund", err)
+		return nil, errors.NewValidationError(fmt.Sprintf("payment of %.2f exceeds the %.2f owed on account", payment.Amount, balance))
 	}
 
-	// Restore inventory for all items in the sale
-	saleItems, err := s.saleItemRepo.FindBySaleID(saleID)
-	if err != nil {
+	if err := tx.Create(payment).Error; err != nil {
 		tx.Rollback()
-		return nil, errors.NewInternalError("failed to fetch sale items", err)
+		return nil, errors.NewInternalError("failed to create payment", err)
 	}
 
-	for _, item := range saleItems {
-		var inv *inventory.Inventory
-		var invErr error
-		if sale.FranchiseID != nil {
-			inv, invErr = s.inventoryRepo.FindByVariantAndFranchise(item.ProductVariantID, *sale.FranchiseID)
-		} else {
-			inv, invErr = s.inventoryRepo.FindByVariantAndCompany(item.ProductVariantID, companyID)
+	// Distribute payment to sales (FIFO: oldest sales first)
+	remainingAmount := payment.Amount
+	for _, open := range openSales {
+		if remainingAmount <= 0 {
+			break
 		}
 
-		if invErr != nil {
-			tx.Rollback()
-			return nil, errors.NewInternalError("failed to fetch inventory", invErr)
+		distributionAmount := roundCurrency(math.Min(remainingAmount, open.Outstanding))
+		if distributionAmount <= 0 {
+			continue
 		}
 
-		previousStock := inv.Stock
-		inv.AddStock(item.Quantity)
-
-		if err := tx.Save(inv).Error; err != nil {
+		sale, err := s.lockSale(tx, open.SaleID)
+		if err != nil {
 			tx.Rollback()
-			return nil, errors.NewInternalError("failed to update inventory", err)
+			return nil, err
 		}
 
-		// Create inventory movement
-		refundIDStr := fmt.Sprintf("%d", refund.ID)
-		movement := &inventory.InventoryMovement{
-			InventoryID:   inv.ID,
-			MovementType:  inventory.MovementTypeReturn,
-			Quantity:      item.Quantity,
-			PreviousStock: previousStock,
-			NewStock:      inv.Stock,
-			ReferenceType: stringPtr("refund"),
-			ReferenceID:   &refundIDStr,
-			CreatedByID:   userID,
+		tender := TenderRequest{
+			PaymentMethod: req.PaymentMethod,
+			Amount:        distributionAmount,
+			Reference:     req.Reference,
+			Notes:         fmt.Sprintf("Account payment #%d", payment.ID),
 		}
-
-		if err := tx.Create(movement).Error; err != nil {
+		salePayments, err := s.applyTenders(tx, sale, []TenderRequest{tender}, userID)
+		if err != nil {
 			tx.Rollback()
-			return nil, errors.NewInternalError("failed to create inventory movement", err)
+			return nil, err
 		}
-	}
-
-	// Update sale status
-	sale.SaleStatus = pos.SaleStatusRefunded
-	sale.PaymentStatus = pos.PaymentStatusRefunded
-	if err := tx.Save(sale).Error; err != nil {
-		tx.Rollback()
-		return nil, errors.NewInternalError("failed to update sale", err)
-	}
-
-	// If refund is cash, deduct from active cash drawer
-	if req.RefundMethod == pos.PaymentMethodCash {
-		var activeDrawer *pos.CashDrawer
-		var drawerErr error
 
-		if sale.FranchiseID != nil {
-			activeDrawer, drawerErr = s.cashDrawerRepo.FindActiveByFranchiseID(*sale.FranchiseID)
-		} else {
-			activeDrawer, drawerErr = s.cashDrawerRepo.FindActiveByCompanyID(companyID)
+		distribution := &pos.CustomerPaymentDistribution{
+			CustomerPaymentID: payment.ID,
+			SaleID:            sale.ID,
+			PaymentID:         salePayments[0].ID,
+			Amount:            distributionAmount,
 		}
-
-		if drawerErr == nil && activeDrawer != nil {
-			drawerTx := &pos.CashDrawerTransaction{
-				CashDrawerID:    activeDrawer.ID,
-				TransactionType: pos.CashDrawerTransactionTypeRefund,
-				Amount:          -req.RefundAmount,
-				Notes:           fmt.Sprintf("Refund for sale #%s", sale.ReceiptNumber),
-			}
-			if err := tx.Create(drawerTx).Error; err != nil {
-				tx.Rollback()
-				return nil, errors.NewInternalError("failed to record cash drawer transaction", err)
-			}
+		if err := tx.Create(distribution).Error; err != nil {
+			tx.Rollback()
+			return nil, errors.NewInternalError("failed to create payment distribution", err)
 		}
+
+		remainingAmount = roundCurrency(remainingAmount - distributionAmount)
 	}
 
 	if err := tx.Commit().Error; err != nil {
 		return nil, errors.NewInternalError("failed to commit transaction", err)
 	}
 
-	// Reload refund
-	createdRefund, err := s.refundRepo.FindByID(refund.ID)
+	// Reload payment with distributions
+	createdPayment, err := s.customerAccountRepo.FindPaymentByID(payment.ID)
 	if err != nil {
-		return nil, errors.NewInternalError("failed to fetch created refund", err)
+		return nil, errors.NewInternalError("failed to fetch created payment", err)
 	}
 
-	return ToRefundResponse(createdRefund), nil
+	return ToCustomerPaymentResponse(createdPayment), nil
 }
 
-func (s *Service) ListRefunds(userID, companyID uint, franchiseID *uint, page, limit int) (*PaginatedResponse, error) {
+// GetCustomerAccount returns what a customer owes on account, aged, with the sales still open
+func (s *Service) GetCustomerAccount(userID, companyID, customerID uint) (*CustomerAccountResponse, error) {
 	// Check user authorization
 	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
 		return nil, err
 	}
 
-	var refunds []*pos.Refund
-	var total int64
-	var err error
+	customer, err := s.customerRepo.FindByID(customerID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("customer not found")
+	}
 
-	if franchiseID != nil {
-		if err := s.checkUserFranchiseAccess(userID, *franchiseID, user.RoleEmployee); err != nil {
-			return nil, err
-		}
-		refunds, total, err = s.refundRepo.FindByFranchiseID(*franchiseID, page, limit)
-	} else {
-		refunds, total, err = s.refundRepo.FindByCompanyID(companyID, page, limit)
+	if customer.CompanyID != companyID {
+		return nil, errors.NewForbiddenError("access denied to this customer")
 	}
 
+	openSales, err := s.customerAccountRepo.FindOpenBalances(companyID, &customerID)
 	if err != nil {
-		return nil, errors.NewInternalError("failed to fetch refunds", err)
+		return nil, errors.NewInternalError("failed to fetch open sales", err)
 	}
 
-	refundResponses := make([]*RefundResponse, len(refunds))
-	for i, refund := range refunds {
-		refundResponses[i] = ToRefundResponse(refund)
+	now := time.Now()
+	result := &CustomerAccountResponse{
+		CustomerID:  customerID,
+		CreditLimit: customer.CreditLimit,
+		OpenSales:   make([]AccountSaleResponse, len(openSales)),
 	}
 
-	return NewPaginatedResponse(refundResponses, total, page, limit), nil
-}
+	for i, open := range openSales {
+		ageDays := daysBetween(open.CreatedAt, now)
+		result.Aging.Add(ageDays, open.Outstanding)
+		result.OpenSales[i] = AccountSaleResponse{
+			SaleID:        open.SaleID,
+			ReceiptNumber: open.ReceiptNumber,
+			Date:          open.CreatedAt,
+			TotalAmount:   open.TotalAmount,
+			ChargedAmount: open.ChargedAmount,
+			Outstanding:   open.Outstanding,
+			AgeDays:       ageDays,
+		}
+	}
 
-// Cash Drawer operations
+	result.Balance = result.Aging.Total
+	result.AvailableCredit = roundCurrency(math.Max(customer.CreditLimit-result.Balance, 0))
 
-func (s *Service) OpenCashDrawer(userID, companyID uint, req *OpenCashDrawerRequest) (*CashDrawerResponse, error) {
+	return result, nil
+}
+
+// GetCustomerStatement lists the sales charged to a customer's account and their repayments
+// over a period, with the running balance
+func (s *Service) GetCustomerStatement(userID, companyID, customerID uint, req *CustomerStatementRequest) (*CustomerStatementResponse, error) {
 	// Check user authorization
 	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
 		return nil, err
 	}
 
-	// If franchise is specified, verify access
-	if req.FranchiseID != nil {
-		if err := s.checkUserFranchiseAccess(userID, *req.FranchiseID, user.RoleEmployee); err != nil {
-			return nil, err
-		}
+	customer, err := s.customerRepo.FindByID(customerID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("customer not found")
 	}
 
-	// Check if there's already an active drawer
-	var activeDrawer *pos.CashDrawer
-	var err error
+	if customer.CompanyID != companyID {
+		return nil, errors.NewForbiddenError("access denied to this customer")
+	}
 
-	if req.FranchiseID != nil {
-		activeDrawer, err = s.cashDrawerRepo.FindActiveByFranchiseID(*req.FranchiseID)
-	} else {
-		activeDrawer, err = s.cashDrawerRepo.FindActiveByCompanyID(companyID)
+	if req.EndDate.Before(req.StartDate) {
+		return nil, errors.NewValidationError("end date must not be before start date")
 	}
 
-	if err == nil && activeDrawer != nil {
-		return nil, errors.NewConflictError("a cash drawer is already open")
+	// The end date is inclusive
+	endDate := req.EndDate.AddDate(0, 0, 1)
+
+	openingBalance, err := s.customerAccountRepo.GetBalanceBefore(customerID, req.StartDate)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to calculate opening balance", err)
 	}
 
-	// Create new cash drawer
-	var companyIDPtr *uint
-	if req.FranchiseID == nil {
-		companyIDPtr = &companyID
+	sales, err := s.customerAccountRepo.FindChargedSales(customerID, req.StartDate, endDate)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch sales on account", err)
 	}
 
-	drawer := &pos.CashDrawer{
-		CompanyID:      companyIDPtr,
-		FranchiseID:    req.FranchiseID,
-		OpeningBalance: req.OpeningBalance,
-		Status:         pos.CashDrawerStatusOpen,
-		OpenedByID:     userID,
-		OpenedAt:       time.Now(),
-		Notes:          req.Notes,
+	payments, err := s.customerAccountRepo.FindPaymentsByCustomerID(customerID, req.StartDate, endDate)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch customer payments", err)
 	}
 
-	if !drawer.IsValid() {
-		return nil, errors.NewValidationError("invalid cash drawer data")
+	entries := make([]StatementEntryResponse, 0, len(sales)+len(payments))
+	for _, sale := range sales {
+		saleID := sale.ID
+		entries = append(entries, StatementEntryResponse{
+			Date:      sale.CreatedAt,
+			Type:      "sale",
+			Reference: sale.ReceiptNumber,
+			SaleID:    &saleID,
+			Debit:     sale.ChargedAmount,
+		})
+	}
+	for _, payment := range payments {
+		paymentID := payment.ID
+		entries = append(entries, StatementEntryResponse{
+			Date:              payment.CreatedAt,
+			Type:              "payment",
+			Reference:         payment.Reference,
+			CustomerPaymentID: &paymentID,
+			Credit:            payment.Amount,
+		})
 	}
 
-	if err := s.cashDrawerRepo.Create(drawer); err != nil {
-		return nil, errors.NewInternalError("failed to open cash drawer", err)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date.Before(entries[j].Date)
+	})
+
+	balance := openingBalance
+	for i := range entries {
+		balance = roundCurrency(balance + entries[i].Debit - entries[i].Credit)
+		entries[i].Balance = balance
 	}
 
-	return ToCashDrawerResponse(drawer), nil
+	return &CustomerStatementResponse{
+		CustomerID:     customerID,
+		CustomerName:   customer.Name,
+		CreditLimit:    customer.CreditLimit,
+		StartDate:      req.StartDate,
+		EndDate:        req.EndDate,
+		OpeningBalance: openingBalance,
+		Entries:        entries,
+		ClosingBalance: balance,
+	}, nil
 }
 
-func (s *Service) CloseCashDrawer(userID, companyID uint, drawerID uint, req *CloseCashDrawerRequest) (*CashDrawerResponse, error) {
+// GetCustomerAgingReport ages what every customer of the company owes on account
+func (s *Service) GetCustomerAgingReport(userID, companyID uint) (*CustomerAgingReportResponse, error) {
 	// Check user authorization
 	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
 		return nil, err
 	}
 
-	drawer, err := s.cashDrawerRepo.FindByID(drawerID)
+	openSales, err := s.customerAccountRepo.FindOpenBalances(companyID, nil)
 	if err != nil {
-		return nil, errors.NewNotFoundError("cash drawer not found")
+		return nil, errors.NewInternalError("failed to fetch open sales", err)
 	}
 
-	// Verify access
-	if drawer.CompanyID != nil && *drawer.CompanyID != companyID {
-		return nil, errors.NewForbiddenError("access denied to this cash drawer")
+	now := time.Now()
+	result := &CustomerAgingReportResponse{
+		AsOf:      now,
+		Customers: []CustomerAgingLine{},
 	}
 
-	if drawer.FranchiseID != nil {
-		if err := s.checkUserFranchiseAccess(userID, *drawer.FranchiseID, user.RoleEmployee); err != nil {
-			return nil, err
+	lineIndex := make(map[uint]int)
+	for _, open := range openSales {
+		index, exists := lineIndex[open.CustomerID]
+		if !exists {
+			index = len(result.Customers)
+			lineIndex[open.CustomerID] = index
+			result.Customers = append(result.Customers, CustomerAgingLine{
+				CustomerID:   open.CustomerID,
+				CustomerName: open.CustomerName,
+				CreditLimit:  open.CreditLimit,
+			})
 		}
-	}
 
-	if !drawer.IsOpen() {
-		return nil, errors.NewValidationError("cash drawer is already closed")
+		ageDays := daysBetween(open.CreatedAt, now)
+		result.Customers[index].Aging.Add(ageDays, open.Outstanding)
+		result.Totals.Add(ageDays, open.Outstanding)
 	}
 
-	// Calculate expected balance
-	totalTransactions, err := s.cashDrawerTransactionRepo.GetTotalByCashDrawerID(drawerID)
-	if err != nil {
-		return nil, errors.NewInternalError("failed to calculate transactions", err)
-	}
+	// Largest debts first
+	sort.SliceStable(result.Customers, func(i, j int) bool {
+		return result.Customers[i].Aging.Total > result.Customers[j].Aging.Total
+	})
 
-	expectedBalance := drawer.OpeningBalance + totalTransactions
+	return result, nil
+}
 
-	// Close drawer
-	drawer.Close(req.ClosingBalance, userID, expectedBalance)
-	drawer.Notes = req.Notes
+// chargeToAccount puts what is still due on a sale on its customer's account, within their credit limit
+func (s *Service) chargeToAccount(tx *gorm.DB, sale *pos.Sale) error {
+	if sale.CustomerID == nil {
+		return errors.NewValidationError("only sales linked to a customer can be charged to an account")
+	}
 
-	if err := s.cashDrawerRepo.Update(drawer); err != nil {
-		return nil, errors.NewInternalError("failed to close cash drawer", err)
+	// Lock the customer so concurrent charges cannot both fit under the limit
+	var customer pos.Customer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, *sale.CustomerID).Error; err != nil {
+		return errors.NewInternalError("failed to lock customer", err)
 	}
 
-	return ToCashDrawerResponse(drawer), nil
-}
+	var totalPaid float64
+	if err := tx.Model(&pos.Payment{}).
+		Where("sale_id = ? AND payment_status = ?", sale.ID, pos.PaymentTransactionStatusCompleted).
+		Select("COALESCE(SUM(amount), 0)").
+		Row().Scan(&totalPaid); err != nil {
+		return errors.NewInternalError("failed to calculate total paid", err)
+	}
 
-func (s *Service) GetActiveCashDrawer(userID, companyID uint, franchiseID *uint) (*CashDrawerResponse, error) {
-	// Check user authorization
-	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
-		return nil, err
+	due := roundCurrency(sale.TotalAmount - totalPaid)
+	if due <= 0 {
+		return nil
 	}
 
-	var drawer *pos.CashDrawer
-	var err error
+	openSales, err := s.customerAccountRepo.FindOpenBalances(sale.CompanyID, sale.CustomerID)
+	if err != nil {
+		return errors.NewInternalError("failed to fetch open sales", err)
+	}
 
-	if franchiseID != nil {
-		if err := s.checkUserFranchiseAccess(userID, *franchiseID, user.RoleEmployee); err != nil {
-			return nil, err
+	balance := 0.0
+	for _, open := range openSales {
+		balance += open.Outstanding
+	}
+	balance = roundCurrency(balance)
+
+	if !customer.CanCharge(balance, due) {
+		if customer.CreditLimit <= 0 {
+			return errors.NewValidationError("customer is not allowed to buy on account")
 		}
-		drawer, err = s.cashDrawerRepo.FindActiveByFranchiseID(*franchiseID)
-	} else {
-		drawer, err = s.cashDrawerRepo.FindActiveByCompanyID(companyID)
+		return errors.NewValidationError(fmt.Sprintf("charging %.2f would exceed the credit limit of %.2f (%.2f already owed)", due, customer.CreditLimit, balance))
 	}
 
-	if err != nil {
-		return nil, errors.NewNotFoundError("no active cash drawer found")
+	sale.ChargedAmount = due
+	if err := tx.Omit("Items", "Payments", "Customer").Save(sale).Error; err != nil {
+		return errors.NewInternalError("failed to update sale", err)
 	}
 
-	return ToCashDrawerResponse(drawer), nil
+	return nil
 }
 
-func (s *Service) ListCashDrawers(userID, companyID uint, franchiseID *uint, page, limit int) (*PaginatedResponse, error) {
+// daysBetween returns the number of whole days from since to now
+func daysBetween(since, now time.Time) int {
+	if now.Before(since) {
+		return 0
+	}
+	return int(now.Sub(since).Hours() / 24)
+}
+
+// Sale operations
+
+func (s *Service) CreateSale(userID, companyID uint, req *CreateSaleRequest) (*SaleResponse, error) {
 	// Check user authorization
 	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
 		return nil, err
 	}
 
-	var drawers []*pos.CashDrawer
-	var total int64
-	var err error
-
-	if franchiseID != nil {
-		if err := s.checkUserFranchiseAccess(userID, *franchiseID, user.RoleEmployee); err != nil {
+	// If franchise is specified, verify access
+	if req.FranchiseID != nil {
+		if err := s.checkUserFranchiseAccess(userID, *req.FranchiseID, user.RoleEmployee); err != nil {
 			return nil, err
 		}
-		drawers, total, err = s.cashDrawerRepo.FindByFranchiseID(*franchiseID, page, limit)
-	} else {
-		drawers, total, err = s.cashDrawerRepo.FindByCompanyID(companyID, page, limit)
 	}
 
-	if err != nil {
-		return nil, errors.NewInternalError("failed to fetch cash drawers", err)
+	// Validate customer if specified
+	if err := s.checkSaleCustomer(companyID, req.CustomerID); err != nil {
+		return nil, err
 	}
-
-	drawerResponses := make([]*CashDrawerResponse, len(drawers))
-	for i, drawer := range drawers {
-		drawerResponses[i] = ToCashDrawerResponse(drawer)
+	if req.IsWholesale {
+		if err := s.checkWholesaleCustomer(req.CustomerID); err != nil {
+			return nil, err
+		}
 	}
 
-	return NewPaginatedResponse(drawerResponses, total, page, limit), nil
-}
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
 
-// Reports
+	// Create sale
+	sale := &pos.Sale{
+		CompanyID:      companyID,
+		FranchiseID:    req.FranchiseID,
+		CustomerID:     req.CustomerID,
+		DiscountAmount: req.DiscountAmount,
+		PaymentStatus:  pos.PaymentStatusUnpaid,
+		SaleStatus:     pos.SaleStatusDraft,
+		Notes:          req.Notes,
+		CouponCode:     promotion.NormalizeCouponCode(req.CouponCode),
+		IsWholesale:    req.IsWholesale,
+		CreatedByID:    userID,
+	}
 
-func (s *Service) GetSalesReport(userID, companyID uint, req *SalesReportRequest) (*SalesReportResponse, error) {
-	// Check user authorization
-	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+	// Create sale items and validate inventory
+	saleItems, err := s.buildSaleItems(userID, companyID, req.FranchiseID, req.CustomerID, req.Items, req.OverrideApproval, nil, req.CouponCode, req.IsWholesale)
+	if err != nil {
+		tx.Rollback()
 		return nil, err
 	}
 
-	if req.FranchiseID != nil {
-		if err := s.checkUserFranchiseAccess(userID, *req.FranchiseID, user.RoleEmployee); err != nil {
+	if req.IsWholesale {
+		if err := s.checkWholesaleMinimumOrder(companyID, saleItems); err != nil {
+			tx.Rollback()
 			return nil, err
 		}
 	}
 
-	data, err := s.saleRepo.GetSalesReport(companyID, req.FranchiseID, req.StartDate, req.EndDate)
+	// Create the sale and its items
+	if err := s.persistSale(tx, sale, saleItems); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Deduct inventory and create movements
+	if err := s.deductSaleInventory(tx, companyID, req.FranchiseID, saleItems, "sale", fmt.Sprintf("%d", sale.ID), userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := s.redeemPromotions(tx, saleItems); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Mark sale as completed
+	sale.Complete()
+	if err := tx.Omit("Items", "Payments", "Customer").Save(sale).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to complete sale", err)
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	// Reload sale with items
+	completedSale, err := s.saleRepo.FindByID(sale.ID)
 	if err != nil {
-		return nil, errors.NewInternalError("failed to generate sales report", err)
+		return nil, errors.NewInternalError("failed to fetch created sale", err)
 	}
 
-	return ToSalesReportResponse(data), nil
+	return ToSaleResponse(completedSale), nil
 }
 
-// Receipt Generation
+// Gift card operations
 
-func (s *Service) GenerateReceiptPDF(userID, companyID, saleID uint) ([]byte, string, error) {
+// SellGiftCard sells a gift card worth the requested amount. The sale has no items and must be
+// paid in full by the tenders; the gift card only exists once it is paid for.
+func (s *Service) SellGiftCard(userID, companyID uint, req *SellGiftCardRequest) (*SellGiftCardResponse, error) {
 	// Check user authorization
 	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
-	// Fetch sale with items, payments, and customer
-	sale, err := s.saleRepo.FindByID(saleID)
-	if err != nil {
-		return nil, "", errors.NewNotFoundError("sale not found")
+	// If franchise is specified, verify access
+	if req.FranchiseID != nil {
+		if err := s.checkUserFranchiseAccess(userID, *req.FranchiseID, user.RoleEmployee); err != nil {
+			return nil, err
+		}
 	}
 
-	if sale.CompanyID != companyID {
-		return nil, "", errors.NewForbiddenError("access denied to this sale")
+	// Validate customer if specified
+	if err := s.checkSaleCustomer(companyID, req.CustomerID); err != nil {
+		return nil, err
 	}
 
-	// Fetch company info
-	var company company.Company
-	if err := s.db.First(&company, companyID).Error; err != nil {
-		return nil, "", errors.NewNotFoundError("company not found")
+	if req.FranchiseOnly && req.FranchiseID == nil {
+		return nil, errors.NewValidationError("a gift card can only be limited to the franchise it is sold in")
 	}
-
-	// Fetch franchise info if applicable
-	var franchiseName string
-	if sale.FranchiseID != nil {
-		var franchise franchise.Franchise
-		if err := s.db.First(&franchise, *sale.FranchiseID).Error; err == nil {
-			franchiseName = franchise.Name
-		}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errors.NewValidationError("expiry date must be in the future")
 	}
-
-	// Fetch product variants for items
-	productVariantMap := make(map[uint]receipt.ProductVariantInfo)
-	for _, item := range sale.Items {
-		variant, err := s.productVariantRepo.FindProductVariantByID(item.ProductVariantID)
-		if err == nil {
-			// Get product name using ProductID from variant
-			product, err := s.productVariantRepo.FindProductByID(variant.ProductID)
-			if err == nil {
-				itemName := fmt.Sprintf("%s - %s", product.Name, variant.Name)
-				productVariantMap[item.ProductVariantID] = receipt.ProductVariantInfo{
-					Name: itemName,
-					SKU:  variant.SKU,
-				}
-			} else {
-				// Fallback to variant name only
-				productVariantMap[item.ProductVariantID] = receipt.ProductVariantInfo{
-					Name: variant.Name,
-					SKU:  variant.SKU,
-				}
-			}
+	for _, tender := range req.Tenders {
+		if tender.PaymentMethod == pos.PaymentMethodVoucher || tender.PaymentMethod == pos.PaymentMethodLoyalty {
+			return nil, errors.NewValidationError("gift cards cannot be bought with vouchers or loyalty points")
 		}
 	}
 
-	// Convert sale to receipt data
-	receiptData := receipt.ConvertSaleToReceiptData(sale, company.Name, franchiseName, productVariantMap)
-
-	// Generate PDF
-	generator := receipt.NewReceiptGenerator()
-	pdfBytes, err := generator.GenerateReceipt(receiptData)
+	code, err := voucher.GenerateCode()
 	if err != nil {
-		return nil, "", errors.NewInternalError("failed to generate receipt PDF", err)
+		return nil, errors.NewInternalError("failed to generate voucher code", err)
 	}
 
-	// Generate filename
-	filename := fmt.Sprintf("receipt_%s.pdf", sale.ReceiptNumber)
+	amount := roundCurrency(req.Amount)
 
-	return pdfBytes, filename, nil
-}
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
 
-// Helper methods
+	sale := &pos.Sale{
+		CompanyID:     companyID,
+		FranchiseID:   req.FranchiseID,
+		CustomerID:    req.CustomerID,
+		SubTotal:      amount,
+		TotalAmount:   amount,
+		PaymentStatus: pos.PaymentStatusUnpaid,
+		SaleStatus:    pos.SaleStatusCompleted,
+		Notes:         "Gift card",
+		CreatedByID:   userID,
+	}
+	if err := s.createSaleRecord(tx, sale); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
-func (s *Service) checkUserCompanyAccess(userID, companyID uint, minimumRole user.Role) error {
-	ucr, err := s.userRepo.FindUserRoleInCompany(userID, companyID)
-	if err != nil {
-		return errors.NewForbiddenError("access denied to this company")
+	giftCard := &voucher.Voucher{
+		CompanyID:     companyID,
+		Code:          code,
+		Type:          voucher.VoucherTypeGiftCard,
+		InitialAmount: amount,
+		Balance:       amount,
+		CustomerID:    req.CustomerID,
+		SaleID:        &sale.ID,
+		ExpiresAt:     req.ExpiresAt,
+		IsActive:      true,
+		CreatedByID:   userID,
+	}
+	if req.FranchiseOnly {
+		giftCard.FranchiseID = req.FranchiseID
+	}
+	if !giftCard.IsValid() {
+		tx.Rollback()
+		return nil, errors.NewValidationError("invalid gift card data")
+	}
+	if err := tx.Create(giftCard).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to create gift card", err)
 	}
 
-	if !ucr.Role.HasPermission(minimumRole) {
-		return errors.NewForbiddenError("insufficient permissions")
+	issue := &voucher.Transaction{
+		VoucherID:    giftCard.ID,
+		Type:         voucher.TransactionTypeIssue,
+		Amount:       giftCard.InitialAmount,
+		BalanceAfter: giftCard.Balance,
+		SaleID:       &sale.ID,
+		CreatedByID:  &userID,
+	}
+	if err := tx.Create(issue).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to record gift card issue", err)
 	}
 
-	return nil
-}
+	// Saved with the payment status by applyTenders
+	sale.VoucherID = &giftCard.ID
 
-func (s *Service) checkUserFranchiseAccess(userID, franchiseID uint, minimumRole user.Role) error {
-	ufr, err := s.userRepo.FindUserRoleInFranchise(userID, franchiseID)
+	payments, err := s.applyTenders(tx, sale, req.Tenders, userID)
 	if err != nil {
-		return errors.NewForbiddenError("access denied to this franchise")
+		tx.Rollback()
+		return nil, err
+	}
+	if sale.PaymentStatus != pos.PaymentStatusPaid {
+		tx.Rollback()
+		return nil, errors.NewValidationError("tenders do not cover the gift card amount")
 	}
 
-	if !ufr.Role.HasPermission(minimumRole) {
-		return errors.NewForbiddenError("insufficient permissions")
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
 	}
 
-	return nil
+	result := &SellGiftCardResponse{
+		GiftCard: &GiftCardResponse{
+			VoucherID:   giftCard.ID,
+			Code:        giftCard.Code,
+			Balance:     giftCard.Balance,
+			FranchiseID: giftCard.FranchiseID,
+			ExpiresAt:   giftCard.ExpiresAt,
+		},
+		Payments: make([]PaymentResponse, len(payments)),
+	}
+	for i := range payments {
+		result.Payments[i] = *ToPaymentResponse(&payments[i])
+		result.ChangeDue += payments[i].ChangeAmount
+	}
+	result.ChangeDue = roundCurrency(result.ChangeDue)
+
+	paidSale, err := s.saleRepo.FindByID(sale.ID)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch sale", err)
+	}
+	result.Sale = ToSaleResponse(paidSale)
+
+	return result, nil
 }
 
-// SearchProductsForSale searches for products/variants with retail pricing for POS sales
-func (s *Service) SearchProductsForSale(userID, companyID uint, req *SearchProductsRequest) ([]*ProductVariantSearchResponse, error) {
+// Parked sale operations
+
+// ParkSale holds a cart as a draft sale and reserves its stock until it is resumed or discarded
+func (s *Service) ParkSale(userID, companyID uint, req *CreateSaleRequest) (*SaleResponse, error) {
 	// Check user authorization
 	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
 		return nil, err
 	}
 
-	// If franchise is specified, validate it belongs to company
+	// If franchise is specified, verify access
 	if req.FranchiseID != nil {
-		franchise, err := s.franchiseRepo.FindByID(*req.FranchiseID)
-		if err != nil {
-			return nil, errors.NewNotFoundError("franchise not found")
-		}
-		if franchise.ParentCompanyID != companyID {
-			return nil, errors.NewForbiddenError("franchise does not belong to this company")
+		if err := s.checkUserFranchiseAccess(userID, *req.FranchiseID, user.RoleEmployee); err != nil {
+			return nil, err
 		}
 	}
 
-	// Set default limit
-	limit := req.Limit
-	if limit <= 0 {
-		limit = 50
+	if err := s.checkSaleCustomer(companyID, req.CustomerID); err != nil {
+		return nil, err
+	}
+	if req.IsWholesale {
+		if err := s.checkWholesaleCustomer(req.CustomerID); err != nil {
+			return nil, err
+		}
 	}
 
-	// Search variants
-	variants, err := s.productVariantRepo.SearchVariantsByCompany(companyID, req.Query, limit)
+	saleItems, err := s.buildSaleItems(userID, companyID, req.FranchiseID, req.CustomerID, req.Items, req.OverrideApproval, nil, req.CouponCode, req.IsWholesale)
 	if err != nil {
-		return nil, errors.NewInternalError("failed to search products", err)
+		return nil, err
 	}
 
-	// Build response with retail pricing information
-	results := make([]*ProductVariantSearchResponse, 0, len(variants))
-	for _, variant := range variants {
-		// Get product (should be preloaded)
-		var product *product.Product
-		if variant.Product != nil {
-			product = variant.Product
-		} else {
-			// Fetch product if not preloaded
-			product, err = s.productVariantRepo.FindProductByID(variant.ProductID)
-			if err != nil {
-				continue // Skip if product not found
-			}
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
 		}
+	}()
+
+	sale := &pos.Sale{
+		CompanyID:      companyID,
+		FranchiseID:    req.FranchiseID,
+		CustomerID:     req.CustomerID,
+		DiscountAmount: req.DiscountAmount,
+		PaymentStatus:  pos.PaymentStatusUnpaid,
+		Notes:          req.Notes,
+		CouponCode:     promotion.NormalizeCouponCode(req.CouponCode),
+		IsWholesale:    req.IsWholesale,
+		CreatedByID:    userID,
+	}
+	sale.Park()
+	sale.ReceiptNumber = draftReceiptNumber()
 
-		// Get base pricing
-		baseRetailPrice := product.BaseRetailPrice
-		baseWholesalePrice := product.BaseWholesalePrice
+	if err := s.persistSale(tx, sale, saleItems); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
-		// Get variant-specific pricing
-		var variantRetailPrice, variantWholesalePrice *float64
-		if !variant.UseParentPricing {
-			if variant.RetailPrice != nil && *variant.RetailPrice > 0 {
-				variantRetailPrice = variant.RetailPrice
-			}
-			if variant.WholesalePrice != nil && *variant.WholesalePrice > 0 {
-				variantWholesalePrice = variant.WholesalePrice
-			}
-		}
+	if err := s.adjustSaleReservations(tx, companyID, req.FranchiseID, sale.ID, nil, saleItemQuantities(saleItems), "parked_sale", userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
+	}
 
-		// Calculate effective pricing (variant or base)
-		effectiveRetailPrice := variant.GetEffectiveRetailPrice(baseRetailPrice)
-		effectiveWholesalePrice := variant.GetEffectiveWholesalePrice(baseWholesalePrice)
+	parkedSale, err := s.saleRepo.FindByID(sale.ID)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch parked sale", err)
+	}
 
-		// Get franchise pricing if available
-		var franchiseRetailPrice, franchiseWholesalePrice *float64
-		if req.FranchiseID != nil {
-			franchisePricing, err := s.franchiseRepo.FindPricing(*req.FranchiseID, variant.ID)
-			if err == nil && franchisePricing != nil {
-				if franchisePricing.RetailPrice != nil && *franchisePricing.RetailPrice > 0 {
-					franchiseRetailPrice = franchisePricing.RetailPrice
-					effectiveRetailPrice = *franchisePricing.RetailPrice
-				}
-				if franchisePricing.WholesalePrice != nil && *franchisePricing.WholesalePrice > 0 {
-					franchiseWholesalePrice = franchisePricing.WholesalePrice
-					effectiveWholesalePrice = *franchisePricing.WholesalePrice
-				}
-			}
-		}
+	return ToSaleResponse(parkedSale), nil
+}
 
-		results = append(results, &ProductVariantSearchResponse{
-			VariantID:                variant.ID,
-			VariantName:              variant.Name,
-			VariantSKU:               variant.SKU,
-			ProductID:                product.ID,
-			ProductName:              product.Name,
-			ProductSKU:               product.SKU,
-			BaseRetailPrice:          baseRetailPrice,
-			BaseWholesalePrice:       baseWholesalePrice,
-			VariantRetailPrice:       variantRetailPrice,
-			VariantWholesalePrice:    variantWholesalePrice,
-			FranchiseRetailPrice:     franchiseRetailPrice,
-			FranchiseWholesalePrice:  franchiseWholesalePrice,
-			EffectiveRetailPrice:     effectiveRetailPrice,
-			EffectiveWholesalePrice:   effectiveWholesalePrice,
-			UseParentPricing:         variant.UseParentPricing,
-		})
+// ListParkedSales lists the carts parked in a franchise, or at company level when no franchise is given
+func (s *Service) ListParkedSales(userID, companyID uint, franchiseID *uint) ([]*SaleResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
 	}
 
-	return results, nil
+	if franchiseID != nil {
+		if err := s.checkUserFranchiseAccess(userID, *franchiseID, user.RoleEmployee); err != nil {
+			return nil, err
+		}
+	}
+
+	sales, err := s.saleRepo.FindParked(companyID, franchiseID)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch parked sales", err)
+	}
+
+	saleResponses := make([]*SaleResponse, len(sales))
+	for i, sale := range sales {
+		saleResponses[i] = ToSaleResponse(sale)
+	}
+
+	return saleResponses, nil
+}
+
+// UpdateParkedSale replaces the cart of a parked sale, adjusting its reserved stock
+func (s *Service) UpdateParkedSale(userID, companyID, saleID uint, req *UpdateParkedSaleRequest) (*SaleResponse, error) {
+	sale, err := s.getParkedSale(userID, companyID, saleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkSaleCustomer(companyID, req.CustomerID); err != nil {
+		return nil, err
+	}
+	if sale.IsWholesale {
+		if err := s.checkWholesaleCustomer(req.CustomerID); err != nil {
+			return nil, err
+		}
+	}
+
+	held := saleItemQuantities(sale.Items)
+	saleItems, err := s.buildSaleItems(userID, companyID, sale.FranchiseID, req.CustomerID, req.Items, req.OverrideApproval, held, req.CouponCode, sale.IsWholesale)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// The cart may have changed since it was read; what it holds now is what gets adjusted
+	sale, err = s.lockParkedSale(tx, sale.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	held = saleItemQuantities(sale.Items)
+
+	sale.CustomerID = req.CustomerID
+	sale.DiscountAmount = req.DiscountAmount
+	sale.Notes = req.Notes
+	sale.CouponCode = promotion.NormalizeCouponCode(req.CouponCode)
+	sale.Items = saleItems
+	sale.CalculateTotals()
+
+	if !sale.IsValid() {
+		tx.Rollback()
+		return nil, errors.NewValidationError("invalid sale data")
+	}
+
+	if err := tx.Where("sale_id = ?", sale.ID).Delete(&pos.SaleItem{}).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to remove sale items", err)
+	}
+
+	for i := range saleItems {
+		saleItems[i].SaleID = sale.ID
+	}
+
+	if err := tx.Create(&saleItems).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to create sale items", err)
+	}
+
+	if err := tx.Omit("Items", "Payments", "Customer").Save(sale).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to update sale", err)
+	}
+
+	if err := s.adjustSaleReservations(tx, companyID, sale.FranchiseID, sale.ID, held, saleItemQuantities(saleItems), "parked_sale", userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	updatedSale, err := s.saleRepo.FindByID(sale.ID)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch parked sale", err)
+	}
+
+	return ToSaleResponse(updatedSale), nil
+}
+
+// ResumeParkedSale completes a parked sale, turning its reserved stock into sold stock
+func (s *Service) ResumeParkedSale(userID, companyID, saleID uint) (*SaleResponse, error) {
+	sale, err := s.getParkedSale(userID, companyID, saleID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A wholesale cart may be built up below the minimum order, but not go through
+	if sale.IsWholesale {
+		if err := s.checkWholesaleMinimumOrder(companyID, sale.Items); err != nil {
+			return nil, err
+		}
+	}
+
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	sale, err = s.lockParkedSale(tx, sale.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := s.fulfillSaleReservations(tx, sale, userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Promotions are only used up once the parked sale goes through
+	if err := s.redeemPromotions(tx, sale.Items); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	receiptNumber, err := s.numberingService.Next(tx, sale.CompanyID, sale.FranchiseID, numbering.DocumentTypeReceipt)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	sale.ReceiptNumber = receiptNumber
+
+	sale.Complete()
+	if err := tx.Omit("Items", "Payments", "Customer").Save(sale).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to complete sale", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	completedSale, err := s.saleRepo.FindByID(sale.ID)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch completed sale", err)
+	}
+
+	return ToSaleResponse(completedSale), nil
+}
+
+// DiscardParkedSale cancels a parked sale and releases its reserved stock
+func (s *Service) DiscardParkedSale(userID, companyID, saleID uint) error {
+	sale, err := s.getParkedSale(userID, companyID, saleID)
+	if err != nil {
+		return err
+	}
+
+	return s.releaseParkedSale(sale, userID)
+}
+
+// ReleaseAbandonedParkedSales discards every parked sale parked before the cutoff
+// and returns how many were released
+func (s *Service) ReleaseAbandonedParkedSales(cutoff time.Time) (int, error) {
+	sales, err := s.saleRepo.FindParkedBefore(cutoff)
+	if err != nil {
+		return 0, errors.NewInternalError("failed to fetch parked sales", err)
+	}
+
+	released := 0
+	for _, sale := range sales {
+		if err := s.releaseParkedSale(sale, sale.CreatedByID); err != nil {
+			log.Printf("Failed to release parked sale %d: %v", sale.ID, err)
+			continue
+		}
+		released++
+	}
+
+	return released, nil
+}
+
+func (s *Service) releaseParkedSale(sale *pos.Sale, userID uint) error {
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	sale, err := s.lockParkedSale(tx, sale.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := s.adjustSaleReservations(tx, sale.CompanyID, sale.FranchiseID, sale.ID, saleItemQuantities(sale.Items), nil, "parked_sale", userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	sale.Cancel()
+	if err := tx.Omit("Items", "Payments", "Customer").Save(sale).Error; err != nil {
+		tx.Rollback()
+		return errors.NewInternalError("failed to cancel sale", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	return nil
+}
+
+// lockParkedSale locks a parked sale and reloads it, checking it is still parked: two registers
+// resuming the same cart, or the abandoned cart job and a cashier, must not both go through
+func (s *Service) lockParkedSale(tx *gorm.DB, saleID uint) (*pos.Sale, error) {
+	sale, err := s.lockSale(tx, saleID)
+	if err != nil {
+		return nil, err
+	}
+	if !sale.IsParked() {
+		return nil, errors.NewValidationError("sale is not parked")
+	}
+	return sale, nil
+}
+
+// getParkedSale loads a parked sale the user may work on; any register of the sale's franchise can resume it
+func (s *Service) getParkedSale(userID, companyID, saleID uint) (*pos.Sale, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	sale, err := s.saleRepo.FindByID(saleID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("sale not found")
+	}
+
+	if sale.CompanyID != companyID {
+		return nil, errors.NewForbiddenError("access denied to this sale")
+	}
+
+	if sale.FranchiseID != nil {
+		if err := s.checkUserFranchiseAccess(userID, *sale.FranchiseID, user.RoleEmployee); err != nil {
+			return nil, err
+		}
+	}
+
+	if !sale.IsParked() {
+		return nil, errors.NewValidationError("sale is not parked")
+	}
+
+	return sale, nil
+}
+
+// Layaway operations
+
+// defaultLayawayDays is how long items are held when the layaway gives no expiry date
+const defaultLayawayDays = 7
+
+// CreateLayaway holds items for a customer against a first deposit. Their stock stays
+// reserved until they are picked up, or released when the layaway is cancelled or expires.
+func (s *Service) CreateLayaway(userID, companyID uint, req *CreateLayawayRequest) (*LayawayResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	// If franchise is specified, verify access
+	if req.FranchiseID != nil {
+		if err := s.checkUserFranchiseAccess(userID, *req.FranchiseID, user.RoleEmployee); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.checkSaleCustomer(companyID, &req.CustomerID); err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().AddDate(0, 0, defaultLayawayDays)
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			return nil, errors.NewValidationError("expiry date must be in the future")
+		}
+		expiresAt = *req.ExpiresAt
+	}
+
+	saleItems, err := s.buildSaleItems(userID, companyID, req.FranchiseID, &req.CustomerID, req.Items, req.OverrideApproval, nil, req.CouponCode, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	sale := &pos.Sale{
+		CompanyID:      companyID,
+		FranchiseID:    req.FranchiseID,
+		CustomerID:     &req.CustomerID,
+		DiscountAmount: req.DiscountAmount,
+		PaymentStatus:  pos.PaymentStatusUnpaid,
+		SaleStatus:     pos.SaleStatusDraft,
+		ReceiptNumber:  draftReceiptNumber(),
+		Notes:          req.Notes,
+		CouponCode:     promotion.NormalizeCouponCode(req.CouponCode),
+		CreatedByID:    userID,
+	}
+
+	if err := s.persistSale(tx, sale, saleItems); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := s.adjustSaleReservations(tx, companyID, req.FranchiseID, sale.ID, nil, saleItemQuantities(saleItems), "layaway", userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if _, err := s.applyTenders(tx, sale, req.Deposits, userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	layaway := &pos.Layaway{
+		CompanyID:       companyID,
+		FranchiseID:     req.FranchiseID,
+		CustomerID:      req.CustomerID,
+		SaleID:          sale.ID,
+		Status:          pos.LayawayStatusActive,
+		ExpiresAt:       expiresAt,
+		ForfeitOnExpiry: req.ForfeitOnExpiry,
+		DepositStatus:   pos.DepositStatusHeld,
+		CreatedByID:     userID,
+	}
+
+	if !layaway.IsValid() {
+		tx.Rollback()
+		return nil, errors.NewValidationError("invalid layaway data")
+	}
+
+	if err := tx.Create(layaway).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to create layaway", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	return s.findLayawayResponse(layaway.ID)
+}
+
+// ListLayaways lists layaways, newest first, optionally only those in one status
+func (s *Service) ListLayaways(userID, companyID uint, franchiseID *uint, status string, page, limit int) (*PaginatedResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	if franchiseID != nil {
+		if err := s.checkUserFranchiseAccess(userID, *franchiseID, user.RoleEmployee); err != nil {
+			return nil, err
+		}
+	}
+
+	layawayStatus := pos.LayawayStatus(status)
+	if status != "" && !layawayStatus.IsValid() {
+		return nil, errors.NewValidationError("invalid layaway status")
+	}
+
+	layaways, total, err := s.layawayRepo.FindByCompanyID(companyID, franchiseID, layawayStatus, page, limit)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch layaways", err)
+	}
+
+	layawayResponses := make([]*LayawayResponse, len(layaways))
+	for i, layaway := range layaways {
+		layawayResponses[i] = ToLayawayResponse(layaway)
+	}
+
+	return NewPaginatedResponse(layawayResponses, total, page, limit), nil
+}
+
+func (s *Service) GetLayaway(userID, companyID, layawayID uint) (*LayawayResponse, error) {
+	layaway, err := s.getLayaway(userID, companyID, layawayID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ToLayawayResponse(layaway), nil
+}
+
+// AddLayawayDeposit records another deposit on an active layaway
+func (s *Service) AddLayawayDeposit(userID, companyID, layawayID uint, req *AddPaymentRequest) (*LayawayResponse, error) {
+	layaway, err := s.getLayaway(userID, companyID, layawayID)
+	if err != nil {
+		return nil, err
+	}
+
+	tender := TenderRequest{
+		PaymentMethod: req.PaymentMethod,
+		Amount:        req.Amount,
+		Reference:     req.Reference,
+		Notes:         req.Notes,
+	}
+
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	layaway, err = s.lockActiveLayaway(tx, layaway.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if _, err := s.applyTenders(tx, layaway.Sale, []TenderRequest{tender}, userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	return s.findLayawayResponse(layaway.ID)
+}
+
+// CompleteLayaway hands the items over: the held sale is completed, its reserved stock
+// becomes sold stock and the tenders pay what the deposits left due
+func (s *Service) CompleteLayaway(userID, companyID, layawayID uint, req *CompleteLayawayRequest) (*LayawayResponse, error) {
+	layaway, err := s.getLayaway(userID, companyID, layawayID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	layaway, err = s.lockActiveLayaway(tx, layaway.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	sale := layaway.Sale
+
+	if err := s.fulfillSaleReservations(tx, sale, userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Promotions are only used up once the items are picked up
+	if err := s.redeemPromotions(tx, sale.Items); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	receiptNumber, err := s.numberingService.Next(tx, sale.CompanyID, sale.FranchiseID, numbering.DocumentTypeReceipt)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	sale.ReceiptNumber = receiptNumber
+
+	sale.Complete()
+	if err := tx.Omit("Items", "Payments", "Customer").Save(sale).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to complete sale", err)
+	}
+
+	if len(req.Tenders) > 0 {
+		if _, err := s.applyTenders(tx, sale, req.Tenders, userID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	} else if sale.PaymentStatus == pos.PaymentStatusPaid {
+		// Paid off by the deposits alone
+		if err := s.recordCustomerPurchase(tx, sale, userID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if sale.PaymentStatus != pos.PaymentStatusPaid {
+		tx.Rollback()
+		return nil, errors.NewValidationError("the balance must be paid before the items are picked up")
+	}
+
+	layaway.Complete(userID)
+	if err := tx.Omit("Sale", "Customer").Save(layaway).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to complete layaway", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	return s.findLayawayResponse(layaway.ID)
+}
+
+// CancelLayaway releases the held stock at the customer's request. The deposits are set
+// aside for a refund, or kept by the store when a manager forfeits them.
+func (s *Service) CancelLayaway(userID, companyID, layawayID uint, req *CancelLayawayRequest) (*LayawayResponse, error) {
+	layaway, err := s.getLayaway(userID, companyID, layawayID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ForfeitDeposit && !s.hasManagerRole(userID, companyID, layaway.FranchiseID) {
+		return nil, errors.NewForbiddenError("only managers can forfeit deposits")
+	}
+
+	err = s.releaseLayaway(layaway, userID, func(l *pos.Layaway) {
+		l.Cancel(userID, req.Reason, req.ForfeitDeposit)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.findLayawayResponse(layaway.ID)
+}
+
+// ExpireLayaways releases every active layaway that expired before now and returns how many were released
+func (s *Service) ExpireLayaways(now time.Time) (int, error) {
+	layaways, err := s.layawayRepo.FindActiveExpiredBefore(now)
+	if err != nil {
+		return 0, errors.NewInternalError("failed to fetch expired layaways", err)
+	}
+
+	released := 0
+	for _, layaway := range layaways {
+		if err := s.releaseLayaway(layaway, layaway.CreatedByID, (*pos.Layaway).Expire); err != nil {
+			log.Printf("Failed to release expired layaway %d: %v", layaway.ID, err)
+			continue
+		}
+		released++
+	}
+
+	return released, nil
+}
+
+// ResolveLayawayDeposit settles the deposits of a cancelled or expired layaway set aside for a
+// refund: they are given back with the tenders they were paid with, or kept by the store
+func (s *Service) ResolveLayawayDeposit(userID, companyID, layawayID uint, req *ResolveLayawayDepositRequest) (*LayawayResponse, error) {
+	layaway, err := s.getLayaway(userID, companyID, layawayID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.hasManagerRole(userID, companyID, layaway.FranchiseID) {
+		return nil, errors.NewForbiddenError("only managers can settle deposits")
+	}
+
+	refunding := req.Action == "refund"
+
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	layaway, err = s.lockLayaway(tx, layaway.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if layaway.DepositStatus != pos.DepositStatusToRefund {
+		tx.Rollback()
+		return nil, errors.NewValidationError(fmt.Sprintf("deposit is %s, only deposits to refund can be settled", layaway.DepositStatus))
+	}
+	sale := layaway.Sale
+
+	if !refunding {
+		layaway.DepositStatus = pos.DepositStatusForfeited
+	} else {
+		refunds, err := s.refundLayawayDeposits(tx, layaway, req.Reason, userID)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		layaway.DepositStatus = pos.DepositStatusRefunded
+		layaway.DepositRefundID = &refunds[0].ID
+
+		sale.PaymentStatus = pos.PaymentStatusRefunded
+		if err := tx.Omit("Items", "Payments", "Customer").Save(sale).Error; err != nil {
+			tx.Rollback()
+			return nil, errors.NewInternalError("failed to update sale", err)
+		}
+	}
+
+	if err := tx.Omit("Sale", "Customer").Save(layaway).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to update layaway", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	return s.findLayawayResponse(layaway.ID)
+}
+
+// refundLayawayDeposits gives the deposits paid on a layaway back with the tenders they were
+// paid with, as one refund of its held sale per payment method: cash leaves the drawer, points
+// go back to the customer, vouchers become store credit and card payments are reversed outside
+func (s *Service) refundLayawayDeposits(tx *gorm.DB, layaway *pos.Layaway, reason string, userID uint) ([]pos.Refund, error) {
+	sale := layaway.Sale
+
+	methods := []pos.PaymentMethod{}
+	deposits := make(map[pos.PaymentMethod]float64)
+	for _, payment := range sale.Payments {
+		if payment.PaymentStatus != pos.PaymentTransactionStatusCompleted {
+			continue
+		}
+		if _, seen := deposits[payment.PaymentMethod]; !seen {
+			methods = append(methods, payment.PaymentMethod)
+		}
+		deposits[payment.PaymentMethod] += payment.Amount
+	}
+
+	if reason == "" {
+		reason = fmt.Sprintf("Deposit refund for layaway #%d", layaway.ID)
+	}
+
+	refunds := make([]pos.Refund, 0, len(methods))
+	for _, method := range methods {
+		amount := roundCurrency(deposits[method])
+		if amount <= 0 {
+			continue
+		}
+
+		refund := pos.Refund{
+			OriginalSaleID: sale.ID,
+			RefundAmount:   amount,
+			Reason:         reason,
+			RefundMethod:   method,
+			RefundStatus:   pos.RefundStatusCompleted,
+			ProcessedByID:  userID,
+		}
+		if !refund.IsValid() {
+			return nil, errors.NewValidationError("invalid refund data")
+		}
+		if err := tx.Create(&refund).Error; err != nil {
+			return nil, errors.NewInternalError("failed to create refund", err)
+		}
+
+		switch method {
+		case pos.PaymentMethodLoyalty:
+			if err := s.restoreLoyaltyPoints(tx, sale, refund.ID, amount, userID); err != nil {
+				return nil, err
+			}
+		case pos.PaymentMethodVoucher:
+			if err := s.issueStoreCredit(tx, sale, &refund, userID); err != nil {
+				return nil, err
+			}
+		case pos.PaymentMethodCash:
+			notes := fmt.Sprintf("Deposit refund for layaway #%d", layaway.ID)
+			if err := s.recordCashDrawerTransaction(tx, sale.CompanyID, sale.FranchiseID, pos.CashDrawerTransactionTypeRefund, -amount, nil, notes); err != nil {
+				return nil, err
+			}
+		}
+
+		refunds = append(refunds, refund)
+	}
+
+	if len(refunds) == 0 {
+		return nil, errors.NewValidationError("no deposit was paid on this layaway")
+	}
+
+	return refunds, nil
+}
+
+// releaseLayaway gives the stock held by an active layaway back and closes it with closeFn
+func (s *Service) releaseLayaway(layaway *pos.Layaway, userID uint, closeFn func(l *pos.Layaway)) error {
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	layaway, err := s.lockActiveLayaway(tx, layaway.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	sale := layaway.Sale
+
+	if err := s.adjustSaleReservations(tx, sale.CompanyID, sale.FranchiseID, sale.ID, saleItemQuantities(sale.Items), nil, "layaway", userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	sale.Cancel()
+	if err := tx.Omit("Items", "Payments", "Customer").Save(sale).Error; err != nil {
+		tx.Rollback()
+		return errors.NewInternalError("failed to cancel sale", err)
+	}
+
+	closeFn(layaway)
+	if err := tx.Omit("Sale", "Customer").Save(layaway).Error; err != nil {
+		tx.Rollback()
+		return errors.NewInternalError("failed to update layaway", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	return nil
+}
+
+// lockLayaway locks the layaway row so that deposits, pick-up, cancellation, expiry and
+// deposit refunds never run on it at the same time, and reloads it with its held sale,
+// locked with lockSale, so that callers change what the previous one saved
+func (s *Service) lockLayaway(tx *gorm.DB, layawayID uint) (*pos.Layaway, error) {
+	var locked pos.Layaway
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, layawayID).Error; err != nil {
+		return nil, errors.NewInternalError("failed to lock layaway", err)
+	}
+
+	sale, err := s.lockSale(tx, locked.SaleID)
+	if err != nil {
+		return nil, err
+	}
+	locked.Sale = sale
+
+	return &locked, nil
+}
+
+// lockActiveLayaway locks the layaway with lockLayaway and checks it still holds its stock
+func (s *Service) lockActiveLayaway(tx *gorm.DB, layawayID uint) (*pos.Layaway, error) {
+	locked, err := s.lockLayaway(tx, layawayID)
+	if err != nil {
+		return nil, err
+	}
+	if !locked.IsActive() {
+		return nil, errors.NewValidationError(fmt.Sprintf("layaway is %s", locked.Status))
+	}
+	return locked, nil
+}
+
+// getLayaway loads a layaway the user may work on
+func (s *Service) getLayaway(userID, companyID, layawayID uint) (*pos.Layaway, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	layaway, err := s.layawayRepo.FindByID(layawayID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("layaway not found")
+	}
+
+	if layaway.CompanyID != companyID {
+		return nil, errors.NewForbiddenError("access denied to this layaway")
+	}
+
+	if layaway.FranchiseID != nil {
+		if err := s.checkUserFranchiseAccess(userID, *layaway.FranchiseID, user.RoleEmployee); err != nil {
+			return nil, err
+		}
+	}
+
+	return layaway, nil
+}
+
+func (s *Service) findLayawayResponse(layawayID uint) (*LayawayResponse, error) {
+	layaway, err := s.layawayRepo.FindByID(layawayID)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch layaway", err)
+	}
+	return ToLayawayResponse(layaway), nil
+}
+
+// Invoice operations
+
+// IssueInvoice issues the legal invoice of a completed sale to its customer. The seller and
+// buyer details are copied on the invoice; issuing it again returns the same invoice.
+func (s *Service) IssueInvoice(userID, companyID, saleID uint) (*InvoiceResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	sale, err := s.saleRepo.FindByID(saleID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("sale not found")
+	}
+	if sale.CompanyID != companyID {
+		return nil, errors.NewForbiddenError("access denied to this sale")
+	}
+
+	if invoice, err := s.invoiceRepo.FindBySaleID(saleID); err == nil {
+		return ToInvoiceResponse(invoice), nil
+	}
+
+	if !sale.CanBeInvoiced() {
+		return nil, errors.NewValidationError(fmt.Sprintf("cannot invoice a %s sale", sale.SaleStatus))
+	}
+	if sale.Customer == nil {
+		return nil, errors.NewValidationError("the sale has no customer to invoice")
+	}
+	if sale.Customer.IsBusiness && sale.Customer.NIF == "" {
+		return nil, errors.NewValidationError("the customer's NIF is required to invoice a business")
+	}
+
+	seller, err := s.sellerParty(companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Lock the sale so that the invoice is issued once
+	var locked pos.Sale
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, saleID).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to lock sale", err)
+	}
+	var existing pos.Invoice
+	if err := tx.Where("sale_id = ?", saleID).First(&existing).Error; err == nil {
+		tx.Rollback()
+		return ToInvoiceResponse(&existing), nil
+	}
+
+	invoiceNumber, err := s.numberingService.Next(tx, companyID, nil, numbering.DocumentTypeInvoice)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	invoice := &pos.Invoice{
+		CompanyID:     companyID,
+		FranchiseID:   sale.FranchiseID,
+		SaleID:        sale.ID,
+		InvoiceNumber: invoiceNumber,
+		Seller:        seller,
+		Buyer:         sale.Customer.LegalParty(),
+		IssuedByID:    userID,
+	}
+
+	if !invoice.IsValid() {
+		tx.Rollback()
+		return nil, errors.NewValidationError("invalid invoice data")
+	}
+
+	if err := tx.Create(invoice).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to create invoice", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	return ToInvoiceResponse(invoice), nil
+}
+
+// GenerateInvoicePDF renders the A4 invoice issued for a sale
+func (s *Service) GenerateInvoicePDF(userID, companyID, saleID uint) ([]byte, string, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, "", err
+	}
+
+	sale, err := s.saleRepo.FindByID(saleID)
+	if err != nil {
+		return nil, "", errors.NewNotFoundError("sale not found")
+	}
+	if sale.CompanyID != companyID {
+		return nil, "", errors.NewForbiddenError("access denied to this sale")
+	}
+
+	invoice, err := s.invoiceRepo.FindBySaleID(saleID)
+	if err != nil {
+		return nil, "", errors.NewNotFoundError("no invoice has been issued for this sale")
+	}
+
+	data := receipt.ConvertSaleToInvoiceData(sale, invoice.Seller, invoice.Buyer, s.loadVariantInfo(sale.Items))
+	data.Title = "INVOICE"
+	data.Number = invoice.InvoiceNumber
+	data.Date = invoice.CreatedAt
+	data.Reference = sale.ReceiptNumber
+
+	return renderInvoicePDF(data, fmt.Sprintf("invoice_%s.pdf", invoice.InvoiceNumber))
+}
+
+// sellerParty returns the company as the seller printed on its invoices
+func (s *Service) sellerParty(companyID uint) (pos.LegalParty, error) {
+	var c company.Company
+	if err := s.db.First(&c, companyID).Error; err != nil {
+		return pos.LegalParty{}, errors.NewNotFoundError("company not found")
+	}
+	if c.NIF == "" {
+		return pos.LegalParty{}, errors.NewValidationError("the company's NIF must be set before issuing invoices")
+	}
+
+	return pos.LegalParty{
+		Name:    c.Name,
+		Address: c.Address,
+		NIF:     c.NIF,
+		NIS:     c.NIS,
+		RC:      c.RC,
+		AI:      c.AI,
+	}, nil
+}
+
+func renderInvoicePDF(data *receipt.InvoiceData, filename string) ([]byte, string, error) {
+	generator := receipt.NewReceiptGenerator()
+	pdfData, err := generator.GenerateInvoice(data)
+	if err != nil {
+		return nil, "", errors.NewInternalError("failed to generate invoice PDF", err)
+	}
+	return pdfData, filename, nil
+}
+
+// Proforma operations
+
+// defaultProformaDays is how long quoted prices are honoured when the proforma gives no date
+const defaultProformaDays = 30
+
+// CreateProforma quotes items to a customer. The lines are priced now and kept on a draft
+// sale; no stock is reserved until the proforma is converted.
+func (s *Service) CreateProforma(userID, companyID uint, req *CreateProformaRequest) (*ProformaResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	// If franchise is specified, verify access
+	if req.FranchiseID != nil {
+		if err := s.checkUserFranchiseAccess(userID, *req.FranchiseID, user.RoleEmployee); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.checkSaleCustomer(companyID, &req.CustomerID); err != nil {
+		return nil, err
+	}
+	if req.IsWholesale {
+		if err := s.checkWholesaleCustomer(&req.CustomerID); err != nil {
+			return nil, err
+		}
+	}
+
+	validUntil := time.Now().AddDate(0, 0, defaultProformaDays)
+	if req.ValidUntil != nil {
+		if !req.ValidUntil.After(time.Now()) {
+			return nil, errors.NewValidationError("validity date must be in the future")
+		}
+		validUntil = *req.ValidUntil
+	}
+
+	saleItems, err := s.priceSaleItems(userID, companyID, req.FranchiseID, &req.CustomerID, req.Items, req.OverrideApproval, req.CouponCode, req.IsWholesale)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.IsWholesale {
+		if err := s.checkWholesaleMinimumOrder(companyID, saleItems); err != nil {
+			return nil, err
+		}
+	}
+
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	sale := &pos.Sale{
+		CompanyID:      companyID,
+		FranchiseID:    req.FranchiseID,
+		CustomerID:     &req.CustomerID,
+		DiscountAmount: req.DiscountAmount,
+		PaymentStatus:  pos.PaymentStatusUnpaid,
+		SaleStatus:     pos.SaleStatusDraft,
+		ReceiptNumber:  draftReceiptNumber(),
+		Notes:          req.Notes,
+		CouponCode:     promotion.NormalizeCouponCode(req.CouponCode),
+		IsWholesale:    req.IsWholesale,
+		CreatedByID:    userID,
+	}
+
+	if err := s.persistSale(tx, sale, saleItems); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	proformaNumber, err := s.numberingService.Next(tx, companyID, nil, numbering.DocumentTypeProforma)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	proforma := &pos.Proforma{
+		CompanyID:      companyID,
+		FranchiseID:    req.FranchiseID,
+		CustomerID:     req.CustomerID,
+		SaleID:         sale.ID,
+		ProformaNumber: proformaNumber,
+		Status:         pos.ProformaStatusOpen,
+		ValidUntil:     validUntil,
+		CreatedByID:    userID,
+	}
+
+	if !proforma.IsValid() {
+		tx.Rollback()
+		return nil, errors.NewValidationError("invalid proforma data")
+	}
+
+	if err := tx.Create(proforma).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to create proforma", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	return s.findProformaResponse(proforma.ID)
+}
+
+// ListProformas lists proformas, newest first, optionally only those in one status
+func (s *Service) ListProformas(userID, companyID uint, franchiseID *uint, status string, page, limit int) (*PaginatedResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	if franchiseID != nil {
+		if err := s.checkUserFranchiseAccess(userID, *franchiseID, user.RoleEmployee); err != nil {
+			return nil, err
+		}
+	}
+
+	proformaStatus := pos.ProformaStatus(status)
+	if status != "" && !proformaStatus.IsValid() {
+		return nil, errors.NewValidationError("invalid proforma status")
+	}
+
+	proformas, total, err := s.proformaRepo.FindByCompanyID(companyID, franchiseID, proformaStatus, page, limit)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch proformas", err)
+	}
+
+	proformaResponses := make([]*ProformaResponse, len(proformas))
+	for i, proforma := range proformas {
+		proformaResponses[i] = ToProformaResponse(proforma)
+	}
+
+	return NewPaginatedResponse(proformaResponses, total, page, limit), nil
+}
+
+func (s *Service) GetProforma(userID, companyID, proformaID uint) (*ProformaResponse, error) {
+	proforma, err := s.getProforma(userID, companyID, proformaID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ToProformaResponse(proforma), nil
+}
+
+// ConvertProforma turns an accepted proforma into a sale at the quoted prices: its draft
+// sale is completed and the stock is taken. Payments are then added to the sale as usual.
+func (s *Service) ConvertProforma(userID, companyID, proformaID uint) (*ProformaResponse, error) {
+	proforma, err := s.getProforma(userID, companyID, proformaID)
+	if err != nil {
+		return nil, err
+	}
+	sale := proforma.Sale
+
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := s.lockOpenProforma(tx, proforma); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if proforma.IsExpired(time.Now()) {
+		tx.Rollback()
+		return nil, errors.NewValidationError(fmt.Sprintf("proforma expired on %s", proforma.ValidUntil.Format("2006-01-02")))
+	}
+
+	if err := s.deductSaleInventory(tx, sale.CompanyID, sale.FranchiseID, sale.Items, "sale", fmt.Sprintf("%d", sale.ID), userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := s.redeemPromotions(tx, sale.Items); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	receiptNumber, err := s.numberingService.Next(tx, sale.CompanyID, sale.FranchiseID, numbering.DocumentTypeReceipt)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	sale.ReceiptNumber = receiptNumber
+
+	// The sale takes place on conversion, not when it was quoted
+	sale.CreatedAt = time.Now()
+	sale.Complete()
+	if err := tx.Omit("Items", "Payments", "Customer").Save(sale).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to complete sale", err)
+	}
+
+	proforma.Convert(userID)
+	if err := tx.Omit("Sale", "Customer").Save(proforma).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to convert proforma", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	return s.findProformaResponse(proforma.ID)
+}
+
+// CancelProforma records that the customer declined the quote
+func (s *Service) CancelProforma(userID, companyID, proformaID uint) (*ProformaResponse, error) {
+	proforma, err := s.getProforma(userID, companyID, proformaID)
+	if err != nil {
+		return nil, err
+	}
+	sale := proforma.Sale
+
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := s.lockOpenProforma(tx, proforma); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	sale.Cancel()
+	if err := tx.Omit("Items", "Payments", "Customer").Save(sale).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to cancel sale", err)
+	}
+
+	proforma.Cancel(userID)
+	if err := tx.Omit("Sale", "Customer").Save(proforma).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to cancel proforma", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	return s.findProformaResponse(proforma.ID)
+}
+
+// GenerateProformaPDF renders the A4 proforma given to the customer
+func (s *Service) GenerateProformaPDF(userID, companyID, proformaID uint) ([]byte, string, error) {
+	proforma, err := s.getProforma(userID, companyID, proformaID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	seller, err := s.sellerParty(companyID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	data := receipt.ConvertSaleToInvoiceData(proforma.Sale, seller, proforma.Customer.LegalParty(), s.loadVariantInfo(proforma.Sale.Items))
+	data.Title = "PROFORMA INVOICE"
+	data.Number = proforma.ProformaNumber
+	data.Date = proforma.CreatedAt
+	data.ValidUntil = &proforma.ValidUntil
+
+	return renderInvoicePDF(data, fmt.Sprintf("proforma_%s.pdf", proforma.ProformaNumber))
+}
+
+// lockOpenProforma locks the proforma row and checks it can still be converted or cancelled
+func (s *Service) lockOpenProforma(tx *gorm.DB, proforma *pos.Proforma) error {
+	var locked pos.Proforma
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, proforma.ID).Error; err != nil {
+		return errors.NewInternalError("failed to lock proforma", err)
+	}
+	if !locked.IsOpen() {
+		return errors.NewValidationError(fmt.Sprintf("proforma is %s", locked.Status))
+	}
+	return nil
+}
+
+// getProforma loads a proforma the user may work on
+func (s *Service) getProforma(userID, companyID, proformaID uint) (*pos.Proforma, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	proforma, err := s.proformaRepo.FindByID(proformaID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("proforma not found")
+	}
+
+	if proforma.CompanyID != companyID {
+		return nil, errors.NewForbiddenError("access denied to this proforma")
+	}
+
+	if proforma.FranchiseID != nil {
+		if err := s.checkUserFranchiseAccess(userID, *proforma.FranchiseID, user.RoleEmployee); err != nil {
+			return nil, err
+		}
+	}
+
+	return proforma, nil
+}
+
+func (s *Service) findProformaResponse(proformaID uint) (*ProformaResponse, error) {
+	proforma, err := s.proformaRepo.FindByID(proformaID)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch proforma", err)
+	}
+	return ToProformaResponse(proforma), nil
+}
+
+// Offline sync operations
+
+// ReserveReceiptRange reserves a block of receipt numbers for a POS device to use while offline.
+// Ranges follow each other per company, so offline receipt numbers never collide.
+func (s *Service) ReserveReceiptRange(userID, companyID uint, req *ReserveReceiptRangeRequest) (*ReceiptRangeResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	if req.FranchiseID != nil {
+		if err := s.checkUserFranchiseAccess(userID, *req.FranchiseID, user.RoleEmployee); err != nil {
+			return nil, err
+		}
+	}
+
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Lock the company so that concurrent reservations get consecutive ranges
+	var c company.Company
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&c, companyID).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewNotFoundError("company not found")
+	}
+
+	var lastNumber int
+	if err := tx.Model(&pos.OfflineReceiptRange{}).Where("company_id = ?", companyID).
+		Select("COALESCE(MAX(end_number), 0)").Scan(&lastNumber).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to fetch receipt ranges", err)
+	}
+
+	receiptRange := &pos.OfflineReceiptRange{
+		CompanyID:    companyID,
+		FranchiseID:  req.FranchiseID,
+		DeviceID:     strings.TrimSpace(req.DeviceID),
+		StartNumber:  lastNumber + 1,
+		EndNumber:    lastNumber + req.Size,
+		ReservedByID: userID,
+	}
+	if err := tx.Create(receiptRange).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to reserve receipt range", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	return ToReceiptRangeResponse(receiptRange), nil
+}
+
+// SyncOfflineSales uploads the sales a device rang up while offline. Each sale is accepted or
+// rejected on its own, and uploads are idempotent on the client UUID, so a replayed batch
+// changes nothing. Sales are kept as they happened on the device: stock may go negative and
+// prices may differ from the server's, which is reported as conflicts instead of rejecting them.
+func (s *Service) SyncOfflineSales(userID, companyID uint, req *SyncOfflineSalesRequest) (*SyncOfflineSalesResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	deviceID := strings.TrimSpace(req.DeviceID)
+	franchiseAccess := make(map[uint]error)
+	result := &SyncOfflineSalesResponse{
+		Results: make([]OfflineSaleResult, 0, len(req.Sales)),
+	}
+
+	for i := range req.Sales {
+		saleReq := &req.Sales[i]
+
+		var saleResult *OfflineSaleResult
+		if saleReq.FranchiseID != nil {
+			if _, checked := franchiseAccess[*saleReq.FranchiseID]; !checked {
+				franchiseAccess[*saleReq.FranchiseID] = s.checkUserFranchiseAccess(userID, *saleReq.FranchiseID, user.RoleEmployee)
+			}
+			if err := franchiseAccess[*saleReq.FranchiseID]; err != nil {
+				saleResult = rejectedOfflineSale(saleReq, err)
+			}
+		}
+		if saleResult == nil {
+			saleResult = s.syncOfflineSale(userID, companyID, deviceID, saleReq)
+		}
+
+		switch saleResult.Status {
+		case OfflineSaleStatusCreated:
+			result.Created++
+			if len(saleResult.Conflicts) > 0 {
+				result.Conflicts++
+			}
+		case OfflineSaleStatusDuplicate:
+			result.Duplicates++
+		case OfflineSaleStatusRejected:
+			result.Rejected++
+		}
+		result.Results = append(result.Results, *saleResult)
+	}
+
+	return result, nil
+}
+
+// syncOfflineSale records one offline sale in its own transaction, unless it was uploaded before
+func (s *Service) syncOfflineSale(userID, companyID uint, deviceID string, req *OfflineSaleRequest) *OfflineSaleResult {
+	if existing, err := s.saleRepo.FindByClientUUID(companyID, req.ClientUUID); err == nil {
+		return s.duplicateOfflineSale(existing)
+	}
+
+	sale, conflicts, err := s.recordOfflineSale(userID, companyID, deviceID, req)
+	if err != nil {
+		// A concurrent upload of the same sale may have been recorded first
+		if existing, findErr := s.saleRepo.FindByClientUUID(companyID, req.ClientUUID); findErr == nil {
+			return s.duplicateOfflineSale(existing)
+		}
+		return rejectedOfflineSale(req, err)
+	}
+
+	saleID := sale.ID
+	result := &OfflineSaleResult{
+		ClientUUID:    req.ClientUUID,
+		Status:        OfflineSaleStatusCreated,
+		SaleID:        &saleID,
+		ReceiptNumber: sale.ReceiptNumber,
+		Conflicts:     make([]SyncConflictResponse, len(conflicts)),
+	}
+	for i := range conflicts {
+		result.Conflicts[i] = ToSyncConflictResponse(&conflicts[i])
+	}
+	return result
+}
+
+// recordOfflineSale creates an offline sale as it happened on the device: at its time, with its
+// receipt number and prices, taking the stock and the tenders. Discrepancies are saved as conflicts.
+func (s *Service) recordOfflineSale(userID, companyID uint, deviceID string, req *OfflineSaleRequest) (*pos.Sale, []pos.SyncConflict, error) {
+	if req.CreatedAt.After(time.Now().Add(5 * time.Minute)) {
+		return nil, nil, errors.NewValidationError("sale date is in the future")
+	}
+
+	if err := s.checkOfflineReceiptNumber(companyID, req.FranchiseID, deviceID, req.ReceiptNumber); err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := s.saleRepo.FindByReceiptNumber(companyID, req.ReceiptNumber); err == nil {
+		return nil, nil, errors.NewConflictError(fmt.Sprintf("receipt number %s is already used by another sale", req.ReceiptNumber))
+	}
+
+	if err := s.checkSaleCustomer(companyID, req.CustomerID); err != nil {
+		return nil, nil, err
+	}
+
+	saleItems, conflicts, err := s.buildOfflineSaleItems(companyID, req.FranchiseID, req.CustomerID, req.Items, req.CreatedAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	now := time.Now()
+	clientUUID := req.ClientUUID
+	sale := &pos.Sale{
+		CompanyID:      companyID,
+		FranchiseID:    req.FranchiseID,
+		CustomerID:     req.CustomerID,
+		ReceiptNumber:  req.ReceiptNumber,
+		DiscountAmount: req.DiscountAmount,
+		PaymentStatus:  pos.PaymentStatusUnpaid,
+		SaleStatus:     pos.SaleStatusCompleted,
+		Notes:          req.Notes,
+		ClientUUID:     &clientUUID,
+		SyncedAt:       &now,
+		CreatedByID:    userID,
+		CreatedAt:      req.CreatedAt,
+	}
+
+	if err := s.persistSale(tx, sale, saleItems); err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	stockConflicts, err := s.deductOfflineSaleInventory(tx, sale, saleItems, userID)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	conflicts = append(conflicts, stockConflicts...)
+
+	if isPriceOverride(req.TotalAmount, sale.TotalAmount) {
+		conflicts = append(conflicts, pos.SyncConflict{
+			Type:     pos.SyncConflictTotalMismatch,
+			Expected: sale.TotalAmount,
+			Actual:   req.TotalAmount,
+			Message:  fmt.Sprintf("the device computed a total of %.2f, the server %.2f", req.TotalAmount, sale.TotalAmount),
+		})
+	}
+
+	tendered := 0.0
+	if len(req.Tenders) > 0 {
+		// Tenders the server cannot apply, such as a card payment above its own total, were
+		// still taken on the device: undo what they recorded and leave them to a manager
+		unpaid := *sale
+		if err := tx.SavePoint("tenders").Error; err != nil {
+			tx.Rollback()
+			return nil, nil, errors.NewInternalError("failed to create savepoint", err)
+		}
+
+		payments, err := s.applyTenders(tx, sale, req.Tenders, userID)
+		if err != nil {
+			if rollbackErr := tx.RollbackTo("tenders").Error; rollbackErr != nil {
+				tx.Rollback()
+				return nil, nil, errors.NewInternalError("failed to roll back tenders", rollbackErr)
+			}
+			*sale = unpaid
+
+			offered := 0.0
+			for _, tender := range req.Tenders {
+				offered += tender.Amount
+			}
+			conflicts = append(conflicts, pos.SyncConflict{
+				Type:     pos.SyncConflictTenderRejected,
+				Expected: sale.TotalAmount,
+				Actual:   roundCurrency(offered),
+				Message:  fmt.Sprintf("the tenders of %.2f could not be applied: %s", offered, err.Error()),
+			})
+		}
+		for _, payment := range payments {
+			tendered += payment.Amount
+		}
+	}
+
+	if sale.PaymentStatus != pos.PaymentStatusPaid {
+		conflicts = append(conflicts, pos.SyncConflict{
+			Type:     pos.SyncConflictUnderpaid,
+			Expected: sale.TotalAmount,
+			Actual:   roundCurrency(tendered),
+			Message:  fmt.Sprintf("the tenders paid %.2f of %.2f", tendered, sale.TotalAmount),
+		})
+	}
+
+	if len(conflicts) > 0 {
+		for i := range conflicts {
+			conflicts[i].SaleID = sale.ID
+		}
+		if err := tx.Create(&conflicts).Error; err != nil {
+			tx.Rollback()
+			return nil, nil, errors.NewInternalError("failed to record sync conflicts", err)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, nil, errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	return sale, conflicts, nil
+}
+
+// duplicateOfflineSale reports a sale that was already uploaded, with the conflicts found then
+func (s *Service) duplicateOfflineSale(sale *pos.Sale) *OfflineSaleResult {
+	saleID := sale.ID
+	result := &OfflineSaleResult{
+		ClientUUID:    *sale.ClientUUID,
+		Status:        OfflineSaleStatusDuplicate,
+		SaleID:        &saleID,
+		ReceiptNumber: sale.ReceiptNumber,
+		Conflicts:     []SyncConflictResponse{},
+	}
+
+	var conflicts []pos.SyncConflict
+	if err := s.db.Where("sale_id = ?", sale.ID).Order("id ASC").Find(&conflicts).Error; err == nil {
+		for i := range conflicts {
+			result.Conflicts = append(result.Conflicts, ToSyncConflictResponse(&conflicts[i]))
+		}
+	}
+	return result
+}
+
+// rejectedOfflineSale reports an offline sale that could not be recorded
+func rejectedOfflineSale(req *OfflineSaleRequest, err error) *OfflineSaleResult {
+	return &OfflineSaleResult{
+		ClientUUID:    req.ClientUUID,
+		Status:        OfflineSaleStatusRejected,
+		ReceiptNumber: req.ReceiptNumber,
+		Conflicts:     []SyncConflictResponse{},
+		Error:         err.Error(),
+	}
+}
+
+// checkOfflineReceiptNumber checks that the receipt number comes from a range the device reserved
+// for the franchise of the sale
+func (s *Service) checkOfflineReceiptNumber(companyID uint, franchiseID *uint, deviceID, receiptNumber string) error {
+	n, ok := pos.ParseOfflineReceiptNumber(companyID, receiptNumber)
+	if !ok {
+		return errors.NewValidationError(fmt.Sprintf("%s is not an offline receipt number of this company", receiptNumber))
+	}
+
+	var receiptRange pos.OfflineReceiptRange
+	err := s.db.Where("company_id = ? AND device_id = ? AND start_number <= ? AND end_number >= ?", companyID, deviceID, n, n).
+		First(&receiptRange).Error
+	if err != nil {
+		return errors.NewValidationError(fmt.Sprintf("receipt number %s was not reserved by device %s", receiptNumber, deviceID))
+	}
+
+	sameFranchise := (receiptRange.FranchiseID == nil && franchiseID == nil) ||
+		(receiptRange.FranchiseID != nil && franchiseID != nil && *receiptRange.FranchiseID == *franchiseID)
+	if !sameFranchise {
+		return errors.NewValidationError(fmt.Sprintf("receipt number %s was reserved for another franchise", receiptNumber))
+	}
+
+	return nil
+}
+
+func (s *Service) GetSaleByID(userID, companyID, saleID uint) (*SaleResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	sale, err := s.saleRepo.FindByID(saleID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("sale not found")
+	}
+
+	if sale.CompanyID != companyID {
+		return nil, errors.NewForbiddenError("access denied to this sale")
+	}
+
+	// Fetch product and variant details for each item
+	itemDetails := make(map[uint]struct {
+		productName string
+		variantName string
+		variantSKU  string
+	})
+
+	for _, item := range sale.Items {
+		if _, exists := itemDetails[item.ProductVariantID]; exists {
+			continue // Already fetched
+		}
+
+		variant, err := s.productVariantRepo.FindProductVariantByID(item.ProductVariantID)
+		if err == nil && variant != nil {
+			var productName string
+			product, err := s.productVariantRepo.FindProductByID(variant.ProductID)
+			if err == nil && product != nil {
+				productName = product.Name
+			}
+
+			itemDetails[item.ProductVariantID] = struct {
+				productName string
+				variantName string
+				variantSKU  string
+			}{
+				productName: productName,
+				variantName: variant.Name,
+				variantSKU:  variant.SKU,
+			}
+		}
+	}
+
+	return ToSaleResponseWithDetails(sale, itemDetails), nil
+}
+
+func (s *Service) ListSales(userID, companyID uint, franchiseID *uint, page, limit int) (*PaginatedResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	var sales []*pos.Sale
+	var total int64
+	var err error
+
+	if franchiseID != nil {
+		// Verify franchise access
+		if err := s.checkUserFranchiseAccess(userID, *franchiseID, user.RoleEmployee); err != nil {
+			return nil, err
+		}
+		sales, total, err = s.saleRepo.FindByFranchiseID(*franchiseID, page, limit)
+	} else {
+		sales, total, err = s.saleRepo.FindByCompanyID(companyID, page, limit)
+	}
+
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch sales", err)
+	}
+
+	saleResponses := make([]*SaleResponse, len(sales))
+	for i, sale := range sales {
+		saleResponses[i] = ToSaleResponse(sale)
+	}
+
+	return NewPaginatedResponse(saleResponses, total, page, limit), nil
+}
+
+// Payment operations
+
+func (s *Service) AddPaymentToSale(userID, companyID, saleID uint, req *AddPaymentRequest) (*PaymentResponse, error) {
+	sale, err := s.getPayableSale(userID, companyID, saleID)
+	if err != nil {
+		return nil, err
+	}
+
+	tender := TenderRequest{
+		PaymentMethod: req.PaymentMethod,
+		Amount:        req.Amount,
+		Reference:     req.Reference,
+		Notes:         req.Notes,
+	}
+
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	sale, err = s.lockPayableSale(tx, sale.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	payments, err := s.applyTenders(tx, sale, []TenderRequest{tender}, userID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	return ToPaymentResponse(&payments[0]), nil
+}
+
+// CheckoutSale pays the amount due on a sale with one or more tenders at once.
+// Cash may exceed what is due; the change is recorded and only the net cash goes to the drawer.
+// With ChargeToAccount, what the tenders leave due goes on the customer's account.
+func (s *Service) CheckoutSale(userID, companyID, saleID uint, req *CheckoutRequest) (*CheckoutResponse, error) {
+	if len(req.Tenders) == 0 && !req.ChargeToAccount {
+		return nil, errors.NewValidationError("at least one tender is required")
+	}
+
+	sale, err := s.getPayableSale(userID, companyID, saleID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	sale, err = s.lockPayableSale(tx, sale.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	payments := []pos.Payment{}
+	if len(req.Tenders) > 0 {
+		payments, err = s.applyTenders(tx, sale, req.Tenders, userID)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if sale.PaymentStatus != pos.PaymentStatusPaid {
+		if !req.ChargeToAccount {
+			tx.Rollback()
+			return nil, errors.NewValidationError("tenders do not cover the amount due")
+		}
+		if err := s.chargeToAccount(tx, sale); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	result := &CheckoutResponse{
+		Payments: make([]PaymentResponse, len(payments)),
+	}
+	for i := range payments {
+		result.Payments[i] = *ToPaymentResponse(&payments[i])
+		result.TenderedAmount += payments[i].TenderedAmount
+		result.ChangeDue += payments[i].ChangeAmount
+	}
+	result.TenderedAmount = roundCurrency(result.TenderedAmount)
+	result.ChangeDue = roundCurrency(result.ChangeDue)
+
+	paidSale, err := s.saleRepo.FindByID(sale.ID)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch sale", err)
+	}
+	result.Sale = ToSaleResponse(paidSale)
+
+	return result, nil
+}
+
+// getPayableSale loads a completed sale that can still take payments
+func (s *Service) getPayableSale(userID, companyID, saleID uint) (*pos.Sale, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	// Get sale
+	sale, err := s.saleRepo.FindByID(saleID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("sale not found")
+	}
+
+	if sale.CompanyID != companyID {
+		return nil, errors.NewForbiddenError("access denied to this sale")
+	}
+
+	if err := checkSalePayable(sale); err != nil {
+		return nil, err
+	}
+
+	return sale, nil
+}
+
+// lockPayableSale locks the sale with lockSale and checks again that it can take payments,
+// so that concurrent payments of the same sale run one after the other
+func (s *Service) lockPayableSale(tx *gorm.DB, saleID uint) (*pos.Sale, error) {
+	sale, err := s.lockSale(tx, saleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSalePayable(sale); err != nil {
+		return nil, err
+	}
+	return sale, nil
+}
+
+// checkSalePayable checks that the sale is completed and still has an amount due to pay
+func checkSalePayable(sale *pos.Sale) error {
+	if sale.SaleStatus != pos.SaleStatusCompleted {
+		return errors.NewValidationError("can only add payments to completed sales")
+	}
+
+	if sale.PaymentStatus == pos.PaymentStatusPaid {
+		return errors.NewValidationError("sale is already paid")
+	}
+
+	if sale.IsOnAccount() {
+		return errors.NewValidationError("sale is on the customer's account; record a customer payment instead")
+	}
+
+	return nil
+}
+
+// applyTenders records payments for the tenders against the amount still due on the sale,
+// which the caller must have created or locked in tx. Non-cash tenders are applied first and may not exceed what is due; cash covers the
+// rest and anything above it is change. Only the net cash is posted to the drawer.
+// Loyalty tenders are converted to points and taken from the customer's balance; voucher
+// tenders name the voucher code in their reference and are taken from its balance.
+func (s *Service) applyTenders(tx *gorm.DB, sale *pos.Sale, tenders []TenderRequest, userID uint) ([]pos.Payment, error) {
+	totalPaid, err := salePaidTotal(tx, sale.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := roundCurrency(sale.TotalAmount - totalPaid)
+	payments := make([]pos.Payment, 0, len(tenders))
+
+	// Non-cash tenders first, so that cash absorbs the change
+	for _, tender := range tenders {
+		if !tender.PaymentMethod.IsTender() {
+			return nil, errors.NewValidationError("invalid payment method")
+		}
+		if tender.PaymentMethod.GivesChange() {
+			continue
+		}
+		if tender.Amount > remaining+0.005 {
+			return nil, errors.NewValidationError(fmt.Sprintf("%s payment of %.2f exceeds the amount due of %.2f", tender.PaymentMethod, tender.Amount, remaining))
+		}
+
+		reference := tender.Reference
+		switch tender.PaymentMethod {
+		case pos.PaymentMethodLoyalty:
+			points, err := s.redeemLoyaltyPoints(tx, sale, tender.Amount, userID)
+			if err != nil {
+				return nil, err
+			}
+			reference = fmt.Sprintf("%d points", points)
+		case pos.PaymentMethodVoucher:
+			code, err := s.redeemVoucher(tx, sale, tender.Reference, tender.Amount, userID)
+			if err != nil {
+				return nil, err
+			}
+			reference = code
+		}
+
+		payments = append(payments, pos.Payment{
+			SaleID:         sale.ID,
+			PaymentMethod:  tender.PaymentMethod,
+			Amount:         tender.Amount,
+			TenderedAmount: tender.Amount,
+			PaymentStatus:  pos.PaymentTransactionStatusCompleted,
+			Reference:      reference,
+			Notes:          tender.Notes,
+		})
+		remaining = roundCurrency(remaining - tender.Amount)
+	}
+
+	cashIn := 0.0
+	for _, tender := range tenders {
+		if !tender.PaymentMethod.GivesChange() {
+			continue
+		}
+		if remaining <= 0 {
+			return nil, errors.NewValidationError("the amount due is already covered")
+		}
+
+		applied := math.Min(tender.Amount, remaining)
+		payments = append(payments, pos.Payment{
+			SaleID:         sale.ID,
+			PaymentMethod:  tender.PaymentMethod,
+			Amount:         applied,
+			TenderedAmount: tender.Amount,
+			ChangeAmount:   roundCurrency(tender.Amount - applied),
+			PaymentStatus:  pos.PaymentTransactionStatusCompleted,
+			Reference:      tender.Reference,
+			Notes:          tender.Notes,
+		})
+		remaining = roundCurrency(remaining - applied)
+		cashIn += applied
+	}
+
+	for i := range payments {
+		if !payments[i].IsValid() {
+			return nil, errors.NewValidationError("invalid payment data")
+		}
+		totalPaid += payments[i].Amount
+	}
+
+	if err := tx.Create(&payments).Error; err != nil {
+		return nil, errors.NewInternalError("failed to create payment", err)
+	}
+
+	// Update sale payment status
+	wasPaid := sale.PaymentStatus == pos.PaymentStatusPaid
+	sale.UpdatePaymentStatus(roundCurrency(totalPaid))
+	if err := tx.Omit("Items", "Payments", "Customer").Save(sale).Error; err != nil {
+		return nil, errors.NewInternalError("failed to update sale", err)
+	}
+
+	// Add the net cash to the active cash drawer
+	if cashIn > 0 {
+		if err := s.recordCashDrawerTransaction(tx, sale.CompanyID, sale.FranchiseID, pos.CashDrawerTransactionTypeSale, roundCurrency(cashIn), &sale.ID, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	// Deposits on a layaway only count as a purchase once the items are picked up
+	if !wasPaid && sale.PaymentStatus == pos.PaymentStatusPaid && sale.SaleStatus == pos.SaleStatusCompleted {
+		if err := s.recordCustomerPurchase(tx, sale, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return payments, nil
+}
+
+// salePaidTotal sums the completed payments of the sale, as seen by tx
+func salePaidTotal(tx *gorm.DB, saleID uint) (float64, error) {
+	var total float64
+	if err := tx.Model(&pos.Payment{}).
+		Where("sale_id = ? AND payment_status = ?", saleID, pos.PaymentTransactionStatusCompleted).
+		Select("COALESCE(SUM(amount), 0)").
+		Row().Scan(&total); err != nil {
+		return 0, errors.NewInternalError("failed to calculate total paid", err)
+	}
+	return total, nil
+}
+
+// recordCustomerPurchase adds a paid sale to its customer's total purchases and loyalty points
+func (s *Service) recordCustomerPurchase(tx *gorm.DB, sale *pos.Sale, userID uint) error {
+	if sale.CustomerID == nil {
+		return nil
+	}
+
+	customer, err := s.customerRepo.FindByID(*sale.CustomerID)
+	if err == nil {
+		customer.AddPurchase(sale.TotalAmount)
+		if err := tx.Save(customer).Error; err != nil {
+			return errors.NewInternalError("failed to update customer", err)
+		}
+	}
+
+	return s.earnLoyaltyPoints(tx, sale, userID)
+}
+
+// checkSaleVoidable checks that a sale is completed, has no refunds or exchanges and, when sold
+// on account, has not been partly repaid
+func (s *Service) checkSaleVoidable(sale *pos.Sale) error {
+	if !sale.CanBeVoided() {
+		return errors.NewValidationError("only completed sales without refunds or exchanges can be voided")
+	}
+
+	refunds, err := s.refundRepo.FindBySaleID(sale.ID)
+	if err != nil {
+		return errors.NewInternalError("failed to fetch refunds", err)
+	}
+	if len(refunds) > 0 {
+		return errors.NewValidationError("only completed sales without refunds or exchanges can be voided")
+	}
+
+	if sale.IsOnAccount() {
+		repayments, err := s.customerAccountRepo.CountDistributionsBySaleID(sale.ID)
+		if err != nil {
+			return errors.NewInternalError("failed to fetch customer payments", err)
+		}
+		if repayments > 0 {
+			return errors.NewValidationError("sale has been partly repaid on account and can no longer be voided")
+		}
+	}
+
+	return nil
+}
+
+// VoidSale cancels a mistyped sale during the drawer session it was rung up in,
+// putting its stock back and taking its cash payments out of the drawer
+func (s *Service) VoidSale(userID, companyID, saleID uint, req *VoidSaleRequest) (*SaleResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	// Get sale
+	sale, err := s.saleRepo.FindByID(saleID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("sale not found")
+	}
+
+	if sale.CompanyID != companyID {
+		return nil, errors.NewForbiddenError("access denied to this sale")
+	}
+
+	if !s.hasManagerRole(userID, companyID, sale.FranchiseID) {
+		return nil, errors.NewForbiddenError("only managers can void sales")
+	}
+
+	if err := s.checkSaleVoidable(sale); err != nil {
+		return nil, err
+	}
+
+	// Voids are only allowed within the drawer session the sale was made in
+	activeDrawer, err := s.findActiveCashDrawer(companyID, sale.FranchiseID)
+	if err != nil {
+		return nil, errors.NewValidationError("sales can only be voided while the cash drawer is open")
+	}
+	if sale.CreatedAt.Before(activeDrawer.OpenedAt) {
+		return nil, errors.NewValidationError("sale was made in a previous cash drawer session; process a refund instead")
+	}
+
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Lock the sale and check again, so that a refund or exchange cannot slip in before the void
+	sale, err = s.lockSale(tx, sale.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := s.checkSaleVoidable(sale); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Lock the drawer too, so that the void is not posted to a drawer being closed
+	activeDrawer, err = s.lockActiveCashDrawer(tx, companyID, sale.FranchiseID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if activeDrawer == nil {
+		tx.Rollback()
+		return nil, errors.NewValidationError("sales can only be voided while the cash drawer is open")
+	}
+	if sale.CreatedAt.Before(activeDrawer.OpenedAt) {
+		tx.Rollback()
+		return nil, errors.NewValidationError("sale was made in a previous cash drawer session; process a refund instead")
+	}
+
+	// Reverse the sale movements
+	saleIDStr := fmt.Sprintf("%d", sale.ID)
+	for _, item := range sale.Items {
+		inv, err := s.findInventoryTx(tx, companyID, sale.FranchiseID, item.ProductVariantID)
+		if err != nil {
+			tx.Rollback()
+			return nil, errors.NewInternalError("failed to fetch inventory", err)
+		}
+
+		previousStock := inv.Stock
+		inv.AddStock(item.Quantity)
+
+		if err := tx.Save(inv).Error; err != nil {
+			tx.Rollback()
+			return nil, errors.NewInternalError("failed to update inventory", err)
+		}
+
+		movement := &inventory.InventoryMovement{
+			InventoryID:   inv.ID,
+			MovementType:  inventory.MovementTypeSale,
+			Quantity:      item.Quantity,
+			PreviousStock: previousStock,
+			NewStock:      inv.Stock,
+			ReferenceType: stringPtr("sale_void"),
+			ReferenceID:   &saleIDStr,
+			Notes:         stringPtr(req.Reason),
+			CreatedByID:   userID,
+		}
+
+		if err := tx.Create(movement).Error; err != nil {
+			tx.Rollback()
+			return nil, errors.NewInternalError("failed to create inventory movement", err)
+		}
+	}
+
+	// Take the cash back out of the drawer and void every payment
+	for i := range sale.Payments {
+		payment := &sale.Payments[i]
+		if payment.PaymentStatus != pos.PaymentTransactionStatusCompleted {
+			continue
+		}
+
+		if payment.PaymentMethod == pos.PaymentMethodCash {
+			drawerTx := &pos.CashDrawerTransaction{
+				CashDrawerID:    activeDrawer.ID,
+				TransactionType: pos.CashDrawerTransactionTypeVoid,
+				Amount:          -payment.Amount,
+				SaleID:          &sale.ID,
+				Notes:           fmt.Sprintf("Void of sale #%s", sale.ReceiptNumber),
+			}
+			if err := tx.Create(drawerTx).Error; err != nil {
+				tx.Rollback()
+				return nil, errors.NewInternalError("failed to record cash drawer transaction", err)
+			}
+		}
+
+		payment.PaymentStatus = pos.PaymentTransactionStatusVoided
+		if err := tx.Save(payment).Error; err != nil {
+			tx.Rollback()
+			return nil, errors.NewInternalError("failed to update payment", err)
+		}
+	}
+
+	// A fully paid sale was counted in the customer's purchases
+	if sale.CustomerID != nil && sale.PaymentStatus == pos.PaymentStatusPaid {
+		customer, err := s.customerRepo.FindByID(*sale.CustomerID)
+		if err == nil {
+			customer.RemovePurchase(sale.TotalAmount)
+			if err := tx.Save(customer).Error; err != nil {
+				tx.Rollback()
+				return nil, errors.NewInternalError("failed to update customer", err)
+			}
+		}
+	}
+
+	// Take back the points earned and give back the points paid with
+	if err := s.voidLoyaltyPoints(tx, sale, userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Give back what vouchers paid and cancel a gift card sold on the sale
+	if err := s.voidSaleVouchers(tx, sale, userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	sale.Void(userID, req.Reason)
+	if err := tx.Omit("Items", "Payments", "Customer").Save(sale).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to void sale", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	voidedSale, err := s.saleRepo.FindByID(sale.ID)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch voided sale", err)
+	}
+
+	return ToSaleResponse(voidedSale), nil
+}
+
+// Refund operations
+
+func (s *Service) ProcessRefund(userID, companyID, saleID uint, req *ProcessRefundRequest) (*RefundResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleManager); err != nil {
+		return nil, err
+	}
+
+	// Get sale
+	sale, err := s.saleRepo.FindByID(saleID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("sale not found")
+	}
+
+	if sale.CompanyID != companyID {
+		return nil, errors.NewForbiddenError("access denied to this sale")
+	}
+
+	if !sale.CanBeRefunded() {
+		return nil, errors.NewValidationError("sale cannot be refunded")
+	}
+
+	if sale.IsGiftCardSale() {
+		return nil, errors.NewValidationError("gift card sales cannot be refunded; void the sale instead")
+	}
+
+	if !req.RefundMethod.IsTender() {
+		return nil, errors.NewValidationError("invalid refund method")
+	}
+
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Lock the sale so that concurrent refunds, exchanges and voids see each other
+	sale, err = s.lockSale(tx, saleID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if !sale.CanBeRefunded() {
+		tx.Rollback()
+		return nil, errors.NewValidationError("sale cannot be refunded")
+	}
+
+	// Work out what is still refundable on each line
+	selection, err := s.selectRefundItems(sale, req.Items)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	refundAmount := req.RefundAmount
+	if refundAmount <= 0 {
+		refundAmount = math.Min(selection.Amount, selection.Refundable)
+	}
+
+	if refundAmount > selection.Amount {
+		tx.Rollback()
+		return nil, errors.NewValidationError(fmt.Sprintf("refund amount cannot exceed %.2f, the value of the items returned", selection.Amount))
+	}
+	if refundAmount > selection.Refundable {
+		tx.Rollback()
+		return nil, errors.NewValidationError("refund amount cannot exceed the amount still refundable on this sale")
+	}
+
+	// Create refund
+	refund := &pos.Refund{
+		OriginalSaleID: saleID,
+		RefundAmount:   refundAmount,
+		Reason:         req.Reason,
+		RefundMethod:   req.RefundMethod,
+		RefundStatus:   pos.RefundStatusCompleted,
+		ProcessedByID:  userID,
+	}
+
+	if !refund.IsValid() {
+		tx.Rollback()
+		return nil, errors.NewValidationError("invalid refund data")
+	}
+
+	if err := tx.Create(refund).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to create refund", err)
+	}
+
+	if err := s.persistRefundItems(tx, refund.ID, selection.Items); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Restock only the returned quantities
+	if err := s.restockRefundItems(tx, companyID, sale.FranchiseID, selection.Items, "refund", fmt.Sprintf("%d", refund.ID), userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Update sale status
+	sale.ApplyRefund(selection.FullyRefunded)
+	if err := tx.Omit("Items", "Payments", "Customer").Save(sale).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to update sale", err)
+	}
+
+	// Take back the points earned on the refunded share of the sale
+	if err := s.reverseLoyaltyPoints(tx, sale, refund.ID, refundAmount, selection.FullyRefunded, userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Refunds paid out in points go back to the customer's balance
+	if req.RefundMethod == pos.PaymentMethodLoyalty {
+		if err := s.restoreLoyaltyPoints(tx, sale, refund.ID, refundAmount, userID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	// Refunds by voucher are given as store credit
+	if req.RefundMethod == pos.PaymentMethodVoucher {
+		if err := s.issueStoreCredit(tx, sale, refund, userID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	// If refund is cash, deduct from active cash drawer
+	if req.RefundMethod == pos.PaymentMethodCash {
+		notes := fmt.Sprintf("Refund for sale #%s", sale.ReceiptNumber)
+		if err := s.recordCashDrawerTransaction(tx, companyID, sale.FranchiseID, pos.CashDrawerTransactionTypeRefund, -refundAmount, nil, notes); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	// Reload refund
+	createdRefund, err := s.refundRepo.FindByID(refund.ID)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch created refund", err)
+	}
+
+	return ToRefundResponse(createdRefund), nil
+}
+
+func (s *Service) ListRefunds(userID, companyID uint, franchiseID *uint, page, limit int) (*PaginatedResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	var refunds []*pos.Refund
+	var total int64
+	var err error
+
+	if franchiseID != nil {
+		if err := s.checkUserFranchiseAccess(userID, *franchiseID, user.RoleEmployee); err != nil {
+			return nil, err
+		}
+		refunds, total, err = s.refundRepo.FindByFranchiseID(*franchiseID, page, limit)
+	} else {
+		refunds, total, err = s.refundRepo.FindByCompanyID(companyID, page, limit)
+	}
+
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch refunds", err)
+	}
+
+	refundResponses := make([]*RefundResponse, len(refunds))
+	for i, refund := range refunds {
+		refundResponses[i] = ToRefundResponse(refund)
+	}
+
+	return NewPaginatedResponse(refundResponses, total, page, limit), nil
+}
+
+// Exchange operations
+
+// ProcessExchange returns lines of a sale and sells replacement items in one transaction.
+// Only the price difference is paid by, or given back to, the customer.
+func (s *Service) ProcessExchange(userID, companyID, saleID uint, req *ProcessExchangeRequest) (*ExchangeResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	// Get sale
+	sale, err := s.saleRepo.FindByID(saleID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("sale not found")
+	}
+
+	if sale.CompanyID != companyID {
+		return nil, errors.NewForbiddenError("access denied to this sale")
+	}
+
+	if sale.FranchiseID != nil {
+		if err := s.checkUserFranchiseAccess(userID, *sale.FranchiseID, user.RoleEmployee); err != nil {
+			return nil, err
+		}
+	}
+
+	if !sale.CanBeRefunded() {
+		return nil, errors.NewValidationError("sale cannot be exchanged")
+	}
+
+	if sale.IsGiftCardSale() {
+		return nil, errors.NewValidationError("gift card sales cannot be exchanged")
+	}
+
+	settlementMethod := req.SettlementMethod
+	if settlementMethod == "" {
+		settlementMethod = pos.PaymentMethodCash
+	}
+	if !settlementMethod.IsTender() || settlementMethod == pos.PaymentMethodLoyalty || settlementMethod == pos.PaymentMethodVoucher {
+		return nil, errors.NewValidationError("invalid settlement method")
+	}
+
+	// Price the replacement items at current prices, wholesale ones for a wholesale sale
+	replacementRequests := make([]SaleItemRequest, len(req.ReplacementItems))
+	for i, item := range req.ReplacementItems {
+		replacementRequests[i] = SaleItemRequest{
+			ProductVariantID: item.ProductVariantID,
+			Quantity:         item.Quantity,
+		}
+	}
+
+	replacementItems, err := s.buildSaleItems(userID, companyID, sale.FranchiseID, sale.CustomerID, replacementRequests, nil, nil, "", sale.IsWholesale)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Lock the sale so that concurrent refunds, exchanges and voids see each other
+	sale, err = s.lockSale(tx, saleID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if !sale.CanBeRefunded() {
+		tx.Rollback()
+		return nil, errors.NewValidationError("sale cannot be exchanged")
+	}
+
+	// Value the returned lines
+	selection, err := s.selectRefundItems(sale, req.ReturnedItems)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Paying the customer back is a refund, which cashiers need a manager's approval for
+	var approvedByID *uint
+	replacementAmount := 0.0
+	for _, item := range replacementItems {
+		replacementAmount += item.TotalAmount
+	}
+	if roundCurrency(replacementAmount-selection.Amount) < 0 {
+		approverID, err := s.resolveApprover(userID, companyID, sale.FranchiseID, req.Approval, "paying back the balance of an exchange")
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		approvedByID = &approverID
+	}
+
+	exchange := &pos.Exchange{
+		CompanyID:        companyID,
+		FranchiseID:      sale.FranchiseID,
+		OriginalSaleID:   sale.ID,
+		ReturnedAmount:   selection.Amount,
+		SettlementMethod: settlementMethod,
+		Reason:           req.Reason,
+		ProcessedByID:    userID,
+		ApprovedByID:     approvedByID,
+	}
+
+	if err := tx.Create(exchange).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to create exchange", err)
+	}
+	exchangeIDStr := fmt.Sprintf("%d", exchange.ID)
+
+	// Return the selected lines
+	refund := &pos.Refund{
+		OriginalSaleID: sale.ID,
+		RefundAmount:   selection.Amount,
+		Reason:         req.Reason,
+		RefundMethod:   pos.PaymentMethodExchange,
+		RefundStatus:   pos.RefundStatusCompleted,
+		ExchangeID:     &exchange.ID,
+		ProcessedByID:  userID,
+	}
+
+	if !refund.IsValid() {
+		tx.Rollback()
+		return nil, errors.NewValidationError("invalid refund data")
+	}
+
+	if err := tx.Create(refund).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to create refund", err)
+	}
+
+	if err := s.persistRefundItems(tx, refund.ID, selection.Items); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := s.restockRefundItems(tx, companyID, sale.FranchiseID, selection.Items, "exchange", exchangeIDStr, userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := s.reverseLoyaltyPoints(tx, sale, refund.ID, selection.Amount, selection.FullyRefunded, userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Sell the replacement items to the same customer
+	replacementSale := &pos.Sale{
+		CompanyID:     companyID,
+		FranchiseID:   sale.FranchiseID,
+		CustomerID:    sale.CustomerID,
+		PaymentStatus: pos.PaymentStatusUnpaid,
+		SaleStatus:    pos.SaleStatusDraft,
+		Notes:         fmt.Sprintf("Exchange for sale #%s", sale.ReceiptNumber),
+		ExchangeID:    &exchange.ID,
+		IsWholesale:   sale.IsWholesale,
+		CreatedByID:   userID,
+	}
+
+	if err := s.persistSale(tx, replacementSale, replacementItems); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := s.deductSaleInventory(tx, companyID, sale.FranchiseID, replacementItems, "exchange", exchangeIDStr, userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := s.redeemPromotions(tx, replacementItems); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	exchange.RefundID = &refund.ID
+	exchange.ReplacementSaleID = &replacementSale.ID
+	exchange.ReplacementAmount = replacementSale.TotalAmount
+	exchange.CalculateBalance()
+
+	if !exchange.IsValid() {
+		tx.Rollback()
+		return nil, errors.NewValidationError("invalid exchange data")
+	}
+
+	// Pay the replacement sale with the returned value, then the balance
+	payments := make([]pos.Payment, 0, 2)
+	if credit := exchange.CreditAmount(); credit > 0 {
+		payments = append(payments, pos.Payment{
+			SaleID:        replacementSale.ID,
+			PaymentMethod: pos.PaymentMethodExchange,
+			Amount:        credit,
+			PaymentStatus: pos.PaymentTransactionStatusCompleted,
+			Reference:     fmt.Sprintf("Sale #%s", sale.ReceiptNumber),
+		})
+	}
+	if exchange.BalanceAmount > 0 {
+		payments = append(payments, pos.Payment{
+			SaleID:        replacementSale.ID,
+			PaymentMethod: settlementMethod,
+			Amount:        exchange.BalanceAmount,
+			PaymentStatus: pos.PaymentTransactionStatusCompleted,
+			Reference:     req.Reference,
+		})
+	}
+
+	totalPaid := 0.0
+	for i := range payments {
+		if !payments[i].IsValid() {
+			tx.Rollback()
+			return nil, errors.NewValidationError("invalid payment data")
+		}
+		totalPaid += payments[i].Amount
+	}
+
+	if len(payments) > 0 {
+		if err := tx.Create(&payments).Error; err != nil {
+			tx.Rollback()
+			return nil, errors.NewInternalError("failed to create payment", err)
+		}
+	}
+
+	replacementSale.Complete()
+	replacementSale.UpdatePaymentStatus(totalPaid)
+	if err := tx.Omit("Items", "Payments", "Customer").Save(replacementSale).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to complete sale", err)
+	}
+
+	if replacementSale.PaymentStatus == pos.PaymentStatusPaid {
+		if err := s.earnLoyaltyPoints(tx, replacementSale, userID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := tx.Omit("OriginalSale", "Refund", "ReplacementSale").Save(exchange).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to update exchange", err)
+	}
+
+	// Only the balance goes through the cash drawer
+	if settlementMethod == pos.PaymentMethodCash && exchange.BalanceAmount != 0 {
+		transactionType := pos.CashDrawerTransactionTypeSale
+		if exchange.BalanceAmount < 0 {
+			transactionType = pos.CashDrawerTransactionTypeRefund
+		}
+		notes := fmt.Sprintf("Exchange on sale #%s", sale.ReceiptNumber)
+		if err := s.recordCashDrawerTransaction(tx, companyID, sale.FranchiseID, transactionType, exchange.BalanceAmount, &replacementSale.ID, notes); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	// Reload exchange
+	createdExchange, err := s.exchangeRepo.FindByID(exchange.ID)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch created exchange", err)
+	}
+
+	return ToExchangeResponse(createdExchange), nil
+}
+
+func (s *Service) ListExchanges(userID, companyID uint, franchiseID *uint, page, limit int) (*PaginatedResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	var exchanges []*pos.Exchange
+	var total int64
+	var err error
+
+	if franchiseID != nil {
+		if err := s.checkUserFranchiseAccess(userID, *franchiseID, user.RoleEmployee); err != nil {
+			return nil, err
+		}
+		exchanges, total, err = s.exchangeRepo.FindByFranchiseID(*franchiseID, page, limit)
+	} else {
+		exchanges, total, err = s.exchangeRepo.FindByCompanyID(companyID, page, limit)
+	}
+
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch exchanges", err)
+	}
+
+	exchangeResponses := make([]*ExchangeResponse, len(exchanges))
+	for i, exchange := range exchanges {
+		exchangeResponses[i] = ToExchangeResponse(exchange)
+	}
+
+	return NewPaginatedResponse(exchangeResponses, total, page, limit), nil
+}
+
+// Cash Drawer operations
+
+func (s *Service) OpenCashDrawer(userID, companyID uint, req *OpenCashDrawerRequest) (*CashDrawerResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	// If franchise is specified, verify access
+	if req.FranchiseID != nil {
+		if err := s.checkUserFranchiseAccess(userID, *req.FranchiseID, user.RoleEmployee); err != nil {
+			return nil, err
+		}
+	}
+
+	// Check if there's already an active drawer
+	var activeDrawer *pos.CashDrawer
+	var err error
+
+	if req.FranchiseID != nil {
+		activeDrawer, err = s.cashDrawerRepo.FindActiveByFranchiseID(*req.FranchiseID)
+	} else {
+		activeDrawer, err = s.cashDrawerRepo.FindActiveByCompanyID(companyID)
+	}
+
+	if err == nil && activeDrawer != nil {
+		return nil, errors.NewConflictError("a cash drawer is already open")
+	}
+
+	// Create new cash drawer
+	var companyIDPtr *uint
+	if req.FranchiseID == nil {
+		companyIDPtr = &companyID
+	}
+
+	drawer := &pos.CashDrawer{
+		CompanyID:      companyIDPtr,
+		FranchiseID:    req.FranchiseID,
+		OpeningBalance: req.OpeningBalance,
+		Status:         pos.CashDrawerStatusOpen,
+		OpenedByID:     userID,
+		OpenedAt:       time.Now(),
+		Notes:          req.Notes,
+	}
+
+	if !drawer.IsValid() {
+		return nil, errors.NewValidationError("invalid cash drawer data")
+	}
+
+	if err := s.cashDrawerRepo.Create(drawer); err != nil {
+		return nil, errors.NewInternalError("failed to open cash drawer", err)
+	}
+
+	return ToCashDrawerResponse(drawer), nil
+}
+
+func (s *Service) CloseCashDrawer(userID, companyID uint, drawerID uint, req *CloseCashDrawerRequest) (*CashDrawerResponse, error) {
+	drawer, err := s.getCashDrawer(userID, companyID, drawerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !drawer.IsOpen() {
+		return nil, errors.NewValidationError("cash drawer is already closed")
+	}
+
+	// Work out the closing balance from the denomination counts when given
+	counts := make([]pos.CashDrawerCount, 0, len(req.Counts))
+	seen := make(map[float64]bool)
+	for _, count := range req.Counts {
+		denomination := roundCurrency(count.Denomination)
+		if seen[denomination] {
+			return nil, errors.NewValidationError(fmt.Sprintf("denomination %.2f is counted twice", denomination))
+		}
+		seen[denomination] = true
+		counts = append(counts, pos.CashDrawerCount{
+			CashDrawerID: drawer.ID,
+			Denomination: denomination,
+			Quantity:     count.Quantity,
+		})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].Denomination > counts[j].Denomination
+	})
+
+	var closingBalance float64
+	switch {
+	case len(counts) > 0:
+		closingBalance = pos.CountedTotal(counts)
+		if req.ClosingBalance != nil && math.Abs(*req.ClosingBalance-closingBalance) >= 0.005 {
+			return nil, errors.NewValidationError(fmt.Sprintf("closing balance does not match the counted total of %.2f", closingBalance))
+		}
+	case req.ClosingBalance != nil:
+		closingBalance = roundCurrency(*req.ClosingBalance)
+	default:
+		return nil, errors.NewValidationError("either a closing balance or denomination counts are required")
+	}
+
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Lock the drawer so that it is closed once, and after any cash movement already under way
+	locked, err := lockCashDrawer(tx, drawer.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if !locked.IsOpen() {
+		tx.Rollback()
+		return nil, errors.NewValidationError("cash drawer is already closed")
+	}
+
+	// Calculate expected balance
+	totalTransactions, err := cashDrawerTotal(tx, drawer.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	expectedBalance := locked.OpeningBalance + totalTransactions
+
+	// Close drawer
+	drawer.Close(closingBalance, userID, expectedBalance)
+	drawer.Notes = req.Notes
+
+	if err := tx.Omit("Transactions", "Counts").Save(drawer).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to close cash drawer", err)
+	}
+
+	if len(counts) > 0 {
+		if err := tx.Create(&counts).Error; err != nil {
+			tx.Rollback()
+			return nil, errors.NewInternalError("failed to record cash counts", err)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
+	}
+	drawer.Counts = counts
+
+	// The Z report is final once the drawer is closed
+	report, err := s.buildShiftReport(drawer)
+	if err != nil {
+		return nil, err
+	}
+
+	result := ToCashDrawerResponse(drawer)
+	result.ZReport = report
+	return result, nil
+}
+
+// RecordCashMovement records a pay-in, pay-out or safe drop on the open cash drawer so that the
+// expected balance at closing accounts for it. Movements above the company's cash approval
+// limit must be approved by a manager.
+func (s *Service) RecordCashMovement(userID, companyID uint, req *CashMovementRequest) (*CashDrawerTransactionResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	// If franchise is specified, verify access
+	if req.FranchiseID != nil {
+		if err := s.checkUserFranchiseAccess(userID, *req.FranchiseID, user.RoleEmployee); err != nil {
+			return nil, err
+		}
+	}
+
+	movementType := pos.CashDrawerTransactionType(req.Type)
+	if !movementType.IsCashMovement() {
+		return nil, errors.NewValidationError("invalid cash movement type")
+	}
+
+	reason := pos.CashMovementReason(req.ReasonCode)
+	if !reason.IsValid() {
+		return nil, errors.NewValidationError("invalid reason code")
+	}
+
+	drawer, err := s.findActiveCashDrawer(companyID, req.FranchiseID)
+	if err != nil {
+		return nil, errors.NewValidationError("no cash drawer is open")
+	}
+
+	amount := roundCurrency(req.Amount)
+	if movementType.TakesCashOut() {
+		amount = -amount
+	}
+
+	var company company.Company
+	if err := s.db.First(&company, companyID).Error; err != nil {
+		return nil, errors.NewNotFoundError("company not found")
+	}
+
+	var approvedByID *uint
+	if company.CashApprovalLimit > 0 && req.Amount > company.CashApprovalLimit {
+		action := fmt.Sprintf("a cash movement above %.2f", company.CashApprovalLimit)
+		approverID, err := s.resolveApprover(userID, companyID, req.FranchiseID, req.Approval, action)
+		if err != nil {
+			return nil, err
+		}
+		approvedByID = &approverID
+	}
+
+	movement := &pos.CashDrawerTransaction{
+		CashDrawerID:    drawer.ID,
+		TransactionType: movementType,
+		Amount:          amount,
+		ReasonCode:      reason,
+		Notes:           req.Notes,
+		CreatedByID:     &userID,
+		ApprovedByID:    approvedByID,
+	}
+
+	if !movement.IsValid() {
+		return nil, errors.NewValidationError("invalid cash movement data")
+	}
+
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Lock the drawer so that concurrent movements cannot take out the same cash, nor the drawer close meanwhile
+	locked, err := lockCashDrawer(tx, drawer.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if !locked.IsOpen() {
+		tx.Rollback()
+		return nil, errors.NewValidationError("no cash drawer is open")
+	}
+
+	if movementType.TakesCashOut() {
+		total, err := cashDrawerTotal(tx, drawer.ID)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if cash := roundCurrency(locked.OpeningBalance + total); -amount > cash {
+			tx.Rollback()
+			return nil, errors.NewValidationError(fmt.Sprintf("the drawer only holds %.2f in cash", cash))
+		}
+	}
+
+	if err := tx.Create(movement).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to record cash movement", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	return ToCashDrawerTransactionResponse(movement), nil
+}
+
+func (s *Service) GetActiveCashDrawer(userID, companyID uint, franchiseID *uint) (*CashDrawerResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	var drawer *pos.CashDrawer
+	var err error
+
+	if franchiseID != nil {
+		if err := s.checkUserFranchiseAccess(userID, *franchiseID, user.RoleEmployee); err != nil {
+			return nil, err
+		}
+		drawer, err = s.cashDrawerRepo.FindActiveByFranchiseID(*franchiseID)
+	} else {
+		drawer, err = s.cashDrawerRepo.FindActiveByCompanyID(companyID)
+	}
+
+	if err != nil {
+		return nil, errors.NewNotFoundError("no active cash drawer found")
+	}
+
+	return ToCashDrawerResponse(drawer), nil
+}
+
+func (s *Service) ListCashDrawers(userID, companyID uint, franchiseID *uint, page, limit int) (*PaginatedResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	var drawers []*pos.CashDrawer
+	var total int64
+	var err error
+
+	if franchiseID != nil {
+		if err := s.checkUserFranchiseAccess(userID, *franchiseID, user.RoleEmployee); err != nil {
+			return nil, err
+		}
+		drawers, total, err = s.cashDrawerRepo.FindByFranchiseID(*franchiseID, page, limit)
+	} else {
+		drawers, total, err = s.cashDrawerRepo.FindByCompanyID(companyID, page, limit)
+	}
+
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch cash drawers", err)
+	}
+
+	drawerResponses := make([]*CashDrawerResponse, len(drawers))
+	for i, drawer := range drawers {
+		drawerResponses[i] = ToCashDrawerResponse(drawer)
+	}
+
+	return NewPaginatedResponse(drawerResponses, total, page, limit), nil
+}
+
+// GetShiftReport returns the X report of an open drawer, a mid-shift snapshot,
+// or the Z report of a closed one
+func (s *Service) GetShiftReport(userID, companyID, drawerID uint, reportType ShiftReportType) (*ShiftReportResponse, error) {
+	drawer, err := s.getCashDrawer(userID, companyID, drawerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if reportType == ShiftReportTypeX && !drawer.IsOpen() {
+		return nil, errors.NewValidationError("cash drawer is closed; use its Z report")
+	}
+	if reportType == ShiftReportTypeZ && drawer.IsOpen() {
+		return nil, errors.NewValidationError("the Z report is generated when the cash drawer is closed")
+	}
+
+	return s.buildShiftReport(drawer)
+}
+
+// GenerateShiftReportPDF prints the X or Z report of a drawer at thermal receipt width
+func (s *Service) GenerateShiftReportPDF(userID, companyID, drawerID uint, reportType ShiftReportType) ([]byte, string, error) {
+	report, err := s.GetShiftReport(userID, companyID, drawerID, reportType)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Fetch company info
+	var company company.Company
+	if err := s.db.First(&company, companyID).Error; err != nil {
+		return nil, "", errors.NewNotFoundError("company not found")
+	}
+
+	// Fetch franchise info if applicable
+	var franchiseName string
+	if report.FranchiseID != nil {
+		var franchise franchise.Franchise
+		if err := s.db.First(&franchise, *report.FranchiseID).Error; err == nil {
+			franchiseName = franchise.Name
+		}
+	}
+
+	data := &receipt.ShiftReportData{
+		Title:          fmt.Sprintf("%s REPORT", report.ReportType),
+		CompanyName:    company.Name,
+		FranchiseName:  franchiseName,
+		CashDrawerID:   report.CashDrawerID,
+		OpenedAt:       report.OpenedAt,
+		ClosedAt:       report.ClosedAt,
+		GeneratedAt:    report.GeneratedAt,
+		SaleCount:      report.SaleCount,
+		SalesTotal:     report.SalesTotal,
+		TaxTotal:       report.TaxTotal,
+		DiscountTotal:  report.DiscountTotal,
+		VoidCount:      report.VoidCount,
+		VoidTotal:      report.VoidTotal,
+		RefundCount:    report.RefundCount,
+		RefundTotal:    report.RefundTotal,
+		CountedBalance: report.Cash.CountedBalance,
+		Variance:       report.Cash.Variance,
+		Cash: []receipt.ShiftReportLine{
+			{Label: "Opening", Amount: report.Cash.OpeningBalance},
+			{Label: "Cash sales", Amount: report.Cash.Sales},
+			{Label: "Cash refunds", Amount: report.Cash.Refunds},
+			{Label: "Voids", Amount: report.Cash.Voids},
+			{Label: "Pay-ins", Amount: report.Cash.PayIns},
+			{Label: "Pay-outs", Amount: report.Cash.PayOuts},
+			{Label: "Safe drops", Amount: report.Cash.SafeDrops},
+			{Label: "Adjustments", Amount: report.Cash.Adjustments},
+			{Label: "Expected", Amount: report.Cash.ExpectedBalance},
+		},
+	}
+	for _, line := range report.PaymentsByMethod {
+		data.Payments = append(data.Payments, receipt.ShiftReportLine{Label: string(line.Method), Count: line.Count, Amount: line.Amount})
+	}
+	for _, line := range report.RefundsByMethod {
+		data.Refunds = append(data.Refunds, receipt.ShiftReportLine{Label: string(line.Method), Count: line.Count, Amount: line.Amount})
+	}
+	for _, count := range report.Counts {
+		data.Counts = append(data.Counts, receipt.ShiftReportCount{Denomination: count.Denomination, Quantity: count.Quantity, Total: count.Total})
+	}
+
+	generator := receipt.NewReceiptGenerator()
+	pdfBytes, err := generator.GenerateShiftReport(data)
+	if err != nil {
+		return nil, "", errors.NewInternalError("failed to generate shift report PDF", err)
+	}
+
+	filename := fmt.Sprintf("%s_report_drawer_%d.pdf", strings.ToLower(string(report.ReportType)), report.CashDrawerID)
+
+	return pdfBytes, filename, nil
+}
+
+// buildShiftReport totals the drawer session up to now, or up to its closing: an X report
+// while the drawer is open and the Z report once it is closed
+func (s *Service) buildShiftReport(drawer *pos.CashDrawer) (*ShiftReportResponse, error) {
+	now := time.Now()
+	until := now
+	reportType := ShiftReportTypeX
+	if !drawer.IsOpen() && drawer.ClosedAt != nil {
+		until = *drawer.ClosedAt
+		reportType = ShiftReportTypeZ
+	}
+
+	data, err := s.cashDrawerRepo.GetShiftReport(drawer, until)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to generate shift report", err)
+	}
+
+	report := &ShiftReportResponse{
+		ReportType:       reportType,
+		CashDrawerID:     drawer.ID,
+		CompanyID:        drawer.CompanyID,
+		FranchiseID:      drawer.FranchiseID,
+		OpenedByID:       drawer.OpenedByID,
+		ClosedByID:       drawer.ClosedByID,
+		OpenedAt:         drawer.OpenedAt,
+		ClosedAt:         drawer.ClosedAt,
+		GeneratedAt:      now,
+		SaleCount:        data.SaleCount,
+		SalesTotal:       roundCurrency(data.SalesTotal),
+		TaxTotal:         roundCurrency(data.TaxTotal),
+		DiscountTotal:    roundCurrency(data.DiscountTotal),
+		VoidCount:        data.VoidCount,
+		VoidTotal:        roundCurrency(data.VoidTotal),
+		RefundCount:      data.RefundCount,
+		RefundTotal:      roundCurrency(data.RefundTotal),
+		RefundsByMethod:  ToMethodTotalResponses(data.RefundsByMethod),
+		PaymentsByMethod: ToMethodTotalResponses(data.PaymentsByMethod),
+		Counts:           ToDenominationCountResponses(drawer.Counts),
+	}
+
+	cash := &report.Cash
+	cash.OpeningBalance = drawer.OpeningBalance
+	total := 0.0
+	for _, line := range data.CashByType {
+		switch line.TransactionType {
+		case pos.CashDrawerTransactionTypeSale:
+			cash.Sales += line.Amount
+		case pos.CashDrawerTransactionTypeRefund:
+			cash.Refunds += line.Amount
+		case pos.CashDrawerTransactionTypeVoid:
+			cash.Voids += line.Amount
+		case pos.CashDrawerTransactionTypePayIn:
+			cash.PayIns += line.Amount
+		case pos.CashDrawerTransactionTypePayOut:
+			cash.PayOuts += line.Amount
+		case pos.CashDrawerTransactionTypeSafeDrop:
+			cash.SafeDrops += line.Amount
+		default:
+			cash.Adjustments += line.Amount
+		}
+		total += line.Amount
+	}
+	cash.ExpectedBalance = roundCurrency(drawer.OpeningBalance + total)
+
+	// A closed drawer keeps the balances worked out when it was closed
+	if reportType == ShiftReportTypeZ {
+		if drawer.ExpectedBalance != nil {
+			cash.ExpectedBalance = *drawer.ExpectedBalance
+		}
+		cash.CountedBalance = drawer.ClosingBalance
+		cash.Variance = drawer.Difference
+	}
+
+	return report, nil
+}
+
+// getCashDrawer loads a drawer of the company or of one of its franchises the user works in
+func (s *Service) getCashDrawer(userID, companyID, drawerID uint) (*pos.CashDrawer, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	drawer, err := s.cashDrawerRepo.FindByID(drawerID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("cash drawer not found")
+	}
+
+	// Verify access
+	if drawer.CompanyID != nil && *drawer.CompanyID != companyID {
+		return nil, errors.NewForbiddenError("access denied to this cash drawer")
+	}
+
+	if drawer.FranchiseID != nil {
+		f, err := s.franchiseRepo.FindByID(*drawer.FranchiseID)
+		if err != nil || !f.BelongsToCompany(companyID) {
+			return nil, errors.NewForbiddenError("access denied to this cash drawer")
+		}
+		if err := s.checkUserFranchiseAccess(userID, *drawer.FranchiseID, user.RoleEmployee); err != nil {
+			return nil, err
+		}
+	}
+
+	return drawer, nil
+}
+
+// Reports
+
+func (s *Service) GetSalesReport(userID, companyID uint, req *SalesReportRequest) (*SalesReportResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	if req.FranchiseID != nil {
+		if err := s.checkUserFranchiseAccess(userID, *req.FranchiseID, user.RoleEmployee); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := s.saleRepo.GetSalesReport(companyID, req.FranchiseID, req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to generate sales report", err)
+	}
+
+	return ToSalesReportResponse(data), nil
+}
+
+// GetSalesAnalytics breaks the sales of a period down by product, category, cashier, hour,
+// weekday, franchise and payment method, optionally compared with an earlier period
+func (s *Service) GetSalesAnalytics(userID, companyID uint, req *SalesAnalyticsRequest) (*SalesAnalyticsResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	if req.FranchiseID != nil {
+		if err := s.checkUserFranchiseAccess(userID, *req.FranchiseID, user.RoleEmployee); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.EndDate.Before(req.StartDate) {
+		return nil, errors.NewValidationError("end date must be after start date")
+	}
+
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, errors.NewValidationError(fmt.Sprintf("unknown timezone %s", timezone))
+	}
+
+	topProducts := req.TopProducts
+	if topProducts == 0 {
+		topProducts = 20
+	}
+
+	filter := pos.SalesAnalyticsFilter{
+		CompanyID:   companyID,
+		FranchiseID: req.FranchiseID,
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+		Timezone:    timezone,
+		TopProducts: topProducts,
+	}
+
+	data, err := s.saleRepo.GetSalesAnalytics(filter)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to generate sales analytics", err)
+	}
+
+	for i := range data.ByCategory {
+		if data.ByCategory[i].Key == "" {
+			data.ByCategory[i].Label = "Uncategorized"
+		}
+	}
+	for i := range data.ByHour {
+		data.ByHour[i].Label = data.ByHour[i].Key + ":00"
+	}
+	for i := range data.ByWeekday {
+		if day, err := strconv.Atoi(data.ByWeekday[i].Key); err == nil {
+			data.ByWeekday[i].Label = time.Weekday(day % 7).String()
+		}
+	}
+	for i := range data.ByFranchise {
+		if data.ByFranchise[i].Key == "" {
+			data.ByFranchise[i].Label = "Company"
+		}
+	}
+
+	revenue := data.Summary.Revenue
+	result := &SalesAnalyticsResponse{
+		StartDate:       req.StartDate,
+		EndDate:         req.EndDate,
+		Timezone:        timezone,
+		Summary:         ToAnalyticsSummaryResponse(&data.Summary),
+		ByProduct:       ToAnalyticsLineResponses(data.ByProduct, revenue),
+		ByCategory:      ToAnalyticsLineResponses(data.ByCategory, revenue),
+		ByCashier:       ToAnalyticsLineResponses(data.ByCashier, revenue),
+		ByHour:          ToAnalyticsLineResponses(data.ByHour, revenue),
+		ByWeekday:       ToAnalyticsLineResponses(data.ByWeekday, revenue),
+		ByFranchise:     ToAnalyticsLineResponses(data.ByFranchise, revenue),
+		ByChannel:       ToAnalyticsLineResponses(data.ByChannel, revenue),
+		ByPaymentMethod: ToMethodTotalResponses(data.ByPaymentMethod),
+	}
+
+	if req.CompareTo != "" {
+		comparison, err := s.compareSalesAnalytics(filter, req.CompareTo, result.Summary)
+		if err != nil {
+			return nil, err
+		}
+		result.Comparison = comparison
+	}
+
+	return result, nil
+}
+
+// compareSalesAnalytics computes the totals of the comparison period and how the period's totals changed from them
+func (s *Service) compareSalesAnalytics(filter pos.SalesAnalyticsFilter, compareTo AnalyticsComparison, current AnalyticsSummaryResponse) (*AnalyticsComparisonResponse, error) {
+	switch compareTo {
+	case AnalyticsComparePreviousPeriod:
+		// Both ends are inclusive, so the previous period stops right before this one starts
+		length := filter.EndDate.Sub(filter.StartDate)
+		filter.EndDate = filter.StartDate.Add(-time.Microsecond)
+		filter.StartDate = filter.EndDate.Add(-length)
+	case AnalyticsComparePreviousYear:
+		filter.StartDate = filter.StartDate.AddDate(-1, 0, 0)
+		filter.EndDate = filter.EndDate.AddDate(-1, 0, 0)
+	default:
+		return nil, errors.NewValidationError("invalid comparison period")
+	}
+
+	previous, err := s.saleRepo.GetSalesAnalyticsSummary(filter)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to generate sales analytics", err)
+	}
+	summary := ToAnalyticsSummaryResponse(previous)
+
+	return &AnalyticsComparisonResponse{
+		CompareTo:               compareTo,
+		StartDate:               filter.StartDate,
+		EndDate:                 filter.EndDate,
+		Summary:                 summary,
+		SaleCountChange:         percentChange(float64(current.SaleCount), float64(summary.SaleCount)),
+		QuantityChange:          percentChange(float64(current.Quantity), float64(summary.Quantity)),
+		RevenueChange:           percentChange(current.Revenue, summary.Revenue),
+		NetRevenueChange:        percentChange(current.NetRevenue, summary.NetRevenue),
+		GrossMarginChange:       percentChange(current.GrossMargin, summary.GrossMargin),
+		AverageOrderValueChange: percentChange(current.AverageOrderValue, summary.AverageOrderValue),
+	}, nil
+}
+
+// percentChange returns the change from previous to current in percent, or nil when previous is zero
+func percentChange(current, previous float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := roundCurrency((current - previous) / math.Abs(previous) * 100)
+	return &change
+}
+
+// Exports
+
+// ExportSales writes the company's sales, optionally of one franchise, one row per sale
+func (s *Service) ExportSales(userID, companyID uint, franchiseID *uint, w export.Writer) error {
+	if err := s.checkExportAccess(userID, companyID, franchiseID); err != nil {
+		return err
+	}
+
+	err := w.WriteRow("Receipt number", "Date", "Franchise ID", "Customer", "Channel", "Status", "Payment status",
+		"Items", "Subtotal", "Discount", "Tax", "Total", "Paid", "Payment methods", "Created by ID", "Voided at", "Notes")
+	if err != nil {
+		return err
+	}
+
+	return s.saleRepo.StreamByCompanyID(companyID, franchiseID, func(sales []*pos.Sale) error {
+		for _, sale := range sales {
+			quantity := 0
+			for _, item := range sale.Items {
+				quantity += item.Quantity
+			}
+
+			paid := 0.0
+			methods := []string{}
+			for _, payment := range sale.Payments {
+				if payment.PaymentStatus != pos.PaymentTransactionStatusCompleted {
+					continue
+				}
+				paid += payment.Amount
+				if method := string(payment.PaymentMethod); !slices.Contains(methods, method) {
+					methods = append(methods, method)
+				}
+			}
+
+			customerName := ""
+			if sale.Customer != nil {
+				customerName = sale.Customer.Name
+			}
+
+			channel := "retail"
+			if sale.IsWholesale {
+				channel = "wholesale"
+			}
+
+			err := w.WriteRow(sale.ReceiptNumber, sale.CreatedAt, sale.FranchiseID, customerName, channel, sale.SaleStatus, sale.PaymentStatus,
+				quantity, sale.SubTotal, sale.DiscountAmount, sale.TaxAmount, sale.TotalAmount, roundCurrency(paid),
+				strings.Join(methods, ", "), sale.CreatedByID, sale.VoidedAt, sale.Notes)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ExportRefunds writes the refunds of the company's sales, optionally of one franchise, one row per refund
+func (s *Service) ExportRefunds(userID, companyID uint, franchiseID *uint, w export.Writer) error {
+	if err := s.checkExportAccess(userID, companyID, franchiseID); err != nil {
+		return err
+	}
+
+	err := w.WriteRow("Refund ID", "Date", "Receipt number", "Franchise ID", "Status", "Method", "Amount",
+		"Items", "Exchange ID", "Voucher code", "Processed by ID", "Reason")
+	if err != nil {
+		return err
+	}
+
+	return s.refundRepo.StreamByCompanyID(companyID, franchiseID, func(refunds []*pos.Refund) error {
+		for _, refund := range refunds {
+			quantity := 0
+			for _, item := range refund.Items {
+				quantity += item.Quantity
+			}
+
+			receiptNumber := ""
+			var saleFranchiseID *uint
+			if refund.OriginalSale != nil {
+				receiptNumber = refund.OriginalSale.ReceiptNumber
+				saleFranchiseID = refund.OriginalSale.FranchiseID
+			}
+
+			err := w.WriteRow(refund.ID, refund.CreatedAt, receiptNumber, saleFranchiseID, refund.RefundStatus, refund.RefundMethod,
+				refund.RefundAmount, quantity, refund.ExchangeID, refund.VoucherCode, refund.ProcessedByID, refund.Reason)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ExportCashDrawers writes the company's cash drawer sessions, optionally of one franchise, one row per session
+func (s *Service) ExportCashDrawers(userID, companyID uint, franchiseID *uint, w export.Writer) error {
+	if err := s.checkExportAccess(userID, companyID, franchiseID); err != nil {
+		return err
+	}
+
+	err := w.WriteRow("Drawer ID", "Franchise ID", "Status", "Opened at", "Opened by ID", "Closed at", "Closed by ID",
+		"Opening balance", "Expected balance", "Closing balance", "Difference", "Transactions", "Notes")
+	if err != nil {
+		return err
+	}
+
+	return s.cashDrawerRepo.StreamByCompanyID(companyID, franchiseID, func(drawers []*pos.CashDrawer) error {
+		for _, drawer := range drawers {
+			err := w.WriteRow(drawer.ID, drawer.FranchiseID, drawer.Status, drawer.OpenedAt, drawer.OpenedByID, drawer.ClosedAt, drawer.ClosedByID,
+				drawer.OpeningBalance, drawer.ExpectedBalance, drawer.ClosingBalance, drawer.Difference, len(drawer.Transactions), drawer.Notes)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// checkExportAccess checks the user may list the company's records, or the franchise's when one is given
+func (s *Service) checkExportAccess(userID, companyID uint, franchiseID *uint) error {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return err
+	}
+	if franchiseID != nil {
+		return s.checkUserFranchiseAccess(userID, *franchiseID, user.RoleEmployee)
+	}
+	return nil
+}
+
+// Receipt Generation
+
+func (s *Service) GenerateReceiptPDF(userID, companyID, saleID uint) ([]byte, string, error) {
+	receiptData, _, err := s.buildReceiptData(userID, companyID, saleID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return renderReceiptPDF(receiptData)
+}
+
+// GeneratePublicReceiptPDF renders the receipt a signed link points to, without authentication
+func (s *Service) GeneratePublicReceiptPDF(token string) ([]byte, string, error) {
+	saleID, companyID, err := s.jwtManager.ValidateReceiptToken(token)
+	if err != nil {
+		return nil, "", errors.NewUnauthorizedError("invalid or expired receipt link")
+	}
+
+	receiptData, _, err := s.loadReceiptData(companyID, saleID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return renderReceiptPDF(receiptData)
+}
+
+// GetReceiptLink signs a public link to the receipt of a sale that can be shared with the customer
+func (s *Service) GetReceiptLink(userID, companyID, saleID uint) (*ReceiptLinkResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	sale, err := s.saleRepo.FindByID(saleID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("sale not found")
+	}
+
+	if sale.CompanyID != companyID {
+		return nil, errors.NewForbiddenError("access denied to this sale")
+	}
+
+	return s.signReceiptLink(sale)
+}
+
+// EmailReceipt queues an email with the receipt PDF attached and a link to the digital receipt.
+// It goes to the address in the request, or to the customer of the sale when none is given.
+func (s *Service) EmailReceipt(userID, companyID, saleID uint, req *EmailReceiptRequest) (*EmailReceiptResponse, error) {
+	receiptData, sale, err := s.buildReceiptData(userID, companyID, saleID)
+	if err != nil {
+		return nil, err
+	}
+
+	email := req.Email
+	if email == "" && sale.Customer != nil {
+		email = sale.Customer.Email
+	}
+	if email == "" {
+		return nil, errors.NewValidationError("email is required when the sale has no customer with an email address")
+	}
+
+	pdfBytes, filename, err := renderReceiptPDF(receiptData)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := s.signReceiptLink(sale)
+	if err != nil {
+		return nil, err
+	}
+
+	companyName := receiptData.CompanyName
+	if receiptData.FranchiseName != "" {
+		companyName = receiptData.FranchiseName
+	}
+
+	emailReq := &emailApp.SendReceiptEmailRequest{
+		CompanyID:     companyID,
+		To:            []string{email},
+		ReceiptNumber: sale.ReceiptNumber,
+		ReceiptDate:   sale.CreatedAt.Format("2006-01-02 15:04"),
+		TotalAmount:   sale.TotalAmount,
+		ReceiptURL:    link.URL,
+		CompanyName:   companyName,
+		PDF:           pdfBytes,
+		PDFFilename:   filename,
+	}
+	if err := s.emailService.SendReceiptEmail(emailReq); err != nil {
+		return nil, errors.NewInternalError("failed to queue receipt email", err)
+	}
+
+	return &EmailReceiptResponse{
+		Email:     email,
+		URL:       link.URL,
+		ExpiresAt: link.ExpiresAt,
+	}, nil
+}
+
+// signReceiptLink creates the public, expiring link to the receipt of a sale
+func (s *Service) signReceiptLink(sale *pos.Sale) (*ReceiptLinkResponse, error) {
+	token, expiresAt, err := s.jwtManager.GenerateReceiptToken(sale.ID, sale.CompanyID, s.receiptLinks.TTL)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to sign receipt link", err)
+	}
+
+	return &ReceiptLinkResponse{
+		URL:       fmt.Sprintf("%s/api/v1/public/receipts/%s", strings.TrimSuffix(s.receiptLinks.BaseURL, "/"), token),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// renderReceiptPDF generates the receipt PDF and its filename
+func renderReceiptPDF(receiptData *receipt.ReceiptData) ([]byte, string, error) {
+	generator := receipt.NewReceiptGenerator()
+	pdfBytes, err := generator.GenerateReceipt(receiptData)
+	if err != nil {
+		return nil, "", errors.NewInternalError("failed to generate receipt PDF", err)
+	}
+
+	// Generate filename
+	filename := fmt.Sprintf("receipt_%s.pdf", receiptData.ReceiptNumber)
+
+	return pdfBytes, filename, nil
+}
+
+// GenerateReceiptEscPos renders the receipt as raw ESC/POS commands to send straight to a
+// thermal printer; openDrawer adds the pulse that opens the cash drawer wired to the printer
+func (s *Service) GenerateReceiptEscPos(userID, companyID, saleID uint, openDrawer bool) ([]byte, string, error) {
+	receiptData, _, err := s.buildReceiptData(userID, companyID, saleID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	generator := receipt.NewEscPosGenerator()
+	data, err := generator.GenerateReceipt(receiptData, openDrawer)
+	if err != nil {
+		return nil, "", errors.NewInternalError("failed to generate ESC/POS receipt", err)
+	}
+
+	filename := fmt.Sprintf("receipt_%s.bin", receiptData.ReceiptNumber)
+
+	return data, filename, nil
+}
+
+// buildReceiptData gathers everything printed on the receipt of a sale, for a user of the company
+func (s *Service) buildReceiptData(userID, companyID, saleID uint) (*receipt.ReceiptData, *pos.Sale, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, nil, err
+	}
+
+	return s.loadReceiptData(companyID, saleID)
+}
+
+// loadReceiptData gathers everything printed on the receipt of a sale
+func (s *Service) loadReceiptData(companyID, saleID uint) (*receipt.ReceiptData, *pos.Sale, error) {
+	// Fetch sale with items, payments, and customer
+	sale, err := s.saleRepo.FindByID(saleID)
+	if err != nil {
+		return nil, nil, errors.NewNotFoundError("sale not found")
+	}
+
+	if sale.CompanyID != companyID {
+		return nil, nil, errors.NewForbiddenError("access denied to this sale")
+	}
+
+	// Fetch company info
+	var company company.Company
+	if err := s.db.First(&company, companyID).Error; err != nil {
+		return nil, nil, errors.NewNotFoundError("company not found")
+	}
+
+	// Fetch franchise info if applicable
+	var franchiseName string
+	if sale.FranchiseID != nil {
+		var franchise franchise.Franchise
+		if err := s.db.First(&franchise, *sale.FranchiseID).Error; err == nil {
+			franchiseName = franchise.Name
+		}
+	}
+
+	// Fetch product variants for items
+	productVariantMap := s.loadVariantInfo(sale.Items)
+
+	// Convert sale to receipt data
+	receiptData := receipt.ConvertSaleToReceiptData(sale, company.Name, franchiseName, productVariantMap)
+
+	// A gift card sale has no items; print the card with its code instead
+	if sale.IsGiftCardSale() {
+		if giftCard, err := s.voucherRepo.FindByID(*sale.VoucherID); err == nil {
+			receiptData.Items = append(receiptData.Items, receipt.ReceiptItem{
+				Name:        "Gift card",
+				SKU:         giftCard.Code,
+				Quantity:    1,
+				UnitPrice:   giftCard.InitialAmount,
+				TotalAmount: giftCard.InitialAmount,
+			})
+		}
+	}
+
+	// Print the customer's points when the company runs a loyalty program
+	if sale.CustomerID != nil && s.activeLoyaltyProgram(companyID) != nil {
+		entries, err := s.loyaltyTransactionRepo.FindBySaleID(sale.ID)
+		if err == nil {
+			for _, entry := range entries {
+				switch entry.Type {
+				case loyalty.TransactionTypeEarn:
+					receiptData.LoyaltyEarned += entry.Points
+				case loyalty.TransactionTypeRedeem:
+					receiptData.LoyaltyRedeemed -= entry.Points
+				}
+			}
+		}
+		if balance, err := s.loyaltyTransactionRepo.GetBalance(*sale.CustomerID); err == nil {
+			receiptData.LoyaltyBalance = &balance
+		}
+	}
+
+	// The QR code opens the digital receipt
+	link, err := s.signReceiptLink(sale)
+	if err != nil {
+		return nil, nil, err
+	}
+	receiptData.QRCode = link.URL
+
+	return receiptData, sale, nil
+}
+
+// loadVariantInfo looks up the names and SKUs printed for the lines of a sale
+func (s *Service) loadVariantInfo(items []pos.SaleItem) map[uint]receipt.ProductVariantInfo {
+	productVariantMap := make(map[uint]receipt.ProductVariantInfo)
+	for _, item := range items {
+		variant, err := s.productVariantRepo.FindProductVariantByID(item.ProductVariantID)
+		if err == nil {
+			// Get product name using ProductID from variant
+			product, err := s.productVariantRepo.FindProductByID(variant.ProductID)
+			if err == nil {
+				itemName := fmt.Sprintf("%s - %s", product.Name, variant.Name)
+				productVariantMap[item.ProductVariantID] = receipt.ProductVariantInfo{
+					Name: itemName,
+					SKU:  variant.SKU,
+				}
+			} else {
+				// Fallback to variant name only
+				productVariantMap[item.ProductVariantID] = receipt.ProductVariantInfo{
+					Name: variant.Name,
+					SKU:  variant.SKU,
+				}
+			}
+		}
+	}
+	return productVariantMap
+}
+
+// Helper methods
+
+func (s *Service) checkUserCompanyAccess(userID, companyID uint, minimumRole user.Role) error {
+	ucr, err := s.userRepo.FindUserRoleInCompany(userID, companyID)
+	if err != nil {
+		return errors.NewForbiddenError("access denied to this company")
+	}
+
+	if !ucr.Role.HasPermission(minimumRole) {
+		return errors.NewForbiddenError("insufficient permissions")
+	}
+
+	return nil
+}
+
+func (s *Service) checkUserFranchiseAccess(userID, franchiseID uint, minimumRole user.Role) error {
+	ufr, err := s.userRepo.FindUserRoleInFranchise(userID, franchiseID)
+	if err != nil {
+		return errors.NewForbiddenError("access denied to this franchise")
+	}
+
+	if !ufr.Role.HasPermission(minimumRole) {
+		return errors.NewForbiddenError("insufficient permissions")
+	}
+
+	return nil
+}
+
+// SearchProductsForSale searches for products/variants with retail pricing for POS sales
+func (s *Service) SearchProductsForSale(userID, companyID uint, req *SearchProductsRequest) ([]*ProductVariantSearchResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	// If franchise is specified, validate it belongs to company
+	if req.FranchiseID != nil {
+		franchise, err := s.franchiseRepo.FindByID(*req.FranchiseID)
+		if err != nil {
+			return nil, errors.NewNotFoundError("franchise not found")
+		}
+		if franchise.ParentCompanyID != companyID {
+			return nil, errors.NewForbiddenError("franchise does not belong to this company")
+		}
+	}
+
+	// Set default limit
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	// Search variants
+	variants, err := s.productVariantRepo.SearchVariantsByCompany(companyID, req.Query, limit)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to search products", err)
+	}
+
+	// Build response with retail pricing information
+	results := make([]*ProductVariantSearchResponse, 0, len(variants))
+	for _, variant := range variants {
+		result, err := s.toVariantSearchResponse(variant, req.FranchiseID)
+		if err != nil {
+			continue // Skip if product not found
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// ScanProduct finds the variant of a scanned code: a barcode first, then a variant SKU as
+// printed on labels, both matched exactly. Unlike the search it returns a single variant,
+// with its pricing and the stock where it is sold.
+func (s *Service) ScanProduct(userID, companyID uint, req *ScanProductRequest) (*ScanProductResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	// If franchise is specified, validate it belongs to company
+	if req.FranchiseID != nil {
+		franchise, err := s.franchiseRepo.FindByID(*req.FranchiseID)
+		if err != nil {
+			return nil, errors.NewNotFoundError("franchise not found")
+		}
+		if franchise.ParentCompanyID != companyID {
+			return nil, errors.NewForbiddenError("franchise does not belong to this company")
+		}
+	}
+
+	code := strings.TrimSpace(req.Code)
+	var variant *product.ProductVariant
+	if barcode, err := product.NormalizeBarcode(code); err == nil {
+		variant, _ = s.productVariantRepo.FindVariantByBarcode(companyID, barcode)
+	}
+	if variant == nil {
+		variants, err := s.productVariantRepo.FindVariantsBySKUAndCompany(code, companyID)
+		if err != nil {
+			return nil, errors.NewInternalError("failed to look up product", err)
+		}
+		if len(variants) > 1 {
+			return nil, errors.NewConflictError("several product variants have this SKU, scan a barcode instead")
+		}
+		if len(variants) == 0 {
+			return nil, errors.NewNotFoundError("no product matches this code")
+		}
+		variant = variants[0]
+	}
+
+	result, err := s.toVariantSearchResponse(variant, req.FranchiseID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("product not found")
+	}
+
+	// Stock of the franchise the sale is rung up in, otherwise of the company warehouse
+	var inv *inventory.Inventory
+	if req.FranchiseID != nil {
+		inv, _ = s.inventoryRepo.FindByVariantAndFranchise(variant.ID, *req.FranchiseID)
+	} else {
+		inv, _ = s.inventoryRepo.FindByVariantAndCompany(variant.ID, companyID)
+	}
+
+	response := &ScanProductResponse{
+		ProductVariantSearchResponse: *result,
+		Barcodes:                     make([]string, 0, len(variant.Barcodes)),
+	}
+	for _, barcode := range variant.Barcodes {
+		response.Barcodes = append(response.Barcodes, barcode.Code)
+	}
+	if inv != nil {
+		response.Stock = inv.Stock
+		response.ReservedStock = inv.ReservedStock
+		response.AvailableStock = inv.GetAvailableStock()
+	}
+
+	return response, nil
+}
+
+// toVariantSearchResponse builds the retail pricing of a variant, with the franchise's prices if any
+func (s *Service) toVariantSearchResponse(variant *product.ProductVariant, franchiseID *uint) (*ProductVariantSearchResponse, error) {
+	// Get product (should be preloaded)
+	product := variant.Product
+	if product == nil {
+		// Fetch product if not preloaded
+		var err error
+		product, err = s.productVariantRepo.FindProductByID(variant.ProductID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Get base pricing
+	baseRetailPrice := product.BaseRetailPrice
+	baseWholesalePrice := product.BaseWholesalePrice
+
+	// Get variant-specific pricing
+	var variantRetailPrice, variantWholesalePrice *float64
+	if !variant.UseParentPricing {
+		if variant.RetailPrice != nil && *variant.RetailPrice > 0 {
+			variantRetailPrice = variant.RetailPrice
+		}
+		if variant.WholesalePrice != nil && *variant.WholesalePrice > 0 {
+			variantWholesalePrice = variant.WholesalePrice
+		}
+	}
+
+	// Calculate effective pricing (variant or base)
+	effectiveRetailPrice := variant.GetEffectiveRetailPrice(baseRetailPrice)
+	effectiveWholesalePrice := variant.GetEffectiveWholesalePrice(baseWholesalePrice)
+
+	// Get franchise pricing if available
+	var franchiseRetailPrice, franchiseWholesalePrice *float64
+	if franchiseID != nil {
+		franchisePricing, err := s.franchiseRepo.FindPricing(*franchiseID, variant.ID)
+		if err == nil && franchisePricing != nil {
+			if franchisePricing.RetailPrice != nil && *franchisePricing.RetailPrice > 0 {
+				franchiseRetailPrice = franchisePricing.RetailPrice
+				effectiveRetailPrice = *franchisePricing.RetailPrice
+			}
+			if franchisePricing.WholesalePrice != nil && *franchisePricing.WholesalePrice > 0 {
+				franchiseWholesalePrice = franchisePricing.WholesalePrice
+				effectiveWholesalePrice = *franchisePricing.WholesalePrice
+			}
+		}
+	}
+
+	return &ProductVariantSearchResponse{
+		VariantID:               variant.ID,
+		VariantName:             variant.Name,
+		VariantSKU:              variant.SKU,
+		ProductID:               product.ID,
+		ProductName:             product.Name,
+		ProductSKU:              product.SKU,
+		BaseRetailPrice:         baseRetailPrice,
+		BaseWholesalePrice:      baseWholesalePrice,
+		VariantRetailPrice:      variantRetailPrice,
+		VariantWholesalePrice:   variantWholesalePrice,
+		FranchiseRetailPrice:    franchiseRetailPrice,
+		FranchiseWholesalePrice: franchiseWholesalePrice,
+		EffectiveRetailPrice:    effectiveRetailPrice,
+		EffectiveWholesalePrice: effectiveWholesalePrice,
+		UseParentPricing:        variant.UseParentPricing,
+	}, nil
+}
+
+// checkSaleCustomer validates the customer attached to a sale, if any
+func (s *Service) checkSaleCustomer(companyID uint, customerID *uint) error {
+	if customerID == nil {
+		return nil
+	}
+
+	customer, err := s.customerRepo.FindByID(*customerID)
+	if err != nil {
+		return errors.NewNotFoundError("customer not found")
+	}
+	if customer.CompanyID != companyID {
+		return errors.NewForbiddenError("customer does not belong to this company")
+	}
+	return nil
+}
+
+// persistSale creates the sale with its final receipt number, then its items
+func (s *Service) persistSale(tx *gorm.DB, sale *pos.Sale, items []pos.SaleItem) error {
+	// Calculate sale totals (temporarily set items for calculation)
+	sale.Items = items
+	sale.CalculateTotals()
+
+	// Create sale in database without items to avoid GORM auto-creating them
+	sale.Items = nil
+	if err := s.createSaleRecord(tx, sale); err != nil {
+		return err
+	}
+
+	for i := range items {
+		items[i].SaleID = sale.ID
+	}
+
+	if err := tx.Create(&items).Error; err != nil {
+		return errors.NewInternalError("failed to create sale items", err)
+	}
+
+	sale.Items = items
+	return nil
+}
+
+// createSaleRecord inserts the sale row alone with its final receipt number.
+// Offline sales keep the receipt number the device gave them, and drafts (parked carts
+// and layaways) keep their placeholder: they only take a number from the receipt sequence
+// once completed, so discarded drafts leave no gaps.
+func (s *Service) createSaleRecord(tx *gorm.DB, sale *pos.Sale) error {
+	if sale.ReceiptNumber == "" {
+		receiptNumber, err := s.numberingService.Next(tx, sale.CompanyID, sale.FranchiseID, numbering.DocumentTypeReceipt)
+		if err != nil {
+			return err
+		}
+		sale.ReceiptNumber = receiptNumber
+	}
+
+	if !sale.IsValid() {
+		return errors.NewValidationError("invalid sale data")
+	}
+
+	if err := tx.Create(sale).Error; err != nil {
+		return errors.NewInternalError("failed to create sale", err)
+	}
+	return nil
+}
+
+// draftReceiptNumber is the placeholder receipt number of a draft until it is completed
+func draftReceiptNumber() string {
+	return fmt.Sprintf("DRAFT-%d", time.Now().UnixNano())
+}
+
+// lockSale locks the sale row and reloads it with its lines and payments, so that refunds,
+// exchanges and voids of the same sale run one after the other and see each other's changes
+func (s *Service) lockSale(tx *gorm.DB, saleID uint) (*pos.Sale, error) {
+	var locked pos.Sale
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Items").Preload("Payments").Preload("Customer").First(&locked, saleID).Error; err != nil {
+		return nil, errors.NewInternalError("failed to lock sale", err)
+	}
+	return &locked, nil
+}
+
+// refundSelection holds the sale lines chosen for a return and their value
+type refundSelection struct {
+	Items         []pos.RefundItem
+	Amount        float64 // Value of the returned lines
+	Refundable    float64 // Amount of the sale not refunded yet
+	FullyRefunded bool    // Whether every line of the sale has now been returned
+}
+
+// selectRefundItems checks the requested lines against what is still returnable on the sale
+// and values them; an empty request selects every remaining quantity. The sale must be locked
+// with lockSale so that the refunds read here cannot change before the caller commits.
+func (s *Service) selectRefundItems(sale *pos.Sale, requested []RefundItemRequest) (*refundSelection, error) {
+	refundedQuantities, err := s.refundRepo.GetRefundedQuantitiesBySaleID(sale.ID)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch refunded quantities", err)
+	}
+
+	alreadyRefunded, err := s.refundRepo.GetTotalRefundedForSale(sale.ID)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch refunded amount", err)
+	}
+
+	saleItemsByID := make(map[uint]pos.SaleItem, len(sale.Items))
+	for _, item := range sale.Items {
+		saleItemsByID[item.ID] = item
+	}
+
+	// Default to returning everything still outstanding
+	if len(requested) == 0 {
+		for _, item := range sale.Items {
+			if remaining := item.Quantity - refundedQuantities[item.ID]; remaining > 0 {
+				requested = append(requested, RefundItemRequest{SaleItemID: item.ID, Quantity: remaining})
+			}
+		}
+	}
+
+	if len(requested) == 0 {
+		return nil, errors.NewValidationError("all items of this sale have already been refunded")
+	}
+
+	// The sale-level discount is apportioned to lines pro rata; line totals already include tax
+	saleRatio := 1.0
+	if itemsTotal := sale.ItemsTotal(); itemsTotal > 0 {
+		saleRatio = sale.TotalAmount / itemsTotal
+	}
+
+	selection := &refundSelection{
+		Items:      make([]pos.RefundItem, 0, len(requested)),
+		Refundable: roundCurrency(sale.TotalAmount - alreadyRefunded),
+	}
+	requestedQuantities := make(map[uint]int)
+	for _, itemReq := range requested {
+		saleItem, exists := saleItemsByID[itemReq.SaleItemID]
+		if !exists {
+			return nil, errors.NewValidationError(fmt.Sprintf("sale item %d does not belong to this sale", itemReq.SaleItemID))
+		}
+
+		requestedQuantities[saleItem.ID] += itemReq.Quantity
+		remaining := saleItem.Quantity - refundedQuantities[saleItem.ID]
+		if requestedQuantities[saleItem.ID] > remaining {
+			return nil, errors.NewValidationError(fmt.Sprintf("only %d unit(s) of sale item %d can still be refunded", remaining, saleItem.ID))
+		}
+
+		lineAmount := roundCurrency(saleItem.TotalAmount / float64(saleItem.Quantity) * float64(itemReq.Quantity) * saleRatio)
+		selection.Amount += lineAmount
+
+		selection.Items = append(selection.Items, pos.RefundItem{
+			SaleItemID:       saleItem.ID,
+			ProductVariantID: saleItem.ProductVariantID,
+			Quantity:         itemReq.Quantity,
+			RefundAmount:     lineAmount,
+		})
+	}
+	selection.Amount = roundCurrency(selection.Amount)
+
+	// Check whether this return exhausts every line
+	selection.FullyRefunded = true
+	for _, item := range sale.Items {
+		if refundedQuantities[item.ID]+requestedQuantities[item.ID] < item.Quantity {
+			selection.FullyRefunded = false
+			break
+		}
+	}
+
+	return selection, nil
+}
+
+// persistRefundItems attaches the selected lines to the refund and creates them
+func (s *Service) persistRefundItems(tx *gorm.DB, refundID uint, items []pos.RefundItem) error {
+	for i := range items {
+		items[i].RefundID = refundID
+		if !items[i].IsValid() {
+			return errors.NewValidationError("invalid refund item data")
+		}
+	}
+
+	if err := tx.Create(&items).Error; err != nil {
+		return errors.NewInternalError("failed to create refund items", err)
+	}
+	return nil
+}
+
+// findActiveCashDrawer returns the open drawer of the franchise, or of the company when no franchise is given
+func (s *Service) findActiveCashDrawer(companyID uint, franchiseID *uint) (*pos.CashDrawer, error) {
+	if franchiseID != nil {
+		return s.cashDrawerRepo.FindActiveByFranchiseID(*franchiseID)
+	}
+	return s.cashDrawerRepo.FindActiveByCompanyID(companyID)
+}
+
+// lockCashDrawer locks the drawer row, so that closing the drawer and cash movements on it run one after the other
+func lockCashDrawer(tx *gorm.DB, drawerID uint) (*pos.CashDrawer, error) {
+	var drawer pos.CashDrawer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&drawer, drawerID).Error; err != nil {
+		return nil, errors.NewInternalError("failed to lock cash drawer", err)
+	}
+	return &drawer, nil
+}
+
+// cashDrawerTotal sums the transactions recorded on the drawer, as seen by tx
+func cashDrawerTotal(tx *gorm.DB, drawerID uint) (float64, error) {
+	var total float64
+	if err := tx.Model(&pos.CashDrawerTransaction{}).
+		Where("cash_drawer_id = ?", drawerID).
+		Select("COALESCE(SUM(amount), 0)").
+		Row().Scan(&total); err != nil {
+		return 0, errors.NewInternalError("failed to calculate transactions", err)
+	}
+	return total, nil
+}
+
+// lockActiveCashDrawer locks the open drawer of the franchise, or of the company when no franchise is
+// given, and returns nil when none is open. A drawer closed while waiting for the lock gives way to the
+// one opened since, so that cash is never posted to a drawer already counted.
+func (s *Service) lockActiveCashDrawer(tx *gorm.DB, companyID uint, franchiseID *uint) (*pos.CashDrawer, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		activeDrawer, err := s.findActiveCashDrawer(companyID, franchiseID)
+		if err != nil || activeDrawer == nil {
+			return nil, nil
+		}
+
+		drawer, err := lockCashDrawer(tx, activeDrawer.ID)
+		if err != nil {
+			return nil, err
+		}
+		if drawer.IsOpen() {
+			return drawer, nil
+		}
+	}
+	return nil, errors.NewConflictError("the cash drawer was closed meanwhile, try again")
+}
+
+// recordCashDrawerTransaction records a cash movement on the open drawer, if there is one
+func (s *Service) recordCashDrawerTransaction(tx *gorm.DB, companyID uint, franchiseID *uint, transactionType pos.CashDrawerTransactionType, amount float64, saleID *uint, notes string) error {
+	activeDrawer, err := s.lockActiveCashDrawer(tx, companyID, franchiseID)
+	if err != nil {
+		return err
+	}
+	if activeDrawer == nil {
+		return nil
+	}
+
+	drawerTx := &pos.CashDrawerTransaction{
+		CashDrawerID:    activeDrawer.ID,
+		TransactionType: transactionType,
+		Amount:          amount,
+		SaleID:          saleID,
+		Notes:           notes,
+	}
+	if err := tx.Create(drawerTx).Error; err != nil {
+		return errors.NewInternalError("failed to record cash drawer transaction", err)
+	}
+	return nil
+}
+
+// buildSaleItems resolves prices, promotions and taxes, applies approved price
+// overrides and checks availability for the requested sale lines. held holds
+// quantities per variant already reserved for this sale, which count as available.
+// Wholesale lines are priced at wholesale prices and quantity breaks instead of promotions
+// and the customer's price lists.
+func (s *Service) buildSaleItems(userID, companyID uint, franchiseID, customerID *uint, items []SaleItemRequest, approval *ManagerApproval, held map[uint]int, couponCode string, wholesale bool) ([]pos.SaleItem, error) {
+	saleItems, err := s.priceSaleItems(userID, companyID, franchiseID, customerID, items, approval, couponCode, wholesale)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check inventory availability
+	for _, item := range saleItems {
+		inv, err := s.findInventory(companyID, franchiseID, item.ProductVariantID)
+		if err != nil || inv.GetAvailableStock()+held[item.ProductVariantID] < item.Quantity {
+			sku := ""
+			if variant, err := s.productVariantRepo.FindProductVariantByID(item.ProductVariantID); err == nil {
+				sku = variant.SKU
+			}
+			return nil, errors.NewValidationError(fmt.Sprintf("insufficient inventory for product variant %d (SKU: %s)", item.ProductVariantID, sku))
+		}
+	}
+
+	return saleItems, nil
+}
+
+// priceSaleItems prices the requested lines with their promotions and taxes, without
+// checking the stock: proformas quote items that may only be available later
+func (s *Service) priceSaleItems(userID, companyID uint, franchiseID, customerID *uint, items []SaleItemRequest, approval *ManagerApproval, couponCode string, wholesale bool) ([]pos.SaleItem, error) {
+	taxes, err := s.loadSaleTaxes(companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	promotions := &salePromotions{}
+	priceLists := &customerPriceLists{}
+	if wholesale {
+		if promotion.NormalizeCouponCode(couponCode) != "" {
+			return nil, errors.NewValidationError("coupons do not apply to wholesale sales")
+		}
+	} else {
+		promotions, err = s.loadSalePromotions(companyID, franchiseID, couponCode)
+		if err != nil {
+			return nil, err
+		}
+		priceLists, err = s.loadCustomerPriceLists(companyID, franchiseID, customerID, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+	couponApplied := false
+
+	// Quantity breaks and minimums count every line of a variant
+	quantities := make(map[uint]int)
+	for _, itemReq := range items {
+		quantities[itemReq.ProductVariantID] += itemReq.Quantity
+	}
+
+	saleItems := make([]pos.SaleItem, len(items))
+	var overrideApproverID *uint
+	for i, itemReq := range items {
+		// Validate product variant
+		variant, err := s.productVariantRepo.FindProductVariantByID(itemReq.ProductVariantID)
+		if err != nil {
+			return nil, errors.NewNotFoundError(fmt.Sprintf("product variant %d not found", itemReq.ProductVariantID))
+		}
+
+		// Resolve the price on the server; the client price is only a requested override
+		var listPrice float64
+		var variantProduct *product.Product
+		if wholesale {
+			listPrice, variantProduct, err = s.resolveWholesalePrice(companyID, variant, franchiseID, quantities[variant.ID])
+		} else {
+			listPrice, variantProduct, err = s.resolveRetailPrice(companyID, variant, franchiseID)
+			if err == nil {
+				listPrice = priceLists.priceFor(variantProduct.ID, variant.ID, listPrice)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if wholesale && quantities[variant.ID] < variantProduct.WholesaleMinQuantity {
+			return nil, errors.NewValidationError(fmt.Sprintf("variant %d (SKU: %s) is sold wholesale by at least %d units", variant.ID, variant.SKU, variantProduct.WholesaleMinQuantity))
+		}
+
+		saleItem := pos.SaleItem{
+			ProductVariantID: itemReq.ProductVariantID,
+			Quantity:         itemReq.Quantity,
+			ListPrice:        listPrice,
+			UnitPrice:        listPrice,
+			DiscountAmount:   itemReq.DiscountAmount,
+			UnitCost:         variantProduct.SupplierCost,
+		}
+
+		if itemReq.UnitPrice != nil && isPriceOverride(*itemReq.UnitPrice, listPrice) {
+			if itemReq.OverrideReason == "" {
+				return nil, errors.NewValidationError(fmt.Sprintf("a reason is required to override the price of variant %d (SKU: %s)", itemReq.ProductVariantID, variant.SKU))
+			}
+			if overrideApproverID == nil {
+				approverID, err := s.resolveApprover(userID, companyID, franchiseID, approval, "price override")
+				if err != nil {
+					return nil, err
+				}
+				overrideApproverID = &approverID
+			}
+			saleItem.ApplyPriceOverride(*itemReq.UnitPrice, *overrideApproverID, itemReq.OverrideReason)
+		}
+
+		// Apply the best matching promotion; lines sold at an overridden price are not discounted further
+		if !saleItem.IsPriceOverride {
+			line := promotion.LineTarget{
+				ProductID:        variantProduct.ID,
+				ProductVariantID: variant.ID,
+				SupplierID:       variantProduct.SupplierID,
+				Attributes:       variantAttributes(variant),
+				Quantity:         saleItem.Quantity,
+				UnitPrice:        saleItem.UnitPrice,
+			}
+			if promo, discount := promotions.bestFor(line); promo != nil {
+				discount = math.Min(discount, roundCurrency(float64(saleItem.Quantity)*saleItem.UnitPrice-saleItem.DiscountAmount))
+				if discount > 0 {
+					saleItem.ApplyPromotion(promo.ID, discount)
+					couponApplied = couponApplied || promo == promotions.coupon
+				}
+			}
+		}
+
+		taxClass, err := taxes.classFor(variantProduct)
+		if err != nil {
+			return nil, err
+		}
+		if taxClass != nil {
+			saleItem.ApplyTax(&taxClass.ID, taxClass.EffectiveRate(), taxes.pricesIncludeTax)
+		} else {
+			saleItem.ApplyTax(nil, 0, taxes.pricesIncludeTax)
+		}
+		saleItem.CalculateTotals()
+		saleItems[i] = saleItem
+	}
+
+	if promotions.coupon != nil && !couponApplied {
+		return nil, errors.NewValidationError(fmt.Sprintf("coupon %s does not apply to any item of this sale", promotions.coupon.CouponCode))
+	}
+
+	return saleItems, nil
+}
+
+// buildOfflineSaleItems prices the lines of an offline sale at the prices the device charged,
+// with the server's taxes. Prices that differ from the server's are returned as conflicts.
+func (s *Service) buildOfflineSaleItems(companyID uint, franchiseID, customerID *uint, items []OfflineSaleItemRequest, soldAt time.Time) ([]pos.SaleItem, []pos.SyncConflict, error) {
+	taxes, err := s.loadSaleTaxes(companyID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The customer's price lists as they were when the sale was rung up
+	priceLists, err := s.loadCustomerPriceLists(companyID, franchiseID, customerID, soldAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	saleItems := make([]pos.SaleItem, len(items))
+	conflicts := []pos.SyncConflict{}
+	for i, itemReq := range items {
+		variant, err := s.productVariantRepo.FindProductVariantByID(itemReq.ProductVariantID)
+		if err != nil {
+			return nil, nil, errors.NewNotFoundError(fmt.Sprintf("product variant %d not found", itemReq.ProductVariantID))
+		}
+
+		listPrice, variantProduct, err := s.resolveRetailPrice(companyID, variant, franchiseID)
+		if err != nil {
+			return nil, nil, err
+		}
+		listPrice = priceLists.priceFor(variantProduct.ID, variant.ID, listPrice)
+
+		saleItem := pos.SaleItem{
+			ProductVariantID: itemReq.ProductVariantID,
+			Quantity:         itemReq.Quantity,
+			ListPrice:        listPrice,
+			UnitPrice:        itemReq.UnitPrice,
+			DiscountAmount:   itemReq.DiscountAmount,
+			UnitCost:         variantProduct.SupplierCost,
+		}
+
+		if isPriceOverride(itemReq.UnitPrice, listPrice) {
+			variantID := variant.ID
+			conflicts = append(conflicts, pos.SyncConflict{
+				Type:             pos.SyncConflictPriceMismatch,
+				ProductVariantID: &variantID,
+				Expected:         listPrice,
+				Actual:           itemReq.UnitPrice,
+				Message:          fmt.Sprintf("variant %d (SKU: %s) was sold at %.2f instead of %.2f", variant.ID, variant.SKU, itemReq.UnitPrice, listPrice),
+			})
+		}
+
+		taxClass, err := taxes.classFor(variantProduct)
+		if err != nil {
+			return nil, nil, err
+		}
+		if taxClass != nil {
+			saleItem.ApplyTax(&taxClass.ID, taxClass.EffectiveRate(), taxes.pricesIncludeTax)
+		} else {
+			saleItem.ApplyTax(nil, 0, taxes.pricesIncludeTax)
+		}
+		saleItem.CalculateTotals()
+		saleItems[i] = saleItem
+	}
+
+	return saleItems, conflicts, nil
+}
+
+// customerPriceLists holds the price lists of the customer's group that may price the lines of a sale
+type customerPriceLists struct {
+	lists []*pricelist.PriceList
+}
+
+// loadCustomerPriceLists returns the price lists of the customer's group that apply at the
+// franchise at the given time. Customers without an active group get none.
+func (s *Service) loadCustomerPriceLists(companyID uint, franchiseID, customerID *uint, at time.Time) (*customerPriceLists, error) {
+	priceLists := &customerPriceLists{}
+	if customerID == nil {
+		return priceLists, nil
+	}
+
+	customer, err := s.customerRepo.FindByID(*customerID)
+	if err != nil || customer.CustomerGroupID == nil {
+		return priceLists, nil
+	}
+
+	group, err := s.customerGroupRepo.FindByIDAndCompany(*customer.CustomerGroupID, companyID)
+	if err != nil || !group.IsActive {
+		return priceLists, nil
+	}
+
+	lists, err := s.priceListRepo.FindActive(companyID, group.ID, at)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch price lists", err)
+	}
+	for _, l := range lists {
+		if l.IsAvailableAt(at) && l.AppliesToFranchise(franchiseID) {
+			priceLists.lists = append(priceLists.lists, l)
+		}
+	}
+
+	return priceLists, nil
+}
+
+// priceFor returns the price the customer pays for a variant otherwise sold at price.
+// When several lists price the variant, the lowest price wins.
+func (cp *customerPriceLists) priceFor(productID, variantID uint, price float64) float64 {
+	best := price
+	matched := false
+	for _, l := range cp.lists {
+		if listPrice, ok := l.PriceFor(productID, variantID, price); ok && (!matched || listPrice < best) {
+			best, matched = listPrice, true
+		}
+	}
+	return best
+}
+
+// salePromotions holds the promotions that may discount the lines of a sale
+type salePromotions struct {
+	available []*promotion.Promotion
+	coupon    *promotion.Promotion
+}
+
+// loadSalePromotions returns the automatic promotions running now at the franchise,
+// plus the promotion of the coupon code entered, if any
+func (s *Service) loadSalePromotions(companyID uint, franchiseID *uint, couponCode string) (*salePromotions, error) {
+	now := time.Now()
+	automatic, err := s.promotionRepo.FindAutomatic(companyID, now)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch promotions", err)
+	}
+
+	promotions := &salePromotions{}
+	for _, p := range automatic {
+		if p.IsAvailableAt(now) && p.AppliesToFranchise(franchiseID) {
+			promotions.available = append(promotions.available, p)
+		}
+	}
+
+	if code := promotion.NormalizeCouponCode(couponCode); code != "" {
+		coupon, err := s.promotionRepo.FindByCouponCode(companyID, code)
+		if err != nil || !coupon.IsAvailableAt(now) || !coupon.AppliesToFranchise(franchiseID) {
+			return nil, errors.NewValidationError(fmt.Sprintf("coupon %s is invalid or has expired", code))
+		}
+		promotions.coupon = coupon
+		promotions.available = append(promotions.available, coupon)
+	}
+
+	return promotions, nil
+}
+
+// bestFor returns the promotion giving the largest discount on a line, and that discount.
+// Promotions do not stack: a line gets at most one.
+func (sp *salePromotions) bestFor(line promotion.LineTarget) (*promotion.Promotion, float64) {
+	var best *promotion.Promotion
+	bestDiscount := 0.0
+	for _, p := range sp.available {
+		if !p.Matches(line) {
+			continue
+		}
+		if discount := p.DiscountFor(line); discount > bestDiscount {
+			best, bestDiscount = p, discount
+		}
+	}
+	return best, bestDiscount
+}
+
+// redeemPromotions counts one use of each promotion applied to a sale, failing when
+// a promotion ran out of uses since the cart was priced
+func (s *Service) redeemPromotions(tx *gorm.DB, items []pos.SaleItem) error {
+	redeemed := make(map[uint]bool)
+	for _, item := range items {
+		if item.PromotionID == nil || redeemed[*item.PromotionID] {
+			continue
+		}
+		redeemed[*item.PromotionID] = true
+
+		result := tx.Model(&promotion.Promotion{}).
+			Where("id = ? AND (usage_limit IS NULL OR usage_count < usage_limit)", *item.PromotionID).
+			UpdateColumn("usage_count", gorm.Expr("usage_count + 1"))
+		if result.Error != nil {
+			return errors.NewInternalError("failed to record promotion usage", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.NewValidationError(fmt.Sprintf("promotion %d has reached its usage limit", *item.PromotionID))
+		}
+	}
+	return nil
+}
+
+// activeLoyaltyProgram returns the company's loyalty program, nil when it has none or it is paused
+func (s *Service) activeLoyaltyProgram(companyID uint) *loyalty.Program {
+	program, err := s.loyaltyProgramRepo.FindByCompanyID(companyID)
+	if err != nil || !program.IsActive {
+		return nil
+	}
+	return program
+}
+
+// redeemLoyaltyPoints takes the points worth amount from the balance of the sale's customer
+func (s *Service) redeemLoyaltyPoints(tx *gorm.DB, sale *pos.Sale, amount float64, userID uint) (int, error) {
+	if sale.CustomerID == nil {
+		return 0, errors.NewValidationError("loyalty points can only pay sales linked to a customer")
+	}
+
+	program := s.activeLoyaltyProgram(sale.CompanyID)
+	if program == nil {
+		return 0, errors.NewValidationError("company has no active loyalty program")
+	}
+
+	points := program.PointsFor(amount)
+	if points < program.MinRedeemPoints {
+		return 0, errors.NewValidationError(fmt.Sprintf("at least %d points must be redeemed at once", program.MinRedeemPoints))
+	}
+
+	balance, err := lockLoyaltyBalance(tx, *sale.CustomerID)
+	if err != nil {
+		return 0, err
+	}
+	if points > balance {
+		return 0, errors.NewValidationError(fmt.Sprintf("customer has %d points, %d are needed", balance, points))
+	}
+
+	if err := consumeLoyaltyLots(tx, *sale.CustomerID, nil, points); err != nil {
+		return 0, err
+	}
+
+	entry := &loyalty.Transaction{
+		CompanyID:   sale.CompanyID,
+		CustomerID:  *sale.CustomerID,
+		SaleID:      &sale.ID,
+		Type:        loyalty.TransactionTypeRedeem,
+		Points:      -points,
+		Notes:       fmt.Sprintf("Paid %.2f of sale #%s", amount, sale.ReceiptNumber),
+		CreatedByID: &userID,
+	}
+	if err := tx.Create(entry).Error; err != nil {
+		return 0, errors.NewInternalError("failed to record points redemption", err)
+	}
+
+	return points, nil
+}
+
+// earnLoyaltyPoints credits the customer of a paid sale with points for the part not paid with points
+func (s *Service) earnLoyaltyPoints(tx *gorm.DB, sale *pos.Sale, userID uint) error {
+	// Gift cards earn points when they are spent, not when they are bought
+	if sale.CustomerID == nil || sale.IsGiftCardSale() {
+		return nil
+	}
+
+	program := s.activeLoyaltyProgram(sale.CompanyID)
+	if program == nil {
+		return nil
+	}
+
+	var paidWithPoints float64
+	if err := tx.Model(&pos.Payment{}).
+		Where("sale_id = ? AND payment_method = ? AND payment_status = ?", sale.ID, pos.PaymentMethodLoyalty, pos.PaymentTransactionStatusCompleted).
+		Select("COALESCE(SUM(amount), 0)").
+		Row().Scan(&paidWithPoints); err != nil {
+		return errors.NewInternalError("failed to calculate points payments", err)
+	}
+
+	points := program.PointsEarned(roundCurrency(sale.TotalAmount - paidWithPoints))
+	if points <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	entry := &loyalty.Transaction{
+		CompanyID:       sale.CompanyID,
+		CustomerID:      *sale.CustomerID,
+		SaleID:          &sale.ID,
+		Type:            loyalty.TransactionTypeEarn,
+		Points:          points,
+		RemainingPoints: points,
+		ExpiresAt:       program.ExpiryFrom(now),
+		Notes:           fmt.Sprintf("Earned on sale #%s", sale.ReceiptNumber),
+		CreatedByID:     &userID,
+		CreatedAt:       now,
+	}
+	if err := tx.Create(entry).Error; err != nil {
+		return errors.NewInternalError("failed to record earned points", err)
+	}
+
+	return nil
+}
+
+// reverseLoyaltyPoints takes back the share of the points earned on a sale that matches
+// the refunded amount, or all of them once the sale is fully refunded
+func (s *Service) reverseLoyaltyPoints(tx *gorm.DB, sale *pos.Sale, refundID uint, refundAmount float64, fullyRefunded bool, userID uint) error {
+	if sale.CustomerID == nil || sale.TotalAmount <= 0 {
+		return nil
+	}
+
+	totals, err := saleLoyaltyTotals(tx, sale.ID)
+	if err != nil {
+		return err
+	}
+
+	outstanding := totals[loyalty.TransactionTypeEarn] + totals[loyalty.TransactionTypeReverse]
+	points := outstanding
+	if !fullyRefunded {
+		points = int(math.Round(float64(totals[loyalty.TransactionTypeEarn]) * refundAmount / sale.TotalAmount))
+		if points > outstanding {
+			points = outstanding
+		}
+	}
+
+	return s.takeBackLoyaltyPoints(tx, sale, &refundID, points, fmt.Sprintf("Refund on sale #%s", sale.ReceiptNumber), userID)
+}
+
+// restoreLoyaltyPoints gives a refund back to the customer as points worth amount
+func (s *Service) restoreLoyaltyPoints(tx *gorm.DB, sale *pos.Sale, refundID uint, amount float64, userID uint) error {
+	if sale.CustomerID == nil {
+		return errors.NewValidationError("only sales linked to a customer can be refunded in loyalty points")
+	}
+
+	program := s.activeLoyaltyProgram(sale.CompanyID)
+	if program == nil {
+		return errors.NewValidationError("company has no active loyalty program")
+	}
+
+	now := time.Now()
+	points := program.PointsFor(amount)
+	entry := &loyalty.Transaction{
+		CompanyID:       sale.CompanyID,
+		CustomerID:      *sale.CustomerID,
+		SaleID:          &sale.ID,
+		RefundID:        &refundID,
+		Type:            loyalty.TransactionTypeRestore,
+		Points:          points,
+		RemainingPoints: points,
+		ExpiresAt:       program.ExpiryFrom(now),
+		Notes:           fmt.Sprintf("Refund of %.2f on sale #%s", amount, sale.ReceiptNumber),
+		CreatedByID:     &userID,
+		CreatedAt:       now,
+	}
+	if err := tx.Create(entry).Error; err != nil {
+		return errors.NewInternalError("failed to record restored points", err)
+	}
+
+	return nil
+}
+
+// voidLoyaltyPoints gives back the points a voided sale was paid with and takes back those it earned
+func (s *Service) voidLoyaltyPoints(tx *gorm.DB, sale *pos.Sale, userID uint) error {
+	if sale.CustomerID == nil {
+		return nil
+	}
+
+	totals, err := saleLoyaltyTotals(tx, sale.ID)
+	if err != nil {
+		return err
+	}
+
+	notes := fmt.Sprintf("Void of sale #%s", sale.ReceiptNumber)
+
+	if redeemed := -totals[loyalty.TransactionTypeRedeem] - totals[loyalty.TransactionTypeRestore]; redeemed > 0 {
+		now := time.Now()
+		entry := &loyalty.Transaction{
+			CompanyID:       sale.CompanyID,
+			CustomerID:      *sale.CustomerID,
+			SaleID:          &sale.ID,
+			Type:            loyalty.TransactionTypeRestore,
+			Points:          redeemed,
+			RemainingPoints: redeemed,
+			Notes:           notes,
+			CreatedByID:     &userID,
+			CreatedAt:       now,
+		}
+		if program, err := s.loyaltyProgramRepo.FindByCompanyID(sale.CompanyID); err == nil {
+			entry.ExpiresAt = program.ExpiryFrom(now)
+		}
+		if err := tx.Create(entry).Error; err != nil {
+			return errors.NewInternalError("failed to record restored points", err)
+		}
+	}
+
+	earned := totals[loyalty.TransactionTypeEarn] + totals[loyalty.TransactionTypeReverse]
+	return s.takeBackLoyaltyPoints(tx, sale, nil, earned, notes, userID)
+}
+
+// takeBackLoyaltyPoints records the reversal of earned points. Points the customer has
+// already spent cannot be taken back, so the reversal never overdraws the balance.
+func (s *Service) takeBackLoyaltyPoints(tx *gorm.DB, sale *pos.Sale, refundID *uint, points int, notes string, userID uint) error {
+	if points <= 0 {
+		return nil
+	}
+
+	balance, err := lockLoyaltyBalance(tx, *sale.CustomerID)
+	if err != nil {
+		return err
+	}
+	if points > balance {
+		points = balance
+	}
+	if points <= 0 {
+		return nil
+	}
+
+	if err := consumeLoyaltyLots(tx, *sale.CustomerID, &sale.ID, points); err != nil {
+		return err
+	}
+
+	entry := &loyalty.Transaction{
+		CompanyID:   sale.CompanyID,
+		CustomerID:  *sale.CustomerID,
+		SaleID:      &sale.ID,
+		RefundID:    refundID,
+		Type:        loyalty.TransactionTypeReverse,
+		Points:      -points,
+		Notes:       notes,
+		CreatedByID: &userID,
+	}
+	if err := tx.Create(entry).Error; err != nil {
+		return errors.NewInternalError("failed to record reversed points", err)
+	}
+
+	return nil
+}
+
+// saleLoyaltyTotals sums the points recorded against a sale by entry type
+func saleLoyaltyTotals(tx *gorm.DB, saleID uint) (map[loyalty.TransactionType]int, error) {
+	var rows []struct {
+		Type   loyalty.TransactionType
+		Points int
+	}
+	if err := tx.Model(&loyalty.Transaction{}).
+		Select("type, COALESCE(SUM(points), 0) AS points").
+		Where("sale_id = ?", saleID).
+		Group("type").
+		Scan(&rows).Error; err != nil {
+		return nil, errors.NewInternalError("failed to fetch sale points", err)
+	}
+
+	totals := make(map[loyalty.TransactionType]int, len(rows))
+	for _, row := range rows {
+		totals[row.Type] = row.Points
+	}
+	return totals, nil
+}
+
+// lockLoyaltyBalance locks the customer so concurrent sales cannot spend the same points, and returns their balance.
+// Lots past their expiry only count for the points already used, even before the expiry worker writes them off.
+func lockLoyaltyBalance(tx *gorm.DB, customerID uint) (int, error) {
+	var customer pos.Customer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, customerID).Error; err != nil {
+		return 0, errors.NewInternalError("failed to lock customer", err)
+	}
+
+	var balance int
+	if err := tx.Model(&loyalty.Transaction{}).
+		Where("customer_id = ?", customerID).
+		Select("COALESCE(SUM(CASE WHEN expires_at IS NULL OR expires_at > ? THEN points ELSE points - remaining_points END), 0)", time.Now()).
+		Row().Scan(&balance); err != nil {
+		return 0, errors.NewInternalError("failed to calculate points balance", err)
+	}
+
+	return balance, nil
+}
+
+// consumeLoyaltyLots draws points from the customer's unused, unexpired lots: those of saleID first,
+// then the ones expiring soonest, then the oldest
+func consumeLoyaltyLots(tx *gorm.DB, customerID uint, saleID *uint, points int) error {
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("customer_id = ? AND remaining_points > 0", customerID).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now())
+	if saleID != nil {
+		query = query.Order(fmt.Sprintf("CASE WHEN sale_id = %d THEN 0 ELSE 1 END", *saleID))
+	}
+
+	var lots []loyalty.Transaction
+	if err := query.Order("expires_at ASC NULLS LAST, created_at ASC, id ASC").Find(&lots).Error; err != nil {
+		return errors.NewInternalError("failed to fetch points lots", err)
+	}
+
+	for i := range lots {
+		if points <= 0 {
+			break
+		}
+		used := min(points, lots[i].RemainingPoints)
+		if err := tx.Model(&lots[i]).Update("remaining_points", lots[i].RemainingPoints-used).Error; err != nil {
+			return errors.NewInternalError("failed to update points lot", err)
+		}
+		points -= used
+	}
+
+	return nil
+}
+
+// lockVoucher loads a voucher of the company for update, so concurrent sales cannot spend the same balance
+func lockVoucher(tx *gorm.DB, companyID uint, query string, arg interface{}) (*voucher.Voucher, error) {
+	var v voucher.Voucher
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("company_id = ?", companyID).
+		Where(query, arg).
+		First(&v).Error
+	if err != nil {
+		return nil, errors.NewNotFoundError("voucher not found")
+	}
+	return &v, nil
+}
+
+// redeemVoucher takes amount off the balance of the voucher with the given code and returns the stored code
+func (s *Service) redeemVoucher(tx *gorm.DB, sale *pos.Sale, code string, amount float64, userID uint) (string, error) {
+	code = voucher.NormalizeCode(code)
+	if code == "" {
+		return "", errors.NewValidationError("voucher payments need the voucher code as reference")
+	}
+
+	v, err := lockVoucher(tx, sale.CompanyID, "code = ?", code)
+	if err != nil {
+		return "", err
+	}
+	if sale.VoucherID != nil && *sale.VoucherID == v.ID {
+		return "", errors.NewValidationError("a gift card cannot pay for itself")
+	}
+	if !v.IsActive {
+		return "", errors.NewValidationError("voucher is not active")
+	}
+	if v.IsExpired(time.Now()) {
+		return "", errors.NewValidationError("voucher has expired")
+	}
+	if !v.IsValidFor(sale.FranchiseID) {
+		return "", errors.NewValidationError("voucher is not valid in this franchise")
+	}
+	if !v.Redeem(amount) {
+		return "", errors.NewValidationError(fmt.Sprintf("voucher balance of %.2f is too low", v.Balance))
+	}
+
+	if err := tx.Save(v).Error; err != nil {
+		return "", errors.NewInternalError("failed to update voucher", err)
+	}
+
+	entry := &voucher.Transaction{
+		VoucherID:    v.ID,
+		Type:         voucher.TransactionTypeRedeem,
+		Amount:       -amount,
+		BalanceAfter: v.Balance,
+		SaleID:       &sale.ID,
+		CreatedByID:  &userID,
+	}
+	if err := tx.Create(entry).Error; err != nil {
+		return "", errors.NewInternalError("failed to record voucher redemption", err)
+	}
+
+	return v.Code, nil
+}
+
+// voidSaleVouchers gives back what vouchers paid on a voided sale, and cancels the gift card
+// the sale sold as long as none of it has been spent
+func (s *Service) voidSaleVouchers(tx *gorm.DB, sale *pos.Sale, userID uint) error {
+	var entries []voucher.Transaction
+	err := tx.Where("sale_id = ? AND type IN ?", sale.ID, []voucher.TransactionType{voucher.TransactionTypeRedeem, voucher.TransactionTypeRestore}).
+		Order("voucher_id").
+		Find(&entries).Error
+	if err != nil {
+		return errors.NewInternalError("failed to fetch voucher redemptions", err)
+	}
+
+	// Net what each voucher paid, keeping voucher order so locks are always taken the same way
+	voucherIDs := []uint{}
+	paid := make(map[uint]float64)
+	for _, entry := range entries {
+		if _, seen := paid[entry.VoucherID]; !seen {
+			voucherIDs = append(voucherIDs, entry.VoucherID)
+		}
+		paid[entry.VoucherID] -= entry.Amount
+	}
+
+	for _, voucherID := range voucherIDs {
+		amount := roundCurrency(paid[voucherID])
+		if amount <= 0 {
+			continue
+		}
+
+		v, err := lockVoucher(tx, sale.CompanyID, "id = ?", voucherID)
+		if err != nil {
+			return err
+		}
+		v.Restore(amount)
+		if err := tx.Save(v).Error; err != nil {
+			return errors.NewInternalError("failed to update voucher", err)
+		}
+
+		entry := &voucher.Transaction{
+			VoucherID:    v.ID,
+			Type:         voucher.TransactionTypeRestore,
+			Amount:       amount,
+			BalanceAfter: v.Balance,
+			SaleID:       &sale.ID,
+			CreatedByID:  &userID,
+		}
+		if err := tx.Create(entry).Error; err != nil {
+			return errors.NewInternalError("failed to record voucher restore", err)
+		}
+	}
+
+	if !sale.IsGiftCardSale() {
+		return nil
+	}
+
+	giftCard, err := lockVoucher(tx, sale.CompanyID, "id = ?", *sale.VoucherID)
+	if err != nil {
+		return err
+	}
+	if giftCard.IsUsed() {
+		return errors.NewValidationError("the gift card sold on this sale has already been used")
+	}
+
+	entry := &voucher.Transaction{
+		VoucherID:    giftCard.ID,
+		Type:         voucher.TransactionTypeCancel,
+		Amount:       -giftCard.Balance,
+		BalanceAfter: 0,
+		SaleID:       &sale.ID,
+		CreatedByID:  &userID,
+	}
+	giftCard.Balance = 0
+	giftCard.IsActive = false
+	if err := tx.Save(giftCard).Error; err != nil {
+		return errors.NewInternalError("failed to cancel gift card", err)
+	}
+	if err := tx.Create(entry).Error; err != nil {
+		return errors.NewInternalError("failed to record gift card cancellation", err)
+	}
+
+	return nil
+}
+
+// issueStoreCredit gives the refund amount back as a store credit voucher, valid in every franchise
+func (s *Service) issueStoreCredit(tx *gorm.DB, sale *pos.Sale, refund *pos.Refund, userID uint) error {
+	code, err := voucher.GenerateCode()
+	if err != nil {
+		return errors.NewInternalError("failed to generate voucher code", err)
+	}
+
+	credit := &voucher.Voucher{
+		CompanyID:     sale.CompanyID,
+		Code:          code,
+		Type:          voucher.VoucherTypeStoreCredit,
+		InitialAmount: refund.RefundAmount,
+		Balance:       refund.RefundAmount,
+		CustomerID:    sale.CustomerID,
+		RefundID:      &refund.ID,
+		IsActive:      true,
+		Notes:         fmt.Sprintf("Refund of sale #%s", sale.ReceiptNumber),
+		CreatedByID:   userID,
+	}
+	if !credit.IsValid() {
+		return errors.NewValidationError("invalid store credit data")
+	}
+	if err := tx.Create(credit).Error; err != nil {
+		return errors.NewInternalError("failed to create store credit", err)
+	}
+
+	entry := &voucher.Transaction{
+		VoucherID:    credit.ID,
+		Type:         voucher.TransactionTypeIssue,
+		Amount:       credit.InitialAmount,
+		BalanceAfter: credit.Balance,
+		RefundID:     &refund.ID,
+		CreatedByID:  &userID,
+	}
+	if err := tx.Create(entry).Error; err != nil {
+		return errors.NewInternalError("failed to record store credit issue", err)
+	}
+
+	refund.VoucherID = &credit.ID
+	refund.VoucherCode = credit.Code
+	if err := tx.Model(refund).Updates(map[string]interface{}{"voucher_id": credit.ID, "voucher_code": credit.Code}).Error; err != nil {
+		return errors.NewInternalError("failed to update refund", err)
+	}
+
+	return nil
+}
+
+// variantAttributes decodes the attributes of a variant, e.g. {"color": "red"}
+func variantAttributes(variant *product.ProductVariant) map[string]interface{} {
+	var attributes map[string]interface{}
+	if len(variant.Attributes) > 0 {
+		json.Unmarshal(variant.Attributes, &attributes)
+	}
+	return attributes
+}
+
+// findInventory returns the franchise inventory when a franchise is given, otherwise the company inventory
+func (s *Service) findInventory(companyID uint, franchiseID *uint, variantID uint) (*inventory.Inventory, error) {
+	if franchiseID != nil {
+		return s.inventoryRepo.FindByVariantAndFranchise(variantID, *franchiseID)
+	}
+	return s.inventoryRepo.FindByVariantAndCompany(variantID, companyID)
+}
+
+// deductSaleInventory removes sold quantities from stock and records sale movements
+func (s *Service) deductSaleInventory(tx *gorm.DB, companyID uint, franchiseID *uint, items []pos.SaleItem, referenceType, referenceID string, userID uint) error {
+	for _, item := range items {
+		inv, err := s.findInventoryTx(tx, companyID, franchiseID, item.ProductVariantID)
+		if err != nil {
+			return errors.NewInternalError("failed to fetch inventory", err)
+		}
+
+		previousStock := inv.Stock
+		if !inv.RemoveStock(item.Quantity) {
+			return errors.NewValidationError(fmt.Sprintf("insufficient stock for variant %d", item.ProductVariantID))
+		}
+
+		if err := tx.Save(inv).Error; err != nil {
+			return errors.NewInternalError("failed to update inventory", err)
+		}
+
+		// Create inventory movement
+		movement := &inventory.InventoryMovement{
+			InventoryID:   inv.ID,
+			MovementType:  inventory.MovementTypeSale,
+			Quantity:      -item.Quantity,
+			PreviousStock: previousStock,
+			NewStock:      inv.Stock,
+			ReferenceType: stringPtr(referenceType),
+			ReferenceID:   &referenceID,
+			CreatedByID:   userID,
+		}
+
+		if err := tx.Create(movement).Error; err != nil {
+			return errors.NewInternalError("failed to create inventory movement", err)
+		}
+	}
+
+	return nil
+}
+
+// deductOfflineSaleInventory removes the quantities of an offline sale from stock, even when they
+// are no longer available since the device could not know; each shortfall is returned as a conflict
+func (s *Service) deductOfflineSaleInventory(tx *gorm.DB, sale *pos.Sale, items []pos.SaleItem, userID uint) ([]pos.SyncConflict, error) {
+	conflicts := []pos.SyncConflict{}
+	referenceID := fmt.Sprintf("%d", sale.ID)
+	for _, item := range items {
+		inv, err := s.findInventoryTx(tx, sale.CompanyID, sale.FranchiseID, item.ProductVariantID)
+		if err != nil {
+			return nil, errors.NewValidationError(fmt.Sprintf("no inventory for product variant %d", item.ProductVariantID))
+		}
+
+		previousStock := inv.Stock
+		available := inv.GetAvailableStock()
+		if shortfall := inv.ForceRemoveStock(item.Quantity); shortfall > 0 {
+			variantID := item.ProductVariantID
+			conflicts = append(conflicts, pos.SyncConflict{
+				Type:             pos.SyncConflictNegativeStock,
+				ProductVariantID: &variantID,
+				Expected:         float64(available),
+				Actual:           float64(item.Quantity),
+				Message:          fmt.Sprintf("%d units of variant %d were sold but only %d were available", item.Quantity, item.ProductVariantID, available),
+			})
+		}
+
+		if err := tx.Save(inv).Error; err != nil {
+			return nil, errors.NewInternalError("failed to update inventory", err)
+		}
+
+		movement := &inventory.InventoryMovement{
+			InventoryID:   inv.ID,
+			MovementType:  inventory.MovementTypeSale,
+			Quantity:      -item.Quantity,
+			PreviousStock: previousStock,
+			NewStock:      inv.Stock,
+			ReferenceType: stringPtr("sale"),
+			ReferenceID:   &referenceID,
+			Notes:         stringPtr("Offline sale " + sale.ReceiptNumber),
+			CreatedByID:   userID,
+		}
+		if err := tx.Create(movement).Error; err != nil {
+			return nil, errors.NewInternalError("failed to create inventory movement", err)
+		}
+	}
+
+	return conflicts, nil
+}
+
+// findInventoryTx loads and locks an inventory row inside the transaction, so that its stock
+// is not changed by another sale before the transaction saves it
+func (s *Service) findInventoryTx(tx *gorm.DB, companyID uint, franchiseID *uint, variantID uint) (*inventory.Inventory, error) {
+	var inv inventory.Inventory
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("product_variant_id = ?", variantID)
+	if franchiseID != nil {
+		query = query.Where("franchise_id = ?", *franchiseID)
+	} else {
+		query = query.Where("company_id = ?", companyID)
+	}
+
+	if err := query.First(&inv).Error; err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// saleItemQuantities sums the quantities of sale lines per variant
+func saleItemQuantities(items []pos.SaleItem) map[uint]int {
+	quantities := make(map[uint]int)
+	for _, item := range items {
+		quantities[item.ProductVariantID] += item.Quantity
+	}
+	return quantities
+}
+
+// adjustSaleReservations moves the stock reserved for a draft sale (parked cart or layaway)
+// from the held quantities to the wanted ones, reserving or releasing the difference per variant
+func (s *Service) adjustSaleReservations(tx *gorm.DB, companyID uint, franchiseID *uint, saleID uint, held, wanted map[uint]int, refType string, userID uint) error {
+	variantIDs := make([]uint, 0, len(held)+len(wanted))
+	for variantID := range held {
+		variantIDs = append(variantIDs, variantID)
+	}
+	for variantID := range wanted {
+		if _, exists := held[variantID]; !exists {
+			variantIDs = append(variantIDs, variantID)
+		}
+	}
+	// Lock inventory rows in a stable order
+	sort.Slice(variantIDs, func(i, j int) bool { return variantIDs[i] < variantIDs[j] })
+
+	refID := fmt.Sprintf("%d", saleID)
+	for _, variantID := range variantIDs {
+		delta := wanted[variantID] - held[variantID]
+		if delta == 0 {
+			continue
+		}
+
+		inv, err := s.findInventoryTx(tx, companyID, franchiseID, variantID)
+		if err != nil {
+			return errors.NewInternalError(fmt.Sprintf("failed to fetch inventory for variant %d", variantID), err)
+		}
+
+		previousReservedStock := inv.ReservedStock
+		movementType := inventory.MovementTypeReserve
+		quantity := delta
+		if delta > 0 {
+			if !inv.ReserveStock(delta) {
+				return errors.NewValidationError(fmt.Sprintf("insufficient stock to hold variant %d", variantID))
+			}
+		} else {
+			movementType = inventory.MovementTypeRelease
+			quantity = -delta
+			inv.ReleaseStock(quantity)
+		}
+
+		if err := tx.Save(inv).Error; err != nil {
+			return errors.NewInternalError("failed to update inventory", err)
+		}
+
+		// Create inventory movement to track the reservation
+		movement := &inventory.InventoryMovement{
+			InventoryID:   inv.ID,
+			MovementType:  movementType,
+			Quantity:      quantity,
+			PreviousStock: previousReservedStock,
+			NewStock:      inv.ReservedStock,
+			ReferenceType: &refType,
+			ReferenceID:   &refID,
+			CreatedByID:   userID,
+		}
+		if err := tx.Create(movement).Error; err != nil {
+			return errors.NewInternalError("failed to create inventory movement", err)
+		}
+	}
+
+	return nil
+}
+
+// fulfillSaleReservations turns the stock reserved for a parked sale into sale movements
+func (s *Service) fulfillSaleReservations(tx *gorm.DB, sale *pos.Sale, userID uint) error {
+	saleIDStr := fmt.Sprintf("%d", sale.ID)
+	for _, item := range sale.Items {
+		inv, err := s.findInventoryTx(tx, sale.CompanyID, sale.FranchiseID, item.ProductVariantID)
+		if err != nil {
+			return errors.NewInternalError("failed to fetch inventory", err)
+		}
+
+		previousStock := inv.Stock
+		if !inv.FulfillReservation(item.Quantity) {
+			return errors.NewValidationError(fmt.Sprintf("the stock held for variant %d is no longer reserved", item.ProductVariantID))
+		}
+		if !inv.RemoveStock(item.Quantity) {
+			return errors.NewValidationError(fmt.Sprintf("insufficient stock for variant %d", item.ProductVariantID))
+		}
+
+		if err := tx.Save(inv).Error; err != nil {
+			return errors.NewInternalError("failed to update inventory", err)
+		}
+
+		// Create inventory movement
+		movement := &inventory.InventoryMovement{
+			InventoryID:   inv.ID,
+			MovementType:  inventory.MovementTypeSale,
+			Quantity:      -item.Quantity,
+			PreviousStock: previousStock,
+			NewStock:      inv.Stock,
+			ReferenceType: stringPtr("sale"),
+			ReferenceID:   &saleIDStr,
+			CreatedByID:   userID,
+		}
+
+		if err := tx.Create(movement).Error; err != nil {
+			return errors.NewInternalError("failed to create inventory movement", err)
+		}
+	}
+
+	return nil
+}
+
+// restockRefundItems puts returned quantities back into stock and records return movements
+func (s *Service) restockRefundItems(tx *gorm.DB, companyID uint, franchiseID *uint, items []pos.RefundItem, referenceType, referenceID string, userID uint) error {
+	for _, item := range items {
+		inv, err := s.findInventoryTx(tx, companyID, franchiseID, item.ProductVariantID)
+		if err != nil {
+			return errors.NewInternalError("failed to fetch inventory", err)
+		}
+
+		previousStock := inv.Stock
+		inv.AddStock(item.Quantity)
+
+		if err := tx.Save(inv).Error; err != nil {
+			return errors.NewInternalError("failed to update inventory", err)
+		}
+
+		// Create inventory movement
+		movement := &inventory.InventoryMovement{
+			InventoryID:   inv.ID,
+			MovementType:  inventory.MovementTypeReturn,
+			Quantity:      item.Quantity,
+			PreviousStock: previousStock,
+			NewStock:      inv.Stock,
+			ReferenceType: stringPtr(referenceType),
+			ReferenceID:   &referenceID,
+			CreatedByID:   userID,
+		}
+
+		if err := tx.Create(movement).Error; err != nil {
+			return errors.NewInternalError("failed to create inventory movement", err)
+		}
+	}
+
+	return nil
+}
+
+// resolveRetailPrice returns the price the POS charges for a variant, applying
+// franchise pricing, then the variant price, then the product base price
+func (s *Service) resolveRetailPrice(companyID uint, variant *product.ProductVariant, franchiseID *uint) (float64, *product.Product, error) {
+	product, err := s.findVariantProduct(companyID, variant)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	price := variant.GetEffectiveRetailPrice(product.BaseRetailPrice)
+
+	if franchiseID != nil {
+		franchisePricing, err := s.franchiseRepo.FindPricing(*franchiseID, variant.ID)
+		if err == nil && franchisePricing != nil && franchisePricing.IsActive {
+			if override := franchisePricing.GetEffectiveRetailPrice(); override != nil {
+				price = *override
+			}
+		}
+	}
+
+	return price, product, nil
+}
+
+// resolveWholesalePrice returns the price a wholesale sale charges for a quantity of a
+// variant: franchise wholesale pricing, then the variant price, then the product base
+// price, lowered by the product's quantity break for that many units
+func (s *Service) resolveWholesalePrice(companyID uint, variant *product.ProductVariant, franchiseID *uint, quantity int) (float64, *product.Product, error) {
+	product, err := s.findVariantProduct(companyID, variant)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	price := variant.GetEffectiveWholesalePrice(product.BaseWholesalePrice)
+
+	if franchiseID != nil {
+		franchisePricing, err := s.franchiseRepo.FindPricing(*franchiseID, variant.ID)
+		if err == nil && franchisePricing != nil && franchisePricing.IsActive {
+			if override := franchisePricing.GetEffectiveWholesalePrice(); override != nil {
+				price = *override
+			}
+		}
+	}
+
+	// A quantity break never raises the price, e.g. against a lower franchise price
+	if tier := product.GetWholesaleTier(variant.ID, quantity); tier != nil && (price <= 0 || tier.UnitPrice < price) {
+		price = tier.UnitPrice
+	}
+
+	if price <= 0 {
+		return 0, nil, errors.NewValidationError(fmt.Sprintf("variant %d (SKU: %s) has no wholesale price", variant.ID, variant.SKU))
+	}
+
+	return price, product, nil
+}
+
+// findVariantProduct loads the product of a variant, checking it belongs to the company
+func (s *Service) findVariantProduct(companyID uint, variant *product.ProductVariant) (*product.Product, error) {
+	product, err := s.productVariantRepo.FindProductByID(variant.ProductID)
+	if err != nil {
+		return nil, errors.NewNotFoundError(fmt.Sprintf("product for variant %d not found", variant.ID))
+	}
+	if product.CompanyID != companyID {
+		return nil, errors.NewForbiddenError(fmt.Sprintf("product variant %d does not belong to this company", variant.ID))
+	}
+	return product, nil
+}
+
+// checkWholesaleCustomer ensures a wholesale sale is made to a business customer
+func (s *Service) checkWholesaleCustomer(customerID *uint) error {
+	if customerID == nil {
+		return errors.NewValidationError("a wholesale sale needs a business customer")
+	}
+
+	customer, err := s.customerRepo.FindByID(*customerID)
+	if err != nil {
+		return errors.NewNotFoundError("customer not found")
+	}
+	if !customer.IsBusiness {
+		return errors.NewValidationError(fmt.Sprintf("customer %s is not a business customer and cannot buy at wholesale prices", customer.Name))
+	}
+	return nil
+}
+
+// checkWholesaleMinimumOrder ensures the lines of a wholesale sale reach the company's
+// minimum order amount
+func (s *Service) checkWholesaleMinimumOrder(companyID uint, items []pos.SaleItem) error {
+	var c company.Company
+	if err := s.db.First(&c, companyID).Error; err != nil {
+		return errors.NewNotFoundError("company not found")
+	}
+	if c.WholesaleMinOrder <= 0 {
+		return nil
+	}
+
+	total := 0.0
+	for _, item := range items {
+		total += item.TotalAmount
+	}
+	if total = roundCurrency(total); total < c.WholesaleMinOrder {
+		return errors.NewValidationError(fmt.Sprintf("wholesale orders must total at least %.2f, this one totals %.2f", c.WholesaleMinOrder, total))
+	}
+	return nil
+}
+
+// saleTaxes holds the tax configuration of a company while pricing a sale
+type saleTaxes struct {
+	pricesIncludeTax bool
+	defaultClass     *tax.TaxClass
+	classes          map[uint]*tax.TaxClass
+	taxRepo          tax.Repository
+	companyID        uint
+}
+
+// loadSaleTaxes reads whether the company's prices include tax and its default tax class
+func (s *Service) loadSaleTaxes(companyID uint) (*saleTaxes, error) {
+	var c company.Company
+	if err := s.db.First(&c, companyID).Error; err != nil {
+		return nil, errors.NewNotFoundError("company not found")
+	}
+
+	taxes := &saleTaxes{
+		pricesIncludeTax: c.PricesIncludeTax,
+		classes:          make(map[uint]*tax.TaxClass),
+		taxRepo:          s.taxRepo,
+		companyID:        companyID,
+	}
+	if defaultClass, err := s.taxRepo.FindDefaultByCompanyID(companyID); err == nil {
+		taxes.defaultClass = defaultClass
+	}
+
+	return taxes, nil
+}
+
+// classFor returns the tax class charged on a product: its own class, else the
+// company default. A nil class means the product is not taxed.
+func (t *saleTaxes) classFor(p *product.Product) (*tax.TaxClass, error) {
+	if p.TaxClassID == nil {
+		return t.defaultClass, nil
+	}
+	if taxClass, ok := t.classes[*p.TaxClassID]; ok {
+		return taxClass, nil
+	}
+
+	taxClass, err := t.taxRepo.FindByIDAndCompany(*p.TaxClassID, t.companyID)
+	if err != nil {
+		return nil, errors.NewNotFoundError(fmt.Sprintf("tax class of product %d not found", p.ID))
+	}
+	if !taxClass.IsActive {
+		return nil, errors.NewValidationError(fmt.Sprintf("tax class %s of product %s is not active", taxClass.Name, p.SKU))
+	}
+	t.classes[taxClass.ID] = taxClass
+	return taxClass, nil
+}
+
+// resolveApprover returns the ID of the manager (or above) approving an action such as a price override.
+// A cashier who is a manager approves their own actions; anyone else needs an approver and their PIN.
+// Every wrong approval gets the same answer and counts against the cashier, who is locked out of
+// approvals for a while after too many in a row, so that PINs cannot be guessed.
+func (s *Service) resolveApprover(cashierID, companyID uint, franchiseID *uint, approval *ManagerApproval, action string) (uint, error) {
+	if approval == nil || (approval.ApproverID == nil && approval.PIN == "") {
+		if s.hasManagerRole(cashierID, companyID, franchiseID) {
+			return cashierID, nil
+		}
+		return 0, errors.NewForbiddenError(fmt.Sprintf("%s requires manager approval", action))
+	}
+
+	if approval.ApproverID == nil {
+		return 0, errors.NewValidationError("select the approving manager along with their PIN")
+	}
+
+	approverID := *approval.ApproverID
+	if approverID == cashierID {
+		if !s.hasManagerRole(approverID, companyID, franchiseID) {
+			return 0, errors.NewForbiddenError("approver must be a manager or above")
+		}
+		return approverID, nil
+	}
+
+	cashier, err := s.userRepo.FindByID(cashierID)
+	if err != nil {
+		return 0, errors.NewNotFoundError("user not found")
+	}
+	now := time.Now()
+	if cashier.IsPOSPinLocked(now) {
+		return 0, errors.NewForbiddenError(fmt.Sprintf("too many wrong approvals, try again after %s", cashier.POSPinLockedUntil.Format("15:04")))
+	}
+
+	approver, err := s.userRepo.FindByID(approverID)
+	if err != nil || !approver.IsActive || !approver.CheckPOSPin(approval.PIN) || !s.hasManagerRole(approverID, companyID, franchiseID) {
+		if err := s.recordApprovalFailure(cashierID, now); err != nil {
+			return 0, err
+		}
+		return 0, errors.NewForbiddenError("invalid approver credentials")
+	}
+
+	if cashier.POSPinFailures > 0 {
+		if err := s.db.Model(&user.User{}).Where("id = ?", cashierID).Update("pos_pin_failures", 0).Error; err != nil {
+			return 0, errors.NewInternalError("failed to reset approval failures", err)
+		}
+	}
+	return approverID, nil
+}
+
+// recordApprovalFailure counts a wrong approval against the cashier, locking them out of
+// approvals once they reach user.MaxPOSPinFailures in a row
+func (s *Service) recordApprovalFailure(cashierID uint, now time.Time) error {
+	reached := fmt.Sprintf("pos_pin_failures + 1 >= %d", user.MaxPOSPinFailures)
+	err := s.db.Model(&user.User{}).Where("id = ?", cashierID).Updates(map[string]interface{}{
+		"pos_pin_failures":     gorm.Expr("CASE WHEN " + reached + " THEN 0 ELSE pos_pin_failures + 1 END"),
+		"pos_pin_locked_until": gorm.Expr("CASE WHEN "+reached+" THEN ? ELSE pos_pin_locked_until END", now.Add(user.POSPinLockout)),
+	}).Error
+	if err != nil {
+		return errors.NewInternalError("failed to record approval failure", err)
+	}
+	return nil
+}
+
+// hasManagerRole checks if a user is a manager or above in the company, or in the franchise when given
+func (s *Service) hasManagerRole(userID, companyID uint, franchiseID *uint) bool {
+	if s.checkUserCompanyAccess(userID, companyID, user.RoleManager) == nil {
+		return true
+	}
+	return franchiseID != nil && s.checkUserFranchiseAccess(userID, *franchiseID, user.RoleManager) == nil
+}
+
+// roundCurrency rounds an amount to cents
+func roundCurrency(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// isPriceOverride checks if a requested price differs from the resolved price by at least one cent
+func isPriceOverride(requestedPrice, listPrice float64) bool {
+	return math.Abs(requestedPrice-listPrice) >= 0.005
 }
 
 func stringPtr(s string) *string {
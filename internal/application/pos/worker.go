@@ -0,0 +1,125 @@
+package pos
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// ParkedSaleWorker periodically releases the stock held by abandoned parked sales
+type ParkedSaleWorker struct {
+	service  *Service
+	interval time.Duration
+	ttl      time.Duration
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// NewParkedSaleWorker creates a new parked sale worker; sales parked longer than ttl are discarded
+func NewParkedSaleWorker(service *Service, interval, ttl time.Duration) *ParkedSaleWorker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ParkedSaleWorker{
+		service:  service,
+		interval: interval,
+		ttl:      ttl,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start starts the worker
+func (w *ParkedSaleWorker) Start() {
+	go w.run()
+}
+
+// Stop stops the worker
+func (w *ParkedSaleWorker) Stop() {
+	w.cancel()
+}
+
+func (w *ParkedSaleWorker) run() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	log.Println("Parked sale worker started")
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			log.Println("Parked sale worker stopped")
+			return
+		case <-ticker.C:
+			w.releaseAbandoned()
+		}
+	}
+}
+
+func (w *ParkedSaleWorker) releaseAbandoned() {
+	released, err := w.service.ReleaseAbandonedParkedSales(time.Now().Add(-w.ttl))
+	if err != nil {
+		log.Printf("Failed to release abandoned parked sales: %v", err)
+		return
+	}
+
+	if released > 0 {
+		log.Printf("Released %d abandoned parked sale(s)", released)
+	}
+}
+
+// LayawayExpiryWorker periodically releases the stock held by layaways that were not picked up in time
+type LayawayExpiryWorker struct {
+	service  *Service
+	interval time.Duration
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// NewLayawayExpiryWorker creates a new layaway expiry worker
+func NewLayawayExpiryWorker(service *Service, interval time.Duration) *LayawayExpiryWorker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &LayawayExpiryWorker{
+		service:  service,
+		interval: interval,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start starts the worker
+func (w *LayawayExpiryWorker) Start() {
+	go w.run()
+}
+
+// Stop stops the worker
+func (w *LayawayExpiryWorker) Stop() {
+	w.cancel()
+}
+
+func (w *LayawayExpiryWorker) run() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	log.Println("Layaway expiry worker started")
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			log.Println("Layaway expiry worker stopped")
+			return
+		case <-ticker.C:
+			w.expireLayaways()
+		}
+	}
+}
+
+func (w *LayawayExpiryWorker) expireLayaways() {
+	released, err := w.service.ExpireLayaways(time.Now())
+	if err != nil {
+		log.Printf("Failed to expire layaways: %v", err)
+		return
+	}
+
+	if released > 0 {
+		log.Printf("Released %d expired layaway(s)", released)
+	}
+}
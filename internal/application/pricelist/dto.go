@@ -0,0 +1,135 @@
+package pricelist
+
+import (
+	"time"
+
+	"github.com/YasserCherfaoui/darween/internal/domain/pricelist"
+)
+
+// Customer group DTOs
+
+type CreateCustomerGroupRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description"`
+}
+
+type UpdateCustomerGroupRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Description *string `json:"description"`
+	IsActive    *bool   `json:"is_active"`
+}
+
+type CustomerGroupResponse struct {
+	ID          uint      `json:"id"`
+	CompanyID   uint      `json:"company_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	IsActive    bool      `json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func ToCustomerGroupResponse(g *pricelist.CustomerGroup) *CustomerGroupResponse {
+	return &CustomerGroupResponse{
+		ID:          g.ID,
+		CompanyID:   g.CompanyID,
+		Name:        g.Name,
+		Description: g.Description,
+		IsActive:    g.IsActive,
+		CreatedAt:   g.CreatedAt,
+		UpdatedAt:   g.UpdatedAt,
+	}
+}
+
+// Price list DTOs
+
+type PriceListItemRequest struct {
+	ProductID        *uint   `json:"product_id"`         // Every variant of the product
+	ProductVariantID *uint   `json:"product_variant_id"` // One variant; with neither set, every product
+	Type             string  `json:"type" binding:"required,oneof=percentage fixed_price"`
+	Value            float64 `json:"value" binding:"gt=0"` // Percentage off, or the price charged
+}
+
+type CreatePriceListRequest struct {
+	CustomerGroupID uint                   `json:"customer_group_id" binding:"required"`
+	FranchiseID     *uint                  `json:"franchise_id"`
+	Name            string                 `json:"name" binding:"required"`
+	Description     string                 `json:"description"`
+	StartsAt        *time.Time             `json:"starts_at"`
+	EndsAt          *time.Time             `json:"ends_at"`
+	Items           []PriceListItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type UpdatePriceListRequest struct {
+	Name        *string                `json:"name" binding:"omitempty,min=1"`
+	Description *string                `json:"description"`
+	StartsAt    *time.Time             `json:"starts_at"`
+	EndsAt      *time.Time             `json:"ends_at"`
+	IsActive    *bool                  `json:"is_active"`
+	Items       []PriceListItemRequest `json:"items" binding:"omitempty,min=1,dive"` // Replaces every item when set
+}
+
+type PriceListItemResponse struct {
+	ID               uint    `json:"id"`
+	ProductID        *uint   `json:"product_id,omitempty"`
+	ProductVariantID *uint   `json:"product_variant_id,omitempty"`
+	Type             string  `json:"type"`
+	Value            float64 `json:"value"`
+}
+
+type PriceListResponse struct {
+	ID              uint                    `json:"id"`
+	CompanyID       uint                    `json:"company_id"`
+	CustomerGroupID uint                    `json:"customer_group_id"`
+	FranchiseID     *uint                   `json:"franchise_id,omitempty"`
+	Name            string                  `json:"name"`
+	Description     string                  `json:"description"`
+	StartsAt        *time.Time              `json:"starts_at,omitempty"`
+	EndsAt          *time.Time              `json:"ends_at,omitempty"`
+	IsActive        bool                    `json:"is_active"`
+	Items           []PriceListItemResponse `json:"items"`
+	CreatedByID     uint                    `json:"created_by_id"`
+	CreatedAt       time.Time               `json:"created_at"`
+	UpdatedAt       time.Time               `json:"updated_at"`
+}
+
+func ToPriceListItems(items []PriceListItemRequest) []pricelist.PriceListItem {
+	result := make([]pricelist.PriceListItem, len(items))
+	for i, item := range items {
+		result[i] = pricelist.PriceListItem{
+			ProductID:        item.ProductID,
+			ProductVariantID: item.ProductVariantID,
+			Type:             pricelist.PriceType(item.Type),
+			Value:            item.Value,
+		}
+	}
+	return result
+}
+
+func ToPriceListResponse(l *pricelist.PriceList) *PriceListResponse {
+	response := &PriceListResponse{
+		ID:              l.ID,
+		CompanyID:       l.CompanyID,
+		CustomerGroupID: l.CustomerGroupID,
+		FranchiseID:     l.FranchiseID,
+		Name:            l.Name,
+		Description:     l.Description,
+		StartsAt:        l.StartsAt,
+		EndsAt:          l.EndsAt,
+		IsActive:        l.IsActive,
+		Items:           make([]PriceListItemResponse, len(l.Items)),
+		CreatedByID:     l.CreatedByID,
+		CreatedAt:       l.CreatedAt,
+		UpdatedAt:       l.UpdatedAt,
+	}
+	for i, item := range l.Items {
+		response.Items[i] = PriceListItemResponse{
+			ID:               item.ID,
+			ProductID:        item.ProductID,
+			ProductVariantID: item.ProductVariantID,
+			Type:             string(item.Type),
+			Value:            item.Value,
+		}
+	}
+	return response
+}
@@ -0,0 +1,335 @@
+package pricelist
+
+import (
+	"github.com/YasserCherfaoui/darween/internal/domain/franchise"
+	"github.com/YasserCherfaoui/darween/internal/domain/pricelist"
+	"github.com/YasserCherfaoui/darween/internal/domain/product"
+	"github.com/YasserCherfaoui/darween/internal/domain/user"
+	"github.com/YasserCherfaoui/darween/pkg/errors"
+)
+
+type Service struct {
+	groupRepo     pricelist.CustomerGroupRepository
+	priceListRepo pricelist.PriceListRepository
+	userRepo      user.Repository
+	productRepo   product.Repository
+	franchiseRepo franchise.Repository
+}
+
+func NewService(groupRepo pricelist.CustomerGroupRepository, priceListRepo pricelist.PriceListRepository, userRepo user.Repository, productRepo product.Repository, franchiseRepo franchise.Repository) *Service {
+	return &Service{
+		groupRepo:     groupRepo,
+		priceListRepo: priceListRepo,
+		userRepo:      userRepo,
+		productRepo:   productRepo,
+		franchiseRepo: franchiseRepo,
+	}
+}
+
+// Customer group operations
+
+func (s *Service) CreateCustomerGroup(userID, companyID uint, req *CreateCustomerGroupRequest) (*CustomerGroupResponse, error) {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleManager); err != nil {
+		return nil, err
+	}
+
+	if existing, _ := s.groupRepo.FindByName(companyID, req.Name); existing != nil {
+		return nil, errors.NewConflictError("a customer group with this name already exists")
+	}
+
+	group := &pricelist.CustomerGroup{
+		CompanyID:   companyID,
+		Name:        req.Name,
+		Description: req.Description,
+		IsActive:    true,
+	}
+
+	if !group.IsValid() {
+		return nil, errors.NewValidationError("invalid customer group data")
+	}
+
+	if err := s.groupRepo.Create(group); err != nil {
+		return nil, errors.NewInternalError("failed to create customer group", err)
+	}
+
+	return ToCustomerGroupResponse(group), nil
+}
+
+func (s *Service) ListCustomerGroups(userID, companyID uint) ([]*CustomerGroupResponse, error) {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	groups, err := s.groupRepo.FindByCompanyID(companyID)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch customer groups", err)
+	}
+
+	responses := make([]*CustomerGroupResponse, len(groups))
+	for i, g := range groups {
+		responses[i] = ToCustomerGroupResponse(g)
+	}
+
+	return responses, nil
+}
+
+func (s *Service) GetCustomerGroup(userID, companyID, groupID uint) (*CustomerGroupResponse, error) {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	group, err := s.groupRepo.FindByIDAndCompany(groupID, companyID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("customer group not found")
+	}
+
+	return ToCustomerGroupResponse(group), nil
+}
+
+func (s *Service) UpdateCustomerGroup(userID, companyID, groupID uint, req *UpdateCustomerGroupRequest) (*CustomerGroupResponse, error) {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleManager); err != nil {
+		return nil, err
+	}
+
+	group, err := s.groupRepo.FindByIDAndCompany(groupID, companyID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("customer group not found")
+	}
+
+	// Update fields
+	if req.Name != nil && *req.Name != group.Name {
+		if existing, _ := s.groupRepo.FindByName(companyID, *req.Name); existing != nil && existing.ID != group.ID {
+			return nil, errors.NewConflictError("a customer group with this name already exists")
+		}
+		group.Name = *req.Name
+	}
+	if req.Description != nil {
+		group.Description = *req.Description
+	}
+	if req.IsActive != nil {
+		group.IsActive = *req.IsActive
+	}
+
+	if !group.IsValid() {
+		return nil, errors.NewValidationError("invalid customer group data")
+	}
+
+	if err := s.groupRepo.Update(group); err != nil {
+		return nil, errors.NewInternalError("failed to update customer group", err)
+	}
+
+	return ToCustomerGroupResponse(group), nil
+}
+
+func (s *Service) DeleteCustomerGroup(userID, companyID, groupID uint) error {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleManager); err != nil {
+		return err
+	}
+
+	group, err := s.groupRepo.FindByIDAndCompany(groupID, companyID)
+	if err != nil {
+		return errors.NewNotFoundError("customer group not found")
+	}
+
+	// Groups still in use are only deactivated
+	customers, err := s.groupRepo.CountCustomers(group.ID)
+	if err != nil {
+		return errors.NewInternalError("failed to count customers of the group", err)
+	}
+	lists, err := s.priceListRepo.CountByCustomerGroup(group.ID)
+	if err != nil {
+		return errors.NewInternalError("failed to count price lists of the group", err)
+	}
+	if customers > 0 || lists > 0 {
+		return errors.NewConflictError("customer group has customers or price lists; deactivate it instead")
+	}
+
+	if err := s.groupRepo.Delete(group.ID); err != nil {
+		return errors.NewInternalError("failed to delete customer group", err)
+	}
+
+	return nil
+}
+
+// Price list operations
+
+func (s *Service) CreatePriceList(userID, companyID uint, req *CreatePriceListRequest) (*PriceListResponse, error) {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleManager); err != nil {
+		return nil, err
+	}
+
+	list := &pricelist.PriceList{
+		CompanyID:       companyID,
+		CustomerGroupID: req.CustomerGroupID,
+		FranchiseID:     req.FranchiseID,
+		Name:            req.Name,
+		Description:     req.Description,
+		StartsAt:        req.StartsAt,
+		EndsAt:          req.EndsAt,
+		IsActive:        true,
+		CreatedByID:     userID,
+		Items:           ToPriceListItems(req.Items),
+	}
+
+	if err := s.validatePriceList(list); err != nil {
+		return nil, err
+	}
+
+	if err := s.priceListRepo.Create(list); err != nil {
+		return nil, errors.NewInternalError("failed to create price list", err)
+	}
+
+	return ToPriceListResponse(list), nil
+}
+
+func (s *Service) ListPriceLists(userID, companyID uint, customerGroupID *uint) ([]*PriceListResponse, error) {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	lists, err := s.priceListRepo.FindByCompanyID(companyID, customerGroupID)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch price lists", err)
+	}
+
+	responses := make([]*PriceListResponse, len(lists))
+	for i, l := range lists {
+		responses[i] = ToPriceListResponse(l)
+	}
+
+	return responses, nil
+}
+
+func (s *Service) GetPriceList(userID, companyID, priceListID uint) (*PriceListResponse, error) {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	list, err := s.priceListRepo.FindByIDAndCompany(priceListID, companyID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("price list not found")
+	}
+
+	return ToPriceListResponse(list), nil
+}
+
+func (s *Service) UpdatePriceList(userID, companyID, priceListID uint, req *UpdatePriceListRequest) (*PriceListResponse, error) {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleManager); err != nil {
+		return nil, err
+	}
+
+	list, err := s.priceListRepo.FindByIDAndCompany(priceListID, companyID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("price list not found")
+	}
+
+	// Update fields
+	if req.Name != nil {
+		list.Name = *req.Name
+	}
+	if req.Description != nil {
+		list.Description = *req.Description
+	}
+	if req.StartsAt != nil {
+		list.StartsAt = req.StartsAt
+	}
+	if req.EndsAt != nil {
+		list.EndsAt = req.EndsAt
+	}
+	if req.IsActive != nil {
+		list.IsActive = *req.IsActive
+	}
+	if req.Items != nil {
+		list.Items = ToPriceListItems(req.Items)
+	}
+
+	if err := s.validatePriceList(list); err != nil {
+		return nil, err
+	}
+
+	if err := s.priceListRepo.Update(list); err != nil {
+		return nil, errors.NewInternalError("failed to update price list", err)
+	}
+
+	if req.Items != nil {
+		if err := s.priceListRepo.ReplaceItems(list.ID, list.Items); err != nil {
+			return nil, errors.NewInternalError("failed to update price list items", err)
+		}
+	}
+
+	return ToPriceListResponse(list), nil
+}
+
+func (s *Service) DeletePriceList(userID, companyID, priceListID uint) error {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleManager); err != nil {
+		return err
+	}
+
+	list, err := s.priceListRepo.FindByIDAndCompany(priceListID, companyID)
+	if err != nil {
+		return errors.NewNotFoundError("price list not found")
+	}
+
+	// Sale lines keep the price they were sold at, so price lists can always be deleted
+	if err := s.priceListRepo.Delete(list.ID); err != nil {
+		return errors.NewInternalError("failed to delete price list", err)
+	}
+
+	return nil
+}
+
+// validatePriceList checks the list itself and that its group, franchise and every
+// product it prices belong to the company
+func (s *Service) validatePriceList(l *pricelist.PriceList) error {
+	if !l.IsValid() {
+		return errors.NewValidationError("invalid price list data")
+	}
+
+	if _, err := s.groupRepo.FindByIDAndCompany(l.CustomerGroupID, l.CompanyID); err != nil {
+		return errors.NewNotFoundError("customer group not found or does not belong to this company")
+	}
+
+	if l.FranchiseID != nil {
+		f, err := s.franchiseRepo.FindByID(*l.FranchiseID)
+		if err != nil || !f.BelongsToCompany(l.CompanyID) {
+			return errors.NewNotFoundError("franchise not found or does not belong to this company")
+		}
+	}
+
+	for _, item := range l.Items {
+		if item.ProductID != nil && item.ProductVariantID != nil {
+			return errors.NewValidationError("a price list item targets a product or one of its variants, not both")
+		}
+
+		if item.ProductID != nil {
+			if _, err := s.productRepo.FindProductByIDAndCompany(*item.ProductID, l.CompanyID); err != nil {
+				return errors.NewNotFoundError("product not found or does not belong to this company")
+			}
+		}
+
+		if item.ProductVariantID != nil {
+			variant, err := s.productRepo.FindProductVariantByID(*item.ProductVariantID)
+			if err != nil {
+				return errors.NewNotFoundError("product variant not found")
+			}
+			if _, err := s.productRepo.FindProductByIDAndCompany(variant.ProductID, l.CompanyID); err != nil {
+				return errors.NewNotFoundError("product variant does not belong to this company")
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) checkUserCompanyAccess(userID, companyID uint, minimumRole user.Role) error {
+	ucr, err := s.userRepo.FindUserRoleInCompany(userID, companyID)
+	if err != nil {
+		return errors.NewForbiddenError("access denied to this company")
+	}
+
+	if !ucr.Role.HasPermission(minimumRole) {
+		return errors.NewForbiddenError("insufficient permissions")
+	}
+
+	return nil
+}
@@ -10,22 +10,39 @@ import (
 type CreateProductRequest struct {
 	Name               string   `json:"name" binding:"required"`
 	Description        string   `json:"description"`
+	Category           string   `json:"category"`
 	SKU                string   `json:"sku" binding:"required"`
 	BaseRetailPrice    float64  `json:"base_retail_price" binding:"min=0"`
 	BaseWholesalePrice float64  `json:"base_wholesale_price" binding:"min=0"`
 	SupplierID         *uint    `json:"supplier_id"`
 	SupplierCost       *float64 `json:"supplier_cost" binding:"omitempty,min=0"`
+	TaxClassID         *uint    `json:"tax_class_id"`
+	// Wholesale rules
+	WholesaleMinQuantity int                    `json:"wholesale_min_quantity" binding:"min=0"`
+	WholesaleTiers       []WholesaleTierRequest `json:"wholesale_tiers" binding:"dive"`
 }
 
 type UpdateProductRequest struct {
 	Name               string   `json:"name"`
 	Description        string   `json:"description"`
+	Category           *string  `json:"category"`
 	SKU                string   `json:"sku"`
 	BaseRetailPrice    *float64 `json:"base_retail_price" binding:"omitempty,min=0"`
 	BaseWholesalePrice *float64 `json:"base_wholesale_price" binding:"omitempty,min=0"`
 	SupplierID         *uint    `json:"supplier_id"`
 	SupplierCost       *float64 `json:"supplier_cost" binding:"omitempty,min=0"`
+	TaxClassID         *uint    `json:"tax_class_id"`
 	IsActive           *bool    `json:"is_active"`
+	// Wholesale rules
+	WholesaleMinQuantity *int                   `json:"wholesale_min_quantity" binding:"omitempty,min=0"`
+	WholesaleTiers       []WholesaleTierRequest `json:"wholesale_tiers" binding:"omitempty,dive"` // Replaces the product's tiers when set, an empty list removes them
+}
+
+// WholesaleTierRequest is a quantity break of the product's wholesale pricing
+type WholesaleTierRequest struct {
+	ProductVariantID *uint   `json:"product_variant_id"` // Empty for a tier of every variant
+	MinQuantity      int     `json:"min_quantity" binding:"required,min=2"`
+	UnitPrice        float64 `json:"unit_price" binding:"required,gt=0"`
 }
 
 type ProductResponse struct {
@@ -33,13 +50,25 @@ type ProductResponse struct {
 	CompanyID          uint                     `json:"company_id"`
 	Name               string                   `json:"name"`
 	Description        string                   `json:"description"`
+	Category           string                   `json:"category,omitempty"`
 	SKU                string                   `json:"sku"`
 	BaseRetailPrice    float64                  `json:"base_retail_price"`
 	BaseWholesalePrice float64                  `json:"base_wholesale_price"`
 	SupplierID         *uint                    `json:"supplier_id,omitempty"`
 	SupplierCost       *float64                 `json:"supplier_cost,omitempty"`
+	TaxClassID         *uint                    `json:"tax_class_id,omitempty"`
 	IsActive           bool                     `json:"is_active"`
 	Variants           []ProductVariantResponse `json:"variants,omitempty"`
+	// Wholesale rules
+	WholesaleMinQuantity int                     `json:"wholesale_min_quantity"`
+	WholesaleTiers       []WholesaleTierResponse `json:"wholesale_tiers,omitempty"`
+}
+
+type WholesaleTierResponse struct {
+	ID               uint    `json:"id"`
+	ProductVariantID *uint   `json:"product_variant_id,omitempty"`
+	MinQuantity      int     `json:"min_quantity"`
+	UnitPrice        float64 `json:"unit_price"`
 }
 
 // Product Variant DTOs
@@ -50,6 +79,7 @@ type CreateProductVariantRequest struct {
 	WholesalePrice   *float64               `json:"wholesale_price" binding:"omitempty,min=0"`
 	UseParentPricing bool                   `json:"use_parent_pricing"`
 	Attributes       map[string]interface{} `json:"attributes"`
+	Barcodes         []string               `json:"barcodes"`
 }
 
 type UpdateProductVariantRequest struct {
@@ -59,6 +89,7 @@ type UpdateProductVariantRequest struct {
 	WholesalePrice   *float64               `json:"wholesale_price" binding:"omitempty,min=0"`
 	UseParentPricing *bool                  `json:"use_parent_pricing"`
 	Attributes       map[string]interface{} `json:"attributes"`
+	Barcodes         []string               `json:"barcodes"` // Replaces the variant's barcodes when set, an empty list removes them
 	IsActive         *bool                  `json:"is_active"`
 }
 
@@ -70,6 +101,7 @@ type ProductVariantResponse struct {
 	RetailPrice    *float64               `json:"retail_price,omitempty"`
 	WholesalePrice *float64               `json:"wholesale_price,omitempty"`
 	Attributes     map[string]interface{} `json:"attributes"`
+	Barcodes       []string               `json:"barcodes,omitempty"`
 	IsActive       bool                   `json:"is_active"`
 }
 
@@ -123,12 +155,25 @@ func ToProductResponse(p *product.Product) *ProductResponse {
 		CompanyID:          p.CompanyID,
 		Name:               p.Name,
 		Description:        p.Description,
+		Category:           p.Category,
 		SKU:                p.SKU,
 		BaseRetailPrice:    p.BaseRetailPrice,
 		BaseWholesalePrice: p.BaseWholesalePrice,
 		SupplierID:         p.SupplierID,
 		SupplierCost:       p.SupplierCost,
+		TaxClassID:         p.TaxClassID,
 		IsActive:           p.IsActive,
+
+		WholesaleMinQuantity: p.WholesaleMinQuantity,
+	}
+
+	for _, tier := range p.WholesaleTiers {
+		response.WholesaleTiers = append(response.WholesaleTiers, WholesaleTierResponse{
+			ID:               tier.ID,
+			ProductVariantID: tier.ProductVariantID,
+			MinQuantity:      tier.MinQuantity,
+			UnitPrice:        tier.UnitPrice,
+		})
 	}
 
 	if len(p.Variants) > 0 {
@@ -147,6 +192,11 @@ func ToProductVariantResponse(v *product.ProductVariant) *ProductVariantResponse
 		json.Unmarshal(v.Attributes, &attributes)
 	}
 
+	var barcodes []string
+	for _, barcode := range v.Barcodes {
+		barcodes = append(barcodes, barcode.Code)
+	}
+
 	return &ProductVariantResponse{
 		ID:             v.ID,
 		ProductID:      v.ProductID,
@@ -155,6 +205,7 @@ func ToProductVariantResponse(v *product.ProductVariant) *ProductVariantResponse
 		RetailPrice:    v.RetailPrice,
 		WholesalePrice: v.WholesalePrice,
 		Attributes:     attributes,
+		Barcodes:       barcodes,
 		IsActive:       v.IsActive,
 	}
 }
@@ -165,13 +216,31 @@ func (req *CreateProductRequest) ToProduct(companyID uint) *product.Product {
 		CompanyID:          companyID,
 		Name:               req.Name,
 		Description:        req.Description,
+		Category:           req.Category,
 		SKU:                req.SKU,
 		BaseRetailPrice:    req.BaseRetailPrice,
 		BaseWholesalePrice: req.BaseWholesalePrice,
 		SupplierID:         req.SupplierID,
 		SupplierCost:       req.SupplierCost,
+		TaxClassID:         req.TaxClassID,
 		IsActive:           true,
+
+		WholesaleMinQuantity: req.WholesaleMinQuantity,
+	}
+}
+
+// ToWholesalePriceTiers converts the requested tiers of a product
+func ToWholesalePriceTiers(productID uint, reqs []WholesaleTierRequest) []product.WholesalePriceTier {
+	tiers := make([]product.WholesalePriceTier, len(reqs))
+	for i, req := range reqs {
+		tiers[i] = product.WholesalePriceTier{
+			ProductID:        productID,
+			ProductVariantID: req.ProductVariantID,
+			MinQuantity:      req.MinQuantity,
+			UnitPrice:        req.UnitPrice,
+		}
 	}
+	return tiers
 }
 
 func (req *CreateProductVariantRequest) ToProductVariant(productID uint) *product.ProductVariant {
@@ -201,6 +270,7 @@ type AttributeDefinition struct {
 type BulkCreateProductVariantsRequest struct {
 	Attributes       []AttributeDefinition `json:"attributes" binding:"required,min=1"`
 	UseParentPricing bool                  `json:"use_parent_pricing"`
+	GenerateBarcodes bool                  `json:"generate_barcodes"` // Give each variant an internal EAN-13
 }
 
 type BulkCreateProductVariantsResponse struct {
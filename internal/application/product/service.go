@@ -2,10 +2,12 @@ package product
 
 import (
 	"encoding/json"
+	"fmt"
 
 	franchiseDomain "github.com/YasserCherfaoui/darween/internal/domain/franchise"
 	"github.com/YasserCherfaoui/darween/internal/domain/product"
 	"github.com/YasserCherfaoui/darween/internal/domain/supplier"
+	"github.com/YasserCherfaoui/darween/internal/domain/tax"
 	"github.com/YasserCherfaoui/darween/internal/domain/user"
 	"github.com/YasserCherfaoui/darween/internal/infrastructure/label"
 	"github.com/YasserCherfaoui/darween/pkg/errors"
@@ -16,14 +18,16 @@ type Service struct {
 	userRepo      user.Repository
 	supplierRepo  supplier.Repository
 	franchiseRepo franchiseDomain.Repository
+	taxRepo       tax.Repository
 }
 
-func NewService(productRepo product.Repository, userRepo user.Repository, supplierRepo supplier.Repository, franchiseRepo franchiseDomain.Repository) *Service {
+func NewService(productRepo product.Repository, userRepo user.Repository, supplierRepo supplier.Repository, franchiseRepo franchiseDomain.Repository, taxRepo tax.Repository) *Service {
 	return &Service{
 		productRepo:   productRepo,
 		userRepo:      userRepo,
 		supplierRepo:  supplierRepo,
 		franchiseRepo: franchiseRepo,
+		taxRepo:       taxRepo,
 	}
 }
 
@@ -41,6 +45,13 @@ func (s *Service) CreateProduct(userID, companyID uint, req *CreateProductReques
 		}
 	}
 
+	// Validate tax class if provided
+	if req.TaxClassID != nil {
+		if err := s.validateTaxClass(*req.TaxClassID, companyID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Check if SKU already exists in company
 	existingProduct, _ := s.productRepo.FindProductBySKUAndCompany(req.SKU, companyID)
 	if existingProduct != nil {
@@ -53,6 +64,13 @@ func (s *Service) CreateProduct(userID, companyID uint, req *CreateProductReques
 		return nil, errors.NewValidationError("invalid product data")
 	}
 
+	// A new product has no variants yet, so its tiers apply to every variant
+	tiers, err := s.validateWholesaleTiers(newProduct, req.WholesaleTiers)
+	if err != nil {
+		return nil, err
+	}
+	newProduct.WholesaleTiers = tiers
+
 	if err := s.productRepo.CreateProduct(newProduct); err != nil {
 		return nil, errors.NewInternalError("failed to create product", err)
 	}
@@ -200,6 +218,13 @@ func (s *Service) UpdateProduct(userID, companyID, productID uint, req *UpdatePr
 		}
 	}
 
+	// Validate tax class if provided
+	if req.TaxClassID != nil {
+		if err := s.validateTaxClass(*req.TaxClassID, companyID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Check SKU uniqueness if SKU is being changed
 	if req.SKU != "" && req.SKU != existingProduct.SKU {
 		skuProduct, _ := s.productRepo.FindProductBySKUAndCompany(req.SKU, companyID)
@@ -216,6 +241,9 @@ func (s *Service) UpdateProduct(userID, companyID, productID uint, req *UpdatePr
 	if req.Description != "" {
 		existingProduct.Description = req.Description
 	}
+	if req.Category != nil {
+		existingProduct.Category = *req.Category
+	}
 	if req.BaseRetailPrice != nil && *req.BaseRetailPrice >= 0 {
 		existingProduct.BaseRetailPrice = *req.BaseRetailPrice
 	}
@@ -228,14 +256,35 @@ func (s *Service) UpdateProduct(userID, companyID, productID uint, req *UpdatePr
 	if req.SupplierCost != nil {
 		existingProduct.SupplierCost = req.SupplierCost
 	}
+	if req.TaxClassID != nil {
+		existingProduct.TaxClassID = req.TaxClassID
+	}
 	if req.IsActive != nil {
 		existingProduct.IsActive = *req.IsActive
 	}
+	if req.WholesaleMinQuantity != nil {
+		existingProduct.WholesaleMinQuantity = *req.WholesaleMinQuantity
+	}
+
+	var tiers []product.WholesalePriceTier
+	if req.WholesaleTiers != nil {
+		tiers, err = s.validateWholesaleTiers(existingProduct, req.WholesaleTiers)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	if err := s.productRepo.UpdateProduct(existingProduct); err != nil {
 		return nil, errors.NewInternalError("failed to update product", err)
 	}
 
+	if req.WholesaleTiers != nil {
+		if err := s.productRepo.ReplaceWholesaleTiers(existingProduct.ID, tiers); err != nil {
+			return nil, errors.NewInternalError("failed to update wholesale tiers", err)
+		}
+		existingProduct.WholesaleTiers = tiers
+	}
+
 	return ToProductResponse(existingProduct), nil
 }
 
@@ -283,6 +332,15 @@ func (s *Service) CreateProductVariant(userID, companyID, productID uint, req *C
 		return nil, errors.NewValidationError("invalid product variant data")
 	}
 
+	// Barcodes are created along with the variant
+	barcodes, err := s.validateBarcodes(companyID, 0, req.Barcodes)
+	if err != nil {
+		return nil, err
+	}
+	for _, code := range barcodes {
+		newVariant.Barcodes = append(newVariant.Barcodes, product.ProductVariantBarcode{CompanyID: companyID, Code: code})
+	}
+
 	if err := s.productRepo.CreateProductVariant(newVariant); err != nil {
 		return nil, errors.NewInternalError("failed to create product variant", err)
 	}
@@ -389,13 +447,57 @@ func (s *Service) UpdateProductVariant(userID, companyID, productID, variantID u
 		existingVariant.IsActive = *req.IsActive
 	}
 
+	var barcodes []string
+	if req.Barcodes != nil {
+		barcodes, err = s.validateBarcodes(companyID, variantID, req.Barcodes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if err := s.productRepo.UpdateProductVariant(existingVariant); err != nil {
 		return nil, errors.NewInternalError("failed to update product variant", err)
 	}
 
+	if req.Barcodes != nil {
+		if err := s.productRepo.ReplaceVariantBarcodes(companyID, variantID, barcodes); err != nil {
+			return nil, errors.NewInternalError("failed to update barcodes", err)
+		}
+		existingVariant.Barcodes = nil
+		for _, code := range barcodes {
+			existingVariant.Barcodes = append(existingVariant.Barcodes, product.ProductVariantBarcode{CompanyID: companyID, ProductVariantID: variantID, Code: code})
+		}
+	}
+
 	return ToProductVariantResponse(existingVariant), nil
 }
 
+// GenerateVariantBarcode gives the variant an internal EAN-13 code, for products without
+// a manufacturer barcode. Generating it again returns the variant unchanged.
+func (s *Service) GenerateVariantBarcode(userID, companyID, productID, variantID uint) (*ProductVariantResponse, error) {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	// Verify product exists and belongs to company
+	_, err := s.productRepo.FindProductByIDAndCompany(productID, companyID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("product not found")
+	}
+
+	variant, err := s.productRepo.FindProductVariantByIDAndProduct(variantID, productID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("product variant not found")
+	}
+
+	if err := s.addInternalBarcode(companyID, variant); err != nil {
+		return nil, err
+	}
+
+	return ToProductVariantResponse(variant), nil
+}
+
 func (s *Service) DeleteProductVariant(userID, companyID, productID, variantID uint) error {
 	// Check user authorization
 	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
@@ -543,6 +645,11 @@ func (s *Service) BulkCreateProductVariants(userID, companyID, productID uint, r
 		if err := s.productRepo.CreateProductVariant(variant); err != nil {
 			return nil, errors.NewInternalError("failed to create product variant", err)
 		}
+		if req.GenerateBarcodes {
+			if err := s.addInternalBarcode(companyID, variant); err != nil {
+				return nil, err
+			}
+		}
 		variantResponses = append(variantResponses, *ToProductVariantResponse(variant))
 	}
 
@@ -639,6 +746,91 @@ func normalizeForSKU(value string) string {
 	return normalized
 }
 
+// validateBarcodes normalizes the codes and checks none of them is used by another variant
+// of the company. variantID is the variant being updated, 0 for a new one.
+func (s *Service) validateBarcodes(companyID, variantID uint, codes []string) ([]string, error) {
+	normalized := make([]string, 0, len(codes))
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		barcode, err := product.NormalizeBarcode(code)
+		if err != nil {
+			return nil, errors.NewValidationError(err.Error())
+		}
+		if seen[barcode] {
+			continue
+		}
+		seen[barcode] = true
+
+		existing, _ := s.productRepo.FindBarcodeByCode(companyID, barcode)
+		if existing != nil && existing.ProductVariantID != variantID {
+			return nil, errors.NewConflictError("barcode " + barcode + " is already used by another product variant")
+		}
+		normalized = append(normalized, barcode)
+	}
+	return normalized, nil
+}
+
+// addInternalBarcode adds the internal EAN-13 code to the variant's barcodes, unless it has it already
+func (s *Service) addInternalBarcode(companyID uint, variant *product.ProductVariant) error {
+	code, err := product.GenerateInternalEAN13(variant.ID)
+	if err != nil {
+		return errors.NewValidationError(err.Error())
+	}
+	for _, barcode := range variant.Barcodes {
+		if barcode.Code == code {
+			return nil
+		}
+	}
+
+	if existing, _ := s.productRepo.FindBarcodeByCode(companyID, code); existing != nil {
+		return errors.NewConflictError("barcode " + code + " is already used by another product variant")
+	}
+
+	barcode := &product.ProductVariantBarcode{
+		CompanyID:        companyID,
+		ProductVariantID: variant.ID,
+		Code:             code,
+	}
+	if err := s.productRepo.CreateVariantBarcode(barcode); err != nil {
+		return errors.NewInternalError("failed to create barcode", err)
+	}
+	variant.Barcodes = append(variant.Barcodes, *barcode)
+	return nil
+}
+
+// validateWholesaleTiers checks the requested tiers are for variants of the product and
+// that no two of them share a quantity break
+func (s *Service) validateWholesaleTiers(p *product.Product, reqs []WholesaleTierRequest) ([]product.WholesalePriceTier, error) {
+	variantIDs := make(map[uint]bool)
+	for _, variant := range p.Variants {
+		variantIDs[variant.ID] = true
+	}
+
+	type tierKey struct {
+		variantID   uint
+		minQuantity int
+	}
+	seen := make(map[tierKey]bool)
+	tiers := ToWholesalePriceTiers(p.ID, reqs)
+	for _, tier := range tiers {
+		key := tierKey{minQuantity: tier.MinQuantity}
+		if tier.ProductVariantID != nil {
+			if !variantIDs[*tier.ProductVariantID] {
+				return nil, errors.NewValidationError(fmt.Sprintf("variant %d is not a variant of this product", *tier.ProductVariantID))
+			}
+			key.variantID = *tier.ProductVariantID
+		}
+		if !tier.IsValid() {
+			return nil, errors.NewValidationError("wholesale tiers need a quantity of at least 2 and a positive price")
+		}
+		if seen[key] {
+			return nil, errors.NewValidationError(fmt.Sprintf("more than one wholesale tier starts at %d units", tier.MinQuantity))
+		}
+		seen[key] = true
+	}
+	return tiers, nil
+}
+
 // Helper function to validate supplier
 func (s *Service) validateSupplier(supplierID, companyID uint) error {
 	sup, err := s.supplierRepo.FindSupplierByIDAndCompany(supplierID, companyID)
@@ -651,6 +843,18 @@ func (s *Service) validateSupplier(supplierID, companyID uint) error {
 	return nil
 }
 
+// validateTaxClass ensures the tax class belongs to the company and is active
+func (s *Service) validateTaxClass(taxClassID, companyID uint) error {
+	taxClass, err := s.taxRepo.FindByIDAndCompany(taxClassID, companyID)
+	if err != nil {
+		return errors.NewNotFoundError("tax class not found or does not belong to this company")
+	}
+	if !taxClass.IsActive {
+		return errors.NewValidationError("tax class is not active")
+	}
+	return nil
+}
+
 // Label generation methods
 
 // buildLabelConfig creates label config from request or uses defaults
@@ -0,0 +1,105 @@
+package promotion
+
+import (
+	"time"
+
+	"github.com/YasserCherfaoui/darween/internal/domain/promotion"
+)
+
+type CreatePromotionRequest struct {
+	FranchiseID      *uint      `json:"franchise_id"`
+	Name             string     `json:"name" binding:"required"`
+	Description      string     `json:"description"`
+	Type             string     `json:"type" binding:"required,oneof=percentage fixed_amount buy_x_get_y"`
+	Value            float64    `json:"value" binding:"min=0"`
+	BuyQuantity      int        `json:"buy_quantity" binding:"min=0"`
+	GetQuantity      int        `json:"get_quantity" binding:"min=0"`
+	ProductID        *uint      `json:"product_id"`
+	ProductVariantID *uint      `json:"product_variant_id"`
+	SupplierID       *uint      `json:"supplier_id"`
+	AttributeName    string     `json:"attribute_name"`
+	AttributeValue   string     `json:"attribute_value"`
+	StartsAt         *time.Time `json:"starts_at"`
+	EndsAt           *time.Time `json:"ends_at"`
+	StartTime        string     `json:"start_time"` // HH:MM
+	EndTime          string     `json:"end_time"`   // HH:MM
+	CouponCode       string     `json:"coupon_code"`
+	UsageLimit       *int       `json:"usage_limit" binding:"omitempty,min=1"`
+}
+
+type UpdatePromotionRequest struct {
+	Name             *string    `json:"name"`
+	Description      *string    `json:"description"`
+	Value            *float64   `json:"value" binding:"omitempty,min=0"`
+	BuyQuantity      *int       `json:"buy_quantity" binding:"omitempty,min=0"`
+	GetQuantity      *int       `json:"get_quantity" binding:"omitempty,min=0"`
+	ProductID        *uint      `json:"product_id"`
+	ProductVariantID *uint      `json:"product_variant_id"`
+	SupplierID       *uint      `json:"supplier_id"`
+	AttributeName    *string    `json:"attribute_name"`
+	AttributeValue   *string    `json:"attribute_value"`
+	StartsAt         *time.Time `json:"starts_at"`
+	EndsAt           *time.Time `json:"ends_at"`
+	StartTime        *string    `json:"start_time"`
+	EndTime          *string    `json:"end_time"`
+	UsageLimit       *int       `json:"usage_limit" binding:"omitempty,min=1"`
+	IsActive         *bool      `json:"is_active"`
+}
+
+type PromotionResponse struct {
+	ID               uint       `json:"id"`
+	CompanyID        uint       `json:"company_id"`
+	FranchiseID      *uint      `json:"franchise_id,omitempty"`
+	Name             string     `json:"name"`
+	Description      string     `json:"description"`
+	Type             string     `json:"type"`
+	Value            float64    `json:"value"`
+	BuyQuantity      int        `json:"buy_quantity,omitempty"`
+	GetQuantity      int        `json:"get_quantity,omitempty"`
+	ProductID        *uint      `json:"product_id,omitempty"`
+	ProductVariantID *uint      `json:"product_variant_id,omitempty"`
+	SupplierID       *uint      `json:"supplier_id,omitempty"`
+	AttributeName    string     `json:"attribute_name,omitempty"`
+	AttributeValue   string     `json:"attribute_value,omitempty"`
+	StartsAt         *time.Time `json:"starts_at,omitempty"`
+	EndsAt           *time.Time `json:"ends_at,omitempty"`
+	StartTime        string     `json:"start_time,omitempty"`
+	EndTime          string     `json:"end_time,omitempty"`
+	CouponCode       string     `json:"coupon_code,omitempty"`
+	UsageLimit       *int       `json:"usage_limit,omitempty"`
+	UsageCount       int        `json:"usage_count"`
+	IsActive         bool       `json:"is_active"`
+	CreatedByID      uint       `json:"created_by_id"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+func ToPromotionResponse(p *promotion.Promotion) *PromotionResponse {
+	return &PromotionResponse{
+		ID:               p.ID,
+		CompanyID:        p.CompanyID,
+		FranchiseID:      p.FranchiseID,
+		Name:             p.Name,
+		Description:      p.Description,
+		Type:             string(p.Type),
+		Value:            p.Value,
+		BuyQuantity:      p.BuyQuantity,
+		GetQuantity:      p.GetQuantity,
+		ProductID:        p.ProductID,
+		ProductVariantID: p.ProductVariantID,
+		SupplierID:       p.SupplierID,
+		AttributeName:    p.AttributeName,
+		AttributeValue:   p.AttributeValue,
+		StartsAt:         p.StartsAt,
+		EndsAt:           p.EndsAt,
+		StartTime:        p.StartTime,
+		EndTime:          p.EndTime,
+		CouponCode:       p.CouponCode,
+		UsageLimit:       p.UsageLimit,
+		UsageCount:       p.UsageCount,
+		IsActive:         p.IsActive,
+		CreatedByID:      p.CreatedByID,
+		CreatedAt:        p.CreatedAt,
+		UpdatedAt:        p.UpdatedAt,
+	}
+}
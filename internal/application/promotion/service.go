@@ -0,0 +1,249 @@
+package promotion
+
+import (
+	"github.com/YasserCherfaoui/darween/internal/domain/franchise"
+	"github.com/YasserCherfaoui/darween/internal/domain/product"
+	"github.com/YasserCherfaoui/darween/internal/domain/promotion"
+	"github.com/YasserCherfaoui/darween/internal/domain/supplier"
+	"github.com/YasserCherfaoui/darween/internal/domain/user"
+	"github.com/YasserCherfaoui/darween/pkg/errors"
+)
+
+type Service struct {
+	promotionRepo promotion.Repository
+	userRepo      user.Repository
+	productRepo   product.Repository
+	supplierRepo  supplier.Repository
+	franchiseRepo franchise.Repository
+}
+
+func NewService(promotionRepo promotion.Repository, userRepo user.Repository, productRepo product.Repository, supplierRepo supplier.Repository, franchiseRepo franchise.Repository) *Service {
+	return &Service{
+		promotionRepo: promotionRepo,
+		userRepo:      userRepo,
+		productRepo:   productRepo,
+		supplierRepo:  supplierRepo,
+		franchiseRepo: franchiseRepo,
+	}
+}
+
+func (s *Service) CreatePromotion(userID, companyID uint, req *CreatePromotionRequest) (*PromotionResponse, error) {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleManager); err != nil {
+		return nil, err
+	}
+
+	newPromotion := &promotion.Promotion{
+		CompanyID:        companyID,
+		FranchiseID:      req.FranchiseID,
+		Name:             req.Name,
+		Description:      req.Description,
+		Type:             promotion.PromotionType(req.Type),
+		Value:            req.Value,
+		BuyQuantity:      req.BuyQuantity,
+		GetQuantity:      req.GetQuantity,
+		ProductID:        req.ProductID,
+		ProductVariantID: req.ProductVariantID,
+		SupplierID:       req.SupplierID,
+		AttributeName:    req.AttributeName,
+		AttributeValue:   req.AttributeValue,
+		StartsAt:         req.StartsAt,
+		EndsAt:           req.EndsAt,
+		StartTime:        req.StartTime,
+		EndTime:          req.EndTime,
+		CouponCode:       promotion.NormalizeCouponCode(req.CouponCode),
+		UsageLimit:       req.UsageLimit,
+		IsActive:         true,
+		CreatedByID:      userID,
+	}
+
+	if err := s.validatePromotion(newPromotion); err != nil {
+		return nil, err
+	}
+
+	if newPromotion.IsCoupon() {
+		if existing, _ := s.promotionRepo.FindByCouponCode(companyID, newPromotion.CouponCode); existing != nil {
+			return nil, errors.NewConflictError("a promotion with this coupon code already exists")
+		}
+	}
+
+	if err := s.promotionRepo.Create(newPromotion); err != nil {
+		return nil, errors.NewInternalError("failed to create promotion", err)
+	}
+
+	return ToPromotionResponse(newPromotion), nil
+}
+
+func (s *Service) ListPromotions(userID, companyID uint, franchiseID *uint) ([]*PromotionResponse, error) {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	promotions, err := s.promotionRepo.FindByCompanyID(companyID, franchiseID)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch promotions", err)
+	}
+
+	responses := make([]*PromotionResponse, len(promotions))
+	for i, p := range promotions {
+		responses[i] = ToPromotionResponse(p)
+	}
+
+	return responses, nil
+}
+
+func (s *Service) GetPromotion(userID, companyID, promotionID uint) (*PromotionResponse, error) {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	p, err := s.promotionRepo.FindByIDAndCompany(promotionID, companyID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("promotion not found")
+	}
+
+	return ToPromotionResponse(p), nil
+}
+
+func (s *Service) UpdatePromotion(userID, companyID, promotionID uint, req *UpdatePromotionRequest) (*PromotionResponse, error) {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleManager); err != nil {
+		return nil, err
+	}
+
+	p, err := s.promotionRepo.FindByIDAndCompany(promotionID, companyID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("promotion not found")
+	}
+
+	// Update fields
+	if req.Name != nil {
+		p.Name = *req.Name
+	}
+	if req.Description != nil {
+		p.Description = *req.Description
+	}
+	if req.Value != nil {
+		p.Value = *req.Value
+	}
+	if req.BuyQuantity != nil {
+		p.BuyQuantity = *req.BuyQuantity
+	}
+	if req.GetQuantity != nil {
+		p.GetQuantity = *req.GetQuantity
+	}
+	if req.ProductID != nil {
+		p.ProductID = req.ProductID
+	}
+	if req.ProductVariantID != nil {
+		p.ProductVariantID = req.ProductVariantID
+	}
+	if req.SupplierID != nil {
+		p.SupplierID = req.SupplierID
+	}
+	if req.AttributeName != nil {
+		p.AttributeName = *req.AttributeName
+	}
+	if req.AttributeValue != nil {
+		p.AttributeValue = *req.AttributeValue
+	}
+	if req.StartsAt != nil {
+		p.StartsAt = req.StartsAt
+	}
+	if req.EndsAt != nil {
+		p.EndsAt = req.EndsAt
+	}
+	if req.StartTime != nil {
+		p.StartTime = *req.StartTime
+	}
+	if req.EndTime != nil {
+		p.EndTime = *req.EndTime
+	}
+	if req.UsageLimit != nil {
+		p.UsageLimit = req.UsageLimit
+	}
+	if req.IsActive != nil {
+		p.IsActive = *req.IsActive
+	}
+
+	if err := s.validatePromotion(p); err != nil {
+		return nil, err
+	}
+
+	if err := s.promotionRepo.Update(p); err != nil {
+		return nil, errors.NewInternalError("failed to update promotion", err)
+	}
+
+	return ToPromotionResponse(p), nil
+}
+
+func (s *Service) DeletePromotion(userID, companyID, promotionID uint) error {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleManager); err != nil {
+		return err
+	}
+
+	p, err := s.promotionRepo.FindByIDAndCompany(promotionID, companyID)
+	if err != nil {
+		return errors.NewNotFoundError("promotion not found")
+	}
+
+	// Sales reference the promotions they used, so used ones are only deactivated
+	if p.UsageCount > 0 {
+		return errors.NewConflictError("promotion has already been used; deactivate it instead")
+	}
+
+	if err := s.promotionRepo.Delete(p.ID); err != nil {
+		return errors.NewInternalError("failed to delete promotion", err)
+	}
+
+	return nil
+}
+
+// validatePromotion checks the rule itself and that every target belongs to the company
+func (s *Service) validatePromotion(p *promotion.Promotion) error {
+	if !p.IsValid() {
+		return errors.NewValidationError("invalid promotion data")
+	}
+
+	if p.FranchiseID != nil {
+		f, err := s.franchiseRepo.FindByID(*p.FranchiseID)
+		if err != nil || !f.BelongsToCompany(p.CompanyID) {
+			return errors.NewNotFoundError("franchise not found or does not belong to this company")
+		}
+	}
+
+	if p.ProductID != nil {
+		if _, err := s.productRepo.FindProductByIDAndCompany(*p.ProductID, p.CompanyID); err != nil {
+			return errors.NewNotFoundError("product not found or does not belong to this company")
+		}
+	}
+
+	if p.ProductVariantID != nil {
+		variant, err := s.productRepo.FindProductVariantByID(*p.ProductVariantID)
+		if err != nil {
+			return errors.NewNotFoundError("product variant not found")
+		}
+		if _, err := s.productRepo.FindProductByIDAndCompany(variant.ProductID, p.CompanyID); err != nil {
+			return errors.NewNotFoundError("product variant does not belong to this company")
+		}
+	}
+
+	if p.SupplierID != nil {
+		if _, err := s.supplierRepo.FindSupplierByIDAndCompany(*p.SupplierID, p.CompanyID); err != nil {
+			return errors.NewNotFoundError("supplier not found or does not belong to this company")
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) checkUserCompanyAccess(userID, companyID uint, minimumRole user.Role) error {
+	ucr, err := s.userRepo.FindUserRoleInCompany(userID, companyID)
+	if err != nil {
+		return errors.NewForbiddenError("access denied to this company")
+	}
+
+	if !ucr.Role.HasPermission(minimumRole) {
+		return errors.NewForbiddenError("insufficient permissions")
+	}
+
+	return nil
+}
@@ -3,31 +3,35 @@ package supplier
 import (
 	"fmt"
 	"strings"
-	"time"
 
+	numberingApp "github.com/YasserCherfaoui/darween/internal/application/numbering"
 	"github.com/YasserCherfaoui/darween/internal/domain/inventory"
+	"github.com/YasserCherfaoui/darween/internal/domain/numbering"
 	"github.com/YasserCherfaoui/darween/internal/domain/product"
 	"github.com/YasserCherfaoui/darween/internal/domain/supplier"
 	"github.com/YasserCherfaoui/darween/internal/domain/user"
+	"github.com/YasserCherfaoui/darween/internal/infrastructure/export"
 	"github.com/YasserCherfaoui/darween/pkg/errors"
 	"gorm.io/gorm"
 )
 
 type Service struct {
-	supplierRepo  supplier.Repository
-	userRepo      user.Repository
-	inventoryRepo inventory.Repository
-	productRepo   product.Repository
-	db            *gorm.DB
+	supplierRepo     supplier.Repository
+	userRepo         user.Repository
+	inventoryRepo    inventory.Repository
+	productRepo      product.Repository
+	numberingService *numberingApp.Service
+	db               *gorm.DB
 }
 
-func NewService(supplierRepo supplier.Repository, userRepo user.Repository, inventoryRepo inventory.Repository, productRepo product.Repository, db *gorm.DB) *Service {
+func NewService(supplierRepo supplier.Repository, userRepo user.Repository, inventoryRepo inventory.Repository, productRepo product.Repository, numberingService *numberingApp.Service, db *gorm.DB) *Service {
 	return &Service{
-		supplierRepo:  supplierRepo,
-		userRepo:      userRepo,
-		inventoryRepo: inventoryRepo,
-		productRepo:   productRepo,
-		db:            db,
+		supplierRepo:     supplierRepo,
+		userRepo:         userRepo,
+		inventoryRepo:    inventoryRepo,
+		productRepo:      productRepo,
+		numberingService: numberingService,
+		db:               db,
 	}
 }
 
@@ -247,12 +251,6 @@ func (s *Service) createInventoryMovement(tx *gorm.DB, inventoryID uint, movemen
 	return tx.Create(movement).Error
 }
 
-// Helper function to generate bill number
-func generateBillNumber(companyID, billID uint) string {
-	timestamp := time.Now().Format("20060102")
-	return fmt.Sprintf("BILL-%d-%s-%d", companyID, timestamp, billID)
-}
-
 // Helper function for string pointer
 func stringPtr(s string) *string {
 	return &s
@@ -304,13 +302,17 @@ func (s *Service) CreateSupplierBill(userID, companyID uint, req *CreateSupplier
 		totalAmount += item.TotalCost
 	}
 
-	// Create bill with temporary bill number (will be updated after getting ID)
-	// Use timestamp-based temp value to ensure uniqueness
-	tempBillNumber := fmt.Sprintf("BILL-TEMP-%d-%d", companyID, time.Now().UnixNano())
+	// Take the bill number from the company's sequence, inside the transaction so it has no gaps
+	billNumber, err := s.numberingService.Next(tx, companyID, nil, numbering.DocumentTypeSupplierBill)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	bill := &supplier.SupplierBill{
 		CompanyID:     companyID,
 		SupplierID:    req.SupplierID,
-		BillNumber:    tempBillNumber, // Temporary value, will be updated
+		BillNumber:    billNumber,
 		TotalAmount:   totalAmount,
 		PaidAmount:    req.PaidAmount,
 		PaymentStatus: supplier.PaymentStatusUnpaid,
@@ -326,19 +328,11 @@ func (s *Service) CreateSupplierBill(userID, companyID uint, req *CreateSupplier
 		return nil, errors.NewValidationError("invalid bill data")
 	}
 
-	// Create bill first to get ID
 	if err := tx.Create(bill).Error; err != nil {
 		tx.Rollback()
 		return nil, errors.NewInternalError("failed to create bill", err)
 	}
 
-	// Update bill number with actual ID
-	bill.BillNumber = generateBillNumber(companyID, bill.ID)
-	if err := tx.Save(bill).Error; err != nil {
-		tx.Rollback()
-		return nil, errors.NewInternalError("failed to update bill number", err)
-	}
-
 	// Create bill items and update inventory
 	for _, item := range billItems {
 		item.SupplierBillID = bill.ID
@@ -447,6 +441,36 @@ func (s *Service) ListSupplierBills(userID, companyID, supplierID uint, page, li
 	return NewPaginatedResponse(billResponses, total, page, limit), nil
 }
 
+// ExportSupplierBills writes the company's supplier bills, optionally of one supplier, one row per bill
+func (s *Service) ExportSupplierBills(userID, companyID uint, supplierID *uint, w export.Writer) error {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return err
+	}
+
+	err := w.WriteRow("Bill number", "Date", "Supplier", "Status", "Payment status", "Items",
+		"Total", "Paid", "Pending", "Created by ID", "Notes")
+	if err != nil {
+		return err
+	}
+
+	return s.supplierRepo.StreamSupplierBills(companyID, supplierID, func(bills []*supplier.SupplierBill) error {
+		for _, bill := range bills {
+			supplierName := ""
+			if bill.Supplier != nil {
+				supplierName = bill.Supplier.Name
+			}
+
+			err := w.WriteRow(bill.BillNumber, bill.CreatedAt, supplierName, bill.BillStatus, bill.PaymentStatus, len(bill.Items),
+				bill.TotalAmount, bill.PaidAmount, bill.PendingAmount, bill.CreatedByID, bill.Notes)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (s *Service) UpdateSupplierBill(userID, companyID, billID uint, req *UpdateSupplierBillRequest) (*SupplierBillResponse, error) {
 	// Check user authorization
 	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleManager); err != nil {
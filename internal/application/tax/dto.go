@@ -0,0 +1,59 @@
+package tax
+
+import (
+	"time"
+
+	"github.com/YasserCherfaoui/darween/internal/domain/tax"
+)
+
+type CreateTaxClassRequest struct {
+	Name        string  `json:"name" binding:"required"`
+	Code        string  `json:"code"`
+	Rate        float64 `json:"rate" binding:"min=0,max=100"`
+	IsExempt    bool    `json:"is_exempt"`
+	IsDefault   bool    `json:"is_default"`
+	Description string  `json:"description"`
+}
+
+type UpdateTaxClassRequest struct {
+	Name        *string  `json:"name"`
+	Code        *string  `json:"code"`
+	Rate        *float64 `json:"rate" binding:"omitempty,min=0,max=100"`
+	IsExempt    *bool    `json:"is_exempt"`
+	IsActive    *bool    `json:"is_active"`
+	Description *string  `json:"description"`
+}
+
+type TaxClassResponse struct {
+	ID          uint    `json:"id"`
+	CompanyID   uint    `json:"company_id"`
+	Name        string  `json:"name"`
+	Code        string  `json:"code"`
+	Rate        float64 `json:"rate"`
+	IsExempt    bool    `json:"is_exempt"`
+	IsDefault   bool    `json:"is_default"`
+	IsActive    bool    `json:"is_active"`
+	Description string  `json:"description"`
+	CreatedAt   string  `json:"created_at"`
+	UpdatedAt   string  `json:"updated_at"`
+}
+
+type ListTaxClassesResponse struct {
+	TaxClasses []*TaxClassResponse `json:"tax_classes"`
+}
+
+func ToTaxClassResponse(t *tax.TaxClass) *TaxClassResponse {
+	return &TaxClassResponse{
+		ID:          t.ID,
+		CompanyID:   t.CompanyID,
+		Name:        t.Name,
+		Code:        t.Code,
+		Rate:        t.Rate,
+		IsExempt:    t.IsExempt,
+		IsDefault:   t.IsDefault,
+		IsActive:    t.IsActive,
+		Description: t.Description,
+		CreatedAt:   t.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   t.UpdatedAt.Format(time.RFC3339),
+	}
+}
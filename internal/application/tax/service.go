@@ -0,0 +1,190 @@
+package tax
+
+import (
+	"github.com/YasserCherfaoui/darween/internal/domain/tax"
+	"github.com/YasserCherfaoui/darween/internal/domain/user"
+	"github.com/YasserCherfaoui/darween/pkg/errors"
+)
+
+type Service struct {
+	taxRepo  tax.Repository
+	userRepo user.Repository
+}
+
+func NewService(taxRepo tax.Repository, userRepo user.Repository) *Service {
+	return &Service{
+		taxRepo:  taxRepo,
+		userRepo: userRepo,
+	}
+}
+
+func (s *Service) CreateTaxClass(userID, companyID uint, req *CreateTaxClassRequest) (*TaxClassResponse, error) {
+	if err := s.checkCanManage(userID, companyID); err != nil {
+		return nil, err
+	}
+
+	newClass := &tax.TaxClass{
+		CompanyID:   companyID,
+		Name:        req.Name,
+		Code:        req.Code,
+		Rate:        req.Rate,
+		IsExempt:    req.IsExempt,
+		IsActive:    true,
+		Description: req.Description,
+	}
+	if newClass.IsExempt {
+		newClass.Rate = 0
+	}
+	if !newClass.IsValid() {
+		return nil, errors.NewValidationError("invalid tax class data")
+	}
+
+	if err := s.taxRepo.Create(newClass); err != nil {
+		return nil, errors.NewInternalError("failed to create tax class", err)
+	}
+
+	if req.IsDefault {
+		if err := s.taxRepo.SetAsDefault(newClass.ID, companyID); err != nil {
+			return nil, errors.NewInternalError("failed to set default tax class", err)
+		}
+		newClass.IsDefault = true
+	}
+
+	return ToTaxClassResponse(newClass), nil
+}
+
+func (s *Service) ListTaxClasses(userID, companyID uint) (*ListTaxClassesResponse, error) {
+	if _, err := s.userRepo.FindUserRoleInCompany(userID, companyID); err != nil {
+		return nil, errors.NewForbiddenError("you don't have access to this company")
+	}
+
+	taxClasses, err := s.taxRepo.FindByCompanyID(companyID)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch tax classes", err)
+	}
+
+	responses := make([]*TaxClassResponse, len(taxClasses))
+	for i, t := range taxClasses {
+		responses[i] = ToTaxClassResponse(t)
+	}
+
+	return &ListTaxClassesResponse{
+		TaxClasses: responses,
+	}, nil
+}
+
+func (s *Service) GetTaxClass(userID, companyID, taxClassID uint) (*TaxClassResponse, error) {
+	if _, err := s.userRepo.FindUserRoleInCompany(userID, companyID); err != nil {
+		return nil, errors.NewForbiddenError("you don't have access to this company")
+	}
+
+	taxClass, err := s.taxRepo.FindByIDAndCompany(taxClassID, companyID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("tax class not found")
+	}
+
+	return ToTaxClassResponse(taxClass), nil
+}
+
+func (s *Service) UpdateTaxClass(userID, companyID, taxClassID uint, req *UpdateTaxClassRequest) (*TaxClassResponse, error) {
+	if err := s.checkCanManage(userID, companyID); err != nil {
+		return nil, err
+	}
+
+	taxClass, err := s.taxRepo.FindByIDAndCompany(taxClassID, companyID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("tax class not found")
+	}
+
+	// Update fields
+	if req.Name != nil {
+		taxClass.Name = *req.Name
+	}
+	if req.Code != nil {
+		taxClass.Code = *req.Code
+	}
+	if req.Rate != nil {
+		taxClass.Rate = *req.Rate
+	}
+	if req.IsExempt != nil {
+		taxClass.IsExempt = *req.IsExempt
+		if taxClass.IsExempt {
+			taxClass.Rate = 0
+		}
+	}
+	if req.IsActive != nil {
+		taxClass.IsActive = *req.IsActive
+	}
+	if req.Description != nil {
+		taxClass.Description = *req.Description
+	}
+
+	if !taxClass.IsValid() {
+		return nil, errors.NewValidationError("invalid tax class data")
+	}
+
+	if err := s.taxRepo.Update(taxClass); err != nil {
+		return nil, errors.NewInternalError("failed to update tax class", err)
+	}
+
+	return ToTaxClassResponse(taxClass), nil
+}
+
+func (s *Service) DeleteTaxClass(userID, companyID, taxClassID uint) error {
+	if err := s.checkCanManage(userID, companyID); err != nil {
+		return err
+	}
+
+	if _, err := s.taxRepo.FindByIDAndCompany(taxClassID, companyID); err != nil {
+		return errors.NewNotFoundError("tax class not found")
+	}
+
+	// Sales keep a snapshot of the rate, but products must not point at a missing class
+	count, err := s.taxRepo.CountProducts(taxClassID)
+	if err != nil {
+		return errors.NewInternalError("failed to check tax class usage", err)
+	}
+	if count > 0 {
+		return errors.NewConflictError("tax class is assigned to products; reassign them or deactivate the class instead")
+	}
+
+	if err := s.taxRepo.Delete(taxClassID); err != nil {
+		return errors.NewInternalError("failed to delete tax class", err)
+	}
+
+	return nil
+}
+
+func (s *Service) SetDefaultTaxClass(userID, companyID, taxClassID uint) error {
+	if err := s.checkCanManage(userID, companyID); err != nil {
+		return err
+	}
+
+	taxClass, err := s.taxRepo.FindByIDAndCompany(taxClassID, companyID)
+	if err != nil {
+		return errors.NewNotFoundError("tax class not found")
+	}
+	if !taxClass.IsActive {
+		return errors.NewValidationError("inactive tax class cannot be the default")
+	}
+
+	if err := s.taxRepo.SetAsDefault(taxClassID, companyID); err != nil {
+		return errors.NewInternalError("failed to set default tax class", err)
+	}
+
+	return nil
+}
+
+// checkCanManage restricts tax configuration to company owners and admins
+func (s *Service) checkCanManage(userID, companyID uint) error {
+	role, err := s.userRepo.FindUserRoleInCompany(userID, companyID)
+	if err != nil {
+		return errors.NewForbiddenError("you don't have access to this company")
+	}
+
+	if role.Role != user.RoleOwner && role.Role != user.RoleAdmin {
+		return errors.NewForbiddenError("only owners and admins can manage tax classes")
+	}
+
+	return nil
+}
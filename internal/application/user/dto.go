@@ -40,3 +40,11 @@ type ChangePasswordResponse struct {
 	Message string `json:"message"`
 }
 
+type SetPOSPinRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	Pin             string `json:"pin" binding:"required,numeric,min=4,max=8"`
+}
+
+type SetPOSPinResponse struct {
+	Message string `json:"message"`
+}
@@ -128,6 +128,33 @@ func (s *Service) ChangePassword(userID uint, req *ChangePasswordRequest) (*Chan
 	}, nil
 }
 
+func (s *Service) SetPOSPin(userID uint, req *SetPOSPinRequest) (*SetPOSPinResponse, error) {
+	// Find user
+	u, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("user not found")
+	}
+
+	// Verify current password before changing the PIN
+	if !u.CheckPassword(req.CurrentPassword) {
+		return nil, errors.NewUnauthorizedError("current password is incorrect")
+	}
+
+	// Hash new PIN
+	if err := u.SetPOSPin(req.Pin); err != nil {
+		return nil, errors.NewInternalError("failed to hash PIN", err)
+	}
+
+	// Update user
+	if err := s.userRepo.Update(u); err != nil {
+		return nil, errors.NewInternalError("failed to update PIN", err)
+	}
+
+	return &SetPOSPinResponse{
+		Message: "POS PIN has been set successfully.",
+	}, nil
+}
+
 func (s *Service) GetUserPortals(userID uint) (*UserPortalsResponse, error) {
 	var portals []*PortalResponse
 
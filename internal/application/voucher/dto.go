@@ -0,0 +1,137 @@
+package voucher
+
+import (
+	"time"
+
+	"github.com/YasserCherfaoui/darween/internal/domain/voucher"
+)
+
+type IssueVoucherRequest struct {
+	Type        string     `json:"type" binding:"required,oneof=gift_card store_credit"`
+	Amount      float64    `json:"amount" binding:"required,gt=0"`
+	Code        string     `json:"code"` // Optional - generated when empty
+	FranchiseID *uint      `json:"franchise_id"`
+	CustomerID  *uint      `json:"customer_id"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	Notes       string     `json:"notes"`
+}
+
+type UpdateVoucherRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+	IsActive  *bool      `json:"is_active"`
+	Notes     *string    `json:"notes"`
+}
+
+type VoucherResponse struct {
+	ID            uint                  `json:"id"`
+	CompanyID     uint                  `json:"company_id"`
+	FranchiseID   *uint                 `json:"franchise_id,omitempty"`
+	Code          string                `json:"code"`
+	Type          voucher.VoucherType   `json:"type"`
+	InitialAmount float64               `json:"initial_amount"`
+	Balance       float64               `json:"balance"`
+	CustomerID    *uint                 `json:"customer_id,omitempty"`
+	SaleID        *uint                 `json:"sale_id,omitempty"`
+	RefundID      *uint                 `json:"refund_id,omitempty"`
+	ExpiresAt     *time.Time            `json:"expires_at,omitempty"`
+	IsActive      bool                  `json:"is_active"`
+	Notes         string                `json:"notes,omitempty"`
+	CreatedByID   uint                  `json:"created_by_id"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+	Transactions  []TransactionResponse `json:"transactions,omitempty"`
+}
+
+type TransactionResponse struct {
+	ID           uint                    `json:"id"`
+	Type         voucher.TransactionType `json:"type"`
+	Amount       float64                 `json:"amount"`
+	BalanceAfter float64                 `json:"balance_after"`
+	SaleID       *uint                   `json:"sale_id,omitempty"`
+	RefundID     *uint                   `json:"refund_id,omitempty"`
+	CreatedByID  *uint                   `json:"created_by_id,omitempty"`
+	CreatedAt    time.Time               `json:"created_at"`
+}
+
+// VoucherBalanceResponse answers a balance check at the till
+type VoucherBalanceResponse struct {
+	Code        string              `json:"code"`
+	Type        voucher.VoucherType `json:"type"`
+	Balance     float64             `json:"balance"`
+	FranchiseID *uint               `json:"franchise_id,omitempty"`
+	ExpiresAt   *time.Time          `json:"expires_at,omitempty"`
+	IsActive    bool                `json:"is_active"`
+	IsExpired   bool                `json:"is_expired"`
+	CanRedeem   bool                `json:"can_redeem"` // Usable now in the franchise the check was made for
+}
+
+type PaginationRequest struct {
+	Page  int `form:"page"`
+	Limit int `form:"limit"`
+}
+
+func (p *PaginationRequest) GetDefaults() {
+	if p.Page <= 0 {
+		p.Page = 1
+	}
+	if p.Limit <= 0 {
+		p.Limit = 20
+	}
+}
+
+type PaginatedResponse struct {
+	Data       interface{} `json:"data"`
+	Page       int         `json:"page"`
+	Limit      int         `json:"limit"`
+	Total      int64       `json:"total"`
+	TotalPages int         `json:"total_pages"`
+}
+
+func NewPaginatedResponse(data interface{}, total int64, page, limit int) *PaginatedResponse {
+	totalPages := int(total) / limit
+	if int(total)%limit != 0 {
+		totalPages++
+	}
+
+	return &PaginatedResponse{
+		Data:       data,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+}
+
+func ToVoucherResponse(v *voucher.Voucher) *VoucherResponse {
+	return &VoucherResponse{
+		ID:            v.ID,
+		CompanyID:     v.CompanyID,
+		FranchiseID:   v.FranchiseID,
+		Code:          v.Code,
+		Type:          v.Type,
+		InitialAmount: v.InitialAmount,
+		Balance:       v.Balance,
+		CustomerID:    v.CustomerID,
+		SaleID:        v.SaleID,
+		RefundID:      v.RefundID,
+		ExpiresAt:     v.ExpiresAt,
+		IsActive:      v.IsActive,
+		Notes:         v.Notes,
+		CreatedByID:   v.CreatedByID,
+		CreatedAt:     v.CreatedAt,
+		UpdatedAt:     v.UpdatedAt,
+	}
+}
+
+func ToTransactionResponse(t *voucher.Transaction) TransactionResponse {
+	return TransactionResponse{
+		ID:           t.ID,
+		Type:         t.Type,
+		Amount:       t.Amount,
+		BalanceAfter: t.BalanceAfter,
+		SaleID:       t.SaleID,
+		RefundID:     t.RefundID,
+		CreatedByID:  t.CreatedByID,
+		CreatedAt:    t.CreatedAt,
+	}
+}
@@ -0,0 +1,234 @@
+package voucher
+
+import (
+	"time"
+
+	"github.com/YasserCherfaoui/darween/internal/domain/franchise"
+	"github.com/YasserCherfaoui/darween/internal/domain/pos"
+	"github.com/YasserCherfaoui/darween/internal/domain/user"
+	"github.com/YasserCherfaoui/darween/internal/domain/voucher"
+	"github.com/YasserCherfaoui/darween/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type Service struct {
+	voucherRepo   voucher.Repository
+	userRepo      user.Repository
+	franchiseRepo franchise.Repository
+	customerRepo  pos.CustomerRepository
+	db            *gorm.DB
+}
+
+func NewService(voucherRepo voucher.Repository, userRepo user.Repository, franchiseRepo franchise.Repository, customerRepo pos.CustomerRepository, db *gorm.DB) *Service {
+	return &Service{
+		voucherRepo:   voucherRepo,
+		userRepo:      userRepo,
+		franchiseRepo: franchiseRepo,
+		customerRepo:  customerRepo,
+		db:            db,
+	}
+}
+
+// IssueVoucher creates a voucher without a sale, e.g. a complimentary gift card or a goodwill store credit
+func (s *Service) IssueVoucher(userID, companyID uint, req *IssueVoucherRequest) (*VoucherResponse, error) {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleManager); err != nil {
+		return nil, err
+	}
+
+	code := voucher.NormalizeCode(req.Code)
+	if code == "" {
+		generated, err := voucher.GenerateCode()
+		if err != nil {
+			return nil, errors.NewInternalError("failed to generate voucher code", err)
+		}
+		code = generated
+	} else if _, err := s.voucherRepo.FindByCode(companyID, code); err == nil {
+		return nil, errors.NewConflictError("a voucher with this code already exists")
+	}
+
+	newVoucher := &voucher.Voucher{
+		CompanyID:     companyID,
+		FranchiseID:   req.FranchiseID,
+		Code:          code,
+		Type:          voucher.VoucherType(req.Type),
+		InitialAmount: req.Amount,
+		Balance:       req.Amount,
+		CustomerID:    req.CustomerID,
+		ExpiresAt:     req.ExpiresAt,
+		IsActive:      true,
+		Notes:         req.Notes,
+		CreatedByID:   userID,
+	}
+
+	if err := s.validateVoucher(newVoucher); err != nil {
+		return nil, err
+	}
+
+	// Start transaction
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Create(newVoucher).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to create voucher", err)
+	}
+
+	issue := &voucher.Transaction{
+		VoucherID:    newVoucher.ID,
+		Type:         voucher.TransactionTypeIssue,
+		Amount:       newVoucher.InitialAmount,
+		BalanceAfter: newVoucher.Balance,
+		CreatedByID:  &userID,
+	}
+	if err := tx.Create(issue).Error; err != nil {
+		tx.Rollback()
+		return nil, errors.NewInternalError("failed to record voucher issue", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.NewInternalError("failed to commit transaction", err)
+	}
+
+	return s.GetVoucher(userID, companyID, newVoucher.ID)
+}
+
+func (s *Service) GetVoucher(userID, companyID, voucherID uint) (*VoucherResponse, error) {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	v, err := s.voucherRepo.FindByIDAndCompany(voucherID, companyID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("voucher not found")
+	}
+
+	transactions, err := s.voucherRepo.FindTransactions(v.ID)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch voucher history", err)
+	}
+
+	result := ToVoucherResponse(v)
+	result.Transactions = make([]TransactionResponse, len(transactions))
+	for i := range transactions {
+		result.Transactions[i] = ToTransactionResponse(&transactions[i])
+	}
+
+	return result, nil
+}
+
+func (s *Service) ListVouchers(userID, companyID uint, customerID *uint, page, limit int) (*PaginatedResponse, error) {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	vouchers, total, err := s.voucherRepo.FindByCompanyID(companyID, customerID, page, limit)
+	if err != nil {
+		return nil, errors.NewInternalError("failed to fetch vouchers", err)
+	}
+
+	responses := make([]*VoucherResponse, len(vouchers))
+	for i, v := range vouchers {
+		responses[i] = ToVoucherResponse(v)
+	}
+
+	return NewPaginatedResponse(responses, total, page, limit), nil
+}
+
+// LookupVoucher checks the balance of a voucher by its code and whether it can be used in the franchise
+func (s *Service) LookupVoucher(userID, companyID uint, code string, franchiseID *uint) (*VoucherBalanceResponse, error) {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleEmployee); err != nil {
+		return nil, err
+	}
+
+	v, err := s.voucherRepo.FindByCode(companyID, code)
+	if err != nil {
+		return nil, errors.NewNotFoundError("voucher not found")
+	}
+
+	now := time.Now()
+	expired := v.IsExpired(now)
+
+	return &VoucherBalanceResponse{
+		Code:        v.Code,
+		Type:        v.Type,
+		Balance:     v.Balance,
+		FranchiseID: v.FranchiseID,
+		ExpiresAt:   v.ExpiresAt,
+		IsActive:    v.IsActive,
+		IsExpired:   expired,
+		CanRedeem:   v.IsActive && !expired && v.Balance > 0 && v.IsValidFor(franchiseID),
+	}, nil
+}
+
+func (s *Service) UpdateVoucher(userID, companyID, voucherID uint, req *UpdateVoucherRequest) (*VoucherResponse, error) {
+	if err := s.checkUserCompanyAccess(userID, companyID, user.RoleManager); err != nil {
+		return nil, err
+	}
+
+	v, err := s.voucherRepo.FindByIDAndCompany(voucherID, companyID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("voucher not found")
+	}
+
+	// Update fields
+	if req.ExpiresAt != nil {
+		v.ExpiresAt = req.ExpiresAt
+	}
+	if req.IsActive != nil {
+		v.IsActive = *req.IsActive
+	}
+	if req.Notes != nil {
+		v.Notes = *req.Notes
+	}
+
+	// Only the fields above change, so a concurrent redemption's balance is kept
+	if err := s.db.Model(v).Select("ExpiresAt", "IsActive", "Notes").Updates(v).Error; err != nil {
+		return nil, errors.NewInternalError("failed to update voucher", err)
+	}
+
+	return s.GetVoucher(userID, companyID, v.ID)
+}
+
+// validateVoucher checks the voucher and that its franchise and customer belong to the company
+func (s *Service) validateVoucher(v *voucher.Voucher) error {
+	if !v.IsValid() {
+		return errors.NewValidationError("invalid voucher data")
+	}
+
+	if v.ExpiresAt != nil && v.IsExpired(time.Now()) {
+		return errors.NewValidationError("expiry date must be in the future")
+	}
+
+	if v.FranchiseID != nil {
+		f, err := s.franchiseRepo.FindByID(*v.FranchiseID)
+		if err != nil || !f.BelongsToCompany(v.CompanyID) {
+			return errors.NewNotFoundError("franchise not found or does not belong to this company")
+		}
+	}
+
+	if v.CustomerID != nil {
+		customer, err := s.customerRepo.FindByID(*v.CustomerID)
+		if err != nil || customer.CompanyID != v.CompanyID {
+			return errors.NewNotFoundError("customer not found or does not belong to this company")
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) checkUserCompanyAccess(userID, companyID uint, minimumRole user.Role) error {
+	ucr, err := s.userRepo.FindUserRoleInCompany(userID, companyID)
+	if err != nil {
+		return errors.NewForbiddenError("access denied to this company")
+	}
+
+	if !ucr.Role.HasPermission(minimumRole) {
+		return errors.NewForbiddenError("insufficient permissions")
+	}
+
+	return nil
+}
@@ -152,8 +152,12 @@ type WarehouseBillResponse struct {
 type PaginationRequest struct {
 	Page  int `form:"page" binding:"min=1"`
 	Limit int `form:"limit" binding:"min=1,max=100"`
-	
-	// Filters
+
+	BillFilterRequest
+}
+
+// BillFilterRequest holds the filters of the list and export endpoints
+type BillFilterRequest struct {
 	FranchiseID *uint   `form:"franchise_id"` // Filter by franchise
 	Status      *string `form:"status"`       // Filter by bill status (draft, completed, cancelled, verified)
 	DateFrom    *string `form:"date_from"`    // Filter by date from (format: YYYY-MM-DD)
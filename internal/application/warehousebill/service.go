@@ -6,12 +6,15 @@ import (
 	"time"
 
 	emailApp "github.com/YasserCherfaoui/darween/internal/application/email"
+	numberingApp "github.com/YasserCherfaoui/darween/internal/application/numbering"
 	companyDomain "github.com/YasserCherfaoui/darween/internal/domain/company"
 	franchiseDomain "github.com/YasserCherfaoui/darween/internal/domain/franchise"
 	"github.com/YasserCherfaoui/darween/internal/domain/inventory"
+	"github.com/YasserCherfaoui/darween/internal/domain/numbering"
 	productDomain "github.com/YasserCherfaoui/darween/internal/domain/product"
 	userDomain "github.com/YasserCherfaoui/darween/internal/domain/user"
 	"github.com/YasserCherfaoui/darween/internal/domain/warehousebill"
+	"github.com/YasserCherfaoui/darween/internal/infrastructure/export"
 	"github.com/YasserCherfaoui/darween/pkg/errors"
 	"gorm.io/gorm"
 )
@@ -24,6 +27,7 @@ type Service struct {
 	userRepo          userDomain.Repository
 	productRepo       productDomain.Repository
 	emailService      *emailApp.Service
+	numberingService  *numberingApp.Service
 	db                *gorm.DB
 }
 
@@ -35,6 +39,7 @@ func NewService(
 	userRepo userDomain.Repository,
 	productRepo productDomain.Repository,
 	emailService *emailApp.Service,
+	numberingService *numberingApp.Service,
 	db *gorm.DB,
 ) *Service {
 	return &Service{
@@ -45,6 +50,7 @@ func NewService(
 		userRepo:          userRepo,
 		productRepo:       productRepo,
 		emailService:      emailService,
+		numberingService:  numberingService,
 		db:                db,
 	}
 }
@@ -174,7 +180,14 @@ func (s *Service) CreateExitBill(userID, companyID uint, req *CreateExitBillRequ
 		return nil, errors.NewValidationError("invalid bill data")
 	}
 
-	if err := s.warehouseBillRepo.Create(bill); err != nil {
+	billNumber, err := s.numberingService.Next(tx, companyID, nil, numbering.DocumentTypeWarehouseExit)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	bill.BillNumber = billNumber
+
+	if err := tx.Create(bill).Error; err != nil {
 		tx.Rollback()
 		return nil, errors.NewInternalError("failed to create exit bill", err)
 	}
@@ -411,17 +424,18 @@ func (s *Service) CreateEntryBill(userID, franchiseID uint, req *CreateEntryBill
 		return nil, errors.NewValidationError("invalid bill data")
 	}
 
-	// Create entry bill within transaction (GORM will cascade create items)
-	if err := tx.Create(bill).Error; err != nil {
+	// Entry bills are numbered per receiving franchise
+	billNumber, err := s.numberingService.Next(tx, bill.CompanyID, &franchiseID, numbering.DocumentTypeWarehouseEntry)
+	if err != nil {
 		tx.Rollback()
-		return nil, errors.NewInternalError("failed to create entry bill", err)
+		return nil, err
 	}
+	bill.BillNumber = billNumber
 
-	// Generate and update bill number (matching repository format: WB-ENTRY-{companyID}-{timestamp}-{billID})
-	bill.BillNumber = fmt.Sprintf("WB-ENTRY-%d-%s-%d", bill.CompanyID, time.Now().Format("20060102"), bill.ID)
-	if err := tx.Save(bill).Error; err != nil {
+	// Create entry bill within transaction (GORM will cascade create items)
+	if err := tx.Create(bill).Error; err != nil {
 		tx.Rollback()
-		return nil, errors.NewInternalError("failed to update bill number", err)
+		return nil, errors.NewInternalError("failed to create entry bill", err)
 	}
 
 	// Update inventory for each item
@@ -1189,37 +1203,9 @@ func (s *Service) ListWarehouseBills(userID, companyID uint, page, limit int, fi
 		return nil, err
 	}
 
-	// Convert DTO filters to domain filters
 	var billFilters *warehousebill.BillFilters
 	if filters != nil {
-		billFilters = &warehousebill.BillFilters{}
-		if filters.FranchiseID != nil {
-			billFilters.FranchiseID = filters.FranchiseID
-		}
-		if filters.Status != nil {
-			status := warehousebill.BillStatus(*filters.Status)
-			if status.IsValid() {
-				billFilters.Status = &status
-			}
-		}
-		if filters.BillType != nil {
-			billType := warehousebill.BillType(*filters.BillType)
-			if billType.IsValid() {
-				billFilters.BillType = &billType
-			}
-		}
-		if filters.DateFrom != nil {
-			dateFrom, err := time.Parse("2006-01-02", *filters.DateFrom)
-			if err == nil {
-				billFilters.DateFrom = &dateFrom
-			}
-		}
-		if filters.DateTo != nil {
-			dateTo, err := time.Parse("2006-01-02", *filters.DateTo)
-			if err == nil {
-				billFilters.DateTo = &dateTo
-			}
-		}
+		billFilters = toBillFilters(&filters.BillFilterRequest)
 	}
 
 	bills, total, err := s.warehouseBillRepo.FindByCompanyIDWithFilters(companyID, page, limit, billFilters)
@@ -1240,6 +1226,64 @@ func (s *Service) ListWarehouseBills(userID, companyID uint, page, limit int, fi
 	return NewPaginatedResponse(responses, total, page, limit), nil
 }
 
+// ExportWarehouseBills writes the company's warehouse bills matching the list filters, one row per bill
+func (s *Service) ExportWarehouseBills(userID, companyID uint, filters *BillFilterRequest, w export.Writer) error {
+	// Check user authorization
+	if err := s.checkUserCompanyAccess(userID, companyID, userDomain.RoleEmployee); err != nil {
+		return err
+	}
+
+	err := w.WriteRow("Bill number", "Date", "Type", "Franchise ID", "Status", "Verification status", "Items",
+		"Total", "Related bill ID", "Verified at", "Created by ID", "Notes")
+	if err != nil {
+		return err
+	}
+
+	return s.warehouseBillRepo.StreamByCompanyIDWithFilters(companyID, toBillFilters(filters), func(bills []*warehousebill.WarehouseBill) error {
+		for _, bill := range bills {
+			err := w.WriteRow(bill.BillNumber, bill.CreatedAt, bill.BillType, bill.FranchiseID, bill.Status, bill.VerificationStatus, len(bill.Items),
+				bill.TotalAmount, bill.RelatedBillID, bill.VerifiedAt, bill.CreatedByID, bill.Notes)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// toBillFilters converts the filters of the list and export endpoints; invalid values are ignored
+func toBillFilters(filters *BillFilterRequest) *warehousebill.BillFilters {
+	billFilters := &warehousebill.BillFilters{}
+	if filters.FranchiseID != nil {
+		billFilters.FranchiseID = filters.FranchiseID
+	}
+	if filters.Status != nil {
+		status := warehousebill.BillStatus(*filters.Status)
+		if status.IsValid() {
+			billFilters.Status = &status
+		}
+	}
+	if filters.BillType != nil {
+		billType := warehousebill.BillType(*filters.BillType)
+		if billType.IsValid() {
+			billFilters.BillType = &billType
+		}
+	}
+	if filters.DateFrom != nil {
+		dateFrom, err := time.Parse("2006-01-02", *filters.DateFrom)
+		if err == nil {
+			billFilters.DateFrom = &dateFrom
+		}
+	}
+	if filters.DateTo != nil {
+		dateTo, err := time.Parse("2006-01-02", *filters.DateTo)
+		if err == nil {
+			billFilters.DateTo = &dateTo
+		}
+	}
+	return billFilters
+}
+
 // ListFranchiseWarehouseBills lists warehouse bills for a franchise
 func (s *Service) ListFranchiseWarehouseBills(userID, franchiseID uint, page, limit int) (*PaginatedResponse, error) {
 	// Check user authorization
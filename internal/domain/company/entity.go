@@ -3,14 +3,24 @@ package company
 import "time"
 
 type Company struct {
-	ID          uint   `gorm:"primaryKey"`
-	Name        string `gorm:"not null;default:''"` // Allow empty string as default
-	Code        string `gorm:"not null;default:''"` // Remove unique constraint temporarily
-	Description string
-	ERPUrl      string `gorm:"default:''"` // Frontend/ERP URL for this company
-	IsActive    bool   `gorm:"default:true"`
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID                uint   `gorm:"primaryKey"`
+	Name              string `gorm:"not null;default:''"` // Allow empty string as default
+	Code              string `gorm:"not null;default:''"` // Remove unique constraint temporarily
+	Description       string
+	ERPUrl            string  `gorm:"default:''"`                   // Frontend/ERP URL for this company
+	PricesIncludeTax  bool    `gorm:"default:false"`                // Whether catalogue prices already contain tax
+	CashApprovalLimit float64 `gorm:"type:decimal(10,2);default:0"` // Cash pay-ins, pay-outs and safe drops above this need a manager; 0 turns approval off
+	WholesaleMinOrder float64 `gorm:"type:decimal(10,2);default:0"` // Least a wholesale sale may total before the sale discount; 0 for no minimum
+	IsActive          bool    `gorm:"default:true"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+
+	// Legal details printed on invoices
+	Address string `gorm:"type:text"`
+	NIF     string `gorm:"type:varchar(30)"` // Numéro d'identification fiscale
+	NIS     string `gorm:"type:varchar(30)"` // Numéro d'identification statistique
+	RC      string `gorm:"type:varchar(30)"` // Registre du commerce
+	AI      string `gorm:"type:varchar(30)"` // Article d'imposition
 }
 
 func (Company) TableName() string {
@@ -17,6 +17,7 @@ type EmailQueue struct {
 	ErrorMessage string      `gorm:"type:text"`
 	ScheduledAt  *time.Time  `gorm:"index"`
 	SentAt       *time.Time
+	Attachments  []Attachment `gorm:"foreignKey:EmailQueueID"`
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
@@ -25,6 +26,20 @@ func (EmailQueue) TableName() string {
 	return "email_queue"
 }
 
+// Attachment is a file sent along with a queued email
+type Attachment struct {
+	ID           uint   `gorm:"primaryKey"`
+	EmailQueueID uint   `gorm:"not null;index"`
+	Filename     string `gorm:"not null"`
+	ContentType  string `gorm:"type:varchar(100);not null"`
+	Content      []byte `gorm:"type:bytea;not null"`
+	CreatedAt    time.Time
+}
+
+func (Attachment) TableName() string {
+	return "email_attachments"
+}
+
 type EmailStatus string
 
 const (
@@ -54,6 +69,7 @@ const (
 	EmailTypeNotification  EmailType = "notification"
 	EmailTypeStockAlert    EmailType = "stock_alert"
 	EmailTypeWarehouseBill EmailType = "warehouse_bill"
+	EmailTypeReceipt       EmailType = "receipt"
 	EmailTypeCustom        EmailType = "custom"
 )
 
@@ -63,7 +79,7 @@ func (e EmailType) String() string {
 
 func (e EmailType) IsValid() bool {
 	switch e {
-	case EmailTypePasswordReset, EmailTypeInvitation, EmailTypeNotification, EmailTypeStockAlert, EmailTypeWarehouseBill, EmailTypeCustom:
+	case EmailTypePasswordReset, EmailTypeInvitation, EmailTypeNotification, EmailTypeStockAlert, EmailTypeWarehouseBill, EmailTypeReceipt, EmailTypeCustom:
 		return true
 	}
 	return false
@@ -107,6 +107,20 @@ func (i *Inventory) RemoveStock(amount int) bool {
 	return false
 }
 
+// ForceRemoveStock decreases the stock level even beyond the available stock, for sales that
+// already happened without the server knowing (e.g. offline). It returns the missing quantity.
+func (i *Inventory) ForceRemoveStock(amount int) int {
+	if amount <= 0 {
+		return 0
+	}
+	shortfall := amount - i.GetAvailableStock()
+	i.Stock -= amount
+	if shortfall < 0 {
+		return 0
+	}
+	return shortfall
+}
+
 // ReserveStock reserves stock for pending orders
 func (i *Inventory) ReserveStock(amount int) bool {
 	if amount > 0 && i.GetAvailableStock() >= amount {
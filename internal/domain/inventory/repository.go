@@ -8,6 +8,8 @@ type Repository interface {
 	FindByVariantAndFranchise(variantID, franchiseID uint) (*Inventory, error)
 	FindByCompany(companyID uint) ([]*Inventory, error)
 	FindByFranchise(franchiseID uint) ([]*Inventory, error)
+	StreamByCompany(companyID uint, fn func(inventories []*Inventory) error) error
+	StreamByFranchise(franchiseID uint, fn func(inventories []*Inventory) error) error
 	Update(inventory *Inventory) error
 	Delete(id uint) error
 
@@ -15,6 +17,7 @@ type Repository interface {
 	CreateMovement(movement *InventoryMovement) error
 	FindMovementsByInventory(inventoryID uint, limit int) ([]*InventoryMovement, error)
 	FindMovementsByInventoryWithFilters(inventoryID uint, movementType *string, startDate *string, endDate *string, page, limit int) ([]*InventoryMovement, int64, error)
+	StreamMovementsByInventoryWithFilters(inventoryID uint, movementType *string, startDate *string, endDate *string, fn func(movements []*InventoryMovement) error) error
 	FindMovementsByReference(referenceType string, referenceID string) ([]*InventoryMovement, error)
 }
 
@@ -0,0 +1,95 @@
+package loyalty
+
+import (
+	"math"
+	"time"
+)
+
+// Program holds a company's loyalty rules
+type Program struct {
+	ID              uint    `gorm:"primaryKey"`
+	CompanyID       uint    `gorm:"not null;uniqueIndex"`
+	PointsPerUnit   float64 `gorm:"type:decimal(10,4);not null;default:1"`    // Points earned per currency unit spent
+	PointValue      float64 `gorm:"type:decimal(10,4);not null;default:0.01"` // Currency value of one point when redeemed
+	MinRedeemPoints int     `gorm:"not null;default:0"`                       // Smallest number of points a redemption may use
+	ExpiryDays      int     `gorm:"not null;default:0"`                       // Days before earned points expire, 0 to never expire
+	IsActive        bool    `gorm:"default:true"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (Program) TableName() string {
+	return "loyalty_programs"
+}
+
+// Business methods for Program
+func (p *Program) IsValid() bool {
+	return p.CompanyID > 0 && p.PointsPerUnit >= 0 && p.PointValue > 0 && p.MinRedeemPoints >= 0 && p.ExpiryDays >= 0
+}
+
+// PointsEarned returns the whole points earned by spending amount
+func (p *Program) PointsEarned(amount float64) int {
+	if amount <= 0 {
+		return 0
+	}
+	return int(math.Floor(amount*p.PointsPerUnit + 1e-9))
+}
+
+// PointsFor returns the points needed to pay amount, rounded up to a whole point
+func (p *Program) PointsFor(amount float64) int {
+	if amount <= 0 {
+		return 0
+	}
+	return int(math.Ceil(amount/p.PointValue - 1e-9))
+}
+
+// ExpiryFrom returns when points earned at the given time expire, nil if they never do
+func (p *Program) ExpiryFrom(earnedAt time.Time) *time.Time {
+	if p.ExpiryDays == 0 {
+		return nil
+	}
+	expiresAt := earnedAt.AddDate(0, 0, p.ExpiryDays)
+	return &expiresAt
+}
+
+// Transaction is an entry of a customer's points ledger. Entries adding points are
+// lots that later redemptions, reversals and expiry draw from, oldest first.
+type Transaction struct {
+	ID              uint            `gorm:"primaryKey"`
+	CompanyID       uint            `gorm:"not null;index"`
+	CustomerID      uint            `gorm:"not null;index"`
+	SaleID          *uint           `gorm:"index"`
+	RefundID        *uint           `gorm:"index"`
+	Type            TransactionType `gorm:"type:varchar(50);not null"`
+	Points          int             `gorm:"not null"`           // Positive when points are added, negative when they are taken
+	RemainingPoints int             `gorm:"not null;default:0"` // Unused points of a lot
+	ExpiresAt       *time.Time      `gorm:"index"`
+	Notes           string          `gorm:"type:text"`
+	CreatedByID     *uint           `gorm:"index"` // Nil for entries made by the expiry job
+	CreatedAt       time.Time       `gorm:"index"`
+}
+
+func (Transaction) TableName() string {
+	return "loyalty_transactions"
+}
+
+// TransactionType represents why points were added to or taken from a customer
+type TransactionType string
+
+const (
+	TransactionTypeEarn    TransactionType = "earn"    // Points earned on a paid sale
+	TransactionTypeRedeem  TransactionType = "redeem"  // Points used to pay a sale
+	TransactionTypeReverse TransactionType = "reverse" // Earned points taken back by a refund or void
+	TransactionTypeRestore TransactionType = "restore" // Redeemed points given back by a refund or void
+	TransactionTypeExpire  TransactionType = "expire"  // Points that were not used in time
+)
+
+// IsLot reports whether the entry added points that can later be used
+func (t *Transaction) IsLot() bool {
+	return t.Points > 0
+}
+
+// IsExpired reports whether the unused points of a lot have expired
+func (t *Transaction) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && t.RemainingPoints > 0 && !now.Before(*t.ExpiresAt)
+}
@@ -0,0 +1,19 @@
+package loyalty
+
+import "time"
+
+// ProgramRepository defines the interface for loyalty program data operations
+type ProgramRepository interface {
+	Create(program *Program) error
+	Update(program *Program) error
+	FindByCompanyID(companyID uint) (*Program, error)
+}
+
+// TransactionRepository defines the interface for loyalty ledger data operations
+type TransactionRepository interface {
+	FindByCustomerID(customerID uint, page, limit int) ([]*Transaction, int64, error)
+	FindBySaleID(saleID uint) ([]Transaction, error)
+	FindExpiredLots(now time.Time) ([]*Transaction, error)
+	GetBalance(customerID uint) (int, error)
+	ExpireLot(lot *Transaction) error
+}
@@ -0,0 +1,249 @@
+package numbering
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DocumentType is the kind of document a sequence numbers
+type DocumentType string
+
+const (
+	DocumentTypeReceipt        DocumentType = "receipt"
+	DocumentTypeSupplierBill   DocumentType = "supplier_bill"
+	DocumentTypeWarehouseExit  DocumentType = "warehouse_exit"
+	DocumentTypeWarehouseEntry DocumentType = "warehouse_entry"
+	DocumentTypeInvoice        DocumentType = "invoice"
+	DocumentTypeProforma       DocumentType = "proforma"
+)
+
+func (d DocumentType) IsValid() bool {
+	_, ok := defaultSequences[d]
+	return ok
+}
+
+// DocumentTypes lists every numbered document type
+func DocumentTypes() []DocumentType {
+	return []DocumentType{
+		DocumentTypeReceipt,
+		DocumentTypeSupplierBill,
+		DocumentTypeWarehouseExit,
+		DocumentTypeWarehouseEntry,
+		DocumentTypeInvoice,
+		DocumentTypeProforma,
+	}
+}
+
+// ResetPolicy tells when the counter of a sequence starts over at 1
+type ResetPolicy string
+
+const (
+	ResetNever   ResetPolicy = "never"
+	ResetYearly  ResetPolicy = "yearly"
+	ResetMonthly ResetPolicy = "monthly"
+)
+
+func (r ResetPolicy) IsValid() bool {
+	switch r {
+	case ResetNever, ResetYearly, ResetMonthly:
+		return true
+	}
+	return false
+}
+
+// CompanyScope is the franchise ID of sequences numbering company-level documents
+const CompanyScope uint = 0
+
+// Sequence numbers one type of document of a company or of one of its franchises. Numbers are
+// taken inside the transaction creating the document, with the row locked, so they have no gaps.
+//
+// Patterns are text with placeholders: {YYYY} and {YY} for the year, {MM} for the month,
+// {FRANCHISE} for the franchise code (HQ for company documents) and {SEQ} for the counter,
+// {SEQ:6} padding it to 6 digits. Example: "RCP-{FRANCHISE}-{YYYY}-{SEQ:6}".
+type Sequence struct {
+	ID           uint         `gorm:"primaryKey"`
+	CompanyID    uint         `gorm:"not null;uniqueIndex:idx_numbering_sequence_scope"`
+	FranchiseID  uint         `gorm:"not null;default:0;uniqueIndex:idx_numbering_sequence_scope"` // CompanyScope for company documents
+	DocumentType DocumentType `gorm:"type:varchar(50);not null;uniqueIndex:idx_numbering_sequence_scope"`
+	Pattern      string       `gorm:"type:varchar(100);not null"`
+	ResetPolicy  ResetPolicy  `gorm:"type:varchar(20);not null;default:'never'"`
+	Period       string       `gorm:"type:varchar(10)"` // Period the counter belongs to, e.g. "2026" for a yearly reset
+	NextNumber   int64        `gorm:"not null;default:1"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (Sequence) TableName() string {
+	return "numbering_sequences"
+}
+
+// defaultSequences are used until a company configures its own. Documents numbered per
+// franchise each have their own counter, so their numbers must show the franchise code.
+var defaultSequences = map[DocumentType]struct {
+	pattern      string
+	reset        ResetPolicy
+	perFranchise bool
+}{
+	DocumentTypeReceipt:        {"RCP-{FRANCHISE}-{YYYY}-{SEQ:6}", ResetYearly, true},
+	DocumentTypeSupplierBill:   {"BILL-{YYYY}-{SEQ:5}", ResetYearly, false},
+	DocumentTypeWarehouseExit:  {"WB-EXIT-{YYYY}-{SEQ:5}", ResetYearly, false},
+	DocumentTypeWarehouseEntry: {"WB-ENTRY-{FRANCHISE}-{YYYY}-{SEQ:5}", ResetYearly, true},
+	DocumentTypeInvoice:        {"INV-{YYYY}-{SEQ:6}", ResetYearly, false},
+	DocumentTypeProforma:       {"PRO-{YYYY}-{SEQ:5}", ResetYearly, false},
+}
+
+// NewSequence creates a sequence with the default pattern of the document type
+func NewSequence(companyID, franchiseID uint, documentType DocumentType) *Sequence {
+	defaults := defaultSequences[documentType]
+	return &Sequence{
+		CompanyID:    companyID,
+		FranchiseID:  franchiseID,
+		DocumentType: documentType,
+		Pattern:      defaults.pattern,
+		ResetPolicy:  defaults.reset,
+		NextNumber:   1,
+	}
+}
+
+// companyCode stands for the franchise code in company documents
+const companyCode = "HQ"
+
+var placeholderPattern = regexp.MustCompile(`\{([A-Z]+)(?::(\d+))?\}`)
+
+// Validate checks the pattern can only produce distinct numbers under the reset policy
+func (s *Sequence) Validate() error {
+	if !s.DocumentType.IsValid() {
+		return fmt.Errorf("invalid document type %q", s.DocumentType)
+	}
+	if !s.ResetPolicy.IsValid() {
+		return fmt.Errorf("invalid reset policy %q", s.ResetPolicy)
+	}
+	if s.NextNumber < 1 {
+		return fmt.Errorf("next number must be at least 1")
+	}
+	if len(s.Pattern) > 100 {
+		return fmt.Errorf("pattern is too long")
+	}
+
+	counters := 0
+	hasYear, hasMonth := false, false
+	for _, match := range placeholderPattern.FindAllStringSubmatch(s.Pattern, -1) {
+		switch match[1] {
+		case "SEQ":
+			counters++
+			if match[2] != "" {
+				if width, _ := strconv.Atoi(match[2]); width < 1 || width > 12 {
+					return fmt.Errorf("counter width must be between 1 and 12")
+				}
+			}
+		case "YYYY", "YY":
+			hasYear = true
+		case "MM":
+			hasMonth = true
+		case "FRANCHISE":
+		default:
+			return fmt.Errorf("unknown placeholder {%s}", match[1])
+		}
+		if match[2] != "" && match[1] != "SEQ" {
+			return fmt.Errorf("only {SEQ} takes a width")
+		}
+	}
+
+	if counters != 1 {
+		return fmt.Errorf("pattern must contain {SEQ} exactly once")
+	}
+	// Franchise counters run side by side, only the franchise code tells their numbers apart
+	if defaultSequences[s.DocumentType].perFranchise && !s.UsesFranchiseCode() {
+		return fmt.Errorf("%s numbers are counted per franchise and must have {FRANCHISE} in their pattern", s.DocumentType)
+	}
+	// Without the period in the number, a counter starting over would repeat numbers
+	if s.ResetPolicy == ResetYearly && !hasYear {
+		return fmt.Errorf("a yearly sequence must have {YYYY} or {YY} in its pattern")
+	}
+	if s.ResetPolicy == ResetMonthly && (!hasYear || !hasMonth) {
+		return fmt.Errorf("a monthly sequence must have the year and {MM} in its pattern")
+	}
+	return nil
+}
+
+// HasIssued checks if the sequence already gave a number
+func (s *Sequence) HasIssued() bool {
+	return s.NextNumber > 1 || s.Period != ""
+}
+
+// CanFollow checks that the sequence, reconfigured from previous, cannot give a number previous
+// already gave. With the same pattern, the counter carrying on and only starting over when a
+// period of the reset policy ends keeps numbers apart; another pattern must start with fixed
+// text that neither starts nor is the start of the previous one.
+func (s *Sequence) CanFollow(previous *Sequence) bool {
+	if !previous.HasIssued() || s.Pattern == previous.Pattern {
+		return true
+	}
+	prefix, previousPrefix := s.fixedPrefix(), previous.fixedPrefix()
+	return !strings.HasPrefix(prefix, previousPrefix) && !strings.HasPrefix(previousPrefix, prefix)
+}
+
+// fixedPrefix returns the text of the pattern before its first placeholder
+func (s *Sequence) fixedPrefix() string {
+	if loc := placeholderPattern.FindStringIndex(s.Pattern); loc != nil {
+		return s.Pattern[:loc[0]]
+	}
+	return s.Pattern
+}
+
+// CurrentPeriod returns the period the counter belongs to at the given time
+func (s *Sequence) CurrentPeriod(at time.Time) string {
+	switch s.ResetPolicy {
+	case ResetYearly:
+		return at.Format("2006")
+	case ResetMonthly:
+		return at.Format("2006-01")
+	default:
+		return ""
+	}
+}
+
+// Take returns the next number and advances the counter, starting it over when a new period began
+func (s *Sequence) Take(at time.Time, franchiseCode string) string {
+	if period := s.CurrentPeriod(at); period != s.Period {
+		s.Period = period
+		s.NextNumber = 1
+	}
+	number := s.Format(s.NextNumber, at, franchiseCode)
+	s.NextNumber++
+	return number
+}
+
+// Format renders the pattern for a counter value
+func (s *Sequence) Format(n int64, at time.Time, franchiseCode string) string {
+	if franchiseCode == "" {
+		franchiseCode = companyCode
+	}
+	return placeholderPattern.ReplaceAllStringFunc(s.Pattern, func(placeholder string) string {
+		match := placeholderPattern.FindStringSubmatch(placeholder)
+		switch match[1] {
+		case "YYYY":
+			return at.Format("2006")
+		case "YY":
+			return at.Format("06")
+		case "MM":
+			return at.Format("01")
+		case "FRANCHISE":
+			return franchiseCode
+		case "SEQ":
+			if match[2] != "" {
+				return fmt.Sprintf("%0"+match[2]+"d", n)
+			}
+			return strconv.FormatInt(n, 10)
+		}
+		return placeholder
+	})
+}
+
+// UsesFranchiseCode checks if numbers show the franchise code
+func (s *Sequence) UsesFranchiseCode() bool {
+	return strings.Contains(s.Pattern, "{FRANCHISE}")
+}
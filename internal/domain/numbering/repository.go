@@ -0,0 +1,7 @@
+package numbering
+
+type Repository interface {
+	FindByCompany(companyID uint) ([]*Sequence, error)
+	FindByScope(companyID, franchiseID uint, documentType DocumentType) (*Sequence, error)
+	Save(sequence *Sequence) error
+}
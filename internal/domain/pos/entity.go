@@ -1,6 +1,14 @@
 package pos
 
-import "time"
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YasserCherfaoui/darween/internal/domain/tax"
+)
 
 // Customer represents a customer entity
 type Customer struct {
@@ -11,15 +19,38 @@ type Customer struct {
 	Phone          string
 	Address        string
 	TotalPurchases float64 `gorm:"type:decimal(10,2);default:0"`
+	CreditLimit    float64 `gorm:"type:decimal(10,2);default:0"` // Most the customer may owe on account, 0 when not allowed to buy on account
+	IsBusiness     bool    `gorm:"default:false"`                // Business buying for resale, may be sold to at wholesale prices
 	IsActive       bool    `gorm:"default:true"`
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
+
+	// Group whose price lists set the customer's prices, nil for retail prices
+	CustomerGroupID *uint `gorm:"index"`
+
+	// Tax identifiers printed on the invoices of business customers
+	NIF string `gorm:"type:varchar(30)"` // Numéro d'identification fiscale
+	NIS string `gorm:"type:varchar(30)"` // Numéro d'identification statistique
+	RC  string `gorm:"type:varchar(30)"` // Registre du commerce
+	AI  string `gorm:"type:varchar(30)"` // Article d'imposition
 }
 
 func (Customer) TableName() string {
 	return "customers"
 }
 
+// LegalParty returns the customer as the buyer printed on an invoice
+func (c *Customer) LegalParty() LegalParty {
+	return LegalParty{
+		Name:    c.Name,
+		Address: c.Address,
+		NIF:     c.NIF,
+		NIS:     c.NIS,
+		RC:      c.RC,
+		AI:      c.AI,
+	}
+}
+
 // IsValid validates the customer entity
 func (c *Customer) IsValid() bool {
 	return c.Name != "" && c.CompanyID > 0
@@ -32,19 +63,87 @@ func (c *Customer) AddPurchase(amount float64) {
 	}
 }
 
+// CanCharge checks if amount can be put on the customer's account on top of what they already owe
+func (c *Customer) CanCharge(balance, amount float64) bool {
+	return c.CreditLimit > 0 && balance+amount <= c.CreditLimit+0.005
+}
+
+// Absorb takes over a duplicate of the customer: their purchases add up, the higher credit
+// limit is kept and details the customer lacks are copied from the duplicate
+func (c *Customer) Absorb(duplicate *Customer) {
+	c.TotalPurchases = math.Round((c.TotalPurchases+duplicate.TotalPurchases)*100) / 100
+	c.CreditLimit = math.Max(c.CreditLimit, duplicate.CreditLimit)
+	c.IsBusiness = c.IsBusiness || duplicate.IsBusiness
+	if c.CustomerGroupID == nil {
+		c.CustomerGroupID = duplicate.CustomerGroupID
+	}
+
+	for _, field := range []struct{ value, fallback *string }{
+		{&c.Email, &duplicate.Email},
+		{&c.Phone, &duplicate.Phone},
+		{&c.Address, &duplicate.Address},
+		{&c.NIF, &duplicate.NIF},
+		{&c.NIS, &duplicate.NIS},
+		{&c.RC, &duplicate.RC},
+		{&c.AI, &duplicate.AI},
+	} {
+		if *field.value == "" {
+			*field.value = *field.fallback
+		}
+	}
+}
+
+// NormalizeCustomerPhone reduces a phone number to its digits in national format, so that
+// "+213 555 12 34 56", "00213555123456" and "0555-12-34-56" match
+func NormalizeCustomerPhone(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+	digits = strings.TrimPrefix(digits, "00")
+	if strings.HasPrefix(digits, "213") && len(digits) > 10 {
+		digits = "0" + strings.TrimPrefix(digits, "213")
+	}
+	return digits
+}
+
+// NormalizeCustomerEmail returns the form customer emails are compared in
+func NormalizeCustomerEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizeCustomerName returns the form customer names are compared in: lower case, with
+// single spaces between words
+func NormalizeCustomerName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// RemovePurchase takes a voided purchase off the total purchases amount
+func (c *Customer) RemovePurchase(amount float64) {
+	if amount > 0 {
+		c.TotalPurchases -= amount
+		if c.TotalPurchases < 0 {
+			c.TotalPurchases = 0
+		}
+	}
+}
+
 // SaleStatus represents the status of a sale
 type SaleStatus string
 
 const (
-	SaleStatusDraft     SaleStatus = "draft"
-	SaleStatusCompleted SaleStatus = "completed"
-	SaleStatusCancelled SaleStatus = "cancelled"
-	SaleStatusRefunded  SaleStatus = "refunded"
+	SaleStatusDraft             SaleStatus = "draft"
+	SaleStatusCompleted         SaleStatus = "completed"
+	SaleStatusCancelled         SaleStatus = "cancelled"
+	SaleStatusRefunded          SaleStatus = "refunded"
+	SaleStatusPartiallyRefunded SaleStatus = "partially_refunded"
 )
 
 func (s SaleStatus) IsValid() bool {
 	switch s {
-	case SaleStatusDraft, SaleStatusCompleted, SaleStatusCancelled, SaleStatusRefunded:
+	case SaleStatusDraft, SaleStatusCompleted, SaleStatusCancelled, SaleStatusRefunded, SaleStatusPartiallyRefunded:
 		return true
 	}
 	return false
@@ -71,10 +170,10 @@ func (ps PaymentStatus) IsValid() bool {
 // Sale represents a sales transaction
 type Sale struct {
 	ID             uint          `gorm:"primaryKey"`
-	CompanyID      uint          `gorm:"not null;index"`
+	CompanyID      uint          `gorm:"not null;index;uniqueIndex:idx_sales_company_receipt_number"`
 	FranchiseID    *uint         `gorm:"index"`
 	CustomerID     *uint         `gorm:"index"`
-	ReceiptNumber  string        `gorm:"uniqueIndex;not null"`
+	ReceiptNumber  string        `gorm:"uniqueIndex:idx_sales_company_receipt_number;not null"` // Unique per company, from its numbering sequences
 	SubTotal       float64       `gorm:"type:decimal(10,2);not null"`
 	TaxAmount      float64       `gorm:"type:decimal(10,2);default:0"`
 	DiscountAmount float64       `gorm:"type:decimal(10,2);default:0"`
@@ -82,6 +181,17 @@ type Sale struct {
 	PaymentStatus  PaymentStatus `gorm:"type:varchar(50);not null;default:'unpaid'"`
 	SaleStatus     SaleStatus    `gorm:"type:varchar(50);not null;default:'draft'"`
 	Notes          string        `gorm:"type:text"`
+	CouponCode     string        `gorm:"type:varchar(50)"`             // Coupon entered at the till, if any
+	ChargedAmount  float64       `gorm:"type:decimal(10,2);default:0"` // Amount put on the customer's account at checkout
+	ExchangeID     *uint         `gorm:"index"`                        // Set when the sale holds the replacement items of an exchange
+	VoucherID      *uint         `gorm:"index"`                        // Set when the sale sold a gift card instead of items
+	IsWholesale    bool          `gorm:"default:false;index"`          // Priced at wholesale prices for a business customer
+	ParkedAt       *time.Time    `gorm:"index"`                        // Set when the cart was parked to be resumed later
+	ClientUUID     *string       `gorm:"type:varchar(36);uniqueIndex"` // Generated by the POS device for sales rung up offline
+	SyncedAt       *time.Time    `gorm:"index"`                        // Set when a sale rung up offline was uploaded
+	VoidedAt       *time.Time    `gorm:"index"`
+	VoidedByID     *uint         `gorm:"index"`
+	VoidReason     string        `gorm:"type:text"`
 	CreatedByID    uint          `gorm:"not null;index"`
 	CreatedAt      time.Time     `gorm:"index"`
 	UpdatedAt      time.Time
@@ -102,13 +212,77 @@ func (s *Sale) IsValid() bool {
 		s.PaymentStatus.IsValid() && s.SaleStatus.IsValid()
 }
 
-// CalculateTotals recalculates all totals based on items
+// CalculateTotals recalculates all totals based on items. The sale-level discount is
+// spread over the lines first, so that their tax is charged on what is actually paid:
+// SubTotal is the net amount of the lines and TaxAmount the sum of their taxes, both
+// after the discount.
 func (s *Sale) CalculateTotals() {
+	s.apportionDiscount()
+
 	s.SubTotal = 0
+	s.TaxAmount = 0
+	for _, item := range s.Items {
+		s.SubTotal += item.NetAmount
+		s.TaxAmount += item.TaxAmount
+	}
+	s.SubTotal = math.Round(s.SubTotal*100) / 100
+	s.TaxAmount = math.Round(s.TaxAmount*100) / 100
+	s.TotalAmount = s.SubTotal + s.TaxAmount
+}
+
+// apportionDiscount spreads the sale-level discount over the lines in proportion to what
+// they charge without it, the last line taking the rounding difference
+func (s *Sale) apportionDiscount() {
+	itemsTotal := 0.0
+	last := -1
+	for i := range s.Items {
+		s.Items[i].SaleDiscountShare = 0
+		s.Items[i].CalculateTotals()
+		if s.Items[i].TotalAmount > 0 {
+			itemsTotal += s.Items[i].TotalAmount
+			last = i
+		}
+	}
+	if s.DiscountAmount <= 0 || last < 0 {
+		return
+	}
+
+	remaining := s.DiscountAmount
+	for i := range s.Items {
+		item := &s.Items[i]
+		if item.TotalAmount <= 0 {
+			continue
+		}
+		share := math.Round(s.DiscountAmount*item.TotalAmount/itemsTotal*100) / 100
+		if i == last {
+			share = math.Round(remaining*100) / 100
+		}
+		remaining -= share
+		item.SaleDiscountShare = share
+		item.CalculateTotals()
+	}
+}
+
+// SubTotalBeforeDiscount returns the net amount of the lines before the sale-level discount,
+// as printed above the discount on receipts
+func (s *Sale) SubTotalBeforeDiscount() float64 {
+	return math.Round((s.TotalAmount-s.TaxAmount+s.DiscountAmount)*100) / 100
+}
+
+// UnapportionedDiscount returns the part of the sale-level discount its lines do not carry:
+// all of it for sales priced before the discount was spread over the lines, none since
+func (s *Sale) UnapportionedDiscount() float64 {
+	return math.Max(math.Round((s.SubTotal+s.TaxAmount-s.TotalAmount)*100)/100, 0)
+}
+
+// ItemsTotal returns the amount charged for the lines. It is the sale total, except for sales
+// priced before the sale-level discount was spread over the lines.
+func (s *Sale) ItemsTotal() float64 {
+	total := 0.0
 	for _, item := range s.Items {
-		s.SubTotal += item.TotalAmount
+		total += item.TotalAmount
 	}
-	s.TotalAmount = s.SubTotal + s.TaxAmount - s.DiscountAmount
+	return total
 }
 
 // UpdatePaymentStatus updates the payment status based on total payments
@@ -122,9 +296,35 @@ func (s *Sale) UpdatePaymentStatus(totalPaid float64) {
 	}
 }
 
+// IsGiftCardSale checks if the sale sold a gift card rather than items
+func (s *Sale) IsGiftCardSale() bool {
+	return s.VoucherID != nil
+}
+
+// IsOffline checks if the sale was rung up offline and uploaded later
+func (s *Sale) IsOffline() bool {
+	return s.ClientUUID != nil
+}
+
+// IsOnAccount checks if part of the sale was bought on the customer's account
+func (s *Sale) IsOnAccount() bool {
+	return s.ChargedAmount > 0
+}
+
 // CanBeRefunded checks if the sale can be refunded
 func (s *Sale) CanBeRefunded() bool {
-	return s.SaleStatus == SaleStatusCompleted && s.PaymentStatus == PaymentStatusPaid
+	return (s.SaleStatus == SaleStatusCompleted || s.SaleStatus == SaleStatusPartiallyRefunded) &&
+		s.PaymentStatus == PaymentStatusPaid
+}
+
+// ApplyRefund updates the sale status after a refund, depending on whether every line has been returned
+func (s *Sale) ApplyRefund(fullyRefunded bool) {
+	if fullyRefunded {
+		s.SaleStatus = SaleStatusRefunded
+		s.PaymentStatus = PaymentStatusRefunded
+	} else {
+		s.SaleStatus = SaleStatusPartiallyRefunded
+	}
 }
 
 // Complete marks the sale as completed
@@ -137,17 +337,76 @@ func (s *Sale) Cancel() {
 	s.SaleStatus = SaleStatusCancelled
 }
 
+// CanBeVoided checks if the sale can still be voided; refunded or exchanged sales cannot
+func (s *Sale) CanBeVoided() bool {
+	return s.SaleStatus == SaleStatusCompleted && s.ExchangeID == nil
+}
+
+// Void cancels a completed sale as if it never happened
+func (s *Sale) Void(voidedByID uint, reason string) {
+	s.SaleStatus = SaleStatusCancelled
+	now := time.Now()
+	s.VoidedAt = &now
+	s.VoidedByID = &voidedByID
+	s.VoidReason = reason
+}
+
+// CanBeInvoiced checks if the sale went through, so that an invoice can be issued for it
+func (s *Sale) CanBeInvoiced() bool {
+	return s.SaleStatus == SaleStatusCompleted || s.SaleStatus == SaleStatusPartiallyRefunded
+}
+
+// IsVoided checks if the sale was voided
+func (s *Sale) IsVoided() bool {
+	return s.VoidedAt != nil
+}
+
+// Park holds the sale as a draft so it can be resumed later
+func (s *Sale) Park() {
+	s.SaleStatus = SaleStatusDraft
+	now := time.Now()
+	s.ParkedAt = &now
+}
+
+// IsParked checks if the sale is a parked cart waiting to be resumed
+func (s *Sale) IsParked() bool {
+	return s.SaleStatus == SaleStatusDraft && s.ParkedAt != nil
+}
+
 // SaleItem represents an item in a sale
 type SaleItem struct {
 	ID               uint    `gorm:"primaryKey"`
 	SaleID           uint    `gorm:"not null;index;constraint:OnDelete:CASCADE"`
 	ProductVariantID uint    `gorm:"not null;index"`
 	Quantity         int     `gorm:"not null"`
-	UnitPrice        float64 `gorm:"type:decimal(10,2);not null"`
-	DiscountAmount   float64 `gorm:"type:decimal(10,2);default:0"`
+	ListPrice        float64 `gorm:"type:decimal(10,2);default:0"` // Price resolved by the server (franchise > variant > product)
+	UnitPrice        float64 `gorm:"type:decimal(10,2);not null"`  // Price actually charged
+	DiscountAmount   float64 `gorm:"type:decimal(10,2);default:0"` // Manual discount plus PromotionDiscount
 	SubTotal         float64 `gorm:"type:decimal(10,2);not null"`
-	TotalAmount      float64 `gorm:"type:decimal(10,2);not null"`
+	TotalAmount      float64 `gorm:"type:decimal(10,2);not null"` // Amount charged for the line, tax included
 	CreatedAt        time.Time
+
+	// Tax snapshot taken when the line was priced
+	TaxClassID   *uint   `gorm:"index"`
+	TaxRate      float64 `gorm:"type:decimal(5,2);default:0"`
+	TaxInclusive bool    `gorm:"default:false"` // Whether UnitPrice already contained the tax
+	NetAmount    float64 `gorm:"type:decimal(10,2);default:0"`
+	TaxAmount    float64 `gorm:"type:decimal(10,2);default:0"`
+
+	// Promotion that discounted the line, if any
+	PromotionID       *uint   `gorm:"index"`
+	PromotionDiscount float64 `gorm:"type:decimal(10,2);default:0"`
+
+	// Share of the sale-level discount taken off the line, tax included
+	SaleDiscountShare float64 `gorm:"type:decimal(10,2);default:0"`
+
+	// Unit cost snapshot taken from the product's supplier cost, used for gross margin
+	UnitCost *float64 `gorm:"type:decimal(10,2)"`
+
+	// Price override audit (set when UnitPrice differs from ListPrice)
+	IsPriceOverride      bool   `gorm:"default:false;index"`
+	OverrideApprovedByID *uint  `gorm:"index"`
+	OverrideReason       string `gorm:"type:text"`
 }
 
 func (SaleItem) TableName() string {
@@ -159,29 +418,84 @@ func (si *SaleItem) IsValid() bool {
 	return si.SaleID > 0 && si.ProductVariantID > 0 && si.Quantity > 0 && si.UnitPrice >= 0
 }
 
-// CalculateTotals calculates the totals for this item
+// CalculateTotals calculates the totals for this item, splitting the discounted
+// amount into its net and tax parts according to the line's tax snapshot
 func (si *SaleItem) CalculateTotals() {
 	si.SubTotal = float64(si.Quantity) * si.UnitPrice
-	si.TotalAmount = si.SubTotal - si.DiscountAmount
+	amount := si.SubTotal - si.DiscountAmount
+	if si.SaleDiscountShare > 0 {
+		// The share includes tax, so only its net part comes off a price excluding tax
+		if si.TaxInclusive || si.TaxRate <= 0 {
+			amount -= si.SaleDiscountShare
+		} else {
+			amount -= si.SaleDiscountShare / (1 + si.TaxRate/100)
+		}
+		amount = math.Round(amount*100) / 100
+	}
+	si.NetAmount, si.TaxAmount = tax.Calculate(amount, si.TaxRate, si.TaxInclusive)
+	si.TotalAmount = si.NetAmount + si.TaxAmount
+}
+
+// ApplyPromotion adds a promotion's discount to the line
+func (si *SaleItem) ApplyPromotion(promotionID uint, discount float64) {
+	si.PromotionID = &promotionID
+	si.PromotionDiscount = discount
+	si.DiscountAmount += discount
+}
+
+// ApplyTax records the tax class and rate charged on the line
+func (si *SaleItem) ApplyTax(taxClassID *uint, rate float64, inclusive bool) {
+	si.TaxClassID = taxClassID
+	si.TaxRate = rate
+	si.TaxInclusive = inclusive
+}
+
+// ApplyPriceOverride charges a price other than the list price and records who approved it and why
+func (si *SaleItem) ApplyPriceOverride(price float64, approvedByID uint, reason string) {
+	si.UnitPrice = price
+	si.IsPriceOverride = true
+	si.OverrideApprovedByID = &approvedByID
+	si.OverrideReason = reason
+}
+
+// OverrideAmount returns the revenue given up (positive) or gained (negative) by a price override
+func (si *SaleItem) OverrideAmount() float64 {
+	if !si.IsPriceOverride {
+		return 0
+	}
+	return (si.ListPrice - si.UnitPrice) * float64(si.Quantity)
 }
 
 // PaymentMethod represents the method of payment
 type PaymentMethod string
 
 const (
-	PaymentMethodCash   PaymentMethod = "cash"
-	PaymentMethodCard   PaymentMethod = "card"
-	PaymentMethodOther  PaymentMethod = "other"
+	PaymentMethodCash     PaymentMethod = "cash"
+	PaymentMethodCard     PaymentMethod = "card"
+	PaymentMethodOther    PaymentMethod = "other"
+	PaymentMethodVoucher  PaymentMethod = "voucher"
+	PaymentMethodExchange PaymentMethod = "exchange"       // Credit from items returned in an exchange
+	PaymentMethodLoyalty  PaymentMethod = "loyalty_points" // Customer's loyalty points, see loyalty.Program
 )
 
 func (pm PaymentMethod) IsValid() bool {
 	switch pm {
-	case PaymentMethodCash, PaymentMethodCard, PaymentMethodOther:
+	case PaymentMethodCash, PaymentMethodCard, PaymentMethodOther, PaymentMethodVoucher, PaymentMethodExchange, PaymentMethodLoyalty:
 		return true
 	}
 	return false
 }
 
+// IsTender checks if the method can be chosen by a cashier to pay or refund money
+func (pm PaymentMethod) IsTender() bool {
+	return pm.IsValid() && pm != PaymentMethodExchange
+}
+
+// GivesChange checks if the customer may hand over more than is due and get change back
+func (pm PaymentMethod) GivesChange() bool {
+	return pm == PaymentMethodCash
+}
+
 // PaymentTransactionStatus represents the status of a payment transaction
 type PaymentTransactionStatus string
 
@@ -190,12 +504,13 @@ const (
 	PaymentTransactionStatusCompleted PaymentTransactionStatus = "completed"
 	PaymentTransactionStatusFailed    PaymentTransactionStatus = "failed"
 	PaymentTransactionStatusRefunded  PaymentTransactionStatus = "refunded"
+	PaymentTransactionStatusVoided    PaymentTransactionStatus = "voided"
 )
 
 func (pts PaymentTransactionStatus) IsValid() bool {
 	switch pts {
 	case PaymentTransactionStatusPending, PaymentTransactionStatusCompleted, 
-		PaymentTransactionStatusFailed, PaymentTransactionStatusRefunded:
+		PaymentTransactionStatusFailed, PaymentTransactionStatusRefunded, PaymentTransactionStatusVoided:
 		return true
 	}
 	return false
@@ -203,14 +518,16 @@ func (pts PaymentTransactionStatus) IsValid() bool {
 
 // Payment represents a payment transaction for a sale
 type Payment struct {
-	ID            uint                     `gorm:"primaryKey"`
-	SaleID        uint                     `gorm:"not null;index;constraint:OnDelete:CASCADE"`
-	PaymentMethod PaymentMethod            `gorm:"type:varchar(50);not null"`
-	Amount        float64                  `gorm:"type:decimal(10,2);not null"`
-	PaymentStatus PaymentTransactionStatus `gorm:"type:varchar(50);not null;default:'pending'"`
-	Reference     string                   `gorm:"type:varchar(255)"` // Card transaction ref, check number, etc.
-	Notes         string                   `gorm:"type:text"`
-	CreatedAt     time.Time                `gorm:"index"`
+	ID             uint                     `gorm:"primaryKey"`
+	SaleID         uint                     `gorm:"not null;index;constraint:OnDelete:CASCADE"`
+	PaymentMethod  PaymentMethod            `gorm:"type:varchar(50);not null"`
+	Amount         float64                  `gorm:"type:decimal(10,2);not null"`  // Amount applied to the sale
+	TenderedAmount float64                  `gorm:"type:decimal(10,2);default:0"` // Amount handed over by the customer
+	ChangeAmount   float64                  `gorm:"type:decimal(10,2);default:0"` // Amount given back
+	PaymentStatus  PaymentTransactionStatus `gorm:"type:varchar(50);not null;default:'pending'"`
+	Reference      string                   `gorm:"type:varchar(255)"` // Card transaction ref, check number, etc.
+	Notes          string                   `gorm:"type:text"`
+	CreatedAt      time.Time                `gorm:"index"`
 }
 
 func (Payment) TableName() string {
@@ -263,6 +580,7 @@ type CashDrawer struct {
 
 	// Relationships
 	Transactions []CashDrawerTransaction `gorm:"foreignKey:CashDrawerID"`
+	Counts       []CashDrawerCount       `gorm:"foreignKey:CashDrawerID"` // Cash counted per denomination at closing
 }
 
 func (CashDrawer) TableName() string {
@@ -294,6 +612,32 @@ func (cd *CashDrawer) Close(closingBalance float64, closedByID uint, expectedBal
 	cd.ClosedAt = &now
 }
 
+// CashDrawerCount is how many banknotes or coins of one denomination were counted when closing a drawer
+type CashDrawerCount struct {
+	ID           uint    `gorm:"primaryKey"`
+	CashDrawerID uint    `gorm:"not null;index;constraint:OnDelete:CASCADE"`
+	Denomination float64 `gorm:"type:decimal(10,2);not null"`
+	Quantity     int     `gorm:"not null"`
+}
+
+func (CashDrawerCount) TableName() string {
+	return "cash_drawer_counts"
+}
+
+// Total returns the value of the counted banknotes or coins
+func (c *CashDrawerCount) Total() float64 {
+	return math.Round(c.Denomination*float64(c.Quantity)*100) / 100
+}
+
+// CountedTotal returns the value of the cash counted per denomination
+func CountedTotal(counts []CashDrawerCount) float64 {
+	total := 0.0
+	for i := range counts {
+		total += counts[i].Total()
+	}
+	return math.Round(total*100) / 100
+}
+
 // CashDrawerTransactionType represents the type of cash drawer transaction
 type CashDrawerTransactionType string
 
@@ -301,24 +645,69 @@ const (
 	CashDrawerTransactionTypeSale       CashDrawerTransactionType = "sale"
 	CashDrawerTransactionTypeRefund     CashDrawerTransactionType = "refund"
 	CashDrawerTransactionTypeAdjustment CashDrawerTransactionType = "adjustment"
+	CashDrawerTransactionTypeVoid       CashDrawerTransactionType = "void"
+	CashDrawerTransactionTypePayIn      CashDrawerTransactionType = "pay_in"    // Cash added, e.g. a float top-up
+	CashDrawerTransactionTypePayOut     CashDrawerTransactionType = "pay_out"   // Cash paid out, e.g. a petty-cash expense
+	CashDrawerTransactionTypeSafeDrop   CashDrawerTransactionType = "safe_drop" // Cash moved to the safe during the shift
 )
 
 func (cdtt CashDrawerTransactionType) IsValid() bool {
 	switch cdtt {
-	case CashDrawerTransactionTypeSale, CashDrawerTransactionTypeRefund, CashDrawerTransactionTypeAdjustment:
+	case CashDrawerTransactionTypeSale, CashDrawerTransactionTypeRefund, CashDrawerTransactionTypeAdjustment,
+		CashDrawerTransactionTypeVoid, CashDrawerTransactionTypePayIn, CashDrawerTransactionTypePayOut,
+		CashDrawerTransactionTypeSafeDrop:
 		return true
 	}
 	return false
 }
 
-// CashDrawerTransaction represents a transaction in the cash drawer
+// IsCashMovement checks if the type is recorded by hand rather than by a sale, refund or void
+func (cdtt CashDrawerTransactionType) IsCashMovement() bool {
+	switch cdtt {
+	case CashDrawerTransactionTypePayIn, CashDrawerTransactionTypePayOut, CashDrawerTransactionTypeSafeDrop:
+		return true
+	}
+	return false
+}
+
+// TakesCashOut checks if the movement removes cash from the drawer
+func (cdtt CashDrawerTransactionType) TakesCashOut() bool {
+	return cdtt == CashDrawerTransactionTypePayOut || cdtt == CashDrawerTransactionTypeSafeDrop
+}
+
+// CashMovementReason is the reason code given for a pay-in, pay-out or safe drop
+type CashMovementReason string
+
+const (
+	CashMovementReasonFloat           CashMovementReason = "float"            // Change float added or topped up
+	CashMovementReasonPettyCash       CashMovementReason = "petty_cash"       // Small expenses such as supplies
+	CashMovementReasonSupplierPayment CashMovementReason = "supplier_payment" // Delivery paid in cash
+	CashMovementReasonBankDeposit     CashMovementReason = "bank_deposit"     // Cash taken to the bank or the safe
+	CashMovementReasonCorrection      CashMovementReason = "correction"       // Fixes a counting or recording mistake
+	CashMovementReasonOther           CashMovementReason = "other"
+)
+
+func (cmr CashMovementReason) IsValid() bool {
+	switch cmr {
+	case CashMovementReasonFloat, CashMovementReasonPettyCash, CashMovementReasonSupplierPayment,
+		CashMovementReasonBankDeposit, CashMovementReasonCorrection, CashMovementReasonOther:
+		return true
+	}
+	return false
+}
+
+// CashDrawerTransaction represents a transaction in the cash drawer.
+// Amounts are signed: money leaving the drawer (refunds, voids) is negative.
 type CashDrawerTransaction struct {
 	ID              uint                      `gorm:"primaryKey"`
 	CashDrawerID    uint                      `gorm:"not null;index;constraint:OnDelete:CASCADE"`
 	TransactionType CashDrawerTransactionType `gorm:"type:varchar(50);not null"`
 	Amount          float64                   `gorm:"type:decimal(10,2);not null"`
-	SaleID          *uint                     `gorm:"index"` // Reference to sale if applicable
+	SaleID          *uint                     `gorm:"index"`            // Reference to sale if applicable
+	ReasonCode      CashMovementReason        `gorm:"type:varchar(50)"` // Set on pay-ins, pay-outs and safe drops
 	Notes           string                    `gorm:"type:text"`
+	CreatedByID     *uint                     `gorm:"index"`
+	ApprovedByID    *uint                     `gorm:"index"` // Manager who approved a movement above the company's limit
 	CreatedAt       time.Time                 `gorm:"index"`
 }
 
@@ -328,6 +717,9 @@ func (CashDrawerTransaction) TableName() string {
 
 // IsValid validates the cash drawer transaction
 func (cdt *CashDrawerTransaction) IsValid() bool {
+	if cdt.TransactionType.IsCashMovement() && (!cdt.ReasonCode.IsValid() || cdt.CreatedByID == nil) {
+		return false
+	}
 	return cdt.CashDrawerID > 0 && cdt.TransactionType.IsValid()
 }
 
@@ -356,12 +748,16 @@ type Refund struct {
 	Reason         string        `gorm:"type:text"`
 	RefundMethod   PaymentMethod `gorm:"type:varchar(50);not null"`
 	RefundStatus   RefundStatus  `gorm:"type:varchar(50);not null;default:'pending'"`
+	ExchangeID     *uint         `gorm:"index"` // Set when the returned items were exchanged rather than refunded
+	VoucherID      *uint         `gorm:"index"` // Store credit issued when refunded by voucher
+	VoucherCode    string        `gorm:"type:varchar(50)"`
 	ProcessedByID  uint          `gorm:"not null;index"`
 	CreatedAt      time.Time     `gorm:"index"`
 	UpdatedAt      time.Time
 
 	// Relationships
-	OriginalSale *Sale `gorm:"foreignKey:OriginalSaleID"`
+	OriginalSale *Sale        `gorm:"foreignKey:OriginalSaleID"`
+	Items        []RefundItem `gorm:"foreignKey:RefundID"`
 }
 
 func (Refund) TableName() string {
@@ -384,3 +780,426 @@ func (r *Refund) Cancel() {
 	r.RefundStatus = RefundStatusCancelled
 }
 
+// RefundItem represents a quantity of a sale item returned in a refund
+type RefundItem struct {
+	ID               uint    `gorm:"primaryKey"`
+	RefundID         uint    `gorm:"not null;index;constraint:OnDelete:CASCADE"`
+	SaleItemID       uint    `gorm:"not null;index"`
+	ProductVariantID uint    `gorm:"not null;index"`
+	Quantity         int     `gorm:"not null"`
+	RefundAmount     float64 `gorm:"type:decimal(10,2);not null"`
+	CreatedAt        time.Time
+}
+
+func (RefundItem) TableName() string {
+	return "refund_items"
+}
+
+// IsValid validates the refund item
+func (ri *RefundItem) IsValid() bool {
+	return ri.SaleItemID > 0 && ri.ProductVariantID > 0 && ri.Quantity > 0 && ri.RefundAmount >= 0
+}
+
+// Exchange represents items returned from a sale and swapped for replacement items,
+// settled by the price difference only
+type Exchange struct {
+	ID                uint          `gorm:"primaryKey"`
+	CompanyID         uint          `gorm:"not null;index"`
+	FranchiseID       *uint         `gorm:"index"`
+	OriginalSaleID    uint          `gorm:"not null;index"`
+	RefundID          *uint         `gorm:"index"`
+	ReplacementSaleID *uint         `gorm:"index"`
+	ReturnedAmount    float64       `gorm:"type:decimal(10,2);not null"`
+	ReplacementAmount float64       `gorm:"type:decimal(10,2);not null"`
+	BalanceAmount     float64       `gorm:"type:decimal(10,2);not null"` // Positive when the customer pays, negative when the customer is paid back
+	SettlementMethod  PaymentMethod `gorm:"type:varchar(50);not null"`
+	Reason            string        `gorm:"type:text"`
+	ProcessedByID     uint          `gorm:"not null;index"`
+	ApprovedByID      *uint         `gorm:"index"` // Manager who approved paying the customer back
+	CreatedAt         time.Time     `gorm:"index"`
+	UpdatedAt         time.Time
+
+	// Relationships
+	OriginalSale    *Sale   `gorm:"foreignKey:OriginalSaleID"`
+	Refund          *Refund `gorm:"foreignKey:RefundID"`
+	ReplacementSale *Sale   `gorm:"foreignKey:ReplacementSaleID"`
+}
+
+func (Exchange) TableName() string {
+	return "exchanges"
+}
+
+// IsValid validates the exchange
+func (e *Exchange) IsValid() bool {
+	return e.CompanyID > 0 && e.OriginalSaleID > 0 && e.ProcessedByID > 0 &&
+		e.ReturnedAmount >= 0 && e.ReplacementAmount >= 0 && e.SettlementMethod.IsTender()
+}
+
+// LayawayStatus represents the status of a layaway
+type LayawayStatus string
+
+const (
+	LayawayStatusActive    LayawayStatus = "active"    // Stock held while the customer pays deposits
+	LayawayStatusCompleted LayawayStatus = "completed" // Picked up, the held sale was completed
+	LayawayStatusExpired   LayawayStatus = "expired"   // Not picked up in time, the stock was released
+	LayawayStatusCancelled LayawayStatus = "cancelled" // Cancelled before expiry, the stock was released
+)
+
+func (ls LayawayStatus) IsValid() bool {
+	switch ls {
+	case LayawayStatusActive, LayawayStatusCompleted, LayawayStatusExpired, LayawayStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// DepositStatus tells what became of the deposits paid on a layaway
+type DepositStatus string
+
+const (
+	DepositStatusHeld      DepositStatus = "held"      // Kept while the layaway is active
+	DepositStatusApplied   DepositStatus = "applied"   // Counted towards the completed sale
+	DepositStatusToRefund  DepositStatus = "to_refund" // To be given back to the customer
+	DepositStatusRefunded  DepositStatus = "refunded"
+	DepositStatusForfeited DepositStatus = "forfeited" // Kept by the store
+)
+
+func (ds DepositStatus) IsValid() bool {
+	switch ds {
+	case DepositStatusHeld, DepositStatusApplied, DepositStatusToRefund, DepositStatusRefunded, DepositStatusForfeited:
+		return true
+	}
+	return false
+}
+
+// Layaway holds items for a customer against deposits until they are picked up. The items,
+// priced when put on layaway, are a draft sale whose stock stays reserved and whose payments
+// are the deposits; picking them up completes that sale.
+type Layaway struct {
+	ID              uint          `gorm:"primaryKey"`
+	CompanyID       uint          `gorm:"not null;index"`
+	FranchiseID     *uint         `gorm:"index"`
+	CustomerID      uint          `gorm:"not null;index"`
+	SaleID          uint          `gorm:"not null;uniqueIndex"`
+	Status          LayawayStatus `gorm:"type:varchar(50);not null;default:'active';index"`
+	ExpiresAt       time.Time     `gorm:"not null;index"`
+	ForfeitOnExpiry bool          `gorm:"default:false"` // Whether the store keeps the deposits when the layaway expires
+	DepositStatus   DepositStatus `gorm:"type:varchar(50);not null;default:'held'"`
+	DepositRefundID *uint         `gorm:"index"` // First of the refunds, one per tender, that gave the deposits back
+	ClosedAt        *time.Time
+	ClosedByID      *uint     `gorm:"index"` // Empty when the layaway expired
+	CloseReason     string    `gorm:"type:text"`
+	CreatedByID     uint      `gorm:"not null;index"`
+	CreatedAt       time.Time `gorm:"index"`
+	UpdatedAt       time.Time
+
+	// Relationships
+	Sale     *Sale     `gorm:"foreignKey:SaleID"`
+	Customer *Customer `gorm:"foreignKey:CustomerID"`
+}
+
+func (Layaway) TableName() string {
+	return "layaways"
+}
+
+// IsValid validates the layaway
+func (l *Layaway) IsValid() bool {
+	return l.CompanyID > 0 && l.CustomerID > 0 && l.SaleID > 0 && l.CreatedByID > 0 &&
+		l.Status.IsValid() && l.DepositStatus.IsValid()
+}
+
+// IsActive checks if the stock is still held for the customer
+func (l *Layaway) IsActive() bool {
+	return l.Status == LayawayStatusActive
+}
+
+// IsExpired checks if the layaway was not picked up in time
+func (l *Layaway) IsExpired(at time.Time) bool {
+	return at.After(l.ExpiresAt)
+}
+
+// Complete records that the customer picked the items up
+func (l *Layaway) Complete(closedByID uint) {
+	l.Status = LayawayStatusCompleted
+	l.DepositStatus = DepositStatusApplied
+	l.close(&closedByID, "")
+}
+
+// Expire releases the layaway once its hold ran out, the deposits going back to the
+// customer unless agreed otherwise
+func (l *Layaway) Expire() {
+	l.Status = LayawayStatusExpired
+	l.DepositStatus = DepositStatusToRefund
+	if l.ForfeitOnExpiry {
+		l.DepositStatus = DepositStatusForfeited
+	}
+	l.close(nil, "Not picked up before the layaway expired")
+}
+
+// Cancel releases the layaway before it expires
+func (l *Layaway) Cancel(closedByID uint, reason string, forfeitDeposit bool) {
+	l.Status = LayawayStatusCancelled
+	l.DepositStatus = DepositStatusToRefund
+	if forfeitDeposit {
+		l.DepositStatus = DepositStatusForfeited
+	}
+	l.close(&closedByID, reason)
+}
+
+func (l *Layaway) close(closedByID *uint, reason string) {
+	now := time.Now()
+	l.ClosedAt = &now
+	l.ClosedByID = closedByID
+	l.CloseReason = reason
+}
+
+// LegalParty is the seller or the buyer as printed on an invoice
+type LegalParty struct {
+	Name    string `gorm:"not null"`
+	Address string `gorm:"type:text"`
+	NIF     string `gorm:"type:varchar(30)"`
+	NIS     string `gorm:"type:varchar(30)"`
+	RC      string `gorm:"type:varchar(30)"`
+	AI      string `gorm:"type:varchar(30)"`
+}
+
+// Invoice is the legal invoice (facture) of a sale. Its number comes from the company's
+// invoice sequence, and the seller and buyer are copied when it is issued so that
+// reprints stay identical to the original.
+type Invoice struct {
+	ID            uint       `gorm:"primaryKey"`
+	CompanyID     uint       `gorm:"not null;index;uniqueIndex:idx_invoices_company_invoice_number"`
+	FranchiseID   *uint      `gorm:"index"`
+	SaleID        uint       `gorm:"not null;uniqueIndex"`
+	InvoiceNumber string     `gorm:"type:varchar(100);not null;uniqueIndex:idx_invoices_company_invoice_number"`
+	Seller        LegalParty `gorm:"embedded;embeddedPrefix:seller_"`
+	Buyer         LegalParty `gorm:"embedded;embeddedPrefix:buyer_"`
+	IssuedByID    uint       `gorm:"not null;index"`
+	CreatedAt     time.Time  `gorm:"index"` // Issue date
+
+	// Relationships
+	Sale *Sale `gorm:"foreignKey:SaleID"`
+}
+
+func (Invoice) TableName() string {
+	return "invoices"
+}
+
+// IsValid validates the invoice
+func (i *Invoice) IsValid() bool {
+	return i.CompanyID > 0 && i.SaleID > 0 && i.InvoiceNumber != "" && i.IssuedByID > 0 &&
+		i.Seller.Name != "" && i.Buyer.Name != ""
+}
+
+// ProformaStatus represents the status of a proforma invoice
+type ProformaStatus string
+
+const (
+	ProformaStatusOpen      ProformaStatus = "open"      // Quoted to the customer, waiting for an answer
+	ProformaStatusConverted ProformaStatus = "converted" // Accepted, its sale was completed
+	ProformaStatusCancelled ProformaStatus = "cancelled"
+)
+
+func (ps ProformaStatus) IsValid() bool {
+	switch ps {
+	case ProformaStatusOpen, ProformaStatusConverted, ProformaStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// Proforma is a quote given to a customer before they commit to buying. Its lines, priced
+// when quoted, are a draft sale that reserves no stock; converting the proforma completes
+// that sale at the quoted prices.
+type Proforma struct {
+	ID             uint           `gorm:"primaryKey"`
+	CompanyID      uint           `gorm:"not null;index;uniqueIndex:idx_proformas_company_proforma_number"`
+	FranchiseID    *uint          `gorm:"index"`
+	CustomerID     uint           `gorm:"not null;index"`
+	SaleID         uint           `gorm:"not null;uniqueIndex"`
+	ProformaNumber string         `gorm:"type:varchar(100);not null;uniqueIndex:idx_proformas_company_proforma_number"`
+	Status         ProformaStatus `gorm:"type:varchar(50);not null;default:'open';index"`
+	ValidUntil     time.Time      `gorm:"not null"` // Prices are honoured until then
+	ClosedAt       *time.Time
+	ClosedByID     *uint     `gorm:"index"`
+	CreatedByID    uint      `gorm:"not null;index"`
+	CreatedAt      time.Time `gorm:"index"`
+	UpdatedAt      time.Time
+
+	// Relationships
+	Sale     *Sale     `gorm:"foreignKey:SaleID"`
+	Customer *Customer `gorm:"foreignKey:CustomerID"`
+}
+
+func (Proforma) TableName() string {
+	return "proformas"
+}
+
+// IsValid validates the proforma
+func (p *Proforma) IsValid() bool {
+	return p.CompanyID > 0 && p.CustomerID > 0 && p.SaleID > 0 && p.ProformaNumber != "" &&
+		p.CreatedByID > 0 && p.Status.IsValid()
+}
+
+// IsOpen checks if the proforma can still be converted or cancelled
+func (p *Proforma) IsOpen() bool {
+	return p.Status == ProformaStatusOpen
+}
+
+// IsExpired checks if the quoted prices are no longer honoured
+func (p *Proforma) IsExpired(at time.Time) bool {
+	return at.After(p.ValidUntil)
+}
+
+// Convert records that the customer accepted the proforma
+func (p *Proforma) Convert(closedByID uint) {
+	p.Status = ProformaStatusConverted
+	p.close(closedByID)
+}
+
+// Cancel records that the proforma will not be converted
+func (p *Proforma) Cancel(closedByID uint) {
+	p.Status = ProformaStatusCancelled
+	p.close(closedByID)
+}
+
+func (p *Proforma) close(closedByID uint) {
+	now := time.Now()
+	p.ClosedAt = &now
+	p.ClosedByID = &closedByID
+}
+
+// CalculateBalance sets the amount the customer owes (positive) or is owed (negative)
+func (e *Exchange) CalculateBalance() {
+	e.BalanceAmount = e.ReplacementAmount - e.ReturnedAmount
+}
+
+// CreditAmount returns the part of the returned value applied to the replacement sale
+func (e *Exchange) CreditAmount() float64 {
+	if e.ReturnedAmount < e.ReplacementAmount {
+		return e.ReturnedAmount
+	}
+	return e.ReplacementAmount
+}
+
+// CustomerPayment is money a customer paid towards the sales they bought on account
+type CustomerPayment struct {
+	ID            uint          `gorm:"primaryKey"`
+	CompanyID     uint          `gorm:"not null;index"`
+	CustomerID    uint          `gorm:"not null;index"`
+	Amount        float64       `gorm:"type:decimal(10,2);not null"`
+	PaymentMethod PaymentMethod `gorm:"type:varchar(50);not null"`
+	Reference     string        `gorm:"type:varchar(255)"`
+	Notes         string        `gorm:"type:text"`
+	CreatedByID   uint          `gorm:"not null;index"`
+	CreatedAt     time.Time     `gorm:"index"`
+
+	// Relationships
+	Distributions []CustomerPaymentDistribution `gorm:"foreignKey:CustomerPaymentID;constraint:OnDelete:CASCADE"`
+}
+
+func (CustomerPayment) TableName() string {
+	return "customer_payments"
+}
+
+// IsValid validates the customer payment
+func (cp *CustomerPayment) IsValid() bool {
+	return cp.CompanyID > 0 && cp.CustomerID > 0 && cp.Amount > 0 && cp.CreatedByID > 0 &&
+		cp.PaymentMethod.IsTender() && cp.PaymentMethod != PaymentMethodLoyalty
+}
+
+// CustomerPaymentDistribution represents the share of a customer payment settled against one sale
+type CustomerPaymentDistribution struct {
+	ID                uint      `gorm:"primaryKey"`
+	CustomerPaymentID uint      `gorm:"not null;index"`
+	SaleID            uint      `gorm:"not null;index"`
+	PaymentID         uint      `gorm:"not null;index"` // Payment recorded on the sale for this share
+	Amount            float64   `gorm:"type:decimal(10,2);not null"`
+	CreatedAt         time.Time
+
+	// Relationships for foreign key constraints
+	CustomerPayment *CustomerPayment `gorm:"foreignKey:CustomerPaymentID;references:ID;constraint:OnDelete:CASCADE"`
+	Sale            *Sale            `gorm:"foreignKey:SaleID;references:ID;constraint:OnDelete:RESTRICT"`
+}
+
+func (CustomerPaymentDistribution) TableName() string {
+	return "customer_payment_distributions"
+}
+
+// IsValid validates the payment distribution
+func (cpd *CustomerPaymentDistribution) IsValid() bool {
+	return cpd.CustomerPaymentID > 0 && cpd.SaleID > 0 && cpd.PaymentID > 0 && cpd.Amount > 0
+}
+
+// OfflineReceiptRange is a block of receipt numbers reserved by a POS device, which it uses
+// for the sales it rings up while it cannot reach the server. Ranges of a company never overlap.
+type OfflineReceiptRange struct {
+	ID           uint   `gorm:"primaryKey"`
+	CompanyID    uint   `gorm:"not null;index"`
+	FranchiseID  *uint  `gorm:"index"`
+	DeviceID     string `gorm:"type:varchar(100);not null;index"`
+	StartNumber  int    `gorm:"not null"`
+	EndNumber    int    `gorm:"not null"`
+	ReservedByID uint   `gorm:"not null"`
+	CreatedAt    time.Time
+}
+
+func (OfflineReceiptRange) TableName() string {
+	return "offline_receipt_ranges"
+}
+
+// OfflineReceiptNumber formats the nth receipt number of the company's offline ranges
+func OfflineReceiptNumber(companyID uint, n int) string {
+	return fmt.Sprintf("%s%06d", OfflineReceiptPrefix(companyID), n)
+}
+
+// OfflineReceiptPrefix returns the prefix of the company's offline receipt numbers
+func OfflineReceiptPrefix(companyID uint) string {
+	return fmt.Sprintf("OFF-%d-", companyID)
+}
+
+// ParseOfflineReceiptNumber returns the number of an offline receipt number of the company
+func ParseOfflineReceiptNumber(companyID uint, receiptNumber string) (int, bool) {
+	digits, ok := strings.CutPrefix(receiptNumber, OfflineReceiptPrefix(companyID))
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(digits)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// Contains checks if the number belongs to the range
+func (r *OfflineReceiptRange) Contains(n int) bool {
+	return n >= r.StartNumber && n <= r.EndNumber
+}
+
+// SyncConflictType identifies what did not match when an offline sale was uploaded
+type SyncConflictType string
+
+const (
+	SyncConflictPriceMismatch  SyncConflictType = "price_mismatch"  // The device charged a price other than the server's
+	SyncConflictNegativeStock  SyncConflictType = "negative_stock"  // The sale took more stock than was available
+	SyncConflictTotalMismatch  SyncConflictType = "total_mismatch"  // The server computed another total than the device
+	SyncConflictUnderpaid      SyncConflictType = "underpaid"       // The tenders do not cover the server's total
+	SyncConflictTenderRejected SyncConflictType = "tender_rejected" // The tenders could not be applied, e.g. a card paid more than the server's total
+)
+
+// SyncConflict records a discrepancy found while accepting an offline sale, for a manager to review.
+// The sale is kept as it happened; conflicts never cause it to be rejected.
+type SyncConflict struct {
+	ID               uint             `gorm:"primaryKey"`
+	SaleID           uint             `gorm:"not null;index;constraint:OnDelete:CASCADE"`
+	Type             SyncConflictType `gorm:"type:varchar(50);not null"`
+	ProductVariantID *uint            `gorm:"index"`
+	Expected         float64          `gorm:"type:decimal(10,2);default:0"` // Server side value
+	Actual           float64          `gorm:"type:decimal(10,2);default:0"` // Value uploaded by the device
+	Message          string           `gorm:"type:text"`
+	CreatedAt        time.Time
+}
+
+func (SyncConflict) TableName() string {
+	return "sale_sync_conflicts"
+}
@@ -9,6 +9,8 @@ type CustomerRepository interface {
 	FindByID(id uint) (*Customer, error)
 	FindByCompanyID(companyID uint, page, limit int) ([]*Customer, int64, error)
 	FindByEmail(email string, companyID uint) (*Customer, error)
+	FindAllByCompanyID(companyID uint) ([]*Customer, error)
+	StreamByCompanyID(companyID uint, fn func(customers []*Customer) error) error
 	Delete(id uint) error
 }
 
@@ -17,12 +19,18 @@ type SaleRepository interface {
 	Create(sale *Sale) error
 	Update(sale *Sale) error
 	FindByID(id uint) (*Sale, error)
-	FindByReceiptNumber(receiptNumber string) (*Sale, error)
+	FindByReceiptNumber(companyID uint, receiptNumber string) (*Sale, error)
+	FindByClientUUID(companyID uint, clientUUID string) (*Sale, error)
 	FindByCompanyID(companyID uint, page, limit int) ([]*Sale, int64, error)
 	FindByFranchiseID(franchiseID uint, page, limit int) ([]*Sale, int64, error)
+	StreamByCompanyID(companyID uint, franchiseID *uint, fn func(sales []*Sale) error) error
 	FindByDateRange(companyID uint, franchiseID *uint, startDate, endDate time.Time, page, limit int) ([]*Sale, int64, error)
 	FindByCustomerID(customerID uint, page, limit int) ([]*Sale, int64, error)
+	FindParked(companyID uint, franchiseID *uint) ([]*Sale, error)
+	FindParkedBefore(cutoff time.Time) ([]*Sale, error)
 	GetSalesReport(companyID uint, franchiseID *uint, startDate, endDate time.Time) (*SalesReportData, error)
+	GetSalesAnalytics(filter SalesAnalyticsFilter) (*SalesAnalyticsData, error)
+	GetSalesAnalyticsSummary(filter SalesAnalyticsFilter) (*AnalyticsLine, error)
 }
 
 // SaleItemRepository defines the interface for sale item data operations
@@ -52,6 +60,8 @@ type CashDrawerRepository interface {
 	FindActiveByFranchiseID(franchiseID uint) (*CashDrawer, error)
 	FindByCompanyID(companyID uint, page, limit int) ([]*CashDrawer, int64, error)
 	FindByFranchiseID(franchiseID uint, page, limit int) ([]*CashDrawer, int64, error)
+	StreamByCompanyID(companyID uint, franchiseID *uint, fn func(drawers []*CashDrawer) error) error
+	GetShiftReport(drawer *CashDrawer, until time.Time) (*ShiftReportData, error)
 }
 
 // CashDrawerTransactionRepository defines the interface for cash drawer transaction data operations
@@ -69,16 +79,171 @@ type RefundRepository interface {
 	FindBySaleID(saleID uint) ([]*Refund, error)
 	FindByCompanyID(companyID uint, page, limit int) ([]*Refund, int64, error)
 	FindByFranchiseID(franchiseID uint, page, limit int) ([]*Refund, int64, error)
+	StreamByCompanyID(companyID uint, franchiseID *uint, fn func(refunds []*Refund) error) error
+	GetRefundedQuantitiesBySaleID(saleID uint) (map[uint]int, error)
+	GetTotalRefundedForSale(saleID uint) (float64, error)
+}
+
+// ExchangeRepository defines the interface for exchange data operations
+type ExchangeRepository interface {
+	Create(exchange *Exchange) error
+	Update(exchange *Exchange) error
+	FindByID(id uint) (*Exchange, error)
+	FindBySaleID(saleID uint) ([]*Exchange, error)
+	FindByCompanyID(companyID uint, page, limit int) ([]*Exchange, int64, error)
+	FindByFranchiseID(franchiseID uint, page, limit int) ([]*Exchange, int64, error)
+}
+
+// LayawayRepository defines the interface for layaway data operations
+type LayawayRepository interface {
+	FindByID(id uint) (*Layaway, error)
+	FindByCompanyID(companyID uint, franchiseID *uint, status LayawayStatus, page, limit int) ([]*Layaway, int64, error)
+	FindActiveExpiredBefore(cutoff time.Time) ([]*Layaway, error)
+}
+
+// InvoiceRepository defines the interface for invoice data operations
+type InvoiceRepository interface {
+	FindBySaleID(saleID uint) (*Invoice, error)
+}
+
+// ProformaRepository defines the interface for proforma data operations
+type ProformaRepository interface {
+	FindByID(id uint) (*Proforma, error)
+	FindByCompanyID(companyID uint, franchiseID *uint, status ProformaStatus, page, limit int) ([]*Proforma, int64, error)
+}
+
+// CustomerAccountRepository defines the interface for customer credit account data operations
+type CustomerAccountRepository interface {
+	FindPaymentByID(id uint) (*CustomerPayment, error)
+	FindPaymentsByCustomerID(customerID uint, startDate, endDate time.Time) ([]*CustomerPayment, error)
+	FindChargedSales(customerID uint, startDate, endDate time.Time) ([]*Sale, error)
+	FindOpenBalances(companyID uint, customerID *uint) ([]AccountSaleBalance, error)
+	GetBalanceBefore(customerID uint, date time.Time) (float64, error)
+	CountDistributionsBySaleID(saleID uint) (int64, error)
+}
+
+// AccountSaleBalance is what a customer still owes on one sale bought on account
+type AccountSaleBalance struct {
+	SaleID        uint
+	CustomerID    uint
+	CustomerName  string
+	CreditLimit   float64
+	ReceiptNumber string
+	TotalAmount   float64
+	ChargedAmount float64
+	Outstanding   float64
+	CreatedAt     time.Time
 }
 
 // SalesReportData represents aggregated sales data for reporting
 type SalesReportData struct {
-	TotalSales       int64
-	TotalRevenue     float64
-	TotalCash        float64
-	TotalCard        float64
-	TotalRefunded    float64
-	AverageOrderValue float64
-	SalesByDate      map[string]float64
+	TotalSales          int64
+	TotalRevenue        float64
+	WholesaleSales      int64
+	WholesaleRevenue    float64 // Part of TotalRevenue made by wholesale sales
+	RetailRevenue       float64
+	TotalCash           float64
+	TotalCard           float64
+	TotalRefunded       float64
+	AverageOrderValue   float64
+	SalesByDate         map[string]float64
+	PriceOverrideCount  int64
+	PriceOverrideAmount float64
+	ExchangeCount       int64
+	ExchangeReturned    float64
+	ExchangeBalance     float64
+	VoidCount           int64
+	VoidAmount          float64
+	GiftCardCount       int64
+	GiftCardAmount      float64 // Gift cards sold, a liability left out of TotalRevenue
+	TotalTax            float64
+	TaxBreakdown        []TaxBreakdownLine
+	PromotionCost       float64
+	PromotionBreakdown  []PromotionCostLine
+}
+
+// SalesAnalyticsFilter selects the sales analysed; Timezone is the IANA zone the
+// hour and weekday breakdowns are computed in
+type SalesAnalyticsFilter struct {
+	CompanyID   uint
+	FranchiseID *uint
+	StartDate   time.Time
+	EndDate     time.Time
+	Timezone    string
+	TopProducts int
+}
+
+// AnalyticsLine aggregates the lines sold under one key, net of the items returned.
+// Amounts include the share of sale-level discounts; Cost uses the unit cost recorded
+// on the line, falling back to the product's current supplier cost.
+type AnalyticsLine struct {
+	Key              string
+	Label            string
+	SKU              string
+	SaleCount        int64
+	Quantity         int64
+	Revenue          float64 // Tax included
+	NetRevenue       float64 // Tax excluded
+	Cost             float64
+	UncostedQuantity int64 // Units sold without a known cost
+}
+
+// SalesAnalyticsData holds the sales analytics of a period
+type SalesAnalyticsData struct {
+	Summary         AnalyticsLine
+	ByProduct       []AnalyticsLine
+	ByCategory      []AnalyticsLine
+	ByCashier       []AnalyticsLine
+	ByHour          []AnalyticsLine
+	ByWeekday       []AnalyticsLine
+	ByFranchise     []AnalyticsLine
+	ByChannel       []AnalyticsLine // Wholesale and retail sales
+	ByPaymentMethod []PaymentMethodTotal
+}
+
+// ShiftReportData aggregates what went through the till during a cash drawer session
+type ShiftReportData struct {
+	SaleCount        int64
+	SalesTotal       float64
+	TaxTotal         float64
+	DiscountTotal    float64
+	VoidCount        int64
+	VoidTotal        float64
+	RefundCount      int64
+	RefundTotal      float64
+	RefundsByMethod  []PaymentMethodTotal
+	PaymentsByMethod []PaymentMethodTotal
+	CashByType       []CashDrawerTypeTotal
+}
+
+// PaymentMethodTotal aggregates the payments or refunds made with one method
+type PaymentMethodTotal struct {
+	Method PaymentMethod
+	Count  int64
+	Amount float64
+}
+
+// CashDrawerTypeTotal aggregates the drawer transactions of one type; amounts are signed
+type CashDrawerTypeTotal struct {
+	TransactionType CashDrawerTransactionType
+	Count           int64
+	Amount          float64
+}
+
+// PromotionCostLine aggregates the discounts one promotion gave
+type PromotionCostLine struct {
+	PromotionID   uint
+	PromotionName string
+	SaleCount     int64
+	Amount        float64
+}
+
+// TaxBreakdownLine aggregates the lines sold at one tax class and rate, net of refunds
+type TaxBreakdownLine struct {
+	TaxClassID   *uint
+	TaxClassName string
+	TaxRate      float64
+	NetAmount    float64
+	TaxAmount    float64
 }
 
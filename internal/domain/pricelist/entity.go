@@ -0,0 +1,173 @@
+package pricelist
+
+import (
+	"math"
+	"time"
+)
+
+// CustomerGroup gathers customers sold to at the same prices, e.g. VIP, staff or resellers
+type CustomerGroup struct {
+	ID          uint   `gorm:"primaryKey"`
+	CompanyID   uint   `gorm:"not null;uniqueIndex:idx_customer_groups_company_name"`
+	Name        string `gorm:"type:varchar(100);not null;uniqueIndex:idx_customer_groups_company_name"`
+	Description string `gorm:"type:text"`
+	IsActive    bool   `gorm:"default:true"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (CustomerGroup) TableName() string {
+	return "customer_groups"
+}
+
+// IsValid validates the customer group
+func (g *CustomerGroup) IsValid() bool {
+	return g.CompanyID > 0 && g.Name != ""
+}
+
+// PriceList sets the retail prices a customer group pays. It applies on top of franchise
+// pricing while it is active and within its validity dates.
+type PriceList struct {
+	ID              uint       `gorm:"primaryKey"`
+	CompanyID       uint       `gorm:"not null;index"`
+	CustomerGroupID uint       `gorm:"not null;index"`
+	FranchiseID     *uint      `gorm:"index"` // Nil applies to every franchise and the company POS
+	Name            string     `gorm:"not null"`
+	Description     string     `gorm:"type:text"`
+	StartsAt        *time.Time `gorm:"index"`
+	EndsAt          *time.Time `gorm:"index"`
+	IsActive        bool       `gorm:"default:true"`
+	CreatedByID     uint       `gorm:"not null"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+
+	// Relationships
+	Items []PriceListItem `gorm:"foreignKey:PriceListID"`
+}
+
+func (PriceList) TableName() string {
+	return "price_lists"
+}
+
+// PriceType represents how a price list item sets the price
+type PriceType string
+
+const (
+	PriceTypePercentage PriceType = "percentage"  // Percentage off the price
+	PriceTypeFixedPrice PriceType = "fixed_price" // Price charged instead
+)
+
+func (t PriceType) IsValid() bool {
+	switch t {
+	case PriceTypePercentage, PriceTypeFixedPrice:
+		return true
+	}
+	return false
+}
+
+// PriceListItem prices a variant, every variant of a product, or with neither set,
+// every product
+type PriceListItem struct {
+	ID               uint      `gorm:"primaryKey"`
+	PriceListID      uint      `gorm:"not null;index;constraint:OnDelete:CASCADE"`
+	ProductID        *uint     `gorm:"index"`
+	ProductVariantID *uint     `gorm:"index"`
+	Type             PriceType `gorm:"type:varchar(50);not null"`
+	Value            float64   `gorm:"type:decimal(10,2);not null"`
+	CreatedAt        time.Time
+}
+
+func (PriceListItem) TableName() string {
+	return "price_list_items"
+}
+
+// IsValid validates the price list and its items
+func (l *PriceList) IsValid() bool {
+	if l.CompanyID == 0 || l.CustomerGroupID == 0 || l.Name == "" {
+		return false
+	}
+	if l.StartsAt != nil && l.EndsAt != nil && l.EndsAt.Before(*l.StartsAt) {
+		return false
+	}
+	for _, item := range l.Items {
+		if !item.IsValid() {
+			return false
+		}
+	}
+	return true
+}
+
+// IsAvailableAt reports whether the price list applies at the given time
+func (l *PriceList) IsAvailableAt(at time.Time) bool {
+	if !l.IsActive {
+		return false
+	}
+	if l.StartsAt != nil && at.Before(*l.StartsAt) {
+		return false
+	}
+	if l.EndsAt != nil && at.After(*l.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// AppliesToFranchise reports whether the price list runs at the given franchise (nil for the company POS)
+func (l *PriceList) AppliesToFranchise(franchiseID *uint) bool {
+	if l.FranchiseID == nil {
+		return true
+	}
+	return franchiseID != nil && *l.FranchiseID == *franchiseID
+}
+
+// PriceFor returns the price the list sets for a variant otherwise sold at price. The most
+// specific item wins: the variant's, then its product's, then the one for every product.
+func (l *PriceList) PriceFor(productID, variantID uint, price float64) (float64, bool) {
+	var match *PriceListItem
+	matchRank := 0
+	for i := range l.Items {
+		item := &l.Items[i]
+		if rank := item.specificity(productID, variantID); rank > matchRank {
+			match, matchRank = item, rank
+		}
+	}
+	if match == nil {
+		return price, false
+	}
+	return match.Apply(price), true
+}
+
+// IsValid validates the price list item
+func (i *PriceListItem) IsValid() bool {
+	switch i.Type {
+	case PriceTypePercentage:
+		return i.Value > 0 && i.Value <= 100
+	case PriceTypeFixedPrice:
+		return i.Value > 0
+	}
+	return false
+}
+
+// Apply returns the price the item sets for a variant otherwise sold at price
+func (i *PriceListItem) Apply(price float64) float64 {
+	if i.Type == PriceTypeFixedPrice {
+		return i.Value
+	}
+	return math.Round(price*(100-i.Value)) / 100
+}
+
+// specificity ranks how closely the item targets a variant, 0 when it does not apply to it
+func (i *PriceListItem) specificity(productID, variantID uint) int {
+	switch {
+	case i.ProductVariantID != nil:
+		if *i.ProductVariantID == variantID {
+			return 3
+		}
+		return 0
+	case i.ProductID != nil:
+		if *i.ProductID == productID {
+			return 2
+		}
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,25 @@
+package pricelist
+
+import "time"
+
+type CustomerGroupRepository interface {
+	Create(group *CustomerGroup) error
+	FindByID(id uint) (*CustomerGroup, error)
+	FindByIDAndCompany(id, companyID uint) (*CustomerGroup, error)
+	FindByCompanyID(companyID uint) ([]*CustomerGroup, error)
+	FindByName(companyID uint, name string) (*CustomerGroup, error)
+	CountCustomers(groupID uint) (int64, error)
+	Update(group *CustomerGroup) error
+	Delete(id uint) error
+}
+
+type PriceListRepository interface {
+	Create(list *PriceList) error
+	FindByIDAndCompany(id, companyID uint) (*PriceList, error)
+	FindByCompanyID(companyID uint, customerGroupID *uint) ([]*PriceList, error)
+	FindActive(companyID, customerGroupID uint, at time.Time) ([]*PriceList, error)
+	CountByCustomerGroup(customerGroupID uint) (int64, error)
+	Update(list *PriceList) error
+	ReplaceItems(priceListID uint, items []PriceListItem) error
+	Delete(id uint) error
+}
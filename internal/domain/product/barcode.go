@@ -0,0 +1,70 @@
+package product
+
+import (
+	"fmt"
+	"strings"
+)
+
+// internalBarcodePrefix is the GS1 prefix for restricted circulation numbers, which
+// stores can use for their own products without clashing with manufacturer codes
+const internalBarcodePrefix = "20"
+
+// NormalizeBarcode cleans up a scanned or typed code. Numeric EAN-8, UPC-A and EAN-13
+// codes must have a valid check digit; UPC-A codes are stored as EAN-13 with a leading
+// zero so both forms of the same code match. Other codes (Code 128...) are kept as is.
+func NormalizeBarcode(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return "", fmt.Errorf("barcode is empty")
+	}
+	if len(code) > 64 {
+		return "", fmt.Errorf("barcode is too long")
+	}
+	if !isDigits(code) {
+		return code, nil
+	}
+
+	switch len(code) {
+	case 12:
+		code = "0" + code
+		fallthrough
+	case 8, 13:
+		if checkDigit(code[:len(code)-1]) != code[len(code)-1] {
+			return "", fmt.Errorf("barcode %s has an invalid check digit", code)
+		}
+	}
+	return code, nil
+}
+
+// GenerateInternalEAN13 returns the EAN-13 code used for a variant without a manufacturer
+// code: the internal prefix, the variant ID on 10 digits and the check digit
+func GenerateInternalEAN13(variantID uint) (string, error) {
+	if variantID >= 1e10 {
+		return "", fmt.Errorf("variant ID %d does not fit in an EAN-13 code", variantID)
+	}
+	digits := fmt.Sprintf("%s%010d", internalBarcodePrefix, variantID)
+	return digits + string(checkDigit(digits)), nil
+}
+
+// checkDigit computes the GS1 check digit of the digits that precede it: from the right,
+// digits are weighted 3, 1, 3, 1...
+func checkDigit(digits string) byte {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := int(digits[i] - '0')
+		if (len(digits)-1-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
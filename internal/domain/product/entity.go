@@ -7,21 +7,25 @@ import (
 )
 
 type Product struct {
-	ID                 uint   `gorm:"primaryKey"`
-	CompanyID          uint   `gorm:"not null;index"`
-	Name               string `gorm:"not null"`
-	Description        string
-	SKU                string  `gorm:"not null"`
-	BaseRetailPrice    float64 `gorm:"type:decimal(10,2);not null"`
-	BaseWholesalePrice float64 `gorm:"type:decimal(10,2);not null"`
-	SupplierID         *uint   `gorm:"index"`                 // Nullable - product may not have a supplier
-	SupplierCost       *float64 `gorm:"type:decimal(10,2)"`    // Nullable - cost from supplier
-	IsActive           bool    `gorm:"default:true"`
-	CreatedAt          time.Time
-	UpdatedAt          time.Time
+	ID                   uint   `gorm:"primaryKey"`
+	CompanyID            uint   `gorm:"not null;index"`
+	Name                 string `gorm:"not null"`
+	Description          string
+	Category             string   `gorm:"index"` // Free-form, used to group sales in analytics
+	SKU                  string   `gorm:"not null"`
+	BaseRetailPrice      float64  `gorm:"type:decimal(10,2);not null"`
+	BaseWholesalePrice   float64  `gorm:"type:decimal(10,2);not null"`
+	SupplierID           *uint    `gorm:"index"`              // Nullable - product may not have a supplier
+	SupplierCost         *float64 `gorm:"type:decimal(10,2)"` // Nullable - cost from supplier
+	TaxClassID           *uint    `gorm:"index"`              // Nullable - falls back to the company default tax class
+	WholesaleMinQuantity int      `gorm:"default:0"`          // Fewest units of a variant a wholesale sale may take, 0 for no minimum
+	IsActive             bool     `gorm:"default:true"`
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
 
 	// Relationships
-	Variants []ProductVariant `gorm:"foreignKey:ProductID"`
+	Variants       []ProductVariant     `gorm:"foreignKey:ProductID"`
+	WholesaleTiers []WholesalePriceTier `gorm:"foreignKey:ProductID"`
 }
 
 func (Product) TableName() string {
@@ -42,13 +46,53 @@ type ProductVariant struct {
 	UpdatedAt        time.Time
 
 	// Relationships
-	Product *Product `gorm:"foreignKey:ProductID"`
+	Product  *Product                `gorm:"foreignKey:ProductID"`
+	Barcodes []ProductVariantBarcode `gorm:"foreignKey:ProductVariantID"`
 }
 
 func (ProductVariant) TableName() string {
 	return "product_variants"
 }
 
+// ProductVariantBarcode is a code scanners read for a variant. A variant can have several
+// (manufacturer EAN, internal code...), but a code points to a single variant per company.
+type ProductVariantBarcode struct {
+	ID               uint   `gorm:"primaryKey"`
+	CompanyID        uint   `gorm:"not null;uniqueIndex:idx_barcode_company_code"`
+	ProductVariantID uint   `gorm:"not null;index"`
+	Code             string `gorm:"type:varchar(64);not null;uniqueIndex:idx_barcode_company_code"`
+	CreatedAt        time.Time
+}
+
+func (ProductVariantBarcode) TableName() string {
+	return "product_variant_barcodes"
+}
+
+// WholesalePriceTier is a quantity break of wholesale pricing: a wholesale sale taking at
+// least MinQuantity units of a variant pays UnitPrice per unit
+type WholesalePriceTier struct {
+	ID               uint    `gorm:"primaryKey"`
+	ProductID        uint    `gorm:"not null;index"`
+	ProductVariantID *uint   `gorm:"index"` // Nullable - applies to every variant of the product if nil
+	MinQuantity      int     `gorm:"not null"`
+	UnitPrice        float64 `gorm:"type:decimal(10,2);not null"`
+	CreatedAt        time.Time
+}
+
+func (WholesalePriceTier) TableName() string {
+	return "wholesale_price_tiers"
+}
+
+// IsValid validates the tier; a tier for a single unit would just be the wholesale price
+func (t *WholesalePriceTier) IsValid() bool {
+	return t.MinQuantity > 1 && t.UnitPrice > 0
+}
+
+// AppliesTo checks if the tier prices the given quantity of a variant
+func (t *WholesalePriceTier) AppliesTo(variantID uint, quantity int) bool {
+	return quantity >= t.MinQuantity && (t.ProductVariantID == nil || *t.ProductVariantID == variantID)
+}
+
 // Business methods for Product
 func (p *Product) IsValid() bool {
 	return p.Name != "" && p.SKU != "" && p.CompanyID > 0 && p.BaseRetailPrice >= 0 && p.BaseWholesalePrice >= 0
@@ -62,6 +106,23 @@ func (p *Product) GetBaseWholesalePrice() float64 {
 	return p.BaseWholesalePrice
 }
 
+// GetWholesaleTier returns the tier pricing a quantity of a variant: the one with the
+// highest threshold reached, a tier of the variant winning over one of the whole product
+func (p *Product) GetWholesaleTier(variantID uint, quantity int) *WholesalePriceTier {
+	var best *WholesalePriceTier
+	for i := range p.WholesaleTiers {
+		tier := &p.WholesaleTiers[i]
+		if !tier.AppliesTo(variantID, quantity) {
+			continue
+		}
+		if best == nil || tier.MinQuantity > best.MinQuantity ||
+			(tier.MinQuantity == best.MinQuantity && tier.ProductVariantID != nil) {
+			best = tier
+		}
+	}
+	return best
+}
+
 // Business methods for ProductVariant
 func (pv *ProductVariant) IsValid() bool {
 	return pv.Name != "" && pv.SKU != "" && pv.ProductID > 0
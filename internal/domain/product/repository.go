@@ -19,6 +19,16 @@ type Repository interface {
 	UpdateProductVariant(variant *ProductVariant) error
 	SoftDeleteProductVariant(id uint) error
 	SearchVariantsByCompany(companyID uint, query string, limit int) ([]*ProductVariant, error)
+	FindVariantsBySKUAndCompany(sku string, companyID uint) ([]*ProductVariant, error)
+
+	// Barcode operations
+	FindBarcodeByCode(companyID uint, code string) (*ProductVariantBarcode, error)
+	FindVariantByBarcode(companyID uint, code string) (*ProductVariant, error)
+	CreateVariantBarcode(barcode *ProductVariantBarcode) error
+	ReplaceVariantBarcodes(companyID, variantID uint, codes []string) error
+
+	// Wholesale tier operations
+	ReplaceWholesaleTiers(productID uint, tiers []WholesalePriceTier) error
 
 	// Stock operations
 	UpdateVariantStock(variantID uint, newStock int) error
@@ -0,0 +1,203 @@
+package promotion
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Promotion is a discount rule applied automatically to matching sale lines, or
+// only when its coupon code is entered at the till
+type Promotion struct {
+	ID          uint          `gorm:"primaryKey"`
+	CompanyID   uint          `gorm:"not null;index"`
+	FranchiseID *uint         `gorm:"index"` // Nil applies to every franchise and the company POS
+	Name        string        `gorm:"not null"`
+	Description string        `gorm:"type:text"`
+	Type        PromotionType `gorm:"type:varchar(50);not null"`
+	Value       float64       `gorm:"type:decimal(10,2);not null;default:0"` // Percentage or amount off; percentage off the free items for buy-X-get-Y
+	BuyQuantity int           `gorm:"default:0"`                             // Buy-X-get-Y: units to pay for
+	GetQuantity int           `gorm:"default:0"`                             // Buy-X-get-Y: units discounted
+
+	// Targeting; every condition set must match, none set matches every line
+	ProductID        *uint  `gorm:"index"`
+	ProductVariantID *uint  `gorm:"index"`
+	SupplierID       *uint  `gorm:"index"`
+	AttributeName    string `gorm:"type:varchar(100)"`
+	AttributeValue   string `gorm:"type:varchar(255)"`
+
+	// Validity window
+	StartsAt  *time.Time `gorm:"index"`
+	EndsAt    *time.Time `gorm:"index"`
+	StartTime string     `gorm:"type:varchar(5)"` // Daily window start, HH:MM
+	EndTime   string     `gorm:"type:varchar(5)"` // Daily window end, HH:MM
+
+	// Coupons
+	CouponCode string `gorm:"type:varchar(50);index"` // Empty for automatic promotions
+	UsageLimit *int   // Maximum number of sales, nil for unlimited
+	UsageCount int    `gorm:"not null;default:0"`
+
+	IsActive    bool `gorm:"default:true"`
+	CreatedByID uint `gorm:"not null"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (Promotion) TableName() string {
+	return "promotions"
+}
+
+// PromotionType represents how a promotion computes its discount
+type PromotionType string
+
+const (
+	PromotionTypePercentage  PromotionType = "percentage"
+	PromotionTypeFixedAmount PromotionType = "fixed_amount"
+	PromotionTypeBuyXGetY    PromotionType = "buy_x_get_y"
+)
+
+func (t PromotionType) IsValid() bool {
+	switch t {
+	case PromotionTypePercentage, PromotionTypeFixedAmount, PromotionTypeBuyXGetY:
+		return true
+	}
+	return false
+}
+
+// LineTarget describes a sale line a promotion is matched against
+type LineTarget struct {
+	ProductID        uint
+	ProductVariantID uint
+	SupplierID       *uint
+	Attributes       map[string]interface{}
+	Quantity         int
+	UnitPrice        float64
+}
+
+// Business methods for Promotion
+func (p *Promotion) IsValid() bool {
+	if p.CompanyID == 0 || p.Name == "" || !p.Type.IsValid() || p.Value < 0 {
+		return false
+	}
+	switch p.Type {
+	case PromotionTypePercentage:
+		if p.Value <= 0 || p.Value > 100 {
+			return false
+		}
+	case PromotionTypeFixedAmount:
+		if p.Value <= 0 {
+			return false
+		}
+	case PromotionTypeBuyXGetY:
+		if p.BuyQuantity < 1 || p.GetQuantity < 1 || p.Value <= 0 || p.Value > 100 {
+			return false
+		}
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && p.EndsAt.Before(*p.StartsAt) {
+		return false
+	}
+	if (p.StartTime == "") != (p.EndTime == "") || !isClockTime(p.StartTime) || !isClockTime(p.EndTime) {
+		return false
+	}
+	if p.AttributeValue != "" && p.AttributeName == "" {
+		return false
+	}
+	if p.UsageLimit != nil && *p.UsageLimit < 1 {
+		return false
+	}
+	return true
+}
+
+// IsCoupon reports whether the promotion only applies when its code is entered
+func (p *Promotion) IsCoupon() bool {
+	return p.CouponCode != ""
+}
+
+// IsExhausted reports whether the promotion has reached its usage limit
+func (p *Promotion) IsExhausted() bool {
+	return p.UsageLimit != nil && p.UsageCount >= *p.UsageLimit
+}
+
+// IsAvailableAt reports whether the promotion can be applied at the given time
+func (p *Promotion) IsAvailableAt(at time.Time) bool {
+	if !p.IsActive || p.IsExhausted() {
+		return false
+	}
+	if p.StartsAt != nil && at.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && at.After(*p.EndsAt) {
+		return false
+	}
+	if p.StartTime != "" {
+		clock := at.Format("15:04")
+		// A window ending before it starts spans midnight, e.g. 22:00-02:00
+		if p.StartTime <= p.EndTime {
+			return clock >= p.StartTime && clock < p.EndTime
+		}
+		return clock >= p.StartTime || clock < p.EndTime
+	}
+	return true
+}
+
+// AppliesToFranchise reports whether the promotion runs at the given franchise (nil for the company POS)
+func (p *Promotion) AppliesToFranchise(franchiseID *uint) bool {
+	if p.FranchiseID == nil {
+		return true
+	}
+	return franchiseID != nil && *p.FranchiseID == *franchiseID
+}
+
+// Matches reports whether a sale line satisfies every targeting condition
+func (p *Promotion) Matches(line LineTarget) bool {
+	if p.ProductID != nil && *p.ProductID != line.ProductID {
+		return false
+	}
+	if p.ProductVariantID != nil && *p.ProductVariantID != line.ProductVariantID {
+		return false
+	}
+	if p.SupplierID != nil && (line.SupplierID == nil || *p.SupplierID != *line.SupplierID) {
+		return false
+	}
+	if p.AttributeName != "" {
+		value, exists := line.Attributes[p.AttributeName]
+		if !exists {
+			return false
+		}
+		if p.AttributeValue != "" && !strings.EqualFold(fmt.Sprint(value), p.AttributeValue) {
+			return false
+		}
+	}
+	return true
+}
+
+// DiscountFor returns the discount the promotion gives on a matching line, never
+// more than the line is worth. Buy-X-get-Y only counts units within the line.
+func (p *Promotion) DiscountFor(line LineTarget) float64 {
+	lineAmount := float64(line.Quantity) * line.UnitPrice
+	discount := 0.0
+	switch p.Type {
+	case PromotionTypePercentage:
+		discount = lineAmount * p.Value / 100
+	case PromotionTypeFixedAmount:
+		discount = p.Value * float64(line.Quantity)
+	case PromotionTypeBuyXGetY:
+		freeUnits := line.Quantity / (p.BuyQuantity + p.GetQuantity) * p.GetQuantity
+		discount = float64(freeUnits) * line.UnitPrice * p.Value / 100
+	}
+	return math.Round(math.Min(discount, lineAmount)*100) / 100
+}
+
+// NormalizeCouponCode returns the canonical form coupon codes are stored and looked up in
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func isClockTime(value string) bool {
+	if value == "" {
+		return true
+	}
+	_, err := time.Parse("15:04", value)
+	return err == nil
+}
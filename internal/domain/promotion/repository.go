@@ -0,0 +1,14 @@
+package promotion
+
+import "time"
+
+type Repository interface {
+	Create(promotion *Promotion) error
+	FindByID(id uint) (*Promotion, error)
+	FindByIDAndCompany(id, companyID uint) (*Promotion, error)
+	FindByCompanyID(companyID uint, franchiseID *uint) ([]*Promotion, error)
+	FindAutomatic(companyID uint, at time.Time) ([]*Promotion, error)
+	FindByCouponCode(companyID uint, code string) (*Promotion, error)
+	Update(promotion *Promotion) error
+	Delete(id uint) error
+}
@@ -101,9 +101,9 @@ func (pts PaymentTransactionStatus) IsValid() bool {
 // SupplierBill represents a bill from a supplier
 type SupplierBill struct {
 	ID            uint          `gorm:"primaryKey"`
-	CompanyID     uint          `gorm:"not null;index"`
+	CompanyID     uint          `gorm:"not null;index;uniqueIndex:idx_supplier_bills_company_bill_number"`
 	SupplierID    uint          `gorm:"not null;index"`
-	BillNumber    string        `gorm:"uniqueIndex;not null"`
+	BillNumber    string        `gorm:"uniqueIndex:idx_supplier_bills_company_bill_number;not null"`
 	TotalAmount   float64       `gorm:"type:decimal(10,2);not null"`
 	PaidAmount    float64       `gorm:"type:decimal(10,2);default:0;not null"`
 	PendingAmount float64       `gorm:"type:decimal(10,2);not null"` // Calculated: TotalAmount - PaidAmount
@@ -20,6 +20,7 @@ type Repository interface {
 	FindSupplierBillByIDAndCompany(id, companyID uint) (*SupplierBill, error)
 	FindSupplierBillsBySupplier(supplierID, companyID uint, page, limit int) ([]*SupplierBill, int64, error)
 	FindSupplierBillsByCompany(companyID uint, page, limit int) ([]*SupplierBill, int64, error)
+	StreamSupplierBills(companyID uint, supplierID *uint, fn func(bills []*SupplierBill) error) error
 	FindUnpaidBillsBySupplier(supplierID, companyID uint) ([]*SupplierBill, error)
 	UpdateSupplierBill(bill *SupplierBill) error
 	DeleteSupplierBill(id uint) error
@@ -0,0 +1,61 @@
+package tax
+
+import (
+	"math"
+	"time"
+)
+
+// TaxClass is a named tax rate products can be assigned to (e.g. standard VAT 19%, reduced VAT 9%, exempt)
+type TaxClass struct {
+	ID          uint    `gorm:"primaryKey"`
+	CompanyID   uint    `gorm:"not null;index"`
+	Name        string  `gorm:"not null"`
+	Code        string  `gorm:"type:varchar(50)"`
+	Rate        float64 `gorm:"type:decimal(5,2);not null;default:0"` // Percentage, e.g. 19 for 19%
+	IsExempt    bool    `gorm:"default:false"`
+	IsDefault   bool    `gorm:"default:false"` // Applied to products without a tax class
+	IsActive    bool    `gorm:"default:true"`
+	Description string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (TaxClass) TableName() string {
+	return "tax_classes"
+}
+
+// Business methods for TaxClass
+func (t *TaxClass) IsValid() bool {
+	if t.CompanyID == 0 || t.Name == "" || t.Rate < 0 || t.Rate > 100 {
+		return false
+	}
+	if t.IsExempt && t.Rate != 0 {
+		return false
+	}
+	return true
+}
+
+// EffectiveRate returns the percentage to charge, which is always zero for exempt classes
+func (t *TaxClass) EffectiveRate() float64 {
+	if t.IsExempt {
+		return 0
+	}
+	return t.Rate
+}
+
+// Calculate splits an amount into its net and tax parts. When inclusive is true the amount
+// already contains the tax, otherwise the tax is added on top of it.
+func Calculate(amount, rate float64, inclusive bool) (net, tax float64) {
+	if rate <= 0 {
+		return amount, 0
+	}
+	if inclusive {
+		net = round(amount / (1 + rate/100))
+		return net, round(amount - net)
+	}
+	return amount, round(amount * rate / 100)
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
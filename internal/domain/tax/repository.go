@@ -0,0 +1,13 @@
+package tax
+
+type Repository interface {
+	Create(taxClass *TaxClass) error
+	FindByID(id uint) (*TaxClass, error)
+	FindByIDAndCompany(id, companyID uint) (*TaxClass, error)
+	FindByCompanyID(companyID uint) ([]*TaxClass, error)
+	FindDefaultByCompanyID(companyID uint) (*TaxClass, error)
+	Update(taxClass *TaxClass) error
+	Delete(id uint) error
+	SetAsDefault(id, companyID uint) error
+	CountProducts(id uint) (int64, error)
+}
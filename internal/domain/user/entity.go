@@ -12,9 +12,14 @@ type User struct {
 	Password  string `gorm:"not null"`
 	FirstName string `gorm:"not null;default:''"` // Allow empty string as default
 	LastName  string `gorm:"not null;default:''"` // Allow empty string as default
+	POSPin    string `gorm:"not null;default:''"` // Hashed PIN used to approve POS operations
 	IsActive  bool   `gorm:"default:true"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
+
+	// Wrong approver PINs entered in a row by the user as cashier; too many lock them out of approvals for a while
+	POSPinFailures    int `gorm:"not null;default:0"`
+	POSPinLockedUntil *time.Time
 }
 
 func (User) TableName() string {
@@ -37,6 +42,41 @@ func (u *User) CheckPassword(password string) bool {
 	return err == nil
 }
 
+// SetPOSPin hashes and stores the user's POS approval PIN
+func (u *User) SetPOSPin(pin string) error {
+	hashedPin, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	u.POSPin = string(hashedPin)
+	return nil
+}
+
+// HasPOSPin checks if the user has configured a POS approval PIN
+func (u *User) HasPOSPin() bool {
+	return u.POSPin != ""
+}
+
+// MaxPOSPinFailures is how many wrong approver PINs in a row lock a cashier out of approvals for POSPinLockout
+const (
+	MaxPOSPinFailures = 5
+	POSPinLockout     = 15 * time.Minute
+)
+
+// IsPOSPinLocked checks if the user, as cashier, may not have actions approved by PIN at the given time
+func (u *User) IsPOSPinLocked(at time.Time) bool {
+	return u.POSPinLockedUntil != nil && at.Before(*u.POSPinLockedUntil)
+}
+
+// CheckPOSPin compares the provided PIN with the user's hashed POS PIN
+func (u *User) CheckPOSPin(pin string) bool {
+	if !u.HasPOSPin() || pin == "" {
+		return false
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(u.POSPin), []byte(pin))
+	return err == nil
+}
+
 // UserCompanyRole represents the many-to-many relationship between users and companies
 type UserCompanyRole struct {
 	ID        uint `gorm:"primaryKey"`
@@ -0,0 +1,145 @@
+package voucher
+
+import (
+	"crypto/rand"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// VoucherType represents how a voucher came to be
+type VoucherType string
+
+const (
+	VoucherTypeGiftCard    VoucherType = "gift_card"    // Sold at the till or given away by a manager
+	VoucherTypeStoreCredit VoucherType = "store_credit" // Issued instead of cash for a refund
+)
+
+func (vt VoucherType) IsValid() bool {
+	switch vt {
+	case VoucherTypeGiftCard, VoucherTypeStoreCredit:
+		return true
+	}
+	return false
+}
+
+// Voucher is a prepaid balance customers can pay with, in one or several goes
+type Voucher struct {
+	ID            uint        `gorm:"primaryKey"`
+	CompanyID     uint        `gorm:"not null;uniqueIndex:idx_voucher_company_code"`
+	FranchiseID   *uint       `gorm:"index"` // Nil when the voucher is valid in every franchise
+	Code          string      `gorm:"type:varchar(50);not null;uniqueIndex:idx_voucher_company_code"`
+	Type          VoucherType `gorm:"type:varchar(50);not null"`
+	InitialAmount float64     `gorm:"type:decimal(10,2);not null"`
+	Balance       float64     `gorm:"type:decimal(10,2);not null"`
+	CustomerID    *uint       `gorm:"index"`
+	SaleID        *uint       `gorm:"index"` // Sale the gift card was sold on
+	RefundID      *uint       `gorm:"index"` // Refund the store credit was issued for
+	ExpiresAt     *time.Time  `gorm:"index"`
+	IsActive      bool        `gorm:"default:true"`
+	Notes         string      `gorm:"type:text"`
+	CreatedByID   uint        `gorm:"not null;index"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (Voucher) TableName() string {
+	return "vouchers"
+}
+
+// Business methods for Voucher
+func (v *Voucher) IsValid() bool {
+	return v.CompanyID > 0 && v.Code != "" && v.Type.IsValid() && v.InitialAmount > 0 &&
+		v.Balance >= 0 && v.Balance <= v.InitialAmount && v.CreatedByID > 0
+}
+
+// IsExpired checks if the voucher can no longer be used at the given time
+func (v *Voucher) IsExpired(now time.Time) bool {
+	return v.ExpiresAt != nil && !now.Before(*v.ExpiresAt)
+}
+
+// IsValidFor checks if the voucher may be used in the franchise, nil meaning the company itself
+func (v *Voucher) IsValidFor(franchiseID *uint) bool {
+	if v.FranchiseID == nil {
+		return true
+	}
+	return franchiseID != nil && *franchiseID == *v.FranchiseID
+}
+
+// IsUsed checks if any of the voucher balance has been spent
+func (v *Voucher) IsUsed() bool {
+	return v.Balance < v.InitialAmount
+}
+
+// Redeem takes amount off the balance; it fails when the balance is too low
+func (v *Voucher) Redeem(amount float64) bool {
+	if amount <= 0 || amount > v.Balance+0.005 {
+		return false
+	}
+	v.Balance = math.Max(round(v.Balance-amount), 0)
+	return true
+}
+
+// Restore gives back a redeemed amount, never beyond the initial amount
+func (v *Voucher) Restore(amount float64) {
+	if amount > 0 {
+		v.Balance = math.Min(round(v.Balance+amount), v.InitialAmount)
+	}
+}
+
+// Transaction is an entry of a voucher's balance history
+type Transaction struct {
+	ID           uint            `gorm:"primaryKey"`
+	VoucherID    uint            `gorm:"not null;index"`
+	Type         TransactionType `gorm:"type:varchar(50);not null"`
+	Amount       float64         `gorm:"type:decimal(10,2);not null"` // Positive when added to the balance, negative when taken
+	BalanceAfter float64         `gorm:"type:decimal(10,2);not null"`
+	SaleID       *uint           `gorm:"index"`
+	RefundID     *uint           `gorm:"index"`
+	CreatedByID  *uint           `gorm:"index"`
+	CreatedAt    time.Time       `gorm:"index"`
+}
+
+func (Transaction) TableName() string {
+	return "voucher_transactions"
+}
+
+// TransactionType represents why a voucher balance changed
+type TransactionType string
+
+const (
+	TransactionTypeIssue   TransactionType = "issue"   // Voucher created with its initial balance
+	TransactionTypeRedeem  TransactionType = "redeem"  // Balance used to pay a sale
+	TransactionTypeRestore TransactionType = "restore" // Redemption given back by a voided sale
+	TransactionTypeCancel  TransactionType = "cancel"  // Unused gift card cancelled with the sale it was sold on
+)
+
+// codeAlphabet leaves out characters that are easily misread, such as 0/O and 1/I
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// GenerateCode returns a random voucher code such as "K7PX-3QMA-9TRW"
+func GenerateCode() (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(codeAlphabet)))
+	for i := 0; i < 12; i++ {
+		if i > 0 && i%4 == 0 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(codeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// NormalizeCode puts a code typed at the till in the form it is stored in
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,13 @@
+package voucher
+
+// Repository defines the interface for voucher data operations
+type Repository interface {
+	Create(voucher *Voucher) error
+	Update(voucher *Voucher) error
+	FindByID(id uint) (*Voucher, error)
+	FindByIDAndCompany(id, companyID uint) (*Voucher, error)
+	FindByCode(companyID uint, code string) (*Voucher, error)
+	FindByCompanyID(companyID uint, customerID *uint, page, limit int) ([]*Voucher, int64, error)
+	CreateTransaction(transaction *Transaction) error
+	FindTransactions(voucherID uint) ([]Transaction, error)
+}
@@ -74,9 +74,9 @@ func (dt DiscrepancyType) IsValid() bool {
 // WarehouseBill represents a bill for transferring inventory between warehouse and franchise
 type WarehouseBill struct {
 	ID                uint               `gorm:"primaryKey"`
-	CompanyID         uint               `gorm:"not null;index"`
+	CompanyID         uint               `gorm:"not null;index;uniqueIndex:idx_warehouse_bills_company_bill_number"`
 	FranchiseID        uint               `gorm:"not null;index"`
-	BillNumber         string             `gorm:"uniqueIndex;not null"`
+	BillNumber         string             `gorm:"uniqueIndex:idx_warehouse_bills_company_bill_number;not null"`
 	BillType           BillType           `gorm:"type:varchar(50);not null;index"`
 	RelatedBillID      *uint              `gorm:"index"` // For entry bills linking to exit bills
 	Status             BillStatus          `gorm:"type:varchar(50);not null;default:'draft'"`
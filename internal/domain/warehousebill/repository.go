@@ -27,6 +27,9 @@ type Repository interface {
 	// FindByCompanyIDWithFilters finds warehouse bills for a company with filters and pagination
 	FindByCompanyIDWithFilters(companyID uint, page, limit int, filters *BillFilters) ([]*WarehouseBill, int64, error)
 
+	// StreamByCompanyIDWithFilters passes the bills matching the filters to fn in batches, oldest first
+	StreamByCompanyIDWithFilters(companyID uint, filters *BillFilters, fn func(bills []*WarehouseBill) error) error
+
 	// FindByFranchiseID finds all warehouse bills for a franchise with pagination
 	FindByFranchiseID(franchiseID uint, page, limit int) ([]*WarehouseBill, int64, error)
 
@@ -0,0 +1,145 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Format is the file format of an export
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// ParseFormat checks the format requested for an export
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(value); format {
+	case FormatCSV, FormatXLSX:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q, use csv or xlsx", value)
+	}
+}
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Filename returns the file name for an export called name
+func (f Format) Filename(name string) string {
+	return name + "." + string(f)
+}
+
+// Writer writes a table one row at a time, so exports never hold more than a row in memory.
+// The first row written is the header. Nothing reaches the underlying writer before the
+// first row, so errors raised before it can still be reported another way.
+type Writer interface {
+	WriteRow(values ...interface{}) error
+	Close() error
+}
+
+// NewWriter creates a writer for the format; sheetName names the worksheet of XLSX files
+func NewWriter(w io.Writer, format Format, sheetName string) Writer {
+	if format == FormatXLSX {
+		return newXLSXWriter(w, sheetName)
+	}
+	return &csvWriter{w: w}
+}
+
+// dateTimeLayout is how times are written to CSV files
+const dateTimeLayout = "2006-01-02 15:04:05"
+
+type csvWriter struct {
+	w       io.Writer
+	csv     *csv.Writer
+	started bool
+}
+
+func (cw *csvWriter) WriteRow(values ...interface{}) error {
+	if !cw.started {
+		cw.started = true
+		// UTF-8 byte order mark, so spreadsheets open accented names correctly
+		if _, err := cw.w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+			return err
+		}
+		cw.csv = csv.NewWriter(cw.w)
+	}
+
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = formatValue(value)
+	}
+	return cw.csv.Write(record)
+}
+
+func (cw *csvWriter) Close() error {
+	if cw.csv == nil {
+		return nil
+	}
+	cw.csv.Flush()
+	return cw.csv.Error()
+}
+
+// formatValue writes a cell value as text; nil pointers are empty cells
+func formatValue(value interface{}) string {
+	switch v := deref(value).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(dateTimeLayout)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// deref returns the value a pointer points to, or nil for a nil pointer
+func deref(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *string:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case *uint:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case *int:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case *float64:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case time.Time:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return value
+	}
+}
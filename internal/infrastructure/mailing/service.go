@@ -3,10 +3,14 @@ package mailing
 import (
 	"bytes"
 	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log"
+	"mime"
+	"mime/multipart"
 	"net/smtp"
+	"net/textproto"
 	"time"
 
 	"github.com/YasserCherfaoui/darween/internal/domain/emailqueue"
@@ -33,6 +37,11 @@ func (m *MailingService) QueueEmail(companyID uint, smtpConfigID *uint, to []str
 
 // QueueEmailWithType adds an email to the queue for processing with a specific email type
 func (m *MailingService) QueueEmailWithType(companyID uint, smtpConfigID *uint, to []string, subject, body string, isHTML bool, emailType emailqueue.EmailType, scheduledAt *time.Time) error {
+	return m.QueueEmailWithAttachments(companyID, smtpConfigID, to, subject, body, isHTML, emailType, nil, scheduledAt)
+}
+
+// QueueEmailWithAttachments adds an email with attached files to the queue for processing
+func (m *MailingService) QueueEmailWithAttachments(companyID uint, smtpConfigID *uint, to []string, subject, body string, isHTML bool, emailType emailqueue.EmailType, attachments []emailqueue.Attachment, scheduledAt *time.Time) error {
 	// Convert to array to JSON string
 	toJSON, err := json.Marshal(to)
 	if err != nil {
@@ -51,6 +60,7 @@ func (m *MailingService) QueueEmailWithType(companyID uint, smtpConfigID *uint,
 		Attempts:     0,
 		MaxAttempts:  3,
 		ScheduledAt:  scheduledAt,
+		Attachments:  attachments,
 	}
 
 	return m.emailQueueRepo.Create(email)
@@ -280,22 +290,16 @@ func (m *MailingService) sendEmailDirectly(email *emailqueue.EmailQueue, config
 	// MIME-Version
 	msg.WriteString("MIME-Version: 1.0\r\n")
 
-	// Content-Type
-	if email.IsHTML {
-		msg.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
-	} else {
-		msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
-	}
-
 	// X-Mailer (identifies the sending application)
 	msg.WriteString("X-Mailer: Darween ERP System\r\n")
 
 	// X-Priority (normal priority)
 	msg.WriteString("X-Priority: 3\r\n")
 
-	// Empty line before body
-	msg.WriteString("\r\n")
-	msg.WriteString(email.Body)
+	// Body, followed by the attachments as a multipart message when there are any
+	if err := writeEmailContent(&msg, email); err != nil {
+		return fmt.Errorf("failed to build message: %w", err)
+	}
 
 	// Connect to SMTP server based on security type
 	addr := fmt.Sprintf("%s:%d", config.Host, config.Port)
@@ -471,6 +475,61 @@ func (m *MailingService) sendWithSTARTTLS(addr string, hostname string, skipVeri
 	return client.Quit()
 }
 
+// writeEmailContent writes the Content-Type header and the body of the email. With attachments,
+// the body becomes the first part of a multipart/mixed message and each file a base64 part.
+func writeEmailContent(msg *bytes.Buffer, email *emailqueue.EmailQueue) error {
+	bodyType := "text/plain; charset=UTF-8"
+	if email.IsHTML {
+		bodyType = "text/html; charset=UTF-8"
+	}
+
+	if len(email.Attachments) == 0 {
+		msg.WriteString(fmt.Sprintf("Content-Type: %s\r\n", bodyType))
+		msg.WriteString("\r\n")
+		msg.WriteString(email.Body)
+		return nil
+	}
+
+	writer := multipart.NewWriter(msg)
+	msg.WriteString(fmt.Sprintf("Content-Type: multipart/mixed; boundary=%q\r\n", writer.Boundary()))
+	msg.WriteString("\r\n")
+
+	bodyHeader := textproto.MIMEHeader{}
+	bodyHeader.Set("Content-Type", bodyType)
+	part, err := writer.CreatePart(bodyHeader)
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write([]byte(email.Body)); err != nil {
+		return err
+	}
+
+	for _, attachment := range email.Attachments {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", attachment.ContentType)
+		header.Set("Content-Transfer-Encoding", "base64")
+		header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return err
+		}
+
+		// Base64 lines must not exceed 76 characters
+		encoded := base64.StdEncoding.EncodeToString(attachment.Content)
+		for len(encoded) > 76 {
+			if _, err := part.Write([]byte(encoded[:76] + "\r\n")); err != nil {
+				return err
+			}
+			encoded = encoded[76:]
+		}
+		if _, err := part.Write([]byte(encoded + "\r\n")); err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
 // decryptPassword decrypts the encrypted SMTP password
 func (m *MailingService) decryptPassword(encryptedPassword string) (string, error) {
 	return encryption.Decrypt(encryptedPassword)
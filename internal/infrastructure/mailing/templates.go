@@ -35,6 +35,11 @@ type EmailTemplateData struct {
 	BillItems   []map[string]interface{}
 	TotalAmount float64
 
+	// Receipt
+	ReceiptNumber string
+	ReceiptDate   string
+	ReceiptURL    string
+
 	// Custom data
 	CustomData map[string]interface{}
 
@@ -334,6 +339,59 @@ This is an automated message, please do not reply.
 	return htmlBody, plainBody
 }
 
+// GenerateReceiptEmail generates HTML and plain text versions of the email sending a receipt
+func GenerateReceiptEmail(data EmailTemplateData) (htmlBody, plainBody string) {
+	linkHTML := ""
+	linkPlain := ""
+	if data.ReceiptURL != "" {
+		linkHTML = fmt.Sprintf(`<p>You can also view it online: <a href="%s" style="color: #3498db;">View receipt</a></p>`, template.HTMLEscapeString(data.ReceiptURL))
+		linkPlain = fmt.Sprintf("You can also view it online: %s\n", data.ReceiptURL)
+	}
+
+	htmlBody = fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="UTF-8">
+	<title>Your receipt - %s</title>
+</head>
+<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+	<div style="max-width: 600px; margin: 0 auto; padding: 20px;">
+		<h2 style="color: #2c3e50;">Thank you for your purchase!</h2>
+		<p>Hello,</p>
+		<p>Please find attached your receipt from %s.</p>
+		<div style="background-color: #f8f9fa; padding: 15px; margin: 20px 0; border-radius: 5px;">
+			<p><strong>Receipt Number:</strong> %s</p>
+			<p><strong>Date:</strong> %s</p>
+			<p><strong>Total:</strong> %.2f</p>
+		</div>
+		%s
+		<hr style="border: none; border-top: 1px solid #eee; margin: 20px 0;">
+		<p style="color: #7f8c8d; font-size: 12px;">This is an automated message, please do not reply.</p>
+	</div>
+</body>
+</html>
+`, template.HTMLEscapeString(data.ReceiptNumber), template.HTMLEscapeString(data.CompanyName), template.HTMLEscapeString(data.ReceiptNumber), data.ReceiptDate, data.TotalAmount, linkHTML)
+
+	plainBody = fmt.Sprintf(`
+Thank you for your purchase!
+
+Hello,
+
+Please find attached your receipt from %s.
+
+Receipt Number: %s
+Date: %s
+Total: %.2f
+
+%s
+---
+This is an automated message, please do not reply.
+`, data.CompanyName, data.ReceiptNumber, data.ReceiptDate, data.TotalAmount, linkPlain)
+
+	return htmlBody, plainBody
+}
+
 // GenerateNotificationEmail generates HTML and plain text versions of generic notification email
 func GenerateNotificationEmail(subject, message string) (htmlBody, plainBody string) {
 	htmlBody = fmt.Sprintf(`
@@ -8,13 +8,19 @@ import (
 	"github.com/YasserCherfaoui/darween/internal/domain/franchise"
 	"github.com/YasserCherfaoui/darween/internal/domain/inventory"
 	"github.com/YasserCherfaoui/darween/internal/domain/invitation"
+	"github.com/YasserCherfaoui/darween/internal/domain/loyalty"
+	"github.com/YasserCherfaoui/darween/internal/domain/numbering"
 	otpDomain "github.com/YasserCherfaoui/darween/internal/domain/otp"
 	"github.com/YasserCherfaoui/darween/internal/domain/pos"
+	"github.com/YasserCherfaoui/darween/internal/domain/pricelist"
 	"github.com/YasserCherfaoui/darween/internal/domain/product"
+	"github.com/YasserCherfaoui/darween/internal/domain/promotion"
 	"github.com/YasserCherfaoui/darween/internal/domain/smtpconfig"
 	"github.com/YasserCherfaoui/darween/internal/domain/subscription"
 	"github.com/YasserCherfaoui/darween/internal/domain/supplier"
+	"github.com/YasserCherfaoui/darween/internal/domain/tax"
 	"github.com/YasserCherfaoui/darween/internal/domain/user"
+	"github.com/YasserCherfaoui/darween/internal/domain/voucher"
 	"github.com/YasserCherfaoui/darween/internal/domain/warehousebill"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -40,30 +46,71 @@ func AutoMigrate(db *gorm.DB) error {
 			&supplier.SupplierBillItem{},
 			&supplier.SupplierPayment{},
 			&supplier.SupplierPaymentDistribution{},
+			&tax.TaxClass{},
 			&product.Product{},
 			&product.ProductVariant{},
+			&product.ProductVariantBarcode{},
+			&product.WholesalePriceTier{},
 			&inventory.Inventory{},
 			&inventory.InventoryMovement{},
 			&franchise.FranchisePricing{},
+			&promotion.Promotion{},
+			&pricelist.CustomerGroup{},
+			&pricelist.PriceList{},
+			&pricelist.PriceListItem{},
 			&pos.Customer{},
 			&pos.Sale{},
 			&pos.SaleItem{},
 			&pos.Payment{},
 			&pos.CashDrawer{},
 			&pos.CashDrawerTransaction{},
+			&pos.CashDrawerCount{},
 			&pos.Refund{},
+			&pos.RefundItem{},
+			&pos.Exchange{},
+			&pos.Layaway{},
+			&pos.Invoice{},
+			&pos.Proforma{},
+			&pos.CustomerPayment{},
+			&pos.CustomerPaymentDistribution{},
+			&pos.OfflineReceiptRange{},
+			&pos.SyncConflict{},
+			&loyalty.Program{},
+			&loyalty.Transaction{},
+			&voucher.Voucher{},
+			&voucher.Transaction{},
 			&warehousebill.WarehouseBill{},
 			&warehousebill.WarehouseBillItem{},
 			&smtpconfig.SMTPConfig{},
 			&emailqueue.EmailQueue{},
+			&emailqueue.Attachment{},
 			&invitation.Invitation{},
 			&otpDomain.OTP{},
+			&numbering.Sequence{},
 		)
 
 		if err != nil {
 			log.Printf("Auto-migration failed: %v", err)
 			return err
 		}
+
+		// Document numbers come from per-company sequences now, so they are only unique within a company
+		legacyIndexes := []struct {
+			model interface{}
+			name  string
+		}{
+			{&pos.Sale{}, "idx_sales_receipt_number"},
+			{&supplier.SupplierBill{}, "idx_supplier_bills_bill_number"},
+			{&warehousebill.WarehouseBill{}, "idx_warehouse_bills_bill_number"},
+		}
+		for _, index := range legacyIndexes {
+			if db.Migrator().HasIndex(index.model, index.name) {
+				if err := db.Migrator().DropIndex(index.model, index.name); err != nil {
+					log.Printf("Failed to drop index %s: %v", index.name, err)
+					return err
+				}
+			}
+		}
 	}
 
 	log.Println("Auto-migration completed successfully")
@@ -9,6 +9,9 @@ import (
 	"gorm.io/gorm/logger"
 )
 
+// exportBatchSize is how many rows exports load from the database at a time
+const exportBatchSize = 500
+
 func NewDatabase(dsn string) (*gorm.DB, error) {
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
@@ -6,6 +6,7 @@ import (
 
 	"github.com/YasserCherfaoui/darween/internal/domain/emailqueue"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type emailQueueRepository struct {
@@ -22,7 +23,7 @@ func (r *emailQueueRepository) Create(email *emailqueue.EmailQueue) error {
 
 func (r *emailQueueRepository) FindByID(id uint) (*emailqueue.EmailQueue, error) {
 	var email emailqueue.EmailQueue
-	err := r.db.Where("id = ?", id).First(&email).Error
+	err := r.db.Preload("Attachments").Where("id = ?", id).First(&email).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("email queue not found")
@@ -35,7 +36,7 @@ func (r *emailQueueRepository) FindByID(id uint) (*emailqueue.EmailQueue, error)
 func (r *emailQueueRepository) FindPendingBySMTPConfig(smtpConfigID uint, limit int) ([]*emailqueue.EmailQueue, error) {
 	var emails []*emailqueue.EmailQueue
 	now := time.Now()
-	query := r.db.Where("smtp_config_id = ? AND status = ?", smtpConfigID, emailqueue.EmailStatusPending).
+	query := r.db.Preload("Attachments").Where("smtp_config_id = ? AND status = ?", smtpConfigID, emailqueue.EmailStatusPending).
 		Where("(scheduled_at IS NULL OR scheduled_at <= ?)", now).
 		Order("created_at ASC").
 		Limit(limit)
@@ -47,7 +48,7 @@ func (r *emailQueueRepository) FindPendingBySMTPConfig(smtpConfigID uint, limit
 func (r *emailQueueRepository) FindPendingByCompany(companyID uint, limit int) ([]*emailqueue.EmailQueue, error) {
 	var emails []*emailqueue.EmailQueue
 	now := time.Now()
-	query := r.db.Where("company_id = ? AND status = ?", companyID, emailqueue.EmailStatusPending).
+	query := r.db.Preload("Attachments").Where("company_id = ? AND status = ?", companyID, emailqueue.EmailStatusPending).
 		Where("(scheduled_at IS NULL OR scheduled_at <= ?)", now).
 		Order("created_at ASC").
 		Limit(limit)
@@ -57,7 +58,8 @@ func (r *emailQueueRepository) FindPendingByCompany(companyID uint, limit int) (
 }
 
 func (r *emailQueueRepository) Update(email *emailqueue.EmailQueue) error {
-	return r.db.Save(email).Error
+	// Attachments never change once queued, only the delivery status does
+	return r.db.Omit(clause.Associations).Save(email).Error
 }
 
 func (r *emailQueueRepository) Delete(id uint) error {
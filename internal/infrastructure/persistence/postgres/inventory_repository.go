@@ -67,6 +67,22 @@ func (r *inventoryRepository) FindByFranchise(franchiseID uint) ([]*inventory.In
 	return inventories, err
 }
 
+func (r *inventoryRepository) StreamByCompany(companyID uint, fn func(inventories []*inventory.Inventory) error) error {
+	var inventories []*inventory.Inventory
+	return r.db.Where("company_id = ? AND is_active = ?", companyID, true).
+		FindInBatches(&inventories, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			return fn(inventories)
+		}).Error
+}
+
+func (r *inventoryRepository) StreamByFranchise(franchiseID uint, fn func(inventories []*inventory.Inventory) error) error {
+	var inventories []*inventory.Inventory
+	return r.db.Where("franchise_id = ? AND is_active = ?", franchiseID, true).
+		FindInBatches(&inventories, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			return fn(inventories)
+		}).Error
+}
+
 func (r *inventoryRepository) Update(inv *inventory.Inventory) error {
 	return r.db.Save(inv).Error
 }
@@ -122,6 +138,27 @@ func (r *inventoryRepository) FindMovementsByInventoryWithFilters(inventoryID ui
 	return movements, total, err
 }
 
+// StreamMovementsByInventoryWithFilters passes the movements matching the same filters as
+// FindMovementsByInventoryWithFilters to fn in batches, oldest first
+func (r *inventoryRepository) StreamMovementsByInventoryWithFilters(inventoryID uint, movementType *string, startDate *string, endDate *string, fn func(movements []*inventory.InventoryMovement) error) error {
+	query := r.db.Model(&inventory.InventoryMovement{}).Where("inventory_id = ?", inventoryID)
+
+	if movementType != nil && *movementType != "" {
+		query = query.Where("LOWER(movement_type) = LOWER(?)", *movementType)
+	}
+	if startDate != nil && *startDate != "" {
+		query = query.Where("created_at >= ?", *startDate)
+	}
+	if endDate != nil && *endDate != "" {
+		query = query.Where("created_at <= ?", *endDate)
+	}
+
+	var movements []*inventory.InventoryMovement
+	return query.FindInBatches(&movements, exportBatchSize, func(tx *gorm.DB, batch int) error {
+		return fn(movements)
+	}).Error
+}
+
 func (r *inventoryRepository) FindMovementsByReference(referenceType string, referenceID string) ([]*inventory.InventoryMovement, error) {
 	var movements []*inventory.InventoryMovement
 	err := r.db.
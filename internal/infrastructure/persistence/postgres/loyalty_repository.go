@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/YasserCherfaoui/darween/internal/domain/loyalty"
+	"gorm.io/gorm"
+)
+
+// LoyaltyProgramRepositoryImpl implements the ProgramRepository interface
+type LoyaltyProgramRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewLoyaltyProgramRepository(db *gorm.DB) loyalty.ProgramRepository {
+	return &LoyaltyProgramRepositoryImpl{db: db}
+}
+
+func (r *LoyaltyProgramRepositoryImpl) Create(program *loyalty.Program) error {
+	return r.db.Create(program).Error
+}
+
+func (r *LoyaltyProgramRepositoryImpl) Update(program *loyalty.Program) error {
+	return r.db.Save(program).Error
+}
+
+func (r *LoyaltyProgramRepositoryImpl) FindByCompanyID(companyID uint) (*loyalty.Program, error) {
+	var program loyalty.Program
+	err := r.db.Where("company_id = ?", companyID).First(&program).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("loyalty program not found")
+		}
+		return nil, err
+	}
+	return &program, nil
+}
+
+// LoyaltyTransactionRepositoryImpl implements the TransactionRepository interface
+type LoyaltyTransactionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewLoyaltyTransactionRepository(db *gorm.DB) loyalty.TransactionRepository {
+	return &LoyaltyTransactionRepositoryImpl{db: db}
+}
+
+func (r *LoyaltyTransactionRepositoryImpl) FindByCustomerID(customerID uint, page, limit int) ([]*loyalty.Transaction, int64, error) {
+	var transactions []*loyalty.Transaction
+	var total int64
+
+	offset := (page - 1) * limit
+
+	if err := r.db.Model(&loyalty.Transaction{}).Where("customer_id = ?", customerID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := r.db.Where("customer_id = ?", customerID).
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&transactions).Error
+
+	return transactions, total, err
+}
+
+func (r *LoyaltyTransactionRepositoryImpl) FindBySaleID(saleID uint) ([]loyalty.Transaction, error) {
+	var transactions []loyalty.Transaction
+	err := r.db.Where("sale_id = ?", saleID).Order("created_at ASC, id ASC").Find(&transactions).Error
+	return transactions, err
+}
+
+func (r *LoyaltyTransactionRepositoryImpl) FindExpiredLots(now time.Time) ([]*loyalty.Transaction, error) {
+	var lots []*loyalty.Transaction
+	err := r.db.Where("remaining_points > 0 AND expires_at IS NOT NULL AND expires_at <= ?", now).
+		Order("expires_at ASC").
+		Find(&lots).Error
+	return lots, err
+}
+
+// GetBalance sums the customer's points, leaving out the unused points of lots already past their expiry
+func (r *LoyaltyTransactionRepositoryImpl) GetBalance(customerID uint) (int, error) {
+	var balance int
+	err := r.db.Model(&loyalty.Transaction{}).
+		Where("customer_id = ?", customerID).
+		Select("COALESCE(SUM(CASE WHEN expires_at IS NULL OR expires_at > ? THEN points ELSE points - remaining_points END), 0)", time.Now()).
+		Row().Scan(&balance)
+	return balance, err
+}
+
+// ExpireLot writes off the unused points of a lot
+func (r *LoyaltyTransactionRepositoryImpl) ExpireLot(lot *loyalty.Transaction) error {
+	tx := r.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Only expire what is still unused; a redemption may have drawn on the lot meanwhile
+	result := tx.Model(&loyalty.Transaction{}).
+		Where("id = ? AND remaining_points = ?", lot.ID, lot.RemainingPoints).
+		Update("remaining_points", 0)
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return fmt.Errorf("loyalty lot %d changed while expiring", lot.ID)
+	}
+
+	if err := tx.Create(&loyalty.Transaction{
+		CompanyID:  lot.CompanyID,
+		CustomerID: lot.CustomerID,
+		Type:       loyalty.TransactionTypeExpire,
+		Points:     -lot.RemainingPoints,
+		Notes:      fmt.Sprintf("Points earned on %s expired", lot.CreatedAt.Format("2006-01-02")),
+	}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
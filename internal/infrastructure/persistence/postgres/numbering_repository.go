@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/YasserCherfaoui/darween/internal/domain/numbering"
+	"gorm.io/gorm"
+)
+
+type numberingRepository struct {
+	db *gorm.DB
+}
+
+func NewNumberingRepository(db *gorm.DB) numbering.Repository {
+	return &numberingRepository{db: db}
+}
+
+func (r *numberingRepository) FindByCompany(companyID uint) ([]*numbering.Sequence, error) {
+	var sequences []*numbering.Sequence
+	err := r.db.Where("company_id = ?", companyID).
+		Order("document_type ASC, franchise_id ASC").
+		Find(&sequences).Error
+	return sequences, err
+}
+
+func (r *numberingRepository) FindByScope(companyID, franchiseID uint, documentType numbering.DocumentType) (*numbering.Sequence, error) {
+	var sequence numbering.Sequence
+	err := r.db.Where("company_id = ? AND franchise_id = ? AND document_type = ?", companyID, franchiseID, documentType).
+		First(&sequence).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("numbering sequence not found")
+		}
+		return nil, err
+	}
+	return &sequence, nil
+}
+
+func (r *numberingRepository) Save(sequence *numbering.Sequence) error {
+	return r.db.Save(sequence).Error
+}
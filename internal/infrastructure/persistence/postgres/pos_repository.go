@@ -1,10 +1,12 @@
 package postgres
 
 import (
+	"math"
 	"time"
 
 	"github.com/YasserCherfaoui/darween/internal/domain/pos"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // CustomerRepositoryImpl implements the CustomerRepository interface
@@ -48,6 +50,21 @@ func (r *CustomerRepositoryImpl) FindByCompanyID(companyID uint, page, limit int
 	return customers, total, err
 }
 
+// FindAllByCompanyID returns every customer of the company, oldest first
+func (r *CustomerRepositoryImpl) FindAllByCompanyID(companyID uint) ([]*pos.Customer, error) {
+	var customers []*pos.Customer
+	err := r.db.Where("company_id = ?", companyID).Order("created_at ASC, id ASC").Find(&customers).Error
+	return customers, err
+}
+
+func (r *CustomerRepositoryImpl) StreamByCompanyID(companyID uint, fn func(customers []*pos.Customer) error) error {
+	var customers []*pos.Customer
+	return r.db.Model(&pos.Customer{}).Where("company_id = ?", companyID).
+		FindInBatches(&customers, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			return fn(customers)
+		}).Error
+}
+
 func (r *CustomerRepositoryImpl) FindByEmail(email string, companyID uint) (*pos.Customer, error) {
 	var customer pos.Customer
 	err := r.db.Where("email = ? AND company_id = ?", email, companyID).First(&customer).Error
@@ -87,10 +104,20 @@ func (r *SaleRepositoryImpl) FindByID(id uint) (*pos.Sale, error) {
 	return &sale, nil
 }
 
-func (r *SaleRepositoryImpl) FindByReceiptNumber(receiptNumber string) (*pos.Sale, error) {
+func (r *SaleRepositoryImpl) FindByReceiptNumber(companyID uint, receiptNumber string) (*pos.Sale, error) {
 	var sale pos.Sale
 	err := r.db.Preload("Items").Preload("Payments").Preload("Customer").
-		Where("receipt_number = ?", receiptNumber).First(&sale).Error
+		Where("company_id = ? AND receipt_number = ?", companyID, receiptNumber).First(&sale).Error
+	if err != nil {
+		return nil, err
+	}
+	return &sale, nil
+}
+
+func (r *SaleRepositoryImpl) FindByClientUUID(companyID uint, clientUUID string) (*pos.Sale, error) {
+	var sale pos.Sale
+	err := r.db.Preload("Items").Preload("Payments").Preload("Customer").
+		Where("company_id = ? AND client_uuid = ?", companyID, clientUUID).First(&sale).Error
 	if err != nil {
 		return nil, err
 	}
@@ -129,6 +156,21 @@ func (r *SaleRepositoryImpl) FindByFranchiseID(franchiseID uint, page, limit int
 	return sales, total, err
 }
 
+// StreamByCompanyID passes the company's sales, optionally of one franchise, to fn in batches, oldest first
+func (r *SaleRepositoryImpl) StreamByCompanyID(companyID uint, franchiseID *uint, fn func(sales []*pos.Sale) error) error {
+	query := r.db.Model(&pos.Sale{}).Where("company_id = ?", companyID)
+
+	if franchiseID != nil {
+		query = query.Where("franchise_id = ?", *franchiseID)
+	}
+
+	var sales []*pos.Sale
+	return query.Preload("Items").Preload("Payments").Preload("Customer").
+		FindInBatches(&sales, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			return fn(sales)
+		}).Error
+}
+
 func (r *SaleRepositoryImpl) FindByDateRange(companyID uint, franchiseID *uint, startDate, endDate time.Time, page, limit int) ([]*pos.Sale, int64, error) {
 	var sales []*pos.Sale
 	var total int64
@@ -166,10 +208,37 @@ func (r *SaleRepositoryImpl) FindByCustomerID(customerID uint, page, limit int)
 	return sales, total, err
 }
 
+func (r *SaleRepositoryImpl) FindParked(companyID uint, franchiseID *uint) ([]*pos.Sale, error) {
+	var sales []*pos.Sale
+	query := r.db.Preload("Items").Preload("Customer").
+		Where("company_id = ? AND sale_status = ? AND parked_at IS NOT NULL", companyID, pos.SaleStatusDraft)
+
+	if franchiseID != nil {
+		query = query.Where("franchise_id = ?", *franchiseID)
+	} else {
+		query = query.Where("franchise_id IS NULL")
+	}
+
+	err := query.Order("parked_at DESC").Find(&sales).Error
+	return sales, err
+}
+
+func (r *SaleRepositoryImpl) FindParkedBefore(cutoff time.Time) ([]*pos.Sale, error) {
+	var sales []*pos.Sale
+	err := r.db.Preload("Items").
+		Where("sale_status = ? AND parked_at IS NOT NULL AND parked_at < ?", pos.SaleStatusDraft, cutoff).
+		Find(&sales).Error
+	return sales, err
+}
+
 func (r *SaleRepositoryImpl) GetSalesReport(companyID uint, franchiseID *uint, startDate, endDate time.Time) (*pos.SalesReportData, error) {
+	// Partially refunded sales still count as revenue; their refunds are reported separately
+	reportedStatuses := []pos.SaleStatus{pos.SaleStatusCompleted, pos.SaleStatusPartiallyRefunded}
+
+	// Gift cards sold are a liability until spent, the sales paid with them are the revenue
 	query := r.db.Model(&pos.Sale{}).
-		Where("company_id = ? AND created_at >= ? AND created_at <= ? AND sale_status = ?",
-			companyID, startDate, endDate, pos.SaleStatusCompleted)
+		Where("company_id = ? AND created_at >= ? AND created_at <= ? AND sale_status IN ? AND voucher_id IS NULL",
+			companyID, startDate, endDate, reportedStatuses)
 
 	if franchiseID != nil {
 		query = query.Where("franchise_id = ?", *franchiseID)
@@ -178,7 +247,8 @@ func (r *SaleRepositoryImpl) GetSalesReport(companyID uint, franchiseID *uint, s
 	var totalSales int64
 	var totalRevenue float64
 	
-	err := query.Count(&totalSales).Error
+	// Replacement sales created by exchanges are not new transactions
+	err := query.Session(&gorm.Session{}).Where("exchange_id IS NULL").Count(&totalSales).Error
 	if err != nil {
 		return nil, err
 	}
@@ -188,12 +258,25 @@ func (r *SaleRepositoryImpl) GetSalesReport(companyID uint, franchiseID *uint, s
 		return nil, err
 	}
 
+	// Get gift cards sold
+	var giftCardCount int64
+	var giftCardAmount float64
+	giftCardQuery := r.db.Model(&pos.Sale{}).
+		Where("company_id = ? AND created_at >= ? AND created_at <= ? AND sale_status IN ? AND voucher_id IS NOT NULL",
+			companyID, startDate, endDate, reportedStatuses)
+
+	if franchiseID != nil {
+		giftCardQuery = giftCardQuery.Where("franchise_id = ?", *franchiseID)
+	}
+
+	giftCardQuery.Select("COUNT(*), COALESCE(SUM(total_amount), 0)").Row().Scan(&giftCardCount, &giftCardAmount)
+
 	// Get cash and card totals
 	var totalCash, totalCard float64
 	paymentQuery := r.db.Table("payments").
 		Joins("JOIN sales ON payments.sale_id = sales.id").
-		Where("sales.company_id = ? AND sales.created_at >= ? AND sales.created_at <= ? AND sales.sale_status = ? AND payments.payment_status = ?",
-			companyID, startDate, endDate, pos.SaleStatusCompleted, pos.PaymentTransactionStatusCompleted)
+		Where("sales.company_id = ? AND sales.created_at >= ? AND sales.created_at <= ? AND sales.sale_status IN ? AND payments.payment_status = ?",
+			companyID, startDate, endDate, reportedStatuses, pos.PaymentTransactionStatusCompleted)
 
 	if franchiseID != nil {
 		paymentQuery = paymentQuery.Where("sales.franchise_id = ?", *franchiseID)
@@ -210,7 +293,8 @@ func (r *SaleRepositoryImpl) GetSalesReport(companyID uint, franchiseID *uint, s
 	refundQuery := r.db.Model(&pos.Refund{}).
 		Joins("JOIN sales ON refunds.original_sale_id = sales.id").
 		Where("sales.company_id = ? AND refunds.created_at >= ? AND refunds.created_at <= ? AND refunds.refund_status = ?",
-			companyID, startDate, endDate, pos.RefundStatusCompleted)
+			companyID, startDate, endDate, pos.RefundStatusCompleted).
+		Where("refunds.exchange_id IS NULL")
 
 	if franchiseID != nil {
 		refundQuery = refundQuery.Where("sales.franchise_id = ?", *franchiseID)
@@ -218,6 +302,107 @@ func (r *SaleRepositoryImpl) GetSalesReport(companyID uint, franchiseID *uint, s
 
 	refundQuery.Select("COALESCE(SUM(refund_amount), 0)").Row().Scan(&totalRefunded)
 
+	// Get price override totals (list price minus charged price)
+	var priceOverrideCount int64
+	var priceOverrideAmount float64
+	overrideQuery := r.db.Table("sale_items").
+		Joins("JOIN sales ON sale_items.sale_id = sales.id").
+		Where("sales.company_id = ? AND sales.created_at >= ? AND sales.created_at <= ? AND sales.sale_status IN ? AND sale_items.is_price_override = ?",
+			companyID, startDate, endDate, reportedStatuses, true)
+
+	if franchiseID != nil {
+		overrideQuery = overrideQuery.Where("sales.franchise_id = ?", *franchiseID)
+	}
+
+	overrideQuery.Select("COUNT(*), COALESCE(SUM((sale_items.list_price - sale_items.unit_price) * sale_items.quantity), 0)").
+		Row().Scan(&priceOverrideCount, &priceOverrideAmount)
+
+	// Get exchanges; the returned value is taken off revenue since the replacement sales carry the new items
+	var exchangeCount int64
+	var exchangeReturned, exchangeBalance float64
+	exchangeQuery := r.db.Model(&pos.Exchange{}).
+		Where("company_id = ? AND created_at >= ? AND created_at <= ?", companyID, startDate, endDate)
+
+	if franchiseID != nil {
+		exchangeQuery = exchangeQuery.Where("franchise_id = ?", *franchiseID)
+	}
+
+	exchangeQuery.Select("COUNT(*), COALESCE(SUM(returned_amount), 0), COALESCE(SUM(balance_amount), 0)").
+		Row().Scan(&exchangeCount, &exchangeReturned, &exchangeBalance)
+
+	totalRevenue -= exchangeReturned
+
+	// Split revenue between wholesale and retail sales, each net of the value exchanged back
+	var wholesaleSales int64
+	var wholesaleRevenue, wholesaleExchanged float64
+	wholesaleQuery := r.db.Model(&pos.Sale{}).
+		Where("company_id = ? AND created_at >= ? AND created_at <= ? AND sale_status IN ? AND is_wholesale = ?",
+			companyID, startDate, endDate, reportedStatuses, true)
+
+	if franchiseID != nil {
+		wholesaleQuery = wholesaleQuery.Where("franchise_id = ?", *franchiseID)
+	}
+
+	wholesaleQuery.Session(&gorm.Session{}).Where("exchange_id IS NULL").Count(&wholesaleSales)
+	wholesaleQuery.Select("COALESCE(SUM(total_amount), 0)").Row().Scan(&wholesaleRevenue)
+
+	wholesaleExchangeQuery := r.db.Model(&pos.Exchange{}).
+		Joins("JOIN sales ON exchanges.original_sale_id = sales.id").
+		Where("exchanges.company_id = ? AND exchanges.created_at >= ? AND exchanges.created_at <= ? AND sales.is_wholesale = ?",
+			companyID, startDate, endDate, true)
+
+	if franchiseID != nil {
+		wholesaleExchangeQuery = wholesaleExchangeQuery.Where("exchanges.franchise_id = ?", *franchiseID)
+	}
+
+	wholesaleExchangeQuery.Select("COALESCE(SUM(exchanges.returned_amount), 0)").Row().Scan(&wholesaleExchanged)
+
+	wholesaleRevenue -= wholesaleExchanged
+
+	// Get voided sales; they are cancelled so they never count as revenue or refunds
+	var voidCount int64
+	var voidAmount float64
+	voidQuery := r.db.Model(&pos.Sale{}).
+		Where("company_id = ? AND voided_at >= ? AND voided_at <= ?", companyID, startDate, endDate)
+
+	if franchiseID != nil {
+		voidQuery = voidQuery.Where("franchise_id = ?", *franchiseID)
+	}
+
+	voidQuery.Select("COUNT(*), COALESCE(SUM(total_amount), 0)").Row().Scan(&voidCount, &voidAmount)
+
+	// Get tax collected per class and rate, net of refunded lines
+	taxBreakdown, totalTax, err := r.getTaxBreakdown(companyID, franchiseID, startDate, endDate, reportedStatuses)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the discounts given by each promotion
+	var promotionBreakdown []pos.PromotionCostLine
+	promotionQuery := r.db.Table("sale_items").
+		Joins("JOIN sales ON sale_items.sale_id = sales.id").
+		Joins("LEFT JOIN promotions ON sale_items.promotion_id = promotions.id").
+		Where("sales.company_id = ? AND sales.created_at >= ? AND sales.created_at <= ? AND sales.sale_status IN ? AND sale_items.promotion_id IS NOT NULL",
+			companyID, startDate, endDate, reportedStatuses)
+
+	if franchiseID != nil {
+		promotionQuery = promotionQuery.Where("sales.franchise_id = ?", *franchiseID)
+	}
+
+	err = promotionQuery.Select("sale_items.promotion_id, COALESCE(MAX(promotions.name), '') AS promotion_name, " +
+		"COUNT(DISTINCT sales.id) AS sale_count, COALESCE(SUM(sale_items.promotion_discount), 0) AS amount").
+		Group("sale_items.promotion_id").
+		Order("amount DESC").
+		Scan(&promotionBreakdown).Error
+	if err != nil {
+		return nil, err
+	}
+
+	promotionCost := 0.0
+	for _, line := range promotionBreakdown {
+		promotionCost += line.Amount
+	}
+
 	averageOrderValue := 0.0
 	if totalSales > 0 {
 		averageOrderValue = totalRevenue / float64(totalSales)
@@ -241,16 +426,246 @@ func (r *SaleRepositoryImpl) GetSalesReport(companyID uint, franchiseID *uint, s
 	}
 
 	return &pos.SalesReportData{
-		TotalSales:        totalSales,
-		TotalRevenue:      totalRevenue,
-		TotalCash:         totalCash,
-		TotalCard:         totalCard,
-		TotalRefunded:     totalRefunded,
-		AverageOrderValue: averageOrderValue,
-		SalesByDate:       salesByDate,
+		TotalSales:          totalSales,
+		TotalRevenue:        totalRevenue,
+		WholesaleSales:      wholesaleSales,
+		WholesaleRevenue:    wholesaleRevenue,
+		RetailRevenue:       totalRevenue - wholesaleRevenue,
+		TotalCash:           totalCash,
+		TotalCard:           totalCard,
+		TotalRefunded:       totalRefunded,
+		AverageOrderValue:   averageOrderValue,
+		SalesByDate:         salesByDate,
+		PriceOverrideCount:  priceOverrideCount,
+		PriceOverrideAmount: priceOverrideAmount,
+		ExchangeCount:       exchangeCount,
+		ExchangeReturned:    exchangeReturned,
+		ExchangeBalance:     exchangeBalance,
+		VoidCount:           voidCount,
+		VoidAmount:          voidAmount,
+		GiftCardCount:       giftCardCount,
+		GiftCardAmount:      giftCardAmount,
+		TotalTax:            totalTax,
+		TaxBreakdown:        taxBreakdown,
+		PromotionCost:       promotionCost,
+		PromotionBreakdown:  promotionBreakdown,
 	}, nil
 }
 
+// getTaxBreakdown sums the net and tax amounts of sold lines per tax class and rate,
+// then takes off the tax share of refunds issued in the period
+func (r *SaleRepositoryImpl) getTaxBreakdown(companyID uint, franchiseID *uint, startDate, endDate time.Time, reportedStatuses []pos.SaleStatus) ([]pos.TaxBreakdownLine, float64, error) {
+	var sold []pos.TaxBreakdownLine
+	soldQuery := r.db.Table("sale_items").
+		Joins("JOIN sales ON sale_items.sale_id = sales.id").
+		Joins("LEFT JOIN tax_classes ON sale_items.tax_class_id = tax_classes.id").
+		Where("sales.company_id = ? AND sales.created_at >= ? AND sales.created_at <= ? AND sales.sale_status IN ?",
+			companyID, startDate, endDate, reportedStatuses)
+
+	if franchiseID != nil {
+		soldQuery = soldQuery.Where("sales.franchise_id = ?", *franchiseID)
+	}
+
+	err := soldQuery.Select("sale_items.tax_class_id, COALESCE(MAX(tax_classes.name), '') AS tax_class_name, sale_items.tax_rate, " +
+		"COALESCE(SUM(sale_items.total_amount - sale_items.tax_amount), 0) AS net_amount, COALESCE(SUM(sale_items.tax_amount), 0) AS tax_amount").
+		Group("sale_items.tax_class_id, sale_items.tax_rate").
+		Order("sale_items.tax_rate DESC").
+		Scan(&sold).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Refunds carry the tax of the lines they return, in proportion to the refunded amount
+	var refunded []pos.TaxBreakdownLine
+	refundQuery := r.db.Table("refund_items").
+		Joins("JOIN refunds ON refund_items.refund_id = refunds.id").
+		Joins("JOIN sale_items ON refund_items.sale_item_id = sale_items.id").
+		Joins("JOIN sales ON refunds.original_sale_id = sales.id").
+		Joins("LEFT JOIN tax_classes ON sale_items.tax_class_id = tax_classes.id").
+		Where("sales.company_id = ? AND refunds.created_at >= ? AND refunds.created_at <= ? AND refunds.refund_status = ? AND sale_items.total_amount > 0",
+			companyID, startDate, endDate, pos.RefundStatusCompleted)
+
+	if franchiseID != nil {
+		refundQuery = refundQuery.Where("sales.franchise_id = ?", *franchiseID)
+	}
+
+	// NetAmount holds the gross refunded amount until its tax share is taken off below
+	err = refundQuery.Select("sale_items.tax_class_id, COALESCE(MAX(tax_classes.name), '') AS tax_class_name, sale_items.tax_rate, " +
+		"COALESCE(SUM(refund_items.refund_amount), 0) AS net_amount, " +
+		"COALESCE(SUM(refund_items.refund_amount * sale_items.tax_amount / sale_items.total_amount), 0) AS tax_amount").
+		Group("sale_items.tax_class_id, sale_items.tax_rate").
+		Scan(&refunded).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, refund := range refunded {
+		refundTax := math.Round(refund.TaxAmount*100) / 100
+		refundNet := refund.NetAmount - refundTax
+		matched := false
+		for i := range sold {
+			if sameTaxClass(sold[i].TaxClassID, refund.TaxClassID) && sold[i].TaxRate == refund.TaxRate {
+				sold[i].NetAmount -= refundNet
+				sold[i].TaxAmount -= refundTax
+				matched = true
+				break
+			}
+		}
+		// Returns of lines sold before the period still reduce the tax due in it
+		if !matched {
+			refund.NetAmount = -refundNet
+			refund.TaxAmount = -refundTax
+			sold = append(sold, refund)
+		}
+	}
+
+	totalTax := 0.0
+	for _, line := range sold {
+		totalTax += line.TaxAmount
+	}
+
+	return sold, totalTax, nil
+}
+
+func sameTaxClass(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// analyticsLineMetrics aggregates sale lines net of their returns. Sale-level discounts are
+// spread over the lines in proportion to their amount.
+const analyticsLineMetrics = "COUNT(DISTINCT sales.id) AS sale_count, " +
+	"COALESCE(SUM(sale_items.quantity - COALESCE(returned.quantity, 0)), 0) AS quantity, " +
+	"COALESCE(SUM(sale_items.total_amount * " + analyticsDiscountFactor + " - COALESCE(returned.amount, 0)), 0) AS revenue, " +
+	"COALESCE(SUM(sale_items.net_amount * " + analyticsDiscountFactor + " - COALESCE(returned.amount * sale_items.net_amount / NULLIF(sale_items.total_amount, 0), 0)), 0) AS net_revenue, " +
+	"COALESCE(SUM((sale_items.quantity - COALESCE(returned.quantity, 0)) * COALESCE(sale_items.unit_cost, products.supplier_cost, 0)), 0) AS cost, " +
+	"COALESCE(SUM(CASE WHEN sale_items.unit_cost IS NULL AND products.supplier_cost IS NULL THEN sale_items.quantity - COALESCE(returned.quantity, 0) ELSE 0 END), 0) AS uncosted_quantity"
+
+const analyticsDiscountFactor = "COALESCE(sales.total_amount / NULLIF(sales.sub_total + sales.tax_amount, 0), 1)"
+
+func (r *SaleRepositoryImpl) GetSalesAnalytics(filter pos.SalesAnalyticsFilter) (*pos.SalesAnalyticsData, error) {
+	summary, err := r.GetSalesAnalyticsSummary(filter)
+	if err != nil {
+		return nil, err
+	}
+	data := &pos.SalesAnalyticsData{Summary: *summary}
+
+	localTime := "(sales.created_at AT TIME ZONE ?)"
+
+	data.ByProduct, err = r.groupAnalyticsLines(r.analyticsLineQuery(filter).Limit(filter.TopProducts),
+		"sale_items.product_variant_id::text", "MAX(products.name || ' - ' || product_variants.name)", "MAX(product_variants.sku)", "revenue DESC")
+	if err != nil {
+		return nil, err
+	}
+
+	data.ByCategory, err = r.groupAnalyticsLines(r.analyticsLineQuery(filter),
+		"products.category", "MAX(products.category)", "''", "revenue DESC")
+	if err != nil {
+		return nil, err
+	}
+
+	data.ByCashier, err = r.groupAnalyticsLines(r.analyticsLineQuery(filter).Joins("LEFT JOIN users ON sales.created_by_id = users.id"),
+		"sales.created_by_id::text", "COALESCE(MAX(TRIM(users.first_name || ' ' || users.last_name)), '')", "''", "revenue DESC")
+	if err != nil {
+		return nil, err
+	}
+
+	// Hours are padded to two digits so they sort in order
+	data.ByHour, err = r.groupAnalyticsLines(r.analyticsLineQuery(filter),
+		"LPAD(EXTRACT(HOUR FROM "+localTime+")::int::text, 2, '0')", "''", "''", "key", filter.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	// ISO weekdays, from 1 (Monday) to 7 (Sunday)
+	data.ByWeekday, err = r.groupAnalyticsLines(r.analyticsLineQuery(filter),
+		"EXTRACT(ISODOW FROM "+localTime+")::int::text", "''", "''", "key", filter.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	data.ByFranchise, err = r.groupAnalyticsLines(r.analyticsLineQuery(filter).Joins("LEFT JOIN franchises ON sales.franchise_id = franchises.id"),
+		"COALESCE(sales.franchise_id::text, '')", "COALESCE(MAX(franchises.name), '')", "''", "revenue DESC")
+	if err != nil {
+		return nil, err
+	}
+
+	data.ByChannel, err = r.groupAnalyticsLines(r.analyticsLineQuery(filter),
+		"CASE WHEN sales.is_wholesale THEN 'wholesale' ELSE 'retail' END",
+		"CASE WHEN BOOL_OR(sales.is_wholesale) THEN 'Wholesale' ELSE 'Retail' END", "''", "revenue DESC")
+	if err != nil {
+		return nil, err
+	}
+
+	paymentQuery := r.db.Table("payments").
+		Joins("JOIN sales ON payments.sale_id = sales.id").
+		Where("sales.company_id = ? AND sales.created_at >= ? AND sales.created_at <= ? AND sales.sale_status IN ? AND payments.payment_status = ?",
+			filter.CompanyID, filter.StartDate, filter.EndDate, analyticsStatuses, pos.PaymentTransactionStatusCompleted)
+
+	if filter.FranchiseID != nil {
+		paymentQuery = paymentQuery.Where("sales.franchise_id = ?", *filter.FranchiseID)
+	}
+
+	err = paymentQuery.Select("payments.payment_method AS method, COUNT(*) AS count, COALESCE(SUM(payments.amount), 0) AS amount").
+		Group("payments.payment_method").
+		Order("amount DESC").
+		Scan(&data.ByPaymentMethod).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// GetSalesAnalyticsSummary returns the totals of the period, without breakdowns
+func (r *SaleRepositoryImpl) GetSalesAnalyticsSummary(filter pos.SalesAnalyticsFilter) (*pos.AnalyticsLine, error) {
+	var summary pos.AnalyticsLine
+	err := r.analyticsLineQuery(filter).Select(analyticsLineMetrics).Scan(&summary).Error
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// Completed and partially refunded sales count, as in the sales report
+var analyticsStatuses = []pos.SaleStatus{pos.SaleStatusCompleted, pos.SaleStatusPartiallyRefunded}
+
+// analyticsLineQuery selects the lines of the sales in the filter, joined with their
+// product and the quantity and amount returned from them
+func (r *SaleRepositoryImpl) analyticsLineQuery(filter pos.SalesAnalyticsFilter) *gorm.DB {
+	returned := r.db.Table("refund_items").
+		Select("refund_items.sale_item_id, SUM(refund_items.quantity) AS quantity, SUM(refund_items.refund_amount) AS amount").
+		Joins("JOIN refunds ON refund_items.refund_id = refunds.id").
+		Where("refunds.refund_status = ?", pos.RefundStatusCompleted).
+		Group("refund_items.sale_item_id")
+
+	query := r.db.Table("sale_items").
+		Joins("JOIN sales ON sale_items.sale_id = sales.id").
+		Joins("JOIN product_variants ON sale_items.product_variant_id = product_variants.id").
+		Joins("JOIN products ON product_variants.product_id = products.id").
+		Joins("LEFT JOIN (?) AS returned ON returned.sale_item_id = sale_items.id", returned).
+		Where("sales.company_id = ? AND sales.created_at >= ? AND sales.created_at <= ? AND sales.sale_status IN ?",
+			filter.CompanyID, filter.StartDate, filter.EndDate, analyticsStatuses)
+
+	if filter.FranchiseID != nil {
+		query = query.Where("sales.franchise_id = ?", *filter.FranchiseID)
+	}
+	return query
+}
+
+// groupAnalyticsLines aggregates the lines per key; args bind the placeholders of the key expression.
+// Grouping by position keeps those placeholders out of the GROUP BY clause.
+func (r *SaleRepositoryImpl) groupAnalyticsLines(query *gorm.DB, keyExpr, labelExpr, skuExpr, order string, args ...interface{}) ([]pos.AnalyticsLine, error) {
+	var lines []pos.AnalyticsLine
+	err := query.Select(keyExpr+" AS key, "+labelExpr+" AS label, "+skuExpr+" AS sku, "+analyticsLineMetrics, args...).
+		Clauses(clause.GroupBy{Columns: []clause.Column{{Name: "1", Raw: true}}}).
+		Order(order).
+		Scan(&lines).Error
+	return lines, err
+}
+
 // SaleItemRepositoryImpl implements the SaleItemRepository interface
 type SaleItemRepositoryImpl struct {
 	db *gorm.DB
@@ -342,7 +757,9 @@ func (r *CashDrawerRepositoryImpl) Update(drawer *pos.CashDrawer) error {
 
 func (r *CashDrawerRepositoryImpl) FindByID(id uint) (*pos.CashDrawer, error) {
 	var drawer pos.CashDrawer
-	err := r.db.Preload("Transactions").First(&drawer, id).Error
+	err := r.db.Preload("Transactions").Preload("Counts", func(db *gorm.DB) *gorm.DB {
+		return db.Order("denomination DESC")
+	}).First(&drawer, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -401,6 +818,91 @@ func (r *CashDrawerRepositoryImpl) FindByFranchiseID(franchiseID uint, page, lim
 	return drawers, total, err
 }
 
+// StreamByCompanyID passes the company's cash drawers, optionally of one franchise, to fn in batches, oldest first
+func (r *CashDrawerRepositoryImpl) StreamByCompanyID(companyID uint, franchiseID *uint, fn func(drawers []*pos.CashDrawer) error) error {
+	query := r.db.Model(&pos.CashDrawer{}).Where("company_id = ?", companyID)
+
+	if franchiseID != nil {
+		query = query.Where("franchise_id = ?", *franchiseID)
+	}
+
+	var drawers []*pos.CashDrawer
+	return query.Preload("Transactions").
+		FindInBatches(&drawers, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			return fn(drawers)
+		}).Error
+}
+
+// GetShiftReport aggregates the sales, refunds and payments of the drawer's company or franchise
+// made between the drawer opening and until, along with the drawer's own transactions
+func (r *CashDrawerRepositoryImpl) GetShiftReport(drawer *pos.CashDrawer, until time.Time) (*pos.ShiftReportData, error) {
+	// Franchise drawers cover the franchise's sales, company drawers the sales made at company level
+	scope := func(query *gorm.DB) *gorm.DB {
+		if drawer.FranchiseID != nil {
+			return query.Where("sales.franchise_id = ?", *drawer.FranchiseID)
+		}
+		return query.Where("sales.company_id = ? AND sales.franchise_id IS NULL", *drawer.CompanyID)
+	}
+
+	data := &pos.ShiftReportData{}
+
+	// Sales rung up during the shift, whatever was refunded since; exchange replacements are not new sales
+	soldStatuses := []pos.SaleStatus{pos.SaleStatusCompleted, pos.SaleStatusPartiallyRefunded, pos.SaleStatusRefunded}
+	err := scope(r.db.Model(&pos.Sale{})).
+		Where("sales.created_at >= ? AND sales.created_at <= ? AND sales.sale_status IN ? AND sales.exchange_id IS NULL",
+			drawer.OpenedAt, until, soldStatuses).
+		Select("COUNT(*), COALESCE(SUM(total_amount), 0), COALESCE(SUM(tax_amount), 0), COALESCE(SUM(discount_amount), 0)").
+		Row().Scan(&data.SaleCount, &data.SalesTotal, &data.TaxTotal, &data.DiscountTotal)
+	if err != nil {
+		return nil, err
+	}
+
+	err = scope(r.db.Model(&pos.Sale{})).
+		Where("sales.voided_at >= ? AND sales.voided_at <= ?", drawer.OpenedAt, until).
+		Select("COUNT(*), COALESCE(SUM(total_amount), 0)").
+		Row().Scan(&data.VoidCount, &data.VoidTotal)
+	if err != nil {
+		return nil, err
+	}
+
+	err = scope(r.db.Table("refunds").Joins("JOIN sales ON refunds.original_sale_id = sales.id")).
+		Where("refunds.created_at >= ? AND refunds.created_at <= ? AND refunds.refund_status = ? AND refunds.exchange_id IS NULL",
+			drawer.OpenedAt, until, pos.RefundStatusCompleted).
+		Select("refunds.refund_method AS method, COUNT(*) AS count, COALESCE(SUM(refunds.refund_amount), 0) AS amount").
+		Group("refunds.refund_method").
+		Order("amount DESC").
+		Scan(&data.RefundsByMethod).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range data.RefundsByMethod {
+		data.RefundCount += line.Count
+		data.RefundTotal += line.Amount
+	}
+
+	err = scope(r.db.Table("payments").Joins("JOIN sales ON payments.sale_id = sales.id")).
+		Where("payments.created_at >= ? AND payments.created_at <= ? AND payments.payment_status = ?",
+			drawer.OpenedAt, until, pos.PaymentTransactionStatusCompleted).
+		Select("payments.payment_method AS method, COUNT(*) AS count, COALESCE(SUM(payments.amount), 0) AS amount").
+		Group("payments.payment_method").
+		Order("amount DESC").
+		Scan(&data.PaymentsByMethod).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.Model(&pos.CashDrawerTransaction{}).
+		Where("cash_drawer_id = ?", drawer.ID).
+		Select("transaction_type, COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount").
+		Group("transaction_type").
+		Scan(&data.CashByType).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
 // CashDrawerTransactionRepositoryImpl implements the CashDrawerTransactionRepository interface
 type CashDrawerTransactionRepositoryImpl struct {
 	db *gorm.DB
@@ -424,7 +926,7 @@ func (r *CashDrawerTransactionRepositoryImpl) GetTotalByCashDrawerID(drawerID ui
 	var total float64
 	err := r.db.Model(&pos.CashDrawerTransaction{}).
 		Where("cash_drawer_id = ?", drawerID).
-		Select("COALESCE(SUM(CASE WHEN transaction_type = 'sale' THEN amount WHEN transaction_type = 'refund' THEN -amount ELSE amount END), 0)").
+		Select("COALESCE(SUM(amount), 0)").
 		Row().Scan(&total)
 	return total, err
 }
@@ -448,7 +950,7 @@ func (r *RefundRepositoryImpl) Update(refund *pos.Refund) error {
 
 func (r *RefundRepositoryImpl) FindByID(id uint) (*pos.Refund, error) {
 	var refund pos.Refund
-	err := r.db.Preload("OriginalSale").First(&refund, id).Error
+	err := r.db.Preload("OriginalSale").Preload("Items").First(&refund, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -457,7 +959,7 @@ func (r *RefundRepositoryImpl) FindByID(id uint) (*pos.Refund, error) {
 
 func (r *RefundRepositoryImpl) FindBySaleID(saleID uint) ([]*pos.Refund, error) {
 	var refunds []*pos.Refund
-	err := r.db.Where("original_sale_id = ?", saleID).Find(&refunds).Error
+	err := r.db.Preload("Items").Where("original_sale_id = ?", saleID).Find(&refunds).Error
 	return refunds, err
 }
 
@@ -474,7 +976,7 @@ func (r *RefundRepositoryImpl) FindByCompanyID(companyID uint, page, limit int)
 	}
 
 	offset := (page - 1) * limit
-	err := query.Preload("OriginalSale").Offset(offset).Limit(limit).Order("refunds.created_at DESC").Find(&refunds).Error
+	err := query.Preload("OriginalSale").Preload("Items").Offset(offset).Limit(limit).Order("refunds.created_at DESC").Find(&refunds).Error
 	return refunds, total, err
 }
 
@@ -491,7 +993,326 @@ func (r *RefundRepositoryImpl) FindByFranchiseID(franchiseID uint, page, limit i
 	}
 
 	offset := (page - 1) * limit
-	err := query.Preload("OriginalSale").Offset(offset).Limit(limit).Order("refunds.created_at DESC").Find(&refunds).Error
+	err := query.Preload("OriginalSale").Preload("Items").Offset(offset).Limit(limit).Order("refunds.created_at DESC").Find(&refunds).Error
 	return refunds, total, err
 }
 
+// StreamByCompanyID passes the refunds of the company's sales, optionally of one franchise, to fn in batches, oldest first
+func (r *RefundRepositoryImpl) StreamByCompanyID(companyID uint, franchiseID *uint, fn func(refunds []*pos.Refund) error) error {
+	query := r.db.Model(&pos.Refund{}).
+		Joins("JOIN sales ON refunds.original_sale_id = sales.id").
+		Where("sales.company_id = ?", companyID)
+
+	if franchiseID != nil {
+		query = query.Where("sales.franchise_id = ?", *franchiseID)
+	}
+
+	var refunds []*pos.Refund
+	return query.Preload("OriginalSale").Preload("Items").
+		FindInBatches(&refunds, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			return fn(refunds)
+		}).Error
+}
+
+func (r *RefundRepositoryImpl) GetRefundedQuantitiesBySaleID(saleID uint) (map[uint]int, error) {
+	type SaleItemQuantity struct {
+		SaleItemID uint
+		Quantity   int
+	}
+	var rows []SaleItemQuantity
+
+	err := r.db.Table("refund_items").
+		Joins("JOIN refunds ON refund_items.refund_id = refunds.id").
+		Where("refunds.original_sale_id = ? AND refunds.refund_status = ?", saleID, pos.RefundStatusCompleted).
+		Select("refund_items.sale_item_id, COALESCE(SUM(refund_items.quantity), 0) as quantity").
+		Group("refund_items.sale_item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		quantities[row.SaleItemID] = row.Quantity
+	}
+	return quantities, nil
+}
+
+func (r *RefundRepositoryImpl) GetTotalRefundedForSale(saleID uint) (float64, error) {
+	var total float64
+	err := r.db.Model(&pos.Refund{}).
+		Where("original_sale_id = ? AND refund_status = ?", saleID, pos.RefundStatusCompleted).
+		Select("COALESCE(SUM(refund_amount), 0)").
+		Row().Scan(&total)
+	return total, err
+}
+
+// ExchangeRepositoryImpl implements the ExchangeRepository interface
+type ExchangeRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewExchangeRepository(db *gorm.DB) pos.ExchangeRepository {
+	return &ExchangeRepositoryImpl{db: db}
+}
+
+func (r *ExchangeRepositoryImpl) Create(exchange *pos.Exchange) error {
+	return r.db.Create(exchange).Error
+}
+
+func (r *ExchangeRepositoryImpl) Update(exchange *pos.Exchange) error {
+	return r.db.Save(exchange).Error
+}
+
+func (r *ExchangeRepositoryImpl) FindByID(id uint) (*pos.Exchange, error) {
+	var exchange pos.Exchange
+	err := r.db.Preload("OriginalSale").Preload("Refund.Items").Preload("ReplacementSale.Items").
+		Preload("ReplacementSale.Payments").First(&exchange, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &exchange, nil
+}
+
+func (r *ExchangeRepositoryImpl) FindBySaleID(saleID uint) ([]*pos.Exchange, error) {
+	var exchanges []*pos.Exchange
+	err := r.db.Preload("Refund.Items").Preload("ReplacementSale.Items").
+		Where("original_sale_id = ?", saleID).Order("created_at DESC").Find(&exchanges).Error
+	return exchanges, err
+}
+
+func (r *ExchangeRepositoryImpl) FindByCompanyID(companyID uint, page, limit int) ([]*pos.Exchange, int64, error) {
+	var exchanges []*pos.Exchange
+	var total int64
+
+	query := r.db.Model(&pos.Exchange{}).Where("company_id = ?", companyID)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Preload("OriginalSale").Preload("Refund.Items").Preload("ReplacementSale.Items").
+		Offset(offset).Limit(limit).Order("created_at DESC").Find(&exchanges).Error
+	return exchanges, total, err
+}
+
+func (r *ExchangeRepositoryImpl) FindByFranchiseID(franchiseID uint, page, limit int) ([]*pos.Exchange, int64, error) {
+	var exchanges []*pos.Exchange
+	var total int64
+
+	query := r.db.Model(&pos.Exchange{}).Where("franchise_id = ?", franchiseID)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Preload("OriginalSale").Preload("Refund.Items").Preload("ReplacementSale.Items").
+		Offset(offset).Limit(limit).Order("created_at DESC").Find(&exchanges).Error
+	return exchanges, total, err
+}
+
+// LayawayRepositoryImpl implements the LayawayRepository interface
+type LayawayRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewLayawayRepository(db *gorm.DB) pos.LayawayRepository {
+	return &LayawayRepositoryImpl{db: db}
+}
+
+func (r *LayawayRepositoryImpl) FindByID(id uint) (*pos.Layaway, error) {
+	var layaway pos.Layaway
+	err := r.db.Preload("Sale.Items").Preload("Sale.Payments").Preload("Customer").First(&layaway, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &layaway, nil
+}
+
+func (r *LayawayRepositoryImpl) FindByCompanyID(companyID uint, franchiseID *uint, status pos.LayawayStatus, page, limit int) ([]*pos.Layaway, int64, error) {
+	var layaways []*pos.Layaway
+	var total int64
+
+	query := r.db.Model(&pos.Layaway{}).Where("company_id = ?", companyID)
+	if franchiseID != nil {
+		query = query.Where("franchise_id = ?", *franchiseID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Preload("Sale.Items").Preload("Sale.Payments").Preload("Customer").
+		Offset(offset).Limit(limit).Order("created_at DESC").Find(&layaways).Error
+	return layaways, total, err
+}
+
+func (r *LayawayRepositoryImpl) FindActiveExpiredBefore(cutoff time.Time) ([]*pos.Layaway, error) {
+	var layaways []*pos.Layaway
+	err := r.db.Preload("Sale.Items").
+		Where("status = ? AND expires_at < ?", pos.LayawayStatusActive, cutoff).
+		Find(&layaways).Error
+	return layaways, err
+}
+
+// InvoiceRepositoryImpl implements the InvoiceRepository interface
+type InvoiceRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewInvoiceRepository(db *gorm.DB) pos.InvoiceRepository {
+	return &InvoiceRepositoryImpl{db: db}
+}
+
+func (r *InvoiceRepositoryImpl) FindBySaleID(saleID uint) (*pos.Invoice, error) {
+	var invoice pos.Invoice
+	err := r.db.Where("sale_id = ?", saleID).First(&invoice).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// ProformaRepositoryImpl implements the ProformaRepository interface
+type ProformaRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewProformaRepository(db *gorm.DB) pos.ProformaRepository {
+	return &ProformaRepositoryImpl{db: db}
+}
+
+func (r *ProformaRepositoryImpl) FindByID(id uint) (*pos.Proforma, error) {
+	var proforma pos.Proforma
+	err := r.db.Preload("Sale.Items").Preload("Sale.Payments").Preload("Customer").First(&proforma, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &proforma, nil
+}
+
+func (r *ProformaRepositoryImpl) FindByCompanyID(companyID uint, franchiseID *uint, status pos.ProformaStatus, page, limit int) ([]*pos.Proforma, int64, error) {
+	var proformas []*pos.Proforma
+	var total int64
+
+	query := r.db.Model(&pos.Proforma{}).Where("company_id = ?", companyID)
+	if franchiseID != nil {
+		query = query.Where("franchise_id = ?", *franchiseID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Preload("Sale.Items").Preload("Customer").
+		Offset(offset).Limit(limit).Order("created_at DESC").Find(&proformas).Error
+	return proformas, total, err
+}
+
+// CustomerAccountRepositoryImpl implements the CustomerAccountRepository interface
+type CustomerAccountRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewCustomerAccountRepository(db *gorm.DB) pos.CustomerAccountRepository {
+	return &CustomerAccountRepositoryImpl{db: db}
+}
+
+func (r *CustomerAccountRepositoryImpl) FindPaymentByID(id uint) (*pos.CustomerPayment, error) {
+	var payment pos.CustomerPayment
+	err := r.db.Preload("Distributions").First(&payment, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *CustomerAccountRepositoryImpl) FindPaymentsByCustomerID(customerID uint, startDate, endDate time.Time) ([]*pos.CustomerPayment, error) {
+	var payments []*pos.CustomerPayment
+	err := r.db.Preload("Distributions").
+		Where("customer_id = ? AND created_at >= ? AND created_at < ?", customerID, startDate, endDate).
+		Order("created_at ASC, id ASC").
+		Find(&payments).Error
+	return payments, err
+}
+
+// FindChargedSales returns the sales put on the customer's account in the period, voided sales excluded
+func (r *CustomerAccountRepositoryImpl) FindChargedSales(customerID uint, startDate, endDate time.Time) ([]*pos.Sale, error) {
+	var sales []*pos.Sale
+	err := r.db.Where("customer_id = ? AND charged_amount > 0 AND sale_status <> ?", customerID, pos.SaleStatusCancelled).
+		Where("created_at >= ? AND created_at < ?", startDate, endDate).
+		Order("created_at ASC, id ASC").
+		Find(&sales).Error
+	return sales, err
+}
+
+// FindOpenBalances returns the sales bought on account that are not paid off yet, oldest first.
+// A nil customerID returns the open sales of every customer of the company.
+func (r *CustomerAccountRepositoryImpl) FindOpenBalances(companyID uint, customerID *uint) ([]pos.AccountSaleBalance, error) {
+	var balances []pos.AccountSaleBalance
+
+	query := r.db.Table("sales s").
+		Select(`s.id AS sale_id, s.customer_id, c.name AS customer_name, c.credit_limit,
+			s.receipt_number, s.total_amount, s.charged_amount, s.created_at,
+			s.total_amount - COALESCE((
+				SELECT SUM(p.amount) FROM payments p
+				WHERE p.sale_id = s.id AND p.payment_status = ?
+			), 0) AS outstanding`, pos.PaymentTransactionStatusCompleted).
+		Joins("JOIN customers c ON c.id = s.customer_id").
+		Where("s.company_id = ? AND s.charged_amount > 0", companyID).
+		Where("s.sale_status IN ?", []pos.SaleStatus{pos.SaleStatusCompleted, pos.SaleStatusPartiallyRefunded}).
+		Where("s.payment_status IN ?", []pos.PaymentStatus{pos.PaymentStatusUnpaid, pos.PaymentStatusPartiallyPaid})
+
+	if customerID != nil {
+		query = query.Where("s.customer_id = ?", *customerID)
+	}
+
+	if err := query.Order("s.created_at ASC, s.id ASC").Scan(&balances).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range balances {
+		balances[i].Outstanding = math.Round(balances[i].Outstanding*100) / 100
+	}
+
+	return balances, nil
+}
+
+// GetBalanceBefore returns what the customer owed on account at the given date
+func (r *CustomerAccountRepositoryImpl) GetBalanceBefore(customerID uint, date time.Time) (float64, error) {
+	var charged float64
+	err := r.db.Model(&pos.Sale{}).
+		Where("customer_id = ? AND charged_amount > 0 AND sale_status <> ? AND created_at < ?", customerID, pos.SaleStatusCancelled, date).
+		Select("COALESCE(SUM(charged_amount), 0)").
+		Row().Scan(&charged)
+	if err != nil {
+		return 0, err
+	}
+
+	var repaid float64
+	err = r.db.Model(&pos.CustomerPayment{}).
+		Where("customer_id = ? AND created_at < ?", customerID, date).
+		Select("COALESCE(SUM(amount), 0)").
+		Row().Scan(&repaid)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Round((charged-repaid)*100) / 100, nil
+}
+
+func (r *CustomerAccountRepositoryImpl) CountDistributionsBySaleID(saleID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&pos.CustomerPaymentDistribution{}).Where("sale_id = ?", saleID).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,160 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/YasserCherfaoui/darween/internal/domain/pricelist"
+	"gorm.io/gorm"
+)
+
+type customerGroupRepository struct {
+	db *gorm.DB
+}
+
+func NewCustomerGroupRepository(db *gorm.DB) pricelist.CustomerGroupRepository {
+	return &customerGroupRepository{db: db}
+}
+
+func (r *customerGroupRepository) Create(group *pricelist.CustomerGroup) error {
+	return r.db.Create(group).Error
+}
+
+func (r *customerGroupRepository) FindByID(id uint) (*pricelist.CustomerGroup, error) {
+	var group pricelist.CustomerGroup
+	err := r.db.Where("id = ?", id).First(&group).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("customer group not found")
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *customerGroupRepository) FindByIDAndCompany(id, companyID uint) (*pricelist.CustomerGroup, error) {
+	var group pricelist.CustomerGroup
+	err := r.db.Where("id = ? AND company_id = ?", id, companyID).First(&group).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("customer group not found")
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *customerGroupRepository) FindByCompanyID(companyID uint) ([]*pricelist.CustomerGroup, error) {
+	var groups []*pricelist.CustomerGroup
+	err := r.db.Where("company_id = ?", companyID).Order("name ASC").Find(&groups).Error
+	return groups, err
+}
+
+func (r *customerGroupRepository) FindByName(companyID uint, name string) (*pricelist.CustomerGroup, error) {
+	var group pricelist.CustomerGroup
+	err := r.db.Where("company_id = ? AND LOWER(name) = LOWER(?)", companyID, name).First(&group).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("customer group not found")
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+// CountCustomers counts the customers assigned to the group
+func (r *customerGroupRepository) CountCustomers(groupID uint) (int64, error) {
+	var count int64
+	err := r.db.Table("customers").Where("customer_group_id = ?", groupID).Count(&count).Error
+	return count, err
+}
+
+func (r *customerGroupRepository) Update(group *pricelist.CustomerGroup) error {
+	return r.db.Save(group).Error
+}
+
+func (r *customerGroupRepository) Delete(id uint) error {
+	return r.db.Delete(&pricelist.CustomerGroup{}, id).Error
+}
+
+type priceListRepository struct {
+	db *gorm.DB
+}
+
+func NewPriceListRepository(db *gorm.DB) pricelist.PriceListRepository {
+	return &priceListRepository{db: db}
+}
+
+func (r *priceListRepository) Create(list *pricelist.PriceList) error {
+	return r.db.Create(list).Error
+}
+
+func (r *priceListRepository) FindByIDAndCompany(id, companyID uint) (*pricelist.PriceList, error) {
+	var list pricelist.PriceList
+	err := r.db.Preload("Items").Where("id = ? AND company_id = ?", id, companyID).First(&list).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("price list not found")
+		}
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (r *priceListRepository) FindByCompanyID(companyID uint, customerGroupID *uint) ([]*pricelist.PriceList, error) {
+	var lists []*pricelist.PriceList
+	query := r.db.Where("company_id = ?", companyID)
+	if customerGroupID != nil {
+		query = query.Where("customer_group_id = ?", *customerGroupID)
+	}
+	err := query.Preload("Items").Order("created_at DESC").Find(&lists).Error
+	return lists, err
+}
+
+// FindActive returns the active price lists of a customer group whose date range includes at;
+// the franchise scope is checked by the caller
+func (r *priceListRepository) FindActive(companyID, customerGroupID uint, at time.Time) ([]*pricelist.PriceList, error) {
+	var lists []*pricelist.PriceList
+	err := r.db.Preload("Items").
+		Where("company_id = ? AND customer_group_id = ? AND is_active = ?", companyID, customerGroupID, true).
+		Where("starts_at IS NULL OR starts_at <= ?", at).
+		Where("ends_at IS NULL OR ends_at >= ?", at).
+		Find(&lists).Error
+	return lists, err
+}
+
+func (r *priceListRepository) CountByCustomerGroup(customerGroupID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&pricelist.PriceList{}).Where("customer_group_id = ?", customerGroupID).Count(&count).Error
+	return count, err
+}
+
+func (r *priceListRepository) Update(list *pricelist.PriceList) error {
+	return r.db.Omit("Items").Save(list).Error
+}
+
+// ReplaceItems swaps the items of a price list for the given ones in one transaction
+func (r *priceListRepository) ReplaceItems(priceListID uint, items []pricelist.PriceListItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("price_list_id = ?", priceListID).Delete(&pricelist.PriceListItem{}).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].ID = 0
+			items[i].PriceListID = priceListID
+			if err := tx.Create(&items[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *priceListRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("price_list_id = ?", id).Delete(&pricelist.PriceListItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&pricelist.PriceList{}, id).Error
+	})
+}
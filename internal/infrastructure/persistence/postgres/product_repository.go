@@ -22,7 +22,8 @@ func (r *productRepository) CreateProduct(p *product.Product) error {
 
 func (r *productRepository) FindProductByID(id uint) (*product.Product, error) {
 	var p product.Product
-	err := r.db.Preload("Variants").Where("id = ?", id).First(&p).Error
+	err := r.db.Preload("Variants").Preload("Variants.Barcodes").Preload("WholesaleTiers", orderWholesaleTiers).
+		Where("id = ?", id).First(&p).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("product not found")
@@ -34,7 +35,8 @@ func (r *productRepository) FindProductByID(id uint) (*product.Product, error) {
 
 func (r *productRepository) FindProductByIDAndCompany(id, companyID uint) (*product.Product, error) {
 	var p product.Product
-	err := r.db.Preload("Variants").Where("id = ? AND company_id = ?", id, companyID).First(&p).Error
+	err := r.db.Preload("Variants").Preload("Variants.Barcodes").Preload("WholesaleTiers", orderWholesaleTiers).
+		Where("id = ? AND company_id = ?", id, companyID).First(&p).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("product not found")
@@ -71,6 +73,7 @@ func (r *productRepository) FindProductsByCompanyID(companyID uint, page, limit
 
 	// Fetch products with variants
 	err = r.db.Preload("Variants", "is_active = ?", true).
+		Preload("Variants.Barcodes").
 		Where("company_id = ? AND is_active = ?", companyID, true).
 		Offset(offset).
 		Limit(limit).
@@ -107,7 +110,7 @@ func (r *productRepository) FindProductVariantByID(id uint) (*product.ProductVar
 
 func (r *productRepository) FindProductVariantByIDAndProduct(id, productID uint) (*product.ProductVariant, error) {
 	var v product.ProductVariant
-	err := r.db.Where("id = ? AND product_id = ?", id, productID).First(&v).Error
+	err := r.db.Preload("Barcodes").Where("id = ? AND product_id = ?", id, productID).First(&v).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("product variant not found")
@@ -131,7 +134,7 @@ func (r *productRepository) FindProductVariantBySKUAndProduct(sku string, produc
 
 func (r *productRepository) FindProductVariantsByProductID(productID uint) ([]*product.ProductVariant, error) {
 	var variants []*product.ProductVariant
-	err := r.db.Where("product_id = ? AND is_active = ?", productID, true).Find(&variants).Error
+	err := r.db.Preload("Barcodes").Where("product_id = ? AND is_active = ?", productID, true).Find(&variants).Error
 	return variants, err
 }
 
@@ -154,14 +157,15 @@ func (r *productRepository) SearchVariantsByCompany(companyID uint, query string
 	// Build search pattern for ILIKE (case-insensitive partial match)
 	searchPattern := "%" + query + "%"
 	
-	// Search across product names, product SKUs, variant names, and variant SKUs
+	// Search across product names, product SKUs, variant names, and variant SKUs; barcodes must match exactly
 	err := r.db.
 		Joins("JOIN products ON products.id = product_variants.product_id").
 		Where("products.company_id = ?", companyID).
 		Where("products.is_active = ? AND product_variants.is_active = ?", true, true).
 		Where(
-			"products.name ILIKE ? OR products.sku ILIKE ? OR product_variants.name ILIKE ? OR product_variants.sku ILIKE ?",
-			searchPattern, searchPattern, searchPattern, searchPattern,
+			"products.name ILIKE ? OR products.sku ILIKE ? OR product_variants.name ILIKE ? OR product_variants.sku ILIKE ? OR "+
+				"EXISTS (SELECT 1 FROM product_variant_barcodes WHERE product_variant_barcodes.product_variant_id = product_variants.id AND product_variant_barcodes.code = ?)",
+			searchPattern, searchPattern, searchPattern, searchPattern, query,
 		).
 		Preload("Product").
 		Limit(limit).
@@ -171,6 +175,96 @@ func (r *productRepository) SearchVariantsByCompany(companyID uint, query string
 	return variants, err
 }
 
+func (r *productRepository) FindVariantsBySKUAndCompany(sku string, companyID uint) ([]*product.ProductVariant, error) {
+	var variants []*product.ProductVariant
+	err := r.db.
+		Joins("JOIN products ON products.id = product_variants.product_id").
+		Where("products.company_id = ? AND product_variants.sku = ?", companyID, sku).
+		Where("products.is_active = ? AND product_variants.is_active = ?", true, true).
+		Preload("Product").
+		Preload("Barcodes").
+		Find(&variants).Error
+	return variants, err
+}
+
+// Barcode operations
+func (r *productRepository) FindBarcodeByCode(companyID uint, code string) (*product.ProductVariantBarcode, error) {
+	var b product.ProductVariantBarcode
+	err := r.db.Where("company_id = ? AND code = ?", companyID, code).First(&b).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("barcode not found")
+		}
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *productRepository) FindVariantByBarcode(companyID uint, code string) (*product.ProductVariant, error) {
+	var v product.ProductVariant
+	err := r.db.
+		Joins("JOIN product_variant_barcodes ON product_variant_barcodes.product_variant_id = product_variants.id").
+		Joins("JOIN products ON products.id = product_variants.product_id").
+		Where("product_variant_barcodes.company_id = ? AND product_variant_barcodes.code = ?", companyID, code).
+		Where("products.is_active = ? AND product_variants.is_active = ?", true, true).
+		Preload("Product").
+		Preload("Barcodes").
+		First(&v).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("product variant not found")
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *productRepository) CreateVariantBarcode(b *product.ProductVariantBarcode) error {
+	return r.db.Create(b).Error
+}
+
+// ReplaceVariantBarcodes swaps the variant's barcodes for the given codes in one transaction
+func (r *productRepository) ReplaceVariantBarcodes(companyID, variantID uint, codes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_variant_id = ?", variantID).Delete(&product.ProductVariantBarcode{}).Error; err != nil {
+			return err
+		}
+		for _, code := range codes {
+			barcode := &product.ProductVariantBarcode{
+				CompanyID:        companyID,
+				ProductVariantID: variantID,
+				Code:             code,
+			}
+			if err := tx.Create(barcode).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// orderWholesaleTiers lists tiers from the lowest quantity break up
+func orderWholesaleTiers(db *gorm.DB) *gorm.DB {
+	return db.Order("min_quantity")
+}
+
+// ReplaceWholesaleTiers swaps the product's wholesale price tiers for the given ones in one transaction
+func (r *productRepository) ReplaceWholesaleTiers(productID uint, tiers []product.WholesalePriceTier) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", productID).Delete(&product.WholesalePriceTier{}).Error; err != nil {
+			return err
+		}
+		for i := range tiers {
+			tiers[i].ID = 0
+			tiers[i].ProductID = productID
+			if err := tx.Create(&tiers[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // Stock operations
 func (r *productRepository) UpdateVariantStock(variantID uint, newStock int) error {
 	return r.db.Model(&product.ProductVariant{}).Where("id = ?", variantID).Update("stock", newStock).Error
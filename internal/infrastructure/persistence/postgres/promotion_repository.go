@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/YasserCherfaoui/darween/internal/domain/promotion"
+	"gorm.io/gorm"
+)
+
+type promotionRepository struct {
+	db *gorm.DB
+}
+
+func NewPromotionRepository(db *gorm.DB) promotion.Repository {
+	return &promotionRepository{db: db}
+}
+
+func (r *promotionRepository) Create(p *promotion.Promotion) error {
+	return r.db.Create(p).Error
+}
+
+func (r *promotionRepository) FindByID(id uint) (*promotion.Promotion, error) {
+	var p promotion.Promotion
+	err := r.db.Where("id = ?", id).First(&p).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("promotion not found")
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *promotionRepository) FindByIDAndCompany(id, companyID uint) (*promotion.Promotion, error) {
+	var p promotion.Promotion
+	err := r.db.Where("id = ? AND company_id = ?", id, companyID).First(&p).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("promotion not found")
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *promotionRepository) FindByCompanyID(companyID uint, franchiseID *uint) ([]*promotion.Promotion, error) {
+	var promotions []*promotion.Promotion
+	query := r.db.Where("company_id = ?", companyID)
+	if franchiseID != nil {
+		query = query.Where("franchise_id IS NULL OR franchise_id = ?", *franchiseID)
+	}
+	err := query.Order("created_at DESC").Find(&promotions).Error
+	return promotions, err
+}
+
+// FindAutomatic returns the active promotions without a coupon code whose date range includes at;
+// daily time windows, franchise scope and usage limits are checked by the caller
+func (r *promotionRepository) FindAutomatic(companyID uint, at time.Time) ([]*promotion.Promotion, error) {
+	var promotions []*promotion.Promotion
+	err := r.db.Where("company_id = ? AND is_active = ? AND coupon_code = ''", companyID, true).
+		Where("starts_at IS NULL OR starts_at <= ?", at).
+		Where("ends_at IS NULL OR ends_at >= ?", at).
+		Find(&promotions).Error
+	return promotions, err
+}
+
+func (r *promotionRepository) FindByCouponCode(companyID uint, code string) (*promotion.Promotion, error) {
+	var p promotion.Promotion
+	err := r.db.Where("company_id = ? AND coupon_code = ?", companyID, code).First(&p).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("promotion not found")
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *promotionRepository) Update(p *promotion.Promotion) error {
+	return r.db.Save(p).Error
+}
+
+func (r *promotionRepository) Delete(id uint) error {
+	return r.db.Delete(&promotion.Promotion{}, id).Error
+}
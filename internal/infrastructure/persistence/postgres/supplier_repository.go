@@ -172,6 +172,22 @@ func (r *supplierRepository) FindSupplierBillsByCompany(companyID uint, page, li
 	return bills, total, err
 }
 
+// StreamSupplierBills passes the company's bills, optionally of one supplier, to fn in batches, oldest first
+func (r *supplierRepository) StreamSupplierBills(companyID uint, supplierID *uint, fn func(bills []*supplier.SupplierBill) error) error {
+	query := r.db.Where("company_id = ?", companyID)
+	if supplierID != nil {
+		query = query.Where("supplier_id = ?", *supplierID)
+	}
+
+	var bills []*supplier.SupplierBill
+	return query.
+		Preload("Items").
+		Preload("Supplier").
+		FindInBatches(&bills, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			return fn(bills)
+		}).Error
+}
+
 func (r *supplierRepository) FindUnpaidBillsBySupplier(supplierID, companyID uint) ([]*supplier.SupplierBill, error) {
 	var bills []*supplier.SupplierBill
 	err := r.db.Where("supplier_id = ? AND company_id = ? AND payment_status IN ?",
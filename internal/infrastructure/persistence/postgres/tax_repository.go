@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/YasserCherfaoui/darween/internal/domain/product"
+	"github.com/YasserCherfaoui/darween/internal/domain/tax"
+	"gorm.io/gorm"
+)
+
+type taxRepository struct {
+	db *gorm.DB
+}
+
+func NewTaxRepository(db *gorm.DB) tax.Repository {
+	return &taxRepository{db: db}
+}
+
+func (r *taxRepository) Create(taxClass *tax.TaxClass) error {
+	return r.db.Create(taxClass).Error
+}
+
+func (r *taxRepository) FindByID(id uint) (*tax.TaxClass, error) {
+	var taxClass tax.TaxClass
+	err := r.db.Where("id = ?", id).First(&taxClass).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("tax class not found")
+		}
+		return nil, err
+	}
+	return &taxClass, nil
+}
+
+func (r *taxRepository) FindByIDAndCompany(id, companyID uint) (*tax.TaxClass, error) {
+	var taxClass tax.TaxClass
+	err := r.db.Where("id = ? AND company_id = ?", id, companyID).First(&taxClass).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("tax class not found")
+		}
+		return nil, err
+	}
+	return &taxClass, nil
+}
+
+func (r *taxRepository) FindByCompanyID(companyID uint) ([]*tax.TaxClass, error) {
+	var taxClasses []*tax.TaxClass
+	err := r.db.Where("company_id = ?", companyID).Order("rate DESC, name ASC").Find(&taxClasses).Error
+	return taxClasses, err
+}
+
+func (r *taxRepository) FindDefaultByCompanyID(companyID uint) (*tax.TaxClass, error) {
+	var taxClass tax.TaxClass
+	err := r.db.Where("company_id = ? AND is_default = ? AND is_active = ?", companyID, true, true).First(&taxClass).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("default tax class not found")
+		}
+		return nil, err
+	}
+	return &taxClass, nil
+}
+
+func (r *taxRepository) Update(taxClass *tax.TaxClass) error {
+	return r.db.Save(taxClass).Error
+}
+
+func (r *taxRepository) Delete(id uint) error {
+	return r.db.Delete(&tax.TaxClass{}, id).Error
+}
+
+func (r *taxRepository) SetAsDefault(id, companyID uint) error {
+	tx := r.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Unset all defaults for this company
+	if err := tx.Model(&tax.TaxClass{}).
+		Where("company_id = ?", companyID).
+		Update("is_default", false).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Set the specified tax class as default
+	if err := tx.Model(&tax.TaxClass{}).
+		Where("id = ? AND company_id = ?", id, companyID).
+		Update("is_default", true).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+func (r *taxRepository) CountProducts(id uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&product.Product{}).Where("tax_class_id = ?", id).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/YasserCherfaoui/darween/internal/domain/voucher"
+	"gorm.io/gorm"
+)
+
+type voucherRepository struct {
+	db *gorm.DB
+}
+
+func NewVoucherRepository(db *gorm.DB) voucher.Repository {
+	return &voucherRepository{db: db}
+}
+
+func (r *voucherRepository) Create(v *voucher.Voucher) error {
+	return r.db.Create(v).Error
+}
+
+func (r *voucherRepository) Update(v *voucher.Voucher) error {
+	return r.db.Save(v).Error
+}
+
+func (r *voucherRepository) FindByID(id uint) (*voucher.Voucher, error) {
+	var v voucher.Voucher
+	err := r.db.Where("id = ?", id).First(&v).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("voucher not found")
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *voucherRepository) FindByIDAndCompany(id, companyID uint) (*voucher.Voucher, error) {
+	var v voucher.Voucher
+	err := r.db.Where("id = ? AND company_id = ?", id, companyID).First(&v).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("voucher not found")
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *voucherRepository) FindByCode(companyID uint, code string) (*voucher.Voucher, error) {
+	var v voucher.Voucher
+	err := r.db.Where("company_id = ? AND code = ?", companyID, voucher.NormalizeCode(code)).First(&v).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("voucher not found")
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *voucherRepository) FindByCompanyID(companyID uint, customerID *uint, page, limit int) ([]*voucher.Voucher, int64, error) {
+	var vouchers []*voucher.Voucher
+	var total int64
+
+	query := r.db.Model(&voucher.Voucher{}).Where("company_id = ?", companyID)
+	if customerID != nil {
+		query = query.Where("customer_id = ?", *customerID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&vouchers).Error
+	return vouchers, total, err
+}
+
+func (r *voucherRepository) CreateTransaction(t *voucher.Transaction) error {
+	return r.db.Create(t).Error
+}
+
+func (r *voucherRepository) FindTransactions(voucherID uint) ([]voucher.Transaction, error) {
+	var transactions []voucher.Transaction
+	err := r.db.Where("voucher_id = ?", voucherID).Order("created_at ASC, id ASC").Find(&transactions).Error
+	return transactions, err
+}
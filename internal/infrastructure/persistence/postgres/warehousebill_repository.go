@@ -2,7 +2,6 @@ package postgres
 
 import (
 	"fmt"
-	"time"
 
 	"github.com/YasserCherfaoui/darween/internal/domain/warehousebill"
 	"gorm.io/gorm"
@@ -16,25 +15,8 @@ func NewWarehouseBillRepository(db *gorm.DB) warehousebill.Repository {
 	return &warehouseBillRepository{db: db}
 }
 
-// generateBillNumber generates a unique bill number
-func (r *warehouseBillRepository) generateBillNumber(billType warehousebill.BillType, companyID, billID uint) string {
-	timestamp := time.Now().Format("20060102")
-	typePrefix := "EXIT"
-	if billType == warehousebill.BillTypeEntry {
-		typePrefix = "ENTRY"
-	}
-	return fmt.Sprintf("WB-%s-%d-%s-%d", typePrefix, companyID, timestamp, billID)
-}
-
 func (r *warehouseBillRepository) Create(bill *warehousebill.WarehouseBill) error {
-	// First create the bill to get the ID
-	if err := r.db.Create(bill).Error; err != nil {
-		return err
-	}
-
-	// Generate and update bill number
-	bill.BillNumber = r.generateBillNumber(bill.BillType, bill.CompanyID, bill.ID)
-	return r.db.Save(bill).Error
+	return r.db.Create(bill).Error
 }
 
 func (r *warehouseBillRepository) FindByID(id uint) (*warehousebill.WarehouseBill, error) {
@@ -74,34 +56,8 @@ func (r *warehouseBillRepository) FindByCompanyIDWithFilters(companyID uint, pag
 	var total int64
 
 	// Build query
-	query := r.db.Model(&warehousebill.WarehouseBill{}).Where("company_id = ?", companyID)
-	countQuery := r.db.Model(&warehousebill.WarehouseBill{}).Where("company_id = ?", companyID)
-
-	// Apply filters
-	if filters != nil {
-		if filters.FranchiseID != nil {
-			query = query.Where("franchise_id = ?", *filters.FranchiseID)
-			countQuery = countQuery.Where("franchise_id = ?", *filters.FranchiseID)
-		}
-		if filters.Status != nil {
-			query = query.Where("status = ?", *filters.Status)
-			countQuery = countQuery.Where("status = ?", *filters.Status)
-		}
-		if filters.BillType != nil {
-			query = query.Where("bill_type = ?", *filters.BillType)
-			countQuery = countQuery.Where("bill_type = ?", *filters.BillType)
-		}
-		if filters.DateFrom != nil {
-			query = query.Where("created_at >= ?", *filters.DateFrom)
-			countQuery = countQuery.Where("created_at >= ?", *filters.DateFrom)
-		}
-		if filters.DateTo != nil {
-			// Add one day to include the entire day
-			dateTo := filters.DateTo.AddDate(0, 0, 1)
-			query = query.Where("created_at < ?", dateTo)
-			countQuery = countQuery.Where("created_at < ?", dateTo)
-		}
-	}
+	query := applyBillFilters(r.db.Model(&warehousebill.WarehouseBill{}).Where("company_id = ?", companyID), filters)
+	countQuery := applyBillFilters(r.db.Model(&warehousebill.WarehouseBill{}).Where("company_id = ?", companyID), filters)
 
 	// Count total
 	err := countQuery.Count(&total).Error
@@ -123,6 +79,41 @@ func (r *warehouseBillRepository) FindByCompanyIDWithFilters(companyID uint, pag
 	return bills, total, err
 }
 
+func (r *warehouseBillRepository) StreamByCompanyIDWithFilters(companyID uint, filters *warehousebill.BillFilters, fn func(bills []*warehousebill.WarehouseBill) error) error {
+	query := applyBillFilters(r.db.Model(&warehousebill.WarehouseBill{}).Where("company_id = ?", companyID), filters)
+
+	var bills []*warehousebill.WarehouseBill
+	return query.
+		Preload("Items").
+		FindInBatches(&bills, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			return fn(bills)
+		}).Error
+}
+
+// applyBillFilters restricts a warehouse bill query to the filters
+func applyBillFilters(query *gorm.DB, filters *warehousebill.BillFilters) *gorm.DB {
+	if filters == nil {
+		return query
+	}
+	if filters.FranchiseID != nil {
+		query = query.Where("franchise_id = ?", *filters.FranchiseID)
+	}
+	if filters.Status != nil {
+		query = query.Where("status = ?", *filters.Status)
+	}
+	if filters.BillType != nil {
+		query = query.Where("bill_type = ?", *filters.BillType)
+	}
+	if filters.DateFrom != nil {
+		query = query.Where("created_at >= ?", *filters.DateFrom)
+	}
+	if filters.DateTo != nil {
+		// Add one day to include the entire day
+		query = query.Where("created_at < ?", filters.DateTo.AddDate(0, 0, 1))
+	}
+	return query
+}
+
 func (r *warehouseBillRepository) FindByFranchiseID(franchiseID uint, page, limit int) ([]*warehousebill.WarehouseBill, int64, error) {
 	var bills []*warehousebill.WarehouseBill
 	var total int64
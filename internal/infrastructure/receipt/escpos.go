@@ -0,0 +1,290 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ESC/POS command bytes
+const (
+	escPosESC = 0x1B
+	escPosGS  = 0x1D
+	escPosLF  = 0x0A
+)
+
+// escPosCodePageWPC1252 selects the Western European code page so accented names print
+const escPosCodePageWPC1252 = 16
+
+// EscPosGenerator renders receipts as raw ESC/POS commands for thermal printers,
+// so they can be sent to the printer as is instead of going through a print dialog
+type EscPosGenerator struct {
+	width int // Characters per line
+}
+
+// NewEscPosGenerator creates a generator for 80mm printers, 48 characters per line in font A
+func NewEscPosGenerator() *EscPosGenerator {
+	return &EscPosGenerator{width: 48}
+}
+
+// GenerateReceipt renders the receipt, ending with a paper cut. With openDrawer, the
+// cash drawer connected to the printer is kicked open once the receipt is printed.
+func (g *EscPosGenerator) GenerateReceipt(data *ReceiptData, openDrawer bool) ([]byte, error) {
+	w := &escPosWriter{width: g.width}
+	w.init()
+
+	// Get business name
+	businessName := data.CompanyName
+	if data.FranchiseName != "" {
+		businessName = data.FranchiseName
+	}
+
+	// Center-aligned header
+	w.align(1)
+	w.bold(true)
+	w.doubleSize(true)
+	w.line(truncate(businessName, w.width/2)) // Double-width characters take two columns
+	w.doubleSize(false)
+	w.bold(false)
+	w.line("Receipt: " + data.ReceiptNumber)
+	w.line("Date: " + data.Date.Format("2006-01-02 15:04"))
+	if data.CustomerName != "" {
+		w.line("Customer: " + data.CustomerName)
+	}
+	w.align(0)
+	w.separator()
+
+	// Items
+	w.bold(true)
+	w.columns("Item", "Qty", "Price")
+	w.bold(false)
+	for _, item := range data.Items {
+		itemPrice := item.UnitPrice*float64(item.Quantity) - item.DiscountAmount
+		w.columns(item.Name, strconv.Itoa(item.Quantity), fmt.Sprintf("%.2f", itemPrice))
+		if item.SKU != "" {
+			w.line("  SKU: " + item.SKU)
+		}
+	}
+	w.separator()
+
+	// Summary section
+	w.amount("Subtotal:", data.SubTotal)
+	if data.DiscountAmount > 0 {
+		w.amount("Discount:", data.DiscountAmount)
+	}
+	if data.TaxAmount > 0 {
+		w.amount("Tax:", data.TaxAmount)
+	}
+
+	w.bold(true)
+	w.amount("TOTAL:", data.TotalAmount)
+	w.bold(false)
+
+	// One line per tender, with the change given back
+	if len(data.Payments) == 0 {
+		w.line("Payment: Cash")
+	}
+	totalChange := 0.0
+	for _, payment := range data.Payments {
+		tendered := payment.Tendered
+		if tendered <= 0 {
+			tendered = payment.Amount
+		}
+		w.amount(formatPaymentMethod(payment.Method)+":", tendered)
+		totalChange += payment.Change
+	}
+	if totalChange > 0 {
+		w.amount("Change:", totalChange)
+	}
+
+	// Tax summary per rate
+	if len(data.TaxLines) > 0 {
+		w.separator()
+		w.columns("Tax rate", "Net", "Tax")
+		for _, line := range data.TaxLines {
+			w.columns(formatTaxRate(line.Rate), fmt.Sprintf("%.2f", line.NetAmount), fmt.Sprintf("%.2f", line.TaxAmount))
+		}
+	}
+
+	// Loyalty points summary
+	if data.LoyaltyBalance != nil {
+		w.separator()
+		if data.LoyaltyRedeemed > 0 {
+			w.pair("Points redeemed:", strconv.Itoa(data.LoyaltyRedeemed))
+		}
+		if data.LoyaltyEarned > 0 {
+			w.pair("Points earned:", strconv.Itoa(data.LoyaltyEarned))
+		}
+		w.pair("Points balance:", strconv.Itoa(*data.LoyaltyBalance))
+	}
+
+	// Footer
+	w.feed(1)
+	w.align(1)
+	w.line("Thank you for your purchase!")
+	w.line("Receipt ID: " + data.ReceiptNumber)
+
+	if data.QRCode != "" {
+		w.feed(1)
+		if err := w.qrCode(data.QRCode); err != nil {
+			return nil, err
+		}
+	}
+	w.align(0)
+
+	w.feed(3)
+	w.cut()
+
+	if openDrawer {
+		w.kickDrawer()
+	}
+
+	return w.buf.Bytes(), nil
+}
+
+// escPosWriter accumulates ESC/POS commands and text laid out on a fixed-width line
+type escPosWriter struct {
+	buf   bytes.Buffer
+	width int
+}
+
+// init resets the printer and selects the code page
+func (w *escPosWriter) init() {
+	w.buf.Write([]byte{escPosESC, '@'})
+	w.buf.Write([]byte{escPosESC, 't', escPosCodePageWPC1252})
+}
+
+// align sets the justification: 0 left, 1 center, 2 right
+func (w *escPosWriter) align(n byte) {
+	w.buf.Write([]byte{escPosESC, 'a', n})
+}
+
+func (w *escPosWriter) bold(on bool) {
+	w.buf.Write([]byte{escPosESC, 'E', boolByte(on)})
+}
+
+// doubleSize doubles the width and height of the characters
+func (w *escPosWriter) doubleSize(on bool) {
+	size := byte(0x00)
+	if on {
+		size = 0x11
+	}
+	w.buf.Write([]byte{escPosGS, '!', size})
+}
+
+// line prints text, cut to the line width, and a line feed
+func (w *escPosWriter) line(text string) {
+	w.buf.Write(encodeEscPosText(truncate(text, w.width)))
+	w.buf.WriteByte(escPosLF)
+}
+
+func (w *escPosWriter) separator() {
+	w.line(strings.Repeat("-", w.width))
+}
+
+// columns prints a left column taking the remaining width and two right-aligned columns
+func (w *escPosWriter) columns(left, middle, right string) {
+	const middleWidth, rightWidth = 6, 12
+	leftWidth := w.width - middleWidth - rightWidth
+	w.line(padRight(truncate(left, leftWidth), leftWidth) +
+		padLeft(truncate(middle, middleWidth), middleWidth) +
+		padLeft(truncate(right, rightWidth), rightWidth))
+}
+
+// pair prints a label and a value, both aligned to the right like the PDF summary
+func (w *escPosWriter) pair(label, value string) {
+	const valueWidth = 12
+	w.line(padLeft(label, w.width-valueWidth) + padLeft(value, valueWidth))
+}
+
+func (w *escPosWriter) amount(label string, amount float64) {
+	w.pair(label, fmt.Sprintf("%.2f", amount))
+}
+
+// feed prints n empty lines
+func (w *escPosWriter) feed(n byte) {
+	w.buf.Write([]byte{escPosESC, 'd', n})
+}
+
+// cut feeds the paper up to the cutter and makes a partial cut
+func (w *escPosWriter) cut() {
+	w.buf.Write([]byte{escPosGS, 'V', 66, 0})
+}
+
+// kickDrawer sends a pulse on pin 2 of the drawer connector, 50ms on and 500ms off
+func (w *escPosWriter) kickDrawer() {
+	w.buf.Write([]byte{escPosESC, 'p', 0, 25, 250})
+}
+
+// qrCode stores the data in the printer's QR symbol buffer and prints it (GS ( k, model 2)
+func (w *escPosWriter) qrCode(data string) error {
+	payload := []byte(data)
+	if len(payload) > 7089 {
+		return fmt.Errorf("QR code data is too long: %d bytes", len(payload))
+	}
+
+	// Model 2, module size 6, error correction level M
+	w.buf.Write([]byte{escPosGS, '(', 'k', 4, 0, 49, 65, 50, 0})
+	w.buf.Write([]byte{escPosGS, '(', 'k', 3, 0, 49, 67, 6})
+	w.buf.Write([]byte{escPosGS, '(', 'k', 3, 0, 49, 69, 49})
+
+	// Store the data, then print it
+	length := len(payload) + 3
+	w.buf.Write([]byte{escPosGS, '(', 'k', byte(length % 256), byte(length / 256), 49, 80, 48})
+	w.buf.Write(payload)
+	w.buf.Write([]byte{escPosGS, '(', 'k', 3, 0, 49, 81, 48})
+	return nil
+}
+
+// encodeEscPosText converts text to the printer's single-byte code page; characters
+// it cannot print are replaced with "?"
+func encodeEscPosText(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r == '€':
+			encoded = append(encoded, 0x80)
+		case r < 0x20:
+			encoded = append(encoded, ' ')
+		case r < 0x80 || (r >= 0xA0 && r <= 0xFF):
+			encoded = append(encoded, byte(r))
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
+
+// truncate cuts text to at most width characters
+func truncate(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	if width <= 3 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-3]) + "..."
+}
+
+func padLeft(text string, width int) string {
+	if n := width - len([]rune(text)); n > 0 {
+		return strings.Repeat(" ", n) + text
+	}
+	return text
+}
+
+func padRight(text string, width int) string {
+	if n := width - len([]rune(text)); n > 0 {
+		return text + strings.Repeat(" ", n)
+	}
+	return text
+}
+
+func boolByte(on bool) byte {
+	if on {
+		return 1
+	}
+	return 0
+}
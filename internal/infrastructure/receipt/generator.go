@@ -3,11 +3,13 @@ package receipt
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/jung-kurt/gofpdf"
+	"github.com/skip2/go-qrcode"
 	"github.com/YasserCherfaoui/darween/internal/domain/pos"
 )
 
@@ -21,18 +23,23 @@ func NewReceiptGenerator() *ReceiptGenerator {
 
 // ReceiptData holds the data needed to generate a receipt
 type ReceiptData struct {
-	CompanyName   string
-	FranchiseName string
-	ReceiptNumber string
-	Date          time.Time
-	CustomerName  string
-	CustomerEmail string
-	Items         []ReceiptItem
-	SubTotal      float64
-	TaxAmount     float64
-	DiscountAmount float64
-	TotalAmount   float64
-	Payments      []ReceiptPayment
+	CompanyName     string
+	FranchiseName   string
+	ReceiptNumber   string
+	Date            time.Time
+	CustomerName    string
+	CustomerEmail   string
+	Items           []ReceiptItem
+	SubTotal        float64
+	TaxAmount       float64
+	DiscountAmount  float64
+	TotalAmount     float64
+	Payments        []ReceiptPayment
+	TaxLines        []ReceiptTaxLine
+	LoyaltyEarned   int    // Points the customer earned on this sale
+	LoyaltyRedeemed int    // Points the customer paid with on this sale
+	LoyaltyBalance  *int   // Customer's points balance, nil when the company runs no loyalty program
+	QRCode          string // Printed as a QR code at the bottom of the receipt when set
 }
 
 // ReceiptItem represents an item on the receipt
@@ -45,30 +52,24 @@ type ReceiptItem struct {
 	TotalAmount    float64
 }
 
+// ReceiptTaxLine summarizes the items charged at one tax rate
+type ReceiptTaxLine struct {
+	Rate      float64
+	NetAmount float64
+	TaxAmount float64
+}
+
 // ReceiptPayment represents a payment on the receipt
 type ReceiptPayment struct {
-	Method string
-	Amount float64
+	Method   string
+	Amount   float64
+	Tendered float64
+	Change   float64
 }
 
 // GenerateReceipt creates a PDF receipt formatted for thermal printers (80mm width)
 func (rg *ReceiptGenerator) GenerateReceipt(data *ReceiptData) ([]byte, error) {
-	// Define the initialization options using the InitType struct
-	// 80mm width (standard thermal receipt width), 297mm height (A4 length)
-	initOptions := gofpdf.InitType{
-		OrientationStr: "P",  // "P"ortrait
-		UnitStr:        "mm", // millimeters
-		FontDirStr:     "",   // Leave empty for default font directory
-		Size: gofpdf.SizeType{
-			Wd: 80,  // 80mm width
-			Ht: 297, // 297mm height
-		},
-	}
-
-	// Create PDF with custom size
-	pdf := gofpdf.NewCustom(&initOptions)
-	pdf.SetMargins(5, 5, 5)
-	pdf.AddPage()
+	pdf := newThermalPDF()
 
 	// Set font for header
 	pdf.SetFont("Helvetica", "B", 10)
@@ -146,14 +147,56 @@ func (rg *ReceiptGenerator) GenerateReceipt(data *ReceiptData) ([]byte, error) {
 
 	// Payment method
 	pdf.SetFont("Courier", "", 8)
-	paymentMethod := "Cash"
-	if len(data.Payments) > 0 {
-		method := strings.ToLower(data.Payments[0].Method)
-		if len(method) > 0 {
-			paymentMethod = strings.ToUpper(method[:1]) + method[1:]
+	if len(data.Payments) == 0 {
+		pdf.CellFormat(70, 4, "Payment: Cash", "", 1, "L", false, 0, "")
+	}
+
+	// One line per tender, with the change given back
+	totalChange := 0.0
+	for _, payment := range data.Payments {
+		tendered := payment.Tendered
+		if tendered <= 0 {
+			tendered = payment.Amount
 		}
+		pdf.CellFormat(45, 4, formatPaymentMethod(payment.Method)+":", "", 0, "R", false, 0, "")
+		pdf.CellFormat(25, 4, fmt.Sprintf("%.2f", tendered), "", 1, "R", false, 0, "")
+		totalChange += payment.Change
+	}
+
+	if totalChange > 0 {
+		pdf.CellFormat(45, 4, "Change:", "", 0, "R", false, 0, "")
+		pdf.CellFormat(25, 4, fmt.Sprintf("%.2f", totalChange), "", 1, "R", false, 0, "")
+	}
+
+	// Tax summary per rate
+	if len(data.TaxLines) > 0 {
+		pdf.CellFormat(70, 2, strings.Repeat("-", 35), "", 1, "C", false, 0, "")
+		pdf.SetFont("Courier", "", 7)
+		pdf.CellFormat(20, 4, "Tax rate", "B", 0, "L", false, 0, "")
+		pdf.CellFormat(25, 4, "Net", "B", 0, "R", false, 0, "")
+		pdf.CellFormat(25, 4, "Tax", "B", 1, "R", false, 0, "")
+		for _, line := range data.TaxLines {
+			pdf.CellFormat(20, 4, formatTaxRate(line.Rate), "", 0, "L", false, 0, "")
+			pdf.CellFormat(25, 4, fmt.Sprintf("%.2f", line.NetAmount), "", 0, "R", false, 0, "")
+			pdf.CellFormat(25, 4, fmt.Sprintf("%.2f", line.TaxAmount), "", 1, "R", false, 0, "")
+		}
+	}
+
+	// Loyalty points summary
+	if data.LoyaltyBalance != nil {
+		pdf.CellFormat(70, 2, strings.Repeat("-", 35), "", 1, "C", false, 0, "")
+		pdf.SetFont("Courier", "", 7)
+		if data.LoyaltyRedeemed > 0 {
+			pdf.CellFormat(45, 4, "Points redeemed:", "", 0, "R", false, 0, "")
+			pdf.CellFormat(25, 4, strconv.Itoa(data.LoyaltyRedeemed), "", 1, "R", false, 0, "")
+		}
+		if data.LoyaltyEarned > 0 {
+			pdf.CellFormat(45, 4, "Points earned:", "", 0, "R", false, 0, "")
+			pdf.CellFormat(25, 4, strconv.Itoa(data.LoyaltyEarned), "", 1, "R", false, 0, "")
+		}
+		pdf.CellFormat(45, 4, "Points balance:", "", 0, "R", false, 0, "")
+		pdf.CellFormat(25, 4, strconv.Itoa(*data.LoyaltyBalance), "", 1, "R", false, 0, "")
 	}
-	pdf.CellFormat(70, 4, "Payment: "+paymentMethod, "", 1, "L", false, 0, "")
 
 	// Footer
 	pdf.SetFont("Courier", "", 7)
@@ -163,6 +206,18 @@ func (rg *ReceiptGenerator) GenerateReceipt(data *ReceiptData) ([]byte, error) {
 	// Optional: Add receipt ID
 	pdf.CellFormat(70, 3, "Receipt ID: "+data.ReceiptNumber, "", 1, "C", false, 0, "")
 
+	// QR code, centered below the footer
+	if data.QRCode != "" {
+		png, err := qrcode.Encode(data.QRCode, qrcode.Medium, 256)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create QR code: %w", err)
+		}
+		options := gofpdf.ImageOptions{ImageType: "PNG"}
+		pdf.RegisterImageOptionsReader("receipt_qr", options, bytes.NewReader(png))
+		pdf.Ln(2)
+		pdf.ImageOptions("receipt_qr", 25, pdf.GetY(), 30, 30, true, options, 0, "")
+	}
+
 	// Output PDF to buffer
 	var buf bytes.Buffer
 	err := pdf.Output(&buf)
@@ -173,6 +228,44 @@ func (rg *ReceiptGenerator) GenerateReceipt(data *ReceiptData) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// newThermalPDF creates a one-page PDF sized for thermal printers, with 70mm of printable width
+func newThermalPDF() *gofpdf.Fpdf {
+	// Define the initialization options using the InitType struct
+	// 80mm width (standard thermal receipt width), 297mm height (A4 length)
+	initOptions := gofpdf.InitType{
+		OrientationStr: "P",  // "P"ortrait
+		UnitStr:        "mm", // millimeters
+		FontDirStr:     "",   // Leave empty for default font directory
+		Size: gofpdf.SizeType{
+			Wd: 80,  // 80mm width
+			Ht: 297, // 297mm height
+		},
+	}
+
+	// Create PDF with custom size
+	pdf := gofpdf.NewCustom(&initOptions)
+	pdf.SetMargins(5, 5, 5)
+	pdf.AddPage()
+	return pdf
+}
+
+// formatPaymentMethod capitalizes a payment method for display, e.g. "Loyalty points"
+func formatPaymentMethod(method string) string {
+	method = strings.ReplaceAll(strings.ToLower(method), "_", " ")
+	if len(method) == 0 {
+		return "Cash"
+	}
+	return strings.ToUpper(method[:1]) + method[1:]
+}
+
+// formatTaxRate renders a tax rate for display, e.g. "19%" or "Exempt"
+func formatTaxRate(rate float64) string {
+	if rate == 0 {
+		return "Exempt"
+	}
+	return strconv.FormatFloat(rate, 'f', -1, 64) + "%"
+}
+
 // ConvertSaleToReceiptData converts a pos.Sale to ReceiptData
 // productVariantMap should map ProductVariantID to a struct with Name and SKU
 type ProductVariantInfo struct {
@@ -186,7 +279,7 @@ func ConvertSaleToReceiptData(sale *pos.Sale, companyName, franchiseName string,
 		FranchiseName:  franchiseName,
 		ReceiptNumber:  sale.ReceiptNumber,
 		Date:           sale.CreatedAt,
-		SubTotal:       sale.SubTotal,
+		SubTotal:       sale.SubTotalBeforeDiscount(),
 		TaxAmount:      sale.TaxAmount,
 		DiscountAmount: sale.DiscountAmount,
 		TotalAmount:    sale.TotalAmount,
@@ -218,13 +311,35 @@ func ConvertSaleToReceiptData(sale *pos.Sale, companyName, franchiseName string,
 			TotalAmount:    item.TotalAmount,
 		}
 	}
+
+	// Group item taxes by rate, highest rate first
+	taxLinesByRate := make(map[float64]*ReceiptTaxLine)
+	for _, item := range sale.Items {
+		line, exists := taxLinesByRate[item.TaxRate]
+		if !exists {
+			line = &ReceiptTaxLine{Rate: item.TaxRate}
+			taxLinesByRate[item.TaxRate] = line
+		}
+		line.NetAmount += item.NetAmount
+		line.TaxAmount += item.TaxAmount
+	}
+	if sale.TaxAmount > 0 {
+		for _, line := range taxLinesByRate {
+			data.TaxLines = append(data.TaxLines, *line)
+		}
+		sort.Slice(data.TaxLines, func(i, j int) bool {
+			return data.TaxLines[i].Rate > data.TaxLines[j].Rate
+		})
+	}
 	
 	// Convert payments
 	data.Payments = make([]ReceiptPayment, len(sale.Payments))
 	for i, payment := range sale.Payments {
 		data.Payments[i] = ReceiptPayment{
-			Method: string(payment.PaymentMethod),
-			Amount: payment.Amount,
+			Method:   string(payment.PaymentMethod),
+			Amount:   payment.Amount,
+			Tendered: payment.TenderedAmount,
+			Change:   payment.ChangeAmount,
 		}
 	}
 	
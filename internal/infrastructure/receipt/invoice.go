@@ -0,0 +1,327 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YasserCherfaoui/darween/internal/domain/pos"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// InvoiceData holds the data needed to generate an A4 invoice or proforma
+type InvoiceData struct {
+	Title          string // e.g. "INVOICE" or "PROFORMA INVOICE"
+	Number         string
+	Date           time.Time
+	ValidUntil     *time.Time // Printed on proformas, until when the prices are honoured
+	Reference      string     // Receipt number of the invoiced sale
+	Seller         pos.LegalParty
+	Buyer          pos.LegalParty
+	Items          []InvoiceItem
+	TaxLines       []ReceiptTaxLine
+	NetTotal       float64 // Total excluding tax
+	TaxAmount      float64
+	DiscountAmount float64 // Discount on the whole sale not already taken off the lines, after tax
+	TotalAmount    float64 // Total including tax
+	PaymentMethods []string
+}
+
+// InvoiceItem is an invoice line, with its amounts excluding tax
+type InvoiceItem struct {
+	Name           string
+	SKU            string
+	Quantity       int
+	UnitPrice      float64
+	DiscountAmount float64
+	TaxRate        float64
+	NetAmount      float64
+}
+
+// GenerateInvoice creates an A4 PDF invoice or proforma with the legal details of both parties
+func (rg *ReceiptGenerator) GenerateInvoice(data *InvoiceData) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	// Title and document details
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(180, 8, data.Title, "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(180, 5, "No. "+data.Number, "", 1, "C", false, 0, "")
+	pdf.CellFormat(180, 5, "Date: "+data.Date.Format("2006-01-02"), "", 1, "C", false, 0, "")
+	if data.ValidUntil != nil {
+		pdf.CellFormat(180, 5, "Valid until: "+data.ValidUntil.Format("2006-01-02"), "", 1, "C", false, 0, "")
+	}
+	if data.Reference != "" {
+		pdf.CellFormat(180, 5, "Receipt: "+data.Reference, "", 1, "C", false, 0, "")
+	}
+	pdf.Ln(6)
+
+	// Seller on the left, buyer on the right
+	top := pdf.GetY()
+	writeLegalParty(pdf, tr, "Seller", data.Seller, 15, top)
+	sellerBottom := pdf.GetY()
+	writeLegalParty(pdf, tr, "Buyer", data.Buyer, 110, top)
+	pdf.SetY(math.Max(sellerBottom, pdf.GetY()))
+	pdf.Ln(6)
+
+	// Items
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(230, 230, 230)
+	pdf.CellFormat(75, 6, "Description", "1", 0, "L", true, 0, "")
+	pdf.CellFormat(15, 6, "Qty", "1", 0, "R", true, 0, "")
+	pdf.CellFormat(25, 6, "Unit price", "1", 0, "R", true, 0, "")
+	pdf.CellFormat(20, 6, "Discount", "1", 0, "R", true, 0, "")
+	pdf.CellFormat(15, 6, "Tax", "1", 0, "R", true, 0, "")
+	pdf.CellFormat(30, 6, "Amount excl. tax", "1", 1, "R", true, 0, "")
+
+	pdf.SetFont("Helvetica", "", 9)
+	for _, item := range data.Items {
+		name := item.Name
+		if item.SKU != "" {
+			name = fmt.Sprintf("%s (%s)", name, item.SKU)
+		}
+		if len(name) > 48 {
+			name = name[:45] + "..."
+		}
+		pdf.CellFormat(75, 6, tr(name), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(15, 6, strconv.Itoa(item.Quantity), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(25, 6, fmt.Sprintf("%.2f", item.UnitPrice), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(20, 6, fmt.Sprintf("%.2f", item.DiscountAmount), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(15, 6, formatTaxRate(item.TaxRate), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(30, 6, fmt.Sprintf("%.2f", item.NetAmount), "1", 1, "R", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	// Tax breakdown on the left, totals on the right
+	top = pdf.GetY()
+	if len(data.TaxLines) > 0 {
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.CellFormat(20, 6, "Tax rate", "1", 0, "L", true, 0, "")
+		pdf.CellFormat(30, 6, "Base", "1", 0, "R", true, 0, "")
+		pdf.CellFormat(30, 6, "Tax", "1", 1, "R", true, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		for _, line := range data.TaxLines {
+			pdf.CellFormat(20, 6, formatTaxRate(line.Rate), "1", 0, "L", false, 0, "")
+			pdf.CellFormat(30, 6, fmt.Sprintf("%.2f", line.NetAmount), "1", 0, "R", false, 0, "")
+			pdf.CellFormat(30, 6, fmt.Sprintf("%.2f", line.TaxAmount), "1", 1, "R", false, 0, "")
+		}
+	}
+	breakdownBottom := pdf.GetY()
+
+	pdf.SetY(top)
+	writeInvoiceTotal(pdf, "Total excl. tax", data.NetTotal, false)
+	writeInvoiceTotal(pdf, "Tax", data.TaxAmount, false)
+	if data.DiscountAmount > 0 {
+		writeInvoiceTotal(pdf, "Discount", -data.DiscountAmount, false)
+	}
+	writeInvoiceTotal(pdf, "Total incl. tax", data.TotalAmount, true)
+	pdf.SetY(math.Max(breakdownBottom, pdf.GetY()))
+	pdf.Ln(6)
+
+	// Amount in words, as the law requires on invoices
+	document := "invoice"
+	if data.ValidUntil != nil {
+		document = "proforma"
+	}
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.MultiCell(180, 5, fmt.Sprintf("This %s amounts to: %s", document, AmountInWords(data.TotalAmount)), "", "L", false)
+
+	if len(data.PaymentMethods) > 0 {
+		pdf.Ln(2)
+		pdf.CellFormat(180, 5, "Payment: "+strings.Join(data.PaymentMethods, ", "), "", 1, "L", false, 0, "")
+	}
+
+	// Output PDF to buffer
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to generate PDF: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeLegalParty prints the name, address and tax identifiers of a party in a 85mm column
+func writeLegalParty(pdf *gofpdf.Fpdf, tr func(string) string, title string, party pos.LegalParty, x, y float64) {
+	pdf.SetXY(x, y)
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(85, 5, title, "B", 2, "L", false, 0, "")
+	pdf.CellFormat(85, 5, tr(party.Name), "", 2, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	if party.Address != "" {
+		pdf.MultiCell(85, 4, tr(party.Address), "", "L", false)
+		pdf.SetX(x)
+	}
+	identifiers := []struct{ label, value string }{
+		{"NIF", party.NIF},
+		{"NIS", party.NIS},
+		{"RC", party.RC},
+		{"AI", party.AI},
+	}
+	for _, identifier := range identifiers {
+		if identifier.value != "" {
+			pdf.CellFormat(85, 4, identifier.label+": "+tr(identifier.value), "", 2, "L", false, 0, "")
+		}
+	}
+}
+
+// writeInvoiceTotal prints a total in the right column of the invoice
+func writeInvoiceTotal(pdf *gofpdf.Fpdf, label string, amount float64, bold bool) {
+	style := ""
+	if bold {
+		style = "B"
+	}
+	pdf.SetFont("Helvetica", style, 10)
+	pdf.SetX(115)
+	pdf.CellFormat(45, 6, label, "1", 0, "L", false, 0, "")
+	pdf.CellFormat(35, 6, fmt.Sprintf("%.2f", amount), "1", 1, "R", false, 0, "")
+}
+
+// ConvertSaleToInvoiceData converts a pos.Sale to InvoiceData between the given parties.
+// Prices are shown excluding tax, whether or not the sale was priced tax included.
+func ConvertSaleToInvoiceData(sale *pos.Sale, seller, buyer pos.LegalParty, productVariantMap map[uint]ProductVariantInfo) *InvoiceData {
+	data := &InvoiceData{
+		Date:           sale.CreatedAt,
+		Seller:         seller,
+		Buyer:          buyer,
+		NetTotal:       sale.SubTotal,
+		TaxAmount:      sale.TaxAmount,
+		DiscountAmount: sale.UnapportionedDiscount(),
+		TotalAmount:    sale.TotalAmount,
+	}
+
+	data.Items = make([]InvoiceItem, len(sale.Items))
+	taxLinesByRate := make(map[float64]*ReceiptTaxLine)
+	for i, item := range sale.Items {
+		variantInfo, exists := productVariantMap[item.ProductVariantID]
+		itemName := fmt.Sprintf("Item #%d", i+1)
+		itemSKU := ""
+		if exists {
+			itemName = variantInfo.Name
+			itemSKU = variantInfo.SKU
+		}
+
+		unitPrice := item.UnitPrice
+		if item.TaxInclusive && item.TaxRate > 0 {
+			unitPrice = item.UnitPrice / (1 + item.TaxRate/100)
+		}
+		unitPrice = math.Round(unitPrice*100) / 100
+
+		data.Items[i] = InvoiceItem{
+			Name:           itemName,
+			SKU:            itemSKU,
+			Quantity:       item.Quantity,
+			UnitPrice:      unitPrice,
+			DiscountAmount: math.Max(math.Round((unitPrice*float64(item.Quantity)-item.NetAmount)*100)/100, 0),
+			TaxRate:        item.TaxRate,
+			NetAmount:      item.NetAmount,
+		}
+
+		line, exists := taxLinesByRate[item.TaxRate]
+		if !exists {
+			line = &ReceiptTaxLine{Rate: item.TaxRate}
+			taxLinesByRate[item.TaxRate] = line
+		}
+		line.NetAmount += item.NetAmount
+		line.TaxAmount += item.TaxAmount
+	}
+
+	// Every rate is listed on an invoice, exempt lines included, highest rate first
+	for _, line := range taxLinesByRate {
+		data.TaxLines = append(data.TaxLines, *line)
+	}
+	sort.Slice(data.TaxLines, func(i, j int) bool {
+		return data.TaxLines[i].Rate > data.TaxLines[j].Rate
+	})
+
+	for _, payment := range sale.Payments {
+		method := formatPaymentMethod(string(payment.PaymentMethod))
+		if !containsString(data.PaymentMethods, method) {
+			data.PaymentMethods = append(data.PaymentMethods, method)
+		}
+	}
+
+	return data
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	smallNumberWords = []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+		"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen"}
+	tensWords  = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+	scaleWords = []struct {
+		value int64
+		word  string
+	}{
+		{1000000000, "billion"},
+		{1000000, "million"},
+		{1000, "thousand"},
+	}
+)
+
+// AmountInWords spells out an amount in dinars and centimes, e.g.
+// "One thousand two hundred fifty dinars and forty centimes"
+func AmountInWords(amount float64) string {
+	cents := int64(math.Round(math.Abs(amount) * 100))
+	dinars, centimes := cents/100, cents%100
+
+	words := numberToWords(dinars) + pluralize(" dinar", dinars)
+	if centimes > 0 {
+		words += " and " + numberToWords(centimes) + pluralize(" centime", centimes)
+	}
+	if amount < 0 {
+		words = "minus " + words
+	}
+	return strings.ToUpper(words[:1]) + words[1:]
+}
+
+func pluralize(word string, count int64) string {
+	if count == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+// numberToWords spells out a whole number in English
+func numberToWords(n int64) string {
+	if n < 20 {
+		return smallNumberWords[n]
+	}
+	if n < 100 {
+		if n%10 == 0 {
+			return tensWords[n/10]
+		}
+		return tensWords[n/10] + "-" + smallNumberWords[n%10]
+	}
+	if n < 1000 {
+		if n%100 == 0 {
+			return smallNumberWords[n/100] + " hundred"
+		}
+		return smallNumberWords[n/100] + " hundred " + numberToWords(n%100)
+	}
+	for _, scale := range scaleWords {
+		if n >= scale.value {
+			words := numberToWords(n/scale.value) + " " + scale.word
+			if rest := n % scale.value; rest > 0 {
+				words += " " + numberToWords(rest)
+			}
+			return words
+		}
+	}
+	return ""
+}
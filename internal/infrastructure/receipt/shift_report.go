@@ -0,0 +1,151 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// ShiftReportData holds the data needed to print an X or Z report of a cash drawer session
+type ShiftReportData struct {
+	Title          string // e.g. "X REPORT"
+	CompanyName    string
+	FranchiseName  string
+	CashDrawerID   uint
+	OpenedAt       time.Time
+	ClosedAt       *time.Time
+	GeneratedAt    time.Time
+	SaleCount      int64
+	SalesTotal     float64
+	TaxTotal       float64
+	DiscountTotal  float64
+	VoidCount      int64
+	VoidTotal      float64
+	RefundCount    int64
+	RefundTotal    float64
+	Payments       []ShiftReportLine // Payments taken per method
+	Refunds        []ShiftReportLine // Refunds given per method
+	Cash           []ShiftReportLine // Cash movements, from the opening balance to the expected balance
+	Counts         []ShiftReportCount
+	CountedBalance *float64 // Cash counted at closing, nil on an X report
+	Variance       *float64
+}
+
+// ShiftReportLine is a labelled total on a shift report
+type ShiftReportLine struct {
+	Label  string
+	Count  int64
+	Amount float64
+}
+
+// ShiftReportCount is the cash counted for one denomination
+type ShiftReportCount struct {
+	Denomination float64
+	Quantity     int
+	Total        float64
+}
+
+// GenerateShiftReport creates a PDF X or Z report formatted for thermal printers (80mm width)
+func (rg *ReceiptGenerator) GenerateShiftReport(data *ShiftReportData) ([]byte, error) {
+	pdf := newThermalPDF()
+
+	// Get business name
+	businessName := data.CompanyName
+	if data.FranchiseName != "" {
+		businessName = data.FranchiseName
+	}
+
+	// Center-aligned header
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(70, 5, businessName, "", 1, "C", false, 0, "")
+	pdf.CellFormat(70, 5, data.Title, "", 1, "C", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.CellFormat(70, 4, fmt.Sprintf("Cash drawer #%d", data.CashDrawerID), "", 1, "C", false, 0, "")
+	pdf.CellFormat(70, 4, "Opened: "+data.OpenedAt.Format("2006-01-02 15:04"), "", 1, "C", false, 0, "")
+	if data.ClosedAt != nil {
+		pdf.CellFormat(70, 4, "Closed: "+data.ClosedAt.Format("2006-01-02 15:04"), "", 1, "C", false, 0, "")
+	}
+	pdf.CellFormat(70, 4, "Printed: "+data.GeneratedAt.Format("2006-01-02 15:04"), "", 1, "C", false, 0, "")
+
+	// Sales summary
+	writeSection(pdf, "Sales")
+	writeCountLine(pdf, "Sales", data.SaleCount, data.SalesTotal)
+	writeAmountLine(pdf, "Tax", data.TaxTotal)
+	writeAmountLine(pdf, "Discounts", data.DiscountTotal)
+	writeCountLine(pdf, "Voids", data.VoidCount, data.VoidTotal)
+	writeCountLine(pdf, "Refunds", data.RefundCount, data.RefundTotal)
+
+	if len(data.Payments) > 0 {
+		writeSection(pdf, "Payments")
+		for _, line := range data.Payments {
+			writeCountLine(pdf, formatPaymentMethod(line.Label), line.Count, line.Amount)
+		}
+	}
+
+	if len(data.Refunds) > 0 {
+		writeSection(pdf, "Refunds")
+		for _, line := range data.Refunds {
+			writeCountLine(pdf, formatPaymentMethod(line.Label), line.Count, line.Amount)
+		}
+	}
+
+	// Cash reconciliation
+	writeSection(pdf, "Cash")
+	for _, line := range data.Cash {
+		writeAmountLine(pdf, line.Label, line.Amount)
+	}
+
+	if len(data.Counts) > 0 {
+		writeSection(pdf, "Counted")
+		for _, count := range data.Counts {
+			label := fmt.Sprintf("%s x %d", strconv.FormatFloat(count.Denomination, 'f', -1, 64), count.Quantity)
+			writeAmountLine(pdf, label, count.Total)
+		}
+	}
+
+	if data.CountedBalance != nil {
+		pdf.CellFormat(70, 2, strings.Repeat("-", 35), "", 1, "C", false, 0, "")
+		pdf.SetFont("Courier", "B", 9)
+		pdf.CellFormat(45, 5, "Counted:", "", 0, "R", false, 0, "")
+		pdf.CellFormat(25, 5, fmt.Sprintf("%.2f", *data.CountedBalance), "", 1, "R", false, 0, "")
+		if data.Variance != nil {
+			pdf.CellFormat(45, 5, "Variance:", "", 0, "R", false, 0, "")
+			pdf.CellFormat(25, 5, fmt.Sprintf("%.2f", *data.Variance), "", 1, "R", false, 0, "")
+		}
+	}
+
+	// Output PDF to buffer
+	var buf bytes.Buffer
+	err := pdf.Output(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate PDF: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeSection prints a separator and a section title
+func writeSection(pdf *gofpdf.Fpdf, title string) {
+	pdf.CellFormat(70, 2, strings.Repeat("-", 35), "", 1, "C", false, 0, "")
+	pdf.SetFont("Courier", "B", 8)
+	pdf.CellFormat(70, 4, strings.ToUpper(title), "", 1, "L", false, 0, "")
+	pdf.SetFont("Courier", "", 8)
+}
+
+// writeAmountLine prints a label and an amount on one line
+func writeAmountLine(pdf *gofpdf.Fpdf, label string, amount float64) {
+	pdf.CellFormat(45, 4, label+":", "", 0, "R", false, 0, "")
+	pdf.CellFormat(25, 4, fmt.Sprintf("%.2f", amount), "", 1, "R", false, 0, "")
+}
+
+// writeCountLine prints a label, a number of transactions and their total on one line
+func writeCountLine(pdf *gofpdf.Fpdf, label string, count int64, amount float64) {
+	pdf.CellFormat(35, 4, label+":", "", 0, "R", false, 0, "")
+	pdf.CellFormat(10, 4, strconv.FormatInt(count, 10), "", 0, "R", false, 0, "")
+	pdf.CellFormat(25, 4, fmt.Sprintf("%.2f", amount), "", 1, "R", false, 0, "")
+}
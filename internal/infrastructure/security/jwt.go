@@ -134,3 +134,57 @@ func (m *JWTManager) ValidateInvitationToken(tokenString string) (email string,
 	return "", 0, fmt.Errorf("invalid token")
 }
 
+
+// GenerateReceiptToken generates a token giving access to the receipt of a sale for the given duration
+func (m *JWTManager) GenerateReceiptToken(saleID, companyID uint, ttl time.Duration) (string, time.Time, error) {
+	expiresAt := time.Now().Add(ttl)
+	claims := jwt.MapClaims{
+		"sale_id":    saleID,
+		"company_id": companyID,
+		"type":       "receipt",
+		"exp":        expiresAt.Unix(),
+		"iat":        time.Now().Unix(),
+		"nbf":        time.Now().Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(m.secretKey))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return tokenString, expiresAt, nil
+}
+
+// ValidateReceiptToken validates a receipt token and returns the sale it gives access to
+func (m *JWTManager) ValidateReceiptToken(tokenString string) (saleID, companyID uint, err error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(m.secretKey), nil
+	})
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+		if tokenType, ok := claims["type"].(string); !ok || tokenType != "receipt" {
+			return 0, 0, fmt.Errorf("invalid token type")
+		}
+
+		saleIDFloat, ok := claims["sale_id"].(float64)
+		if !ok {
+			return 0, 0, fmt.Errorf("invalid token")
+		}
+		companyIDFloat, ok := claims["company_id"].(float64)
+		if !ok {
+			return 0, 0, fmt.Errorf("invalid token")
+		}
+
+		return uint(saleIDFloat), uint(companyIDFloat), nil
+	}
+
+	return 0, 0, fmt.Errorf("invalid token")
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/YasserCherfaoui/darween/internal/infrastructure/export"
+	"github.com/YasserCherfaoui/darween/internal/presentation/response"
+	"github.com/YasserCherfaoui/darween/pkg/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// exportResponse sends the download headers along with the first bytes of an export, so
+// errors returned before any row is written still get a regular JSON error response
+type exportResponse struct {
+	c        *gin.Context
+	format   export.Format
+	filename string
+	started  bool
+}
+
+func (r *exportResponse) Write(p []byte) (int, error) {
+	if !r.started {
+		r.started = true
+		r.c.Header("Content-Type", r.format.ContentType())
+		r.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", r.filename))
+		r.c.Status(http.StatusOK)
+	}
+	return r.c.Writer.Write(p)
+}
+
+// streamExport streams the rows written by write in the format given by the format query
+// parameter, csv by default. name is used for the file and the worksheet.
+func streamExport(c *gin.Context, name string, write func(w export.Writer) error) {
+	format, err := export.ParseFormat(c.DefaultQuery("format", string(export.FormatCSV)))
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	out := &exportResponse{
+		c:        c,
+		format:   format,
+		filename: format.Filename(fmt.Sprintf("%s_%s", name, time.Now().Format("2006-01-02"))),
+	}
+	w := export.NewWriter(out, format, name)
+
+	err = write(w)
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		if !out.started {
+			response.Error(c, err)
+			return
+		}
+		// The headers are already sent, the download is cut short
+		log.Printf("export %s failed after it started: %v", name, err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	inventoryApp "github.com/YasserCherfaoui/darween/internal/application/inventory"
+	"github.com/YasserCherfaoui/darween/internal/infrastructure/export"
 	"github.com/YasserCherfaoui/darween/internal/presentation/http/middleware"
 	"github.com/YasserCherfaoui/darween/internal/presentation/response"
 	"github.com/YasserCherfaoui/darween/pkg/errors"
@@ -222,6 +223,58 @@ func (h *InventoryHandler) GetInventoryMovements(c *gin.Context) {
 	response.Success(c, http.StatusOK, result)
 }
 
+func (h *InventoryHandler) ExportInventory(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	// Check for franchise filter
+	var franchiseID *uint
+	if franchiseIDStr := c.Query("franchise_id"); franchiseIDStr != "" {
+		fID, err := strconv.ParseUint(franchiseIDStr, 10, 32)
+		if err == nil {
+			fIDUint := uint(fID)
+			franchiseID = &fIDUint
+		}
+	}
+
+	streamExport(c, "inventory", func(w export.Writer) error {
+		return h.inventoryService.ExportInventory(userID, uint(companyID), franchiseID, w)
+	})
+}
+
+func (h *InventoryHandler) ExportInventoryMovements(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	inventoryID, err := strconv.ParseUint(c.Param("inventoryId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid inventory id"))
+		return
+	}
+
+	var filters inventoryApp.MovementFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	streamExport(c, "inventory_movements", func(w export.Writer) error {
+		return h.inventoryService.ExportInventoryMovements(userID, uint(inventoryID), &filters, w)
+	})
+}
+
 func (h *InventoryHandler) InitializeCompanyInventory(c *gin.Context) {
 	// This method is not implemented in the inventory service
 	// It should be handled by the franchise service instead
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	loyaltyApp "github.com/YasserCherfaoui/darween/internal/application/loyalty"
+	"github.com/YasserCherfaoui/darween/internal/presentation/http/middleware"
+	"github.com/YasserCherfaoui/darween/internal/presentation/response"
+	"github.com/YasserCherfaoui/darween/pkg/errors"
+	"github.com/gin-gonic/gin"
+)
+
+type LoyaltyHandler struct {
+	loyaltyService *loyaltyApp.Service
+}
+
+func NewLoyaltyHandler(loyaltyService *loyaltyApp.Service) *LoyaltyHandler {
+	return &LoyaltyHandler{
+		loyaltyService: loyaltyService,
+	}
+}
+
+func (h *LoyaltyHandler) GetProgram(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	result, err := h.loyaltyService.GetProgram(userID, uint(companyID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *LoyaltyHandler) UpdateProgram(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	var req loyaltyApp.UpdateProgramRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.loyaltyService.UpdateProgram(userID, uint(companyID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Loyalty program updated successfully", result)
+}
+
+func (h *LoyaltyHandler) GetCustomerLoyalty(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	customerID, err := strconv.ParseUint(c.Param("customerId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid customer id"))
+		return
+	}
+
+	var pagination loyaltyApp.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+	pagination.GetDefaults()
+
+	result, err := h.loyaltyService.GetCustomerLoyalty(userID, uint(companyID), uint(customerID), pagination.Page, pagination.Limit)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	numberingApp "github.com/YasserCherfaoui/darween/internal/application/numbering"
+	"github.com/YasserCherfaoui/darween/internal/presentation/http/middleware"
+	"github.com/YasserCherfaoui/darween/internal/presentation/response"
+	"github.com/YasserCherfaoui/darween/pkg/errors"
+	"github.com/gin-gonic/gin"
+)
+
+type NumberingHandler struct {
+	numberingService *numberingApp.Service
+}
+
+func NewNumberingHandler(numberingService *numberingApp.Service) *NumberingHandler {
+	return &NumberingHandler{
+		numberingService: numberingService,
+	}
+}
+
+func (h *NumberingHandler) ListSequences(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	result, err := h.numberingService.ListSequences(userID, uint(companyID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *NumberingHandler) ConfigureSequence(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	var req numberingApp.ConfigureSequenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.numberingService.ConfigureSequence(userID, uint(companyID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Numbering sequence updated successfully", result)
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	posApp "github.com/YasserCherfaoui/darween/internal/application/pos"
+	"github.com/YasserCherfaoui/darween/internal/infrastructure/export"
 	"github.com/YasserCherfaoui/darween/internal/presentation/http/middleware"
 	"github.com/YasserCherfaoui/darween/internal/presentation/response"
 	"github.com/YasserCherfaoui/darween/pkg/errors"
@@ -171,6 +172,224 @@ func (h *POSHandler) DeleteCustomer(c *gin.Context) {
 	response.SuccessWithMessage(c, http.StatusOK, "Customer deleted successfully", nil)
 }
 
+// maxCustomerImportSize caps the size of a customer import file
+const maxCustomerImportSize = 5 << 20
+
+func (h *POSHandler) ExportCustomers(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	streamExport(c, "customers", func(w export.Writer) error {
+		return h.posService.ExportCustomers(userID, uint(companyID), w)
+	})
+}
+
+// ImportCustomers imports the CSV file uploaded as the file form field; dry_run=true only
+// reports what the import would do
+func (h *POSHandler) ImportCustomers(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("a CSV file is required in the file field"))
+		return
+	}
+	if fileHeader.Size > maxCustomerImportSize {
+		response.Error(c, errors.NewBadRequestError("the file is larger than 5 MB"))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("failed to read the file"))
+		return
+	}
+	defer file.Close()
+
+	dryRun := c.Query("dry_run") == "true" || c.PostForm("dry_run") == "true"
+
+	result, err := h.posService.ImportCustomers(userID, uint(companyID), file, dryRun)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *POSHandler) FindDuplicateCustomers(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	result, err := h.posService.FindDuplicateCustomers(userID, uint(companyID), c.Query("match"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *POSHandler) MergeCustomers(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	customerID, err := strconv.ParseUint(c.Param("customerId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid customer id"))
+		return
+	}
+
+	var req posApp.MergeCustomersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.posService.MergeCustomers(userID, uint(companyID), uint(customerID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Customers merged successfully", result)
+}
+
+// Customer account endpoints
+
+func (h *POSHandler) RecordCustomerPayment(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	customerID, err := strconv.ParseUint(c.Param("customerId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid customer id"))
+		return
+	}
+
+	var req posApp.RecordCustomerPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.posService.RecordCustomerPayment(userID, uint(companyID), uint(customerID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusCreated, "Customer payment recorded successfully", result)
+}
+
+func (h *POSHandler) GetCustomerAccount(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	customerID, err := strconv.ParseUint(c.Param("customerId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid customer id"))
+		return
+	}
+
+	result, err := h.posService.GetCustomerAccount(userID, uint(companyID), uint(customerID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *POSHandler) GetCustomerStatement(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	customerID, err := strconv.ParseUint(c.Param("customerId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid customer id"))
+		return
+	}
+
+	var req posApp.CustomerStatementRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.posService.GetCustomerStatement(userID, uint(companyID), uint(customerID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
 // Sale endpoints
 
 func (h *POSHandler) CreateSale(c *gin.Context) {
@@ -198,10 +417,1154 @@ func (h *POSHandler) CreateSale(c *gin.Context) {
 		return
 	}
 
-	response.SuccessWithMessage(c, http.StatusCreated, "Sale created successfully", result)
+	response.SuccessWithMessage(c, http.StatusCreated, "Sale created successfully", result)
+}
+
+func (h *POSHandler) GetSale(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	saleID, err := strconv.ParseUint(c.Param("saleId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid sale id"))
+		return
+	}
+
+	result, err := h.posService.GetSaleByID(userID, uint(companyID), uint(saleID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *POSHandler) ListSales(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	var pagination posApp.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+	pagination.GetDefaults()
+
+	// Check for franchise filter
+	var franchiseID *uint
+	if franchiseIDStr := c.Query("franchise_id"); franchiseIDStr != "" {
+		fID, err := strconv.ParseUint(franchiseIDStr, 10, 32)
+		if err == nil {
+			fIDUint := uint(fID)
+			franchiseID = &fIDUint
+		}
+	}
+
+	result, err := h.posService.ListSales(userID, uint(companyID), franchiseID, pagination.Page, pagination.Limit)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *POSHandler) ListFranchiseSales(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	franchiseID, err := strconv.ParseUint(c.Param("franchiseId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid franchise id"))
+		return
+	}
+
+	var pagination posApp.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+	pagination.GetDefaults()
+
+	// Get franchise to find company ID
+	// This is a simplified approach - in production, you'd fetch the franchise first
+	fIDUint := uint(franchiseID)
+	result, err := h.posService.ListSales(userID, 0, &fIDUint, pagination.Page, pagination.Limit)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// Payment endpoints
+
+func (h *POSHandler) AddPayment(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	saleID, err := strconv.ParseUint(c.Param("saleId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid sale id"))
+		return
+	}
+
+	var req posApp.AddPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.posService.AddPaymentToSale(userID, uint(companyID), uint(saleID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusCreated, "Payment added successfully", result)
+}
+
+func (h *POSHandler) CheckoutSale(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	saleID, err := strconv.ParseUint(c.Param("saleId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid sale id"))
+		return
+	}
+
+	var req posApp.CheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.posService.CheckoutSale(userID, uint(companyID), uint(saleID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusCreated, "Sale paid successfully", result)
+}
+
+func (h *POSHandler) SellGiftCard(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	var req posApp.SellGiftCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.posService.SellGiftCard(userID, uint(companyID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusCreated, "Gift card sold successfully", result)
+}
+
+func (h *POSHandler) VoidSale(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	saleID, err := strconv.ParseUint(c.Param("saleId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid sale id"))
+		return
+	}
+
+	var req posApp.VoidSaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.posService.VoidSale(userID, uint(companyID), uint(saleID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Sale voided successfully", result)
+}
+
+// Offline sync endpoints
+
+func (h *POSHandler) ReserveReceiptRange(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	var req posApp.ReserveReceiptRangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.posService.ReserveReceiptRange(userID, uint(companyID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusCreated, "Receipt range reserved successfully", result)
+}
+
+func (h *POSHandler) SyncOfflineSales(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	var req posApp.SyncOfflineSalesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.posService.SyncOfflineSales(userID, uint(companyID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	// Each sale carries its own outcome; the batch itself always goes through
+	response.SuccessWithMessage(c, http.StatusOK, "Offline sales synced", result)
+}
+
+// Parked sale endpoints
+
+func (h *POSHandler) ParkSale(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	var req posApp.CreateSaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.posService.ParkSale(userID, uint(companyID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusCreated, "Sale parked successfully", result)
+}
+
+func (h *POSHandler) ListParkedSales(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	// Check for franchise filter
+	var franchiseID *uint
+	if franchiseIDStr := c.Query("franchise_id"); franchiseIDStr != "" {
+		fID, err := strconv.ParseUint(franchiseIDStr, 10, 32)
+		if err == nil {
+			fIDUint := uint(fID)
+			franchiseID = &fIDUint
+		}
+	}
+
+	result, err := h.posService.ListParkedSales(userID, uint(companyID), franchiseID)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *POSHandler) UpdateParkedSale(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	saleID, err := strconv.ParseUint(c.Param("saleId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid sale id"))
+		return
+	}
+
+	var req posApp.UpdateParkedSaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.posService.UpdateParkedSale(userID, uint(companyID), uint(saleID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Parked sale updated successfully", result)
+}
+
+func (h *POSHandler) ResumeParkedSale(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	saleID, err := strconv.ParseUint(c.Param("saleId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid sale id"))
+		return
+	}
+
+	result, err := h.posService.ResumeParkedSale(userID, uint(companyID), uint(saleID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Parked sale completed successfully", result)
+}
+
+func (h *POSHandler) DiscardParkedSale(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	saleID, err := strconv.ParseUint(c.Param("saleId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid sale id"))
+		return
+	}
+
+	err = h.posService.DiscardParkedSale(userID, uint(companyID), uint(saleID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Parked sale discarded successfully", nil)
+}
+
+// Layaway endpoints
+
+func (h *POSHandler) CreateLayaway(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	var req posApp.CreateLayawayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.posService.CreateLayaway(userID, uint(companyID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusCreated, "Layaway created successfully", result)
+}
+
+func (h *POSHandler) ListLayaways(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	var pagination posApp.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+	pagination.GetDefaults()
+
+	// Check for franchise filter
+	var franchiseID *uint
+	if franchiseIDStr := c.Query("franchise_id"); franchiseIDStr != "" {
+		fID, err := strconv.ParseUint(franchiseIDStr, 10, 32)
+		if err == nil {
+			fIDUint := uint(fID)
+			franchiseID = &fIDUint
+		}
+	}
+
+	result, err := h.posService.ListLayaways(userID, uint(companyID), franchiseID, c.Query("status"), pagination.Page, pagination.Limit)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *POSHandler) GetLayaway(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	layawayID, err := strconv.ParseUint(c.Param("layawayId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid layaway id"))
+		return
+	}
+
+	result, err := h.posService.GetLayaway(userID, uint(companyID), uint(layawayID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *POSHandler) AddLayawayDeposit(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	layawayID, err := strconv.ParseUint(c.Param("layawayId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid layaway id"))
+		return
+	}
+
+	var req posApp.AddPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.posService.AddLayawayDeposit(userID, uint(companyID), uint(layawayID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusCreated, "Deposit recorded successfully", result)
+}
+
+func (h *POSHandler) CompleteLayaway(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	layawayID, err := strconv.ParseUint(c.Param("layawayId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid layaway id"))
+		return
+	}
+
+	var req posApp.CompleteLayawayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.posService.CompleteLayaway(userID, uint(companyID), uint(layawayID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Layaway completed successfully", result)
+}
+
+func (h *POSHandler) CancelLayaway(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	layawayID, err := strconv.ParseUint(c.Param("layawayId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid layaway id"))
+		return
+	}
+
+	var req posApp.CancelLayawayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.posService.CancelLayaway(userID, uint(companyID), uint(layawayID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Layaway cancelled successfully", result)
+}
+
+func (h *POSHandler) ResolveLayawayDeposit(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	layawayID, err := strconv.ParseUint(c.Param("layawayId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid layaway id"))
+		return
+	}
+
+	var req posApp.ResolveLayawayDepositRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.posService.ResolveLayawayDeposit(userID, uint(companyID), uint(layawayID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Layaway deposit settled successfully", result)
+}
+
+// Invoice endpoints
+
+func (h *POSHandler) IssueInvoice(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	saleID, err := strconv.ParseUint(c.Param("saleId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid sale id"))
+		return
+	}
+
+	result, err := h.posService.IssueInvoice(userID, uint(companyID), uint(saleID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Invoice issued successfully", result)
+}
+
+func (h *POSHandler) GenerateInvoice(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	saleID, err := strconv.ParseUint(c.Param("saleId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid sale id"))
+		return
+	}
+
+	pdfData, filename, err := h.posService.GenerateInvoicePDF(userID, uint(companyID), uint(saleID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Data(http.StatusOK, "application/pdf", pdfData)
+}
+
+// Proforma endpoints
+
+func (h *POSHandler) CreateProforma(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	var req posApp.CreateProformaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.posService.CreateProforma(userID, uint(companyID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusCreated, "Proforma created successfully", result)
+}
+
+func (h *POSHandler) ListProformas(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	var pagination posApp.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+	pagination.GetDefaults()
+
+	// Check for franchise filter
+	var franchiseID *uint
+	if franchiseIDStr := c.Query("franchise_id"); franchiseIDStr != "" {
+		fID, err := strconv.ParseUint(franchiseIDStr, 10, 32)
+		if err == nil {
+			fIDUint := uint(fID)
+			franchiseID = &fIDUint
+		}
+	}
+
+	result, err := h.posService.ListProformas(userID, uint(companyID), franchiseID, c.Query("status"), pagination.Page, pagination.Limit)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *POSHandler) GetProforma(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	proformaID, err := strconv.ParseUint(c.Param("proformaId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid proforma id"))
+		return
+	}
+
+	result, err := h.posService.GetProforma(userID, uint(companyID), uint(proformaID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *POSHandler) ConvertProforma(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	proformaID, err := strconv.ParseUint(c.Param("proformaId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid proforma id"))
+		return
+	}
+
+	result, err := h.posService.ConvertProforma(userID, uint(companyID), uint(proformaID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Proforma converted successfully", result)
+}
+
+func (h *POSHandler) CancelProforma(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	proformaID, err := strconv.ParseUint(c.Param("proformaId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid proforma id"))
+		return
+	}
+
+	result, err := h.posService.CancelProforma(userID, uint(companyID), uint(proformaID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Proforma cancelled successfully", result)
+}
+
+func (h *POSHandler) GenerateProforma(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	proformaID, err := strconv.ParseUint(c.Param("proformaId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid proforma id"))
+		return
+	}
+
+	pdfData, filename, err := h.posService.GenerateProformaPDF(userID, uint(companyID), uint(proformaID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Data(http.StatusOK, "application/pdf", pdfData)
+}
+
+// Refund endpoints
+
+func (h *POSHandler) ProcessRefund(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	saleID, err := strconv.ParseUint(c.Param("saleId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid sale id"))
+		return
+	}
+
+	var req posApp.ProcessRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.posService.ProcessRefund(userID, uint(companyID), uint(saleID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusCreated, "Refund processed successfully", result)
+}
+
+func (h *POSHandler) ListRefunds(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	var pagination posApp.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+	pagination.GetDefaults()
+
+	// Check for franchise filter
+	var franchiseID *uint
+	if franchiseIDStr := c.Query("franchise_id"); franchiseIDStr != "" {
+		fID, err := strconv.ParseUint(franchiseIDStr, 10, 32)
+		if err == nil {
+			fIDUint := uint(fID)
+			franchiseID = &fIDUint
+		}
+	}
+
+	result, err := h.posService.ListRefunds(userID, uint(companyID), franchiseID, pagination.Page, pagination.Limit)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *POSHandler) ListFranchiseRefunds(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	franchiseID, err := strconv.ParseUint(c.Param("franchiseId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid franchise id"))
+		return
+	}
+
+	var pagination posApp.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+	pagination.GetDefaults()
+
+	fIDUint := uint(franchiseID)
+	result, err := h.posService.ListRefunds(userID, 0, &fIDUint, pagination.Page, pagination.Limit)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// Exchange endpoints
+
+func (h *POSHandler) ProcessExchange(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	saleID, err := strconv.ParseUint(c.Param("saleId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid sale id"))
+		return
+	}
+
+	var req posApp.ProcessExchangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.posService.ProcessExchange(userID, uint(companyID), uint(saleID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusCreated, "Exchange processed successfully", result)
+}
+
+func (h *POSHandler) ListExchanges(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	var pagination posApp.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+	pagination.GetDefaults()
+
+	// Check for franchise filter
+	var franchiseID *uint
+	if franchiseIDStr := c.Query("franchise_id"); franchiseIDStr != "" {
+		fID, err := strconv.ParseUint(franchiseIDStr, 10, 32)
+		if err == nil {
+			fIDUint := uint(fID)
+			franchiseID = &fIDUint
+		}
+	}
+
+	result, err := h.posService.ListExchanges(userID, uint(companyID), franchiseID, pagination.Page, pagination.Limit)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// Cash Drawer endpoints
+
+func (h *POSHandler) OpenCashDrawer(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	var req posApp.OpenCashDrawerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.posService.OpenCashDrawer(userID, uint(companyID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusCreated, "Cash drawer opened successfully", result)
+}
+
+func (h *POSHandler) GetXReport(c *gin.Context) {
+	h.getShiftReport(c, posApp.ShiftReportTypeX)
+}
+
+func (h *POSHandler) GetZReport(c *gin.Context) {
+	h.getShiftReport(c, posApp.ShiftReportTypeZ)
 }
 
-func (h *POSHandler) GetSale(c *gin.Context) {
+func (h *POSHandler) GenerateXReportPDF(c *gin.Context) {
+	h.generateShiftReportPDF(c, posApp.ShiftReportTypeX)
+}
+
+func (h *POSHandler) GenerateZReportPDF(c *gin.Context) {
+	h.generateShiftReportPDF(c, posApp.ShiftReportTypeZ)
+}
+
+func (h *POSHandler) getShiftReport(c *gin.Context, reportType posApp.ShiftReportType) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		response.Error(c, err)
@@ -214,13 +1577,13 @@ func (h *POSHandler) GetSale(c *gin.Context) {
 		return
 	}
 
-	saleID, err := strconv.ParseUint(c.Param("saleId"), 10, 32)
+	drawerID, err := strconv.ParseUint(c.Param("drawerId"), 10, 32)
 	if err != nil {
-		response.Error(c, errors.NewBadRequestError("invalid sale id"))
+		response.Error(c, errors.NewBadRequestError("invalid drawer id"))
 		return
 	}
 
-	result, err := h.posService.GetSaleByID(userID, uint(companyID), uint(saleID))
+	result, err := h.posService.GetShiftReport(userID, uint(companyID), uint(drawerID), reportType)
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -229,7 +1592,7 @@ func (h *POSHandler) GetSale(c *gin.Context) {
 	response.Success(c, http.StatusOK, result)
 }
 
-func (h *POSHandler) ListSales(c *gin.Context) {
+func (h *POSHandler) generateShiftReportPDF(c *gin.Context, reportType posApp.ShiftReportType) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		response.Error(c, err)
@@ -242,67 +1605,53 @@ func (h *POSHandler) ListSales(c *gin.Context) {
 		return
 	}
 
-	var pagination posApp.PaginationRequest
-	if err := c.ShouldBindQuery(&pagination); err != nil {
-		response.Error(c, errors.NewValidationError(err.Error()))
+	drawerID, err := strconv.ParseUint(c.Param("drawerId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid drawer id"))
 		return
 	}
-	pagination.GetDefaults()
-
-	// Check for franchise filter
-	var franchiseID *uint
-	if franchiseIDStr := c.Query("franchise_id"); franchiseIDStr != "" {
-		fID, err := strconv.ParseUint(franchiseIDStr, 10, 32)
-		if err == nil {
-			fIDUint := uint(fID)
-			franchiseID = &fIDUint
-		}
-	}
 
-	result, err := h.posService.ListSales(userID, uint(companyID), franchiseID, pagination.Page, pagination.Limit)
+	pdfData, filename, err := h.posService.GenerateShiftReportPDF(userID, uint(companyID), uint(drawerID), reportType)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	response.Success(c, http.StatusOK, result)
+	// Set response headers for PDF download
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Data(http.StatusOK, "application/pdf", pdfData)
 }
 
-func (h *POSHandler) ListFranchiseSales(c *gin.Context) {
+func (h *POSHandler) RecordCashMovement(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	franchiseID, err := strconv.ParseUint(c.Param("franchiseId"), 10, 32)
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
 	if err != nil {
-		response.Error(c, errors.NewBadRequestError("invalid franchise id"))
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
 		return
 	}
 
-	var pagination posApp.PaginationRequest
-	if err := c.ShouldBindQuery(&pagination); err != nil {
+	var req posApp.CashMovementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, errors.NewValidationError(err.Error()))
 		return
 	}
-	pagination.GetDefaults()
 
-	// Get franchise to find company ID
-	// This is a simplified approach - in production, you'd fetch the franchise first
-	fIDUint := uint(franchiseID)
-	result, err := h.posService.ListSales(userID, 0, &fIDUint, pagination.Page, pagination.Limit)
+	result, err := h.posService.RecordCashMovement(userID, uint(companyID), &req)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	response.Success(c, http.StatusOK, result)
+	response.SuccessWithMessage(c, http.StatusCreated, "Cash movement recorded successfully", result)
 }
 
-// Payment endpoints
-
-func (h *POSHandler) AddPayment(c *gin.Context) {
+func (h *POSHandler) CloseCashDrawer(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		response.Error(c, err)
@@ -315,30 +1664,28 @@ func (h *POSHandler) AddPayment(c *gin.Context) {
 		return
 	}
 
-	saleID, err := strconv.ParseUint(c.Param("saleId"), 10, 32)
+	drawerID, err := strconv.ParseUint(c.Param("drawerId"), 10, 32)
 	if err != nil {
-		response.Error(c, errors.NewBadRequestError("invalid sale id"))
+		response.Error(c, errors.NewBadRequestError("invalid drawer id"))
 		return
 	}
 
-	var req posApp.AddPaymentRequest
+	var req posApp.CloseCashDrawerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, errors.NewValidationError(err.Error()))
 		return
 	}
 
-	result, err := h.posService.AddPaymentToSale(userID, uint(companyID), uint(saleID), &req)
+	result, err := h.posService.CloseCashDrawer(userID, uint(companyID), uint(drawerID), &req)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	response.SuccessWithMessage(c, http.StatusCreated, "Payment added successfully", result)
+	response.SuccessWithMessage(c, http.StatusOK, "Cash drawer closed successfully", result)
 }
 
-// Refund endpoints
-
-func (h *POSHandler) ProcessRefund(c *gin.Context) {
+func (h *POSHandler) GetActiveCashDrawer(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		response.Error(c, err)
@@ -351,28 +1698,49 @@ func (h *POSHandler) ProcessRefund(c *gin.Context) {
 		return
 	}
 
-	saleID, err := strconv.ParseUint(c.Param("saleId"), 10, 32)
+	// Check for franchise filter
+	var franchiseID *uint
+	if franchiseIDStr := c.Query("franchise_id"); franchiseIDStr != "" {
+		fID, err := strconv.ParseUint(franchiseIDStr, 10, 32)
+		if err == nil {
+			fIDUint := uint(fID)
+			franchiseID = &fIDUint
+		}
+	}
+
+	result, err := h.posService.GetActiveCashDrawer(userID, uint(companyID), franchiseID)
 	if err != nil {
-		response.Error(c, errors.NewBadRequestError("invalid sale id"))
+		response.Error(c, err)
 		return
 	}
 
-	var req posApp.ProcessRefundRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error(c, errors.NewValidationError(err.Error()))
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *POSHandler) GetActiveFranchiseCashDrawer(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
 		return
 	}
 
-	result, err := h.posService.ProcessRefund(userID, uint(companyID), uint(saleID), &req)
+	franchiseID, err := strconv.ParseUint(c.Param("franchiseId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid franchise id"))
+		return
+	}
+
+	fIDUint := uint(franchiseID)
+	result, err := h.posService.GetActiveCashDrawer(userID, 0, &fIDUint)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	response.SuccessWithMessage(c, http.StatusCreated, "Refund processed successfully", result)
+	response.Success(c, http.StatusOK, result)
 }
 
-func (h *POSHandler) ListRefunds(c *gin.Context) {
+func (h *POSHandler) ListCashDrawers(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		response.Error(c, err)
@@ -402,7 +1770,7 @@ func (h *POSHandler) ListRefunds(c *gin.Context) {
 		}
 	}
 
-	result, err := h.posService.ListRefunds(userID, uint(companyID), franchiseID, pagination.Page, pagination.Limit)
+	result, err := h.posService.ListCashDrawers(userID, uint(companyID), franchiseID, pagination.Page, pagination.Limit)
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -411,7 +1779,7 @@ func (h *POSHandler) ListRefunds(c *gin.Context) {
 	response.Success(c, http.StatusOK, result)
 }
 
-func (h *POSHandler) ListFranchiseRefunds(c *gin.Context) {
+func (h *POSHandler) ListFranchiseCashDrawers(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		response.Error(c, err)
@@ -432,7 +1800,7 @@ func (h *POSHandler) ListFranchiseRefunds(c *gin.Context) {
 	pagination.GetDefaults()
 
 	fIDUint := uint(franchiseID)
-	result, err := h.posService.ListRefunds(userID, 0, &fIDUint, pagination.Page, pagination.Limit)
+	result, err := h.posService.ListCashDrawers(userID, 0, &fIDUint, pagination.Page, pagination.Limit)
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -441,9 +1809,9 @@ func (h *POSHandler) ListFranchiseRefunds(c *gin.Context) {
 	response.Success(c, http.StatusOK, result)
 }
 
-// Cash Drawer endpoints
+// Report endpoints
 
-func (h *POSHandler) OpenCashDrawer(c *gin.Context) {
+func (h *POSHandler) GetSalesReport(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		response.Error(c, err)
@@ -456,22 +1824,22 @@ func (h *POSHandler) OpenCashDrawer(c *gin.Context) {
 		return
 	}
 
-	var req posApp.OpenCashDrawerRequest
+	var req posApp.SalesReportRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, errors.NewValidationError(err.Error()))
 		return
 	}
 
-	result, err := h.posService.OpenCashDrawer(userID, uint(companyID), &req)
+	result, err := h.posService.GetSalesReport(userID, uint(companyID), &req)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	response.SuccessWithMessage(c, http.StatusCreated, "Cash drawer opened successfully", result)
+	response.Success(c, http.StatusOK, result)
 }
 
-func (h *POSHandler) CloseCashDrawer(c *gin.Context) {
+func (h *POSHandler) GetSalesAnalytics(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		response.Error(c, err)
@@ -484,28 +1852,37 @@ func (h *POSHandler) CloseCashDrawer(c *gin.Context) {
 		return
 	}
 
-	drawerID, err := strconv.ParseUint(c.Param("drawerId"), 10, 32)
-	if err != nil {
-		response.Error(c, errors.NewBadRequestError("invalid drawer id"))
-		return
-	}
-
-	var req posApp.CloseCashDrawerRequest
+	var req posApp.SalesAnalyticsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, errors.NewValidationError(err.Error()))
 		return
 	}
 
-	result, err := h.posService.CloseCashDrawer(userID, uint(companyID), uint(drawerID), &req)
+	result, err := h.posService.GetSalesAnalytics(userID, uint(companyID), &req)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	response.SuccessWithMessage(c, http.StatusOK, "Cash drawer closed successfully", result)
+	response.Success(c, http.StatusOK, result)
 }
 
-func (h *POSHandler) GetActiveCashDrawer(c *gin.Context) {
+// Exports
+
+func (h *POSHandler) ExportSales(c *gin.Context) {
+	h.exportCompanyRecords(c, "sales", h.posService.ExportSales)
+}
+
+func (h *POSHandler) ExportRefunds(c *gin.Context) {
+	h.exportCompanyRecords(c, "refunds", h.posService.ExportRefunds)
+}
+
+func (h *POSHandler) ExportCashDrawers(c *gin.Context) {
+	h.exportCompanyRecords(c, "cash_drawers", h.posService.ExportCashDrawers)
+}
+
+// exportCompanyRecords streams an export of the company, filtered like the lists by franchise_id
+func (h *POSHandler) exportCompanyRecords(c *gin.Context, name string, exportFn func(userID, companyID uint, franchiseID *uint, w export.Writer) error) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		response.Error(c, err)
@@ -528,30 +1905,25 @@ func (h *POSHandler) GetActiveCashDrawer(c *gin.Context) {
 		}
 	}
 
-	result, err := h.posService.GetActiveCashDrawer(userID, uint(companyID), franchiseID)
-	if err != nil {
-		response.Error(c, err)
-		return
-	}
-
-	response.Success(c, http.StatusOK, result)
+	streamExport(c, name, func(w export.Writer) error {
+		return exportFn(userID, uint(companyID), franchiseID, w)
+	})
 }
 
-func (h *POSHandler) GetActiveFranchiseCashDrawer(c *gin.Context) {
+func (h *POSHandler) GetCustomerAgingReport(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	franchiseID, err := strconv.ParseUint(c.Param("franchiseId"), 10, 32)
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
 	if err != nil {
-		response.Error(c, errors.NewBadRequestError("invalid franchise id"))
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
 		return
 	}
 
-	fIDUint := uint(franchiseID)
-	result, err := h.posService.GetActiveCashDrawer(userID, 0, &fIDUint)
+	result, err := h.posService.GetCustomerAgingReport(userID, uint(companyID))
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -560,37 +1932,29 @@ func (h *POSHandler) GetActiveFranchiseCashDrawer(c *gin.Context) {
 	response.Success(c, http.StatusOK, result)
 }
 
-func (h *POSHandler) ListCashDrawers(c *gin.Context) {
+func (h *POSHandler) GetFranchiseSalesReport(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	franchiseID, err := strconv.ParseUint(c.Param("franchiseId"), 10, 32)
 	if err != nil {
-		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		response.Error(c, errors.NewBadRequestError("invalid franchise id"))
 		return
 	}
 
-	var pagination posApp.PaginationRequest
-	if err := c.ShouldBindQuery(&pagination); err != nil {
+	var req posApp.SalesReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, errors.NewValidationError(err.Error()))
 		return
 	}
-	pagination.GetDefaults()
 
-	// Check for franchise filter
-	var franchiseID *uint
-	if franchiseIDStr := c.Query("franchise_id"); franchiseIDStr != "" {
-		fID, err := strconv.ParseUint(franchiseIDStr, 10, 32)
-		if err == nil {
-			fIDUint := uint(fID)
-			franchiseID = &fIDUint
-		}
-	}
+	fIDUint := uint(franchiseID)
+	req.FranchiseID = &fIDUint
 
-	result, err := h.posService.ListCashDrawers(userID, uint(companyID), franchiseID, pagination.Page, pagination.Limit)
+	result, err := h.posService.GetSalesReport(userID, 0, &req)
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -599,28 +1963,28 @@ func (h *POSHandler) ListCashDrawers(c *gin.Context) {
 	response.Success(c, http.StatusOK, result)
 }
 
-func (h *POSHandler) ListFranchiseCashDrawers(c *gin.Context) {
+// Product Search endpoints
+
+func (h *POSHandler) SearchProducts(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	franchiseID, err := strconv.ParseUint(c.Param("franchiseId"), 10, 32)
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
 	if err != nil {
-		response.Error(c, errors.NewBadRequestError("invalid franchise id"))
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
 		return
 	}
 
-	var pagination posApp.PaginationRequest
-	if err := c.ShouldBindQuery(&pagination); err != nil {
+	var req posApp.SearchProductsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
 		response.Error(c, errors.NewValidationError(err.Error()))
 		return
 	}
-	pagination.GetDefaults()
 
-	fIDUint := uint(franchiseID)
-	result, err := h.posService.ListCashDrawers(userID, 0, &fIDUint, pagination.Page, pagination.Limit)
+	result, err := h.posService.SearchProductsForSale(userID, uint(companyID), &req)
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -629,9 +1993,7 @@ func (h *POSHandler) ListFranchiseCashDrawers(c *gin.Context) {
 	response.Success(c, http.StatusOK, result)
 }
 
-// Report endpoints
-
-func (h *POSHandler) GetSalesReport(c *gin.Context) {
+func (h *POSHandler) ScanProduct(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		response.Error(c, err)
@@ -644,13 +2006,13 @@ func (h *POSHandler) GetSalesReport(c *gin.Context) {
 		return
 	}
 
-	var req posApp.SalesReportRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	var req posApp.ScanProductRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
 		response.Error(c, errors.NewValidationError(err.Error()))
 		return
 	}
 
-	result, err := h.posService.GetSalesReport(userID, uint(companyID), &req)
+	result, err := h.posService.ScanProduct(userID, uint(companyID), &req)
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -659,40 +2021,59 @@ func (h *POSHandler) GetSalesReport(c *gin.Context) {
 	response.Success(c, http.StatusOK, result)
 }
 
-func (h *POSHandler) GetFranchiseSalesReport(c *gin.Context) {
+// Receipt endpoints
+
+func (h *POSHandler) GenerateReceipt(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	franchiseID, err := strconv.ParseUint(c.Param("franchiseId"), 10, 32)
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
 	if err != nil {
-		response.Error(c, errors.NewBadRequestError("invalid franchise id"))
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
 		return
 	}
 
-	var req posApp.SalesReportRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error(c, errors.NewValidationError(err.Error()))
+	saleID, err := strconv.ParseUint(c.Param("saleId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid sale id"))
 		return
 	}
 
-	fIDUint := uint(franchiseID)
-	req.FranchiseID = &fIDUint
+	// Raw ESC/POS for thermal printers driven directly, PDF otherwise
+	switch c.DefaultQuery("format", "pdf") {
+	case "pdf":
+	case "escpos":
+		openDrawer := c.Query("open_drawer") == "true"
+		data, filename, err := h.posService.GenerateReceiptEscPos(userID, uint(companyID), uint(saleID), openDrawer)
+		if err != nil {
+			response.Error(c, err)
+			return
+		}
 
-	result, err := h.posService.GetSalesReport(userID, 0, &req)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+		c.Data(http.StatusOK, "application/octet-stream", data)
+		return
+	default:
+		response.Error(c, errors.NewBadRequestError("format must be pdf or escpos"))
+		return
+	}
+
+	pdfData, filename, err := h.posService.GenerateReceiptPDF(userID, uint(companyID), uint(saleID))
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	response.Success(c, http.StatusOK, result)
+	// Set response headers for PDF download
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Data(http.StatusOK, "application/pdf", pdfData)
 }
 
-// Product Search endpoints
-
-func (h *POSHandler) SearchProducts(c *gin.Context) {
+func (h *POSHandler) EmailReceipt(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		response.Error(c, err)
@@ -705,24 +2086,28 @@ func (h *POSHandler) SearchProducts(c *gin.Context) {
 		return
 	}
 
-	var req posApp.SearchProductsRequest
-	if err := c.ShouldBindQuery(&req); err != nil {
+	saleID, err := strconv.ParseUint(c.Param("saleId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid sale id"))
+		return
+	}
+
+	var req posApp.EmailReceiptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, errors.NewValidationError(err.Error()))
 		return
 	}
 
-	result, err := h.posService.SearchProductsForSale(userID, uint(companyID), &req)
+	result, err := h.posService.EmailReceipt(userID, uint(companyID), uint(saleID), &req)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	response.Success(c, http.StatusOK, result)
+	response.SuccessWithMessage(c, http.StatusOK, "Receipt queued for sending", result)
 }
 
-// Receipt endpoints
-
-func (h *POSHandler) GenerateReceipt(c *gin.Context) {
+func (h *POSHandler) GetReceiptLink(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		response.Error(c, err)
@@ -741,15 +2126,26 @@ func (h *POSHandler) GenerateReceipt(c *gin.Context) {
 		return
 	}
 
-	pdfData, filename, err := h.posService.GenerateReceiptPDF(userID, uint(companyID), uint(saleID))
+	result, err := h.posService.GetReceiptLink(userID, uint(companyID), uint(saleID))
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	// Set response headers for PDF download
+	response.Success(c, http.StatusOK, result)
+}
+
+// GetPublicReceipt serves the receipt a signed link points to; it needs no authentication
+func (h *POSHandler) GetPublicReceipt(c *gin.Context) {
+	pdfData, filename, err := h.posService.GeneratePublicReceiptPDF(c.Param("token"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	// Displayed in the browser rather than downloaded
 	c.Header("Content-Type", "application/pdf")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", filename))
 	c.Data(http.StatusOK, "application/pdf", pdfData)
 }
 
@@ -0,0 +1,312 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	pricelistApp "github.com/YasserCherfaoui/darween/internal/application/pricelist"
+	"github.com/YasserCherfaoui/darween/internal/presentation/http/middleware"
+	"github.com/YasserCherfaoui/darween/internal/presentation/response"
+	"github.com/YasserCherfaoui/darween/pkg/errors"
+	"github.com/gin-gonic/gin"
+)
+
+type PriceListHandler struct {
+	pricelistService *pricelistApp.Service
+}
+
+func NewPriceListHandler(pricelistService *pricelistApp.Service) *PriceListHandler {
+	return &PriceListHandler{
+		pricelistService: pricelistService,
+	}
+}
+
+func (h *PriceListHandler) CreateCustomerGroup(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	var req pricelistApp.CreateCustomerGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.pricelistService.CreateCustomerGroup(userID, uint(companyID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusCreated, "Customer group created successfully", result)
+}
+
+func (h *PriceListHandler) ListCustomerGroups(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	result, err := h.pricelistService.ListCustomerGroups(userID, uint(companyID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *PriceListHandler) GetCustomerGroup(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	groupID, err := strconv.ParseUint(c.Param("groupId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid customer group id"))
+		return
+	}
+
+	result, err := h.pricelistService.GetCustomerGroup(userID, uint(companyID), uint(groupID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *PriceListHandler) UpdateCustomerGroup(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	groupID, err := strconv.ParseUint(c.Param("groupId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid customer group id"))
+		return
+	}
+
+	var req pricelistApp.UpdateCustomerGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.pricelistService.UpdateCustomerGroup(userID, uint(companyID), uint(groupID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Customer group updated successfully", result)
+}
+
+func (h *PriceListHandler) DeleteCustomerGroup(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	groupID, err := strconv.ParseUint(c.Param("groupId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid customer group id"))
+		return
+	}
+
+	err = h.pricelistService.DeleteCustomerGroup(userID, uint(companyID), uint(groupID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Customer group deleted successfully", nil)
+}
+
+func (h *PriceListHandler) CreatePriceList(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	var req pricelistApp.CreatePriceListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.pricelistService.CreatePriceList(userID, uint(companyID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusCreated, "Price list created successfully", result)
+}
+
+func (h *PriceListHandler) ListPriceLists(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	// Check for customer group filter
+	var customerGroupID *uint
+	if groupIDStr := c.Query("customer_group_id"); groupIDStr != "" {
+		gID, err := strconv.ParseUint(groupIDStr, 10, 32)
+		if err == nil {
+			gIDUint := uint(gID)
+			customerGroupID = &gIDUint
+		}
+	}
+
+	result, err := h.pricelistService.ListPriceLists(userID, uint(companyID), customerGroupID)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *PriceListHandler) GetPriceList(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	priceListID, err := strconv.ParseUint(c.Param("priceListId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid price list id"))
+		return
+	}
+
+	result, err := h.pricelistService.GetPriceList(userID, uint(companyID), uint(priceListID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *PriceListHandler) UpdatePriceList(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	priceListID, err := strconv.ParseUint(c.Param("priceListId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid price list id"))
+		return
+	}
+
+	var req pricelistApp.UpdatePriceListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.pricelistService.UpdatePriceList(userID, uint(companyID), uint(priceListID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Price list updated successfully", result)
+}
+
+func (h *PriceListHandler) DeletePriceList(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	priceListID, err := strconv.ParseUint(c.Param("priceListId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid price list id"))
+		return
+	}
+
+	err = h.pricelistService.DeletePriceList(userID, uint(companyID), uint(priceListID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Price list deleted successfully", nil)
+}
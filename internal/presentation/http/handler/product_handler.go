@@ -318,6 +318,40 @@ func (h *ProductHandler) UpdateProductVariant(c *gin.Context) {
 	response.SuccessWithMessage(c, http.StatusOK, "Product variant updated successfully", result)
 }
 
+func (h *ProductHandler) GenerateVariantBarcode(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	productID, err := strconv.ParseUint(c.Param("productId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid product id"))
+		return
+	}
+
+	variantID, err := strconv.ParseUint(c.Param("variantId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid variant id"))
+		return
+	}
+
+	result, err := h.productService.GenerateVariantBarcode(userID, uint(companyID), uint(productID), uint(variantID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
 func (h *ProductHandler) DeleteProductVariant(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	promotionApp "github.com/YasserCherfaoui/darween/internal/application/promotion"
+	"github.com/YasserCherfaoui/darween/internal/presentation/http/middleware"
+	"github.com/YasserCherfaoui/darween/internal/presentation/response"
+	"github.com/YasserCherfaoui/darween/pkg/errors"
+	"github.com/gin-gonic/gin"
+)
+
+type PromotionHandler struct {
+	promotionService *promotionApp.Service
+}
+
+func NewPromotionHandler(promotionService *promotionApp.Service) *PromotionHandler {
+	return &PromotionHandler{
+		promotionService: promotionService,
+	}
+}
+
+func (h *PromotionHandler) CreatePromotion(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	var req promotionApp.CreatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.promotionService.CreatePromotion(userID, uint(companyID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusCreated, "Promotion created successfully", result)
+}
+
+func (h *PromotionHandler) ListPromotions(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	// Check for franchise filter
+	var franchiseID *uint
+	if franchiseIDStr := c.Query("franchise_id"); franchiseIDStr != "" {
+		fID, err := strconv.ParseUint(franchiseIDStr, 10, 32)
+		if err == nil {
+			fIDUint := uint(fID)
+			franchiseID = &fIDUint
+		}
+	}
+
+	result, err := h.promotionService.ListPromotions(userID, uint(companyID), franchiseID)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *PromotionHandler) GetPromotion(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	promotionID, err := strconv.ParseUint(c.Param("promotionId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid promotion id"))
+		return
+	}
+
+	result, err := h.promotionService.GetPromotion(userID, uint(companyID), uint(promotionID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *PromotionHandler) UpdatePromotion(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	promotionID, err := strconv.ParseUint(c.Param("promotionId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid promotion id"))
+		return
+	}
+
+	var req promotionApp.UpdatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.promotionService.UpdatePromotion(userID, uint(companyID), uint(promotionID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Promotion updated successfully", result)
+}
+
+func (h *PromotionHandler) DeletePromotion(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	promotionID, err := strconv.ParseUint(c.Param("promotionId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid promotion id"))
+		return
+	}
+
+	err = h.promotionService.DeletePromotion(userID, uint(companyID), uint(promotionID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Promotion deleted successfully", nil)
+}
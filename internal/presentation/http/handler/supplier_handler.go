@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	supplierApp "github.com/YasserCherfaoui/darween/internal/application/supplier"
+	"github.com/YasserCherfaoui/darween/internal/infrastructure/export"
 	"github.com/YasserCherfaoui/darween/internal/presentation/http/middleware"
 	"github.com/YasserCherfaoui/darween/internal/presentation/response"
 	"github.com/YasserCherfaoui/darween/pkg/errors"
@@ -332,6 +333,36 @@ func (h *SupplierHandler) ListSupplierBills(c *gin.Context) {
 	response.Success(c, http.StatusOK, result)
 }
 
+func (h *SupplierHandler) ExportSupplierBills(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	// Check for supplier filter
+	var supplierID *uint
+	if supplierIDStr := c.Query("supplier_id"); supplierIDStr != "" {
+		sID, err := strconv.ParseUint(supplierIDStr, 10, 32)
+		if err != nil {
+			response.Error(c, errors.NewBadRequestError("invalid supplier id"))
+			return
+		}
+		sIDUint := uint(sID)
+		supplierID = &sIDUint
+	}
+
+	streamExport(c, "supplier_bills", func(w export.Writer) error {
+		return h.supplierService.ExportSupplierBills(userID, uint(companyID), supplierID, w)
+	})
+}
+
 func (h *SupplierHandler) UpdateSupplierBill(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	taxApp "github.com/YasserCherfaoui/darween/internal/application/tax"
+	"github.com/YasserCherfaoui/darween/internal/presentation/http/middleware"
+	"github.com/YasserCherfaoui/darween/internal/presentation/response"
+	"github.com/YasserCherfaoui/darween/pkg/errors"
+	"github.com/gin-gonic/gin"
+)
+
+type TaxHandler struct {
+	taxService *taxApp.Service
+}
+
+func NewTaxHandler(taxService *taxApp.Service) *TaxHandler {
+	return &TaxHandler{
+		taxService: taxService,
+	}
+}
+
+func (h *TaxHandler) CreateTaxClass(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	var req taxApp.CreateTaxClassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.taxService.CreateTaxClass(userID, uint(companyID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusCreated, "Tax class created successfully", result)
+}
+
+func (h *TaxHandler) ListTaxClasses(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	result, err := h.taxService.ListTaxClasses(userID, uint(companyID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *TaxHandler) GetTaxClass(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	taxClassID, err := strconv.ParseUint(c.Param("taxClassId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid tax class id"))
+		return
+	}
+
+	result, err := h.taxService.GetTaxClass(userID, uint(companyID), uint(taxClassID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *TaxHandler) UpdateTaxClass(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	taxClassID, err := strconv.ParseUint(c.Param("taxClassId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid tax class id"))
+		return
+	}
+
+	var req taxApp.UpdateTaxClassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.taxService.UpdateTaxClass(userID, uint(companyID), uint(taxClassID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Tax class updated successfully", result)
+}
+
+func (h *TaxHandler) DeleteTaxClass(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	taxClassID, err := strconv.ParseUint(c.Param("taxClassId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid tax class id"))
+		return
+	}
+
+	err = h.taxService.DeleteTaxClass(userID, uint(companyID), uint(taxClassID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Tax class deleted successfully", nil)
+}
+
+func (h *TaxHandler) SetDefaultTaxClass(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	taxClassID, err := strconv.ParseUint(c.Param("taxClassId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid tax class id"))
+		return
+	}
+
+	err = h.taxService.SetDefaultTaxClass(userID, uint(companyID), uint(taxClassID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Default tax class set successfully", nil)
+}
@@ -115,6 +115,28 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 	response.SuccessWithMessage(c, http.StatusOK, result.Message, result)
 }
 
+func (h *UserHandler) SetPOSPin(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	var req userApp.SetPOSPinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.userService.SetPOSPin(userID, &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, result.Message, result)
+}
+
 func (h *UserHandler) GetUserPortals(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
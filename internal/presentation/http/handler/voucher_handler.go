@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	voucherApp "github.com/YasserCherfaoui/darween/internal/application/voucher"
+	"github.com/YasserCherfaoui/darween/internal/presentation/http/middleware"
+	"github.com/YasserCherfaoui/darween/internal/presentation/response"
+	"github.com/YasserCherfaoui/darween/pkg/errors"
+	"github.com/gin-gonic/gin"
+)
+
+type VoucherHandler struct {
+	voucherService *voucherApp.Service
+}
+
+func NewVoucherHandler(voucherService *voucherApp.Service) *VoucherHandler {
+	return &VoucherHandler{
+		voucherService: voucherService,
+	}
+}
+
+func (h *VoucherHandler) IssueVoucher(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	var req voucherApp.IssueVoucherRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.voucherService.IssueVoucher(userID, uint(companyID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusCreated, "Voucher issued successfully", result)
+}
+
+func (h *VoucherHandler) ListVouchers(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	var pagination voucherApp.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+	pagination.GetDefaults()
+
+	// Check for customer filter
+	var customerID *uint
+	if customerIDStr := c.Query("customer_id"); customerIDStr != "" {
+		cID, err := strconv.ParseUint(customerIDStr, 10, 32)
+		if err == nil {
+			cIDUint := uint(cID)
+			customerID = &cIDUint
+		}
+	}
+
+	result, err := h.voucherService.ListVouchers(userID, uint(companyID), customerID, pagination.Page, pagination.Limit)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *VoucherHandler) LookupVoucher(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		response.Error(c, errors.NewBadRequestError("code is required"))
+		return
+	}
+
+	// Check for franchise filter
+	var franchiseID *uint
+	if franchiseIDStr := c.Query("franchise_id"); franchiseIDStr != "" {
+		fID, err := strconv.ParseUint(franchiseIDStr, 10, 32)
+		if err == nil {
+			fIDUint := uint(fID)
+			franchiseID = &fIDUint
+		}
+	}
+
+	result, err := h.voucherService.LookupVoucher(userID, uint(companyID), code, franchiseID)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *VoucherHandler) GetVoucher(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	voucherID, err := strconv.ParseUint(c.Param("voucherId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid voucher id"))
+		return
+	}
+
+	result, err := h.voucherService.GetVoucher(userID, uint(companyID), uint(voucherID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *VoucherHandler) UpdateVoucher(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	voucherID, err := strconv.ParseUint(c.Param("voucherId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid voucher id"))
+		return
+	}
+
+	var req voucherApp.UpdateVoucherRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.voucherService.UpdateVoucher(userID, uint(companyID), uint(voucherID), &req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, http.StatusOK, "Voucher updated successfully", result)
+}
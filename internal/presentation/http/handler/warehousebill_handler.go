@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	warehousebillApp "github.com/YasserCherfaoui/darween/internal/application/warehousebill"
+	"github.com/YasserCherfaoui/darween/internal/infrastructure/export"
 	"github.com/YasserCherfaoui/darween/internal/presentation/http/middleware"
 	"github.com/YasserCherfaoui/darween/internal/presentation/response"
 	"github.com/YasserCherfaoui/darween/pkg/errors"
@@ -144,6 +145,31 @@ func (h *WarehouseBillHandler) ListWarehouseBills(c *gin.Context) {
 	response.Success(c, http.StatusOK, result)
 }
 
+// ExportWarehouseBills exports the warehouse bills matching the list filters
+func (h *WarehouseBillHandler) ExportWarehouseBills(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	companyID, err := strconv.ParseUint(c.Param("companyId"), 10, 32)
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid company id"))
+		return
+	}
+
+	var filters warehousebillApp.BillFilterRequest
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		response.Error(c, errors.NewValidationError(err.Error()))
+		return
+	}
+
+	streamExport(c, "warehouse_bills", func(w export.Writer) error {
+		return h.warehouseBillService.ExportWarehouseBills(userID, uint(companyID), &filters, w)
+	})
+}
+
 // ListFranchiseWarehouseBills lists warehouse bills for a franchise
 func (h *WarehouseBillHandler) ListFranchiseWarehouseBills(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
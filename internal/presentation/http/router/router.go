@@ -20,6 +20,12 @@ type Router struct {
 	warehouseBillHandler *handler.WarehouseBillHandler
 	smtpConfigHandler    *handler.SMTPConfigHandler
 	emailHandler         *handler.EmailHandler
+	taxHandler           *handler.TaxHandler
+	promotionHandler     *handler.PromotionHandler
+	loyaltyHandler       *handler.LoyaltyHandler
+	voucherHandler       *handler.VoucherHandler
+	numberingHandler     *handler.NumberingHandler
+	priceListHandler     *handler.PriceListHandler
 	jwtManager           *security.JWTManager
 }
 
@@ -36,6 +42,12 @@ func NewRouter(
 	warehouseBillHandler *handler.WarehouseBillHandler,
 	smtpConfigHandler *handler.SMTPConfigHandler,
 	emailHandler *handler.EmailHandler,
+	taxHandler *handler.TaxHandler,
+	promotionHandler *handler.PromotionHandler,
+	loyaltyHandler *handler.LoyaltyHandler,
+	voucherHandler *handler.VoucherHandler,
+	numberingHandler *handler.NumberingHandler,
+	priceListHandler *handler.PriceListHandler,
 	jwtManager *security.JWTManager,
 ) *Router {
 	return &Router{
@@ -51,6 +63,12 @@ func NewRouter(
 		warehouseBillHandler: warehouseBillHandler,
 		smtpConfigHandler:    smtpConfigHandler,
 		emailHandler:         emailHandler,
+		taxHandler:           taxHandler,
+		promotionHandler:     promotionHandler,
+		loyaltyHandler:       loyaltyHandler,
+		voucherHandler:       voucherHandler,
+		numberingHandler:     numberingHandler,
+		priceListHandler:     priceListHandler,
 		jwtManager:           jwtManager,
 	}
 }
@@ -76,6 +94,9 @@ func (r *Router) SetupRoutes(engine *gin.Engine) {
 		auth.POST("/otp/change-password", r.authHandler.ChangePasswordWithOTP)
 	}
 
+	// Public routes (Digital receipts, authorized by the signed token in the link)
+	v1.GET("/public/receipts/:token", r.posHandler.GetPublicReceipt)
+
 	// Protected routes
 	protected := v1.Group("")
 	protected.Use(middleware.AuthMiddleware(r.jwtManager))
@@ -86,6 +107,7 @@ func (r *Router) SetupRoutes(engine *gin.Engine) {
 		users.GET("/me", r.userHandler.GetMe)
 		users.PUT("/me", r.userHandler.UpdateMe)
 		users.PUT("/me/password", r.userHandler.ChangePassword)
+		users.PUT("/me/pos-pin", r.userHandler.SetPOSPin)
 		users.GET("/me/portals", r.userHandler.GetUserPortals)
 		users.GET("", r.userHandler.ListUsers)
 	}
@@ -114,6 +136,48 @@ func (r *Router) SetupRoutes(engine *gin.Engine) {
 		companies.DELETE("/:companyId/smtp-configs/:configId", r.smtpConfigHandler.DeleteSMTPConfig)
 		companies.PUT("/:companyId/smtp-configs/:configId/default", r.smtpConfigHandler.SetDefaultSMTPConfig)
 
+		// Tax class routes nested under company
+		companies.POST("/:companyId/tax-classes", r.taxHandler.CreateTaxClass)
+		companies.GET("/:companyId/tax-classes", r.taxHandler.ListTaxClasses)
+		companies.GET("/:companyId/tax-classes/:taxClassId", r.taxHandler.GetTaxClass)
+		companies.PUT("/:companyId/tax-classes/:taxClassId", r.taxHandler.UpdateTaxClass)
+		companies.DELETE("/:companyId/tax-classes/:taxClassId", r.taxHandler.DeleteTaxClass)
+		companies.PUT("/:companyId/tax-classes/:taxClassId/default", r.taxHandler.SetDefaultTaxClass)
+
+		// Promotion routes nested under company
+		companies.POST("/:companyId/promotions", r.promotionHandler.CreatePromotion)
+		companies.GET("/:companyId/promotions", r.promotionHandler.ListPromotions)
+		companies.GET("/:companyId/promotions/:promotionId", r.promotionHandler.GetPromotion)
+		companies.PUT("/:companyId/promotions/:promotionId", r.promotionHandler.UpdatePromotion)
+		companies.DELETE("/:companyId/promotions/:promotionId", r.promotionHandler.DeletePromotion)
+
+		// Customer groups and their price lists nested under company
+		companies.POST("/:companyId/customer-groups", r.priceListHandler.CreateCustomerGroup)
+		companies.GET("/:companyId/customer-groups", r.priceListHandler.ListCustomerGroups)
+		companies.GET("/:companyId/customer-groups/:groupId", r.priceListHandler.GetCustomerGroup)
+		companies.PUT("/:companyId/customer-groups/:groupId", r.priceListHandler.UpdateCustomerGroup)
+		companies.DELETE("/:companyId/customer-groups/:groupId", r.priceListHandler.DeleteCustomerGroup)
+		companies.POST("/:companyId/price-lists", r.priceListHandler.CreatePriceList)
+		companies.GET("/:companyId/price-lists", r.priceListHandler.ListPriceLists)
+		companies.GET("/:companyId/price-lists/:priceListId", r.priceListHandler.GetPriceList)
+		companies.PUT("/:companyId/price-lists/:priceListId", r.priceListHandler.UpdatePriceList)
+		companies.DELETE("/:companyId/price-lists/:priceListId", r.priceListHandler.DeletePriceList)
+
+		// Loyalty program routes nested under company
+		companies.GET("/:companyId/loyalty/program", r.loyaltyHandler.GetProgram)
+		companies.PUT("/:companyId/loyalty/program", r.loyaltyHandler.UpdateProgram)
+
+		// Voucher routes (gift cards and store credit) nested under company
+		companies.POST("/:companyId/vouchers", r.voucherHandler.IssueVoucher)
+		companies.GET("/:companyId/vouchers", r.voucherHandler.ListVouchers)
+		companies.GET("/:companyId/vouchers/lookup", r.voucherHandler.LookupVoucher)
+		companies.GET("/:companyId/vouchers/:voucherId", r.voucherHandler.GetVoucher)
+		companies.PUT("/:companyId/vouchers/:voucherId", r.voucherHandler.UpdateVoucher)
+
+		// Document numbering sequences nested under company
+		companies.GET("/:companyId/numbering-sequences", r.numberingHandler.ListSequences)
+		companies.PUT("/:companyId/numbering-sequences", r.numberingHandler.ConfigureSequence)
+
 		// Email routes nested under company
 		companies.POST("/:companyId/emails/send", r.emailHandler.SendEmail)
 
@@ -131,6 +195,7 @@ func (r *Router) SetupRoutes(engine *gin.Engine) {
 		companies.GET("/:companyId/products/:productId/variants/:variantId", r.productHandler.GetProductVariant)
 		companies.PUT("/:companyId/products/:productId/variants/:variantId", r.productHandler.UpdateProductVariant)
 		companies.DELETE("/:companyId/products/:productId/variants/:variantId", r.productHandler.DeleteProductVariant)
+		companies.POST("/:companyId/products/:productId/variants/:variantId/barcodes/generate", r.productHandler.GenerateVariantBarcode)
 
 		// Label generation routes for products and variants
 		companies.GET("/:companyId/products/:productId/label", r.productHandler.GenerateProductLabel)
@@ -155,6 +220,7 @@ func (r *Router) SetupRoutes(engine *gin.Engine) {
 		companies.DELETE("/:companyId/suppliers/:supplierId/bills/:billId", r.supplierHandler.DeleteSupplierBill)
 
 		// Bill endpoint without supplier ID (for inventory movement references)
+		companies.GET("/:companyId/bills/export", r.supplierHandler.ExportSupplierBills)
 		companies.GET("/:companyId/bills/:billId", r.supplierHandler.GetSupplierBillByID)
 
 		// Supplier bill item routes nested under bills
@@ -168,37 +234,93 @@ func (r *Router) SetupRoutes(engine *gin.Engine) {
 
 		// Inventory routes
 		companies.GET("/:companyId/inventory", r.inventoryHandler.GetCompanyInventory)
+		companies.GET("/:companyId/inventory/export", r.inventoryHandler.ExportInventory)
 		companies.POST("/:companyId/inventory/initialize", r.inventoryHandler.InitializeCompanyInventory)
 
 		// POS routes
 		companies.POST("/:companyId/pos/customers", r.posHandler.CreateCustomer)
 		companies.GET("/:companyId/pos/customers", r.posHandler.ListCustomers)
+		companies.GET("/:companyId/pos/customers/export", r.posHandler.ExportCustomers)
+		companies.POST("/:companyId/pos/customers/import", r.posHandler.ImportCustomers)
+		companies.GET("/:companyId/pos/customers/duplicates", r.posHandler.FindDuplicateCustomers)
 		companies.GET("/:companyId/pos/customers/:customerId", r.posHandler.GetCustomer)
 		companies.PUT("/:companyId/pos/customers/:customerId", r.posHandler.UpdateCustomer)
 		companies.DELETE("/:companyId/pos/customers/:customerId", r.posHandler.DeleteCustomer)
+		companies.POST("/:companyId/pos/customers/:customerId/merge", r.posHandler.MergeCustomers)
+		companies.GET("/:companyId/pos/customers/:customerId/loyalty", r.loyaltyHandler.GetCustomerLoyalty)
+		companies.POST("/:companyId/pos/customers/:customerId/payments", r.posHandler.RecordCustomerPayment)
+		companies.GET("/:companyId/pos/customers/:customerId/account", r.posHandler.GetCustomerAccount)
+		companies.GET("/:companyId/pos/customers/:customerId/statement", r.posHandler.GetCustomerStatement)
 
 		companies.GET("/:companyId/pos/products/search", r.posHandler.SearchProducts)
+		companies.GET("/:companyId/pos/products/scan", r.posHandler.ScanProduct)
 
 		companies.POST("/:companyId/pos/sales", r.posHandler.CreateSale)
 		companies.GET("/:companyId/pos/sales", r.posHandler.ListSales)
+		companies.GET("/:companyId/pos/sales/export", r.posHandler.ExportSales)
 		companies.GET("/:companyId/pos/sales/:saleId", r.posHandler.GetSale)
 		companies.GET("/:companyId/pos/sales/:saleId/receipt", r.posHandler.GenerateReceipt)
+		companies.GET("/:companyId/pos/sales/:saleId/receipt/link", r.posHandler.GetReceiptLink)
+		companies.POST("/:companyId/pos/sales/:saleId/receipt/email", r.posHandler.EmailReceipt)
 		companies.POST("/:companyId/pos/sales/:saleId/payments", r.posHandler.AddPayment)
+		companies.POST("/:companyId/pos/sales/:saleId/checkout", r.posHandler.CheckoutSale)
+		companies.POST("/:companyId/pos/sales/:saleId/void", r.posHandler.VoidSale)
 		companies.POST("/:companyId/pos/sales/:saleId/refund", r.posHandler.ProcessRefund)
+		companies.POST("/:companyId/pos/sales/:saleId/exchange", r.posHandler.ProcessExchange)
+		companies.POST("/:companyId/pos/sales/:saleId/invoice", r.posHandler.IssueInvoice)
+		companies.GET("/:companyId/pos/sales/:saleId/invoice", r.posHandler.GenerateInvoice)
+		companies.POST("/:companyId/pos/gift-cards", r.posHandler.SellGiftCard)
+
+		companies.POST("/:companyId/pos/parked-sales", r.posHandler.ParkSale)
+		companies.GET("/:companyId/pos/parked-sales", r.posHandler.ListParkedSales)
+		companies.PUT("/:companyId/pos/parked-sales/:saleId", r.posHandler.UpdateParkedSale)
+		companies.POST("/:companyId/pos/parked-sales/:saleId/resume", r.posHandler.ResumeParkedSale)
+		companies.DELETE("/:companyId/pos/parked-sales/:saleId", r.posHandler.DiscardParkedSale)
+
+		// Layaways: items held against deposits until picked up
+		companies.POST("/:companyId/pos/layaways", r.posHandler.CreateLayaway)
+		companies.GET("/:companyId/pos/layaways", r.posHandler.ListLayaways)
+		companies.GET("/:companyId/pos/layaways/:layawayId", r.posHandler.GetLayaway)
+		companies.POST("/:companyId/pos/layaways/:layawayId/deposits", r.posHandler.AddLayawayDeposit)
+		companies.POST("/:companyId/pos/layaways/:layawayId/complete", r.posHandler.CompleteLayaway)
+		companies.POST("/:companyId/pos/layaways/:layawayId/cancel", r.posHandler.CancelLayaway)
+		companies.POST("/:companyId/pos/layaways/:layawayId/deposit/settle", r.posHandler.ResolveLayawayDeposit)
+
+		// Proformas: quotes converted into sales once accepted
+		companies.POST("/:companyId/pos/proformas", r.posHandler.CreateProforma)
+		companies.GET("/:companyId/pos/proformas", r.posHandler.ListProformas)
+		companies.GET("/:companyId/pos/proformas/:proformaId", r.posHandler.GetProforma)
+		companies.GET("/:companyId/pos/proformas/:proformaId/pdf", r.posHandler.GenerateProforma)
+		companies.POST("/:companyId/pos/proformas/:proformaId/convert", r.posHandler.ConvertProforma)
+		companies.POST("/:companyId/pos/proformas/:proformaId/cancel", r.posHandler.CancelProforma)
+
+		companies.POST("/:companyId/pos/offline/receipt-ranges", r.posHandler.ReserveReceiptRange)
+		companies.POST("/:companyId/pos/offline/sales", r.posHandler.SyncOfflineSales)
 
 		companies.GET("/:companyId/pos/refunds", r.posHandler.ListRefunds)
+		companies.GET("/:companyId/pos/refunds/export", r.posHandler.ExportRefunds)
+		companies.GET("/:companyId/pos/exchanges", r.posHandler.ListExchanges)
 
 		companies.POST("/:companyId/pos/cash-drawer/open", r.posHandler.OpenCashDrawer)
 		companies.GET("/:companyId/pos/cash-drawer/active", r.posHandler.GetActiveCashDrawer)
+		companies.POST("/:companyId/pos/cash-drawer/movements", r.posHandler.RecordCashMovement)
 		companies.PUT("/:companyId/pos/cash-drawer/:drawerId/close", r.posHandler.CloseCashDrawer)
+		companies.GET("/:companyId/pos/cash-drawer/:drawerId/x-report", r.posHandler.GetXReport)
+		companies.GET("/:companyId/pos/cash-drawer/:drawerId/x-report/pdf", r.posHandler.GenerateXReportPDF)
+		companies.GET("/:companyId/pos/cash-drawer/:drawerId/z-report", r.posHandler.GetZReport)
+		companies.GET("/:companyId/pos/cash-drawer/:drawerId/z-report/pdf", r.posHandler.GenerateZReportPDF)
 		companies.GET("/:companyId/pos/cash-drawer", r.posHandler.ListCashDrawers)
+		companies.GET("/:companyId/pos/cash-drawer/export", r.posHandler.ExportCashDrawers)
 
 		companies.POST("/:companyId/pos/reports/sales", r.posHandler.GetSalesReport)
+		companies.POST("/:companyId/pos/reports/analytics", r.posHandler.GetSalesAnalytics)
+		companies.GET("/:companyId/pos/reports/customer-aging", r.posHandler.GetCustomerAgingReport)
 
 		// Warehouse bill routes (exit bills)
 		companies.POST("/:companyId/warehouse-bills/exit", r.warehouseBillHandler.CreateExitBill)
 		companies.GET("/:companyId/warehouse-bills/search", r.warehouseBillHandler.SearchProductsForExitBill)
 		companies.GET("/:companyId/warehouse-bills", r.warehouseBillHandler.ListWarehouseBills)
+		companies.GET("/:companyId/warehouse-bills/export", r.warehouseBillHandler.ExportWarehouseBills)
 		companies.GET("/:companyId/warehouse-bills/:billId", r.warehouseBillHandler.GetWarehouseBill)
 		companies.PUT("/:companyId/warehouse-bills/:billId/items", r.warehouseBillHandler.UpdateExitBillItems)
 		companies.PUT("/:companyId/warehouse-bills/:billId/complete", r.warehouseBillHandler.CompleteExitBill)
@@ -245,6 +367,7 @@ func (r *Router) SetupRoutes(engine *gin.Engine) {
 		inventory.POST("/:inventoryId/reserve", r.inventoryHandler.ReserveStock)
 		inventory.POST("/:inventoryId/release", r.inventoryHandler.ReleaseStock)
 		inventory.GET("/:inventoryId/movements", r.inventoryHandler.GetInventoryMovements)
+		inventory.GET("/:inventoryId/movements/export", r.inventoryHandler.ExportInventoryMovements)
 	}
 
 	// Health check
@@ -13,6 +13,7 @@ type Config struct {
 	Database DatabaseConfig
 	JWT      JWTConfig
 	Server   ServerConfig
+	POS      POSConfig
 }
 
 type DatabaseConfig struct {
@@ -30,8 +31,14 @@ type JWTConfig struct {
 }
 
 type ServerConfig struct {
-	Port    string
-	GinMode string
+	Port      string
+	GinMode   string
+	PublicURL string // URL the API is reachable at from outside, used in links sent to customers
+}
+
+type POSConfig struct {
+	ParkedSaleTTL  int // in hours, parked sales older than this release their reserved stock
+	ReceiptLinkTTL int // in hours, how long the public link to a digital receipt stays valid
 }
 
 func Load() (*Config, error) {
@@ -56,8 +63,13 @@ func Load() (*Config, error) {
 			Expiration: getEnvAsInt("JWT_EXPIRATION", 24),
 		},
 		Server: ServerConfig{
-			Port:    getEnv("SERVER_PORT", "8080"),
-			GinMode: getEnv("GIN_MODE", "debug"),
+			Port:      getEnv("SERVER_PORT", "8080"),
+			GinMode:   getEnv("GIN_MODE", "debug"),
+			PublicURL: getEnv("PUBLIC_URL", "http://localhost:8080"),
+		},
+		POS: POSConfig{
+			ParkedSaleTTL:  getEnvAsInt("POS_PARKED_SALE_TTL", 24),
+			ReceiptLinkTTL: getEnvAsInt("POS_RECEIPT_LINK_TTL", 720),
 		},
 	}
 